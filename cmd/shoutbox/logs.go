@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+func runLogs(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("logs", stderr)
+	q := &shoutbox.EventQuery{}
+	fs.StringVar(&q.Recipient, "recipient", "", "only show events for this recipient")
+	fs.StringVar(&q.MessageID, "message-id", "", "only show events for this message")
+	fs.StringVar(&q.Type, "type", "", "only show events of this type, e.g. bounced")
+	since := fs.Duration("since", 0, "only show events newer than this, e.g. 24h")
+	fs.IntVar(&q.Limit, "limit", 0, "maximum number of events")
+	fs.StringVar(&q.Cursor, "cursor", "", "cursor of the page to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *since > 0 {
+		q.Since = time.Now().Add(-*since)
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	list, err := client.ListEvents(ctx, q)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, e := range list.Events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Timestamp.Format(time.RFC3339), e.Type, e.Recipient, e.MessageID)
+	}
+	tw.Flush()
+	if list.NextCursor != "" {
+		fmt.Fprintf(stderr, "more results: --cursor %s\n", list.NextCursor)
+	}
+	return nil
+}
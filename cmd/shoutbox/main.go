@@ -0,0 +1,144 @@
+// Command shoutbox sends email and manages a Shoutbox account from the
+// shell. It is configured by the SHOUTBOX_ environment variables read by
+// shoutbox.ConfigFromEnv, e.g. SHOUTBOX_API_KEY.
+//
+// Usage:
+//
+//	shoutbox send --from me@example.com --to you@example.com --subject Hi --html-file body.html --attach report.pdf
+//	shoutbox templates render --id welcome --var name=Ada
+//	shoutbox suppressions list|add|remove
+//	shoutbox logs --recipient you@example.com --since 24h
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{name: "send", summary: "send an email", run: runSend},
+	{name: "templates", summary: "render hosted templates", run: runTemplates},
+	{name: "suppressions", summary: "list, add and remove suppressed addresses", run: runSuppressions},
+	{name: "logs", summary: "show delivery and engagement events", run: runLogs},
+}
+
+// errUsage is returned for invalid arguments after usage has been printed
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "shoutbox:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		return errUsage
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		return cmd.run(ctx, args[1:], stdout, stderr)
+	}
+	fmt.Fprintf(stderr, "shoutbox: unknown command %q\n\n", args[0])
+	usage(stderr)
+	return errUsage
+}
+
+// newClient builds the API client from the environment. Commands call it
+// after parsing their flags, so -h works without credentials.
+func newClient() (*shoutbox.Client, error) {
+	client, err := shoutbox.NewClientFromEnv()
+	if errors.Is(err, shoutbox.ErrMissingAPIKey) {
+		return nil, errors.New("SHOUTBOX_API_KEY is not set")
+	}
+	return client, err
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: shoutbox <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'shoutbox <command> -h' for the flags of a command.")
+}
+
+// newFlagSet returns a flag set that reports errors to stderr instead of
+// exiting
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("shoutbox "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// listFlag collects a flag that may be repeated or given a comma-separated
+// list
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+	return nil
+}
+
+// varsFlag collects repeated key=value template variables
+type varsFlag map[string]any
+
+func (f varsFlag) String() string {
+	return fmt.Sprint(map[string]any(f))
+}
+
+func (f varsFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("variable %q is not key=value", value)
+	}
+	f[key] = val
+	return nil
+}
+
+// readFlagFile returns the contents of path, or value if path is empty
+func readFlagFile(value, path string) (string, error) {
+	if path == "" {
+		return value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
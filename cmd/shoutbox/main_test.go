@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+func TestRun(t *testing.T) {
+	var got *http.Request
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/send":
+			w.Write([]byte(`{"message_id":"msg_1"}`))
+		case "/suppressions":
+			json.NewEncoder(w).Encode(shoutbox.SuppressionList{
+				Suppressions: []shoutbox.Suppression{{Email: "ada@example.com", Reason: "bounce"}},
+				NextCursor:   "next",
+			})
+		case "/render":
+			w.Write([]byte(`{"subject":"Welcome Ada","html":"<p>Hi Ada</p>"}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("SHOUTBOX_API_KEY", "test-key")
+	t.Setenv("SHOUTBOX_BASE_URL", srv.URL)
+
+	dir := t.TempDir()
+	htmlFile := filepath.Join(dir, "body.html")
+	os.WriteFile(htmlFile, []byte("<p>Report attached</p>"), 0o644)
+	report := filepath.Join(dir, "report.txt")
+	os.WriteFile(report, []byte("all good"), 0o644)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantPath   string
+		wantOut    string
+		wantStderr string
+		check      func(t *testing.T)
+	}{
+		{
+			name:     "send",
+			args:     []string{"send", "--from", "ops@example.com", "--to", "ada@example.com,bob@example.com", "--subject", "Report", "--html-file", htmlFile, "--attach", report},
+			wantPath: "/send",
+			wantOut:  "msg_1\n",
+			check: func(t *testing.T) {
+				attachments, _ := body["attachments"].([]any)
+				if body["html"] != "<p>Report attached</p>" || len(body["to"].([]any)) != 2 || len(attachments) != 1 {
+					t.Errorf("request = %v", body)
+				}
+			},
+		},
+		{
+			name:       "suppressions list",
+			args:       []string{"suppressions", "list", "--reason", "bounce"},
+			wantPath:   "/suppressions",
+			wantOut:    "ada@example.com  bounce",
+			wantStderr: "--cursor next",
+			check: func(t *testing.T) {
+				if got.URL.Query().Get("reason") != "bounce" {
+					t.Errorf("query = %s", got.URL.RawQuery)
+				}
+			},
+		},
+		{
+			name:     "suppressions remove",
+			args:     []string{"suppressions", "remove", "ada@example.com"},
+			wantPath: "/suppressions/ada@example.com",
+			check: func(t *testing.T) {
+				if got.Method != http.MethodDelete {
+					t.Errorf("method = %s", got.Method)
+				}
+			},
+		},
+		{
+			name:     "templates render",
+			args:     []string{"templates", "render", "--id", "welcome", "--var", "name=Ada"},
+			wantPath: "/render",
+			wantOut:  "Subject: Welcome Ada\n\n<p>Hi Ada</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(context.Background(), tt.args, &stdout, &stderr); err != nil {
+				t.Fatalf("run() error = %v, stderr = %s", err, stderr.String())
+			}
+			if got.URL.Path != tt.wantPath {
+				t.Errorf("path = %s, want %s", got.URL.Path, tt.wantPath)
+			}
+			if !strings.Contains(stdout.String(), tt.wantOut) {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantOut)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+			if tt.check != nil {
+				tt.check(t)
+			}
+		})
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	t.Setenv("SHOUTBOX_API_KEY", "test-key")
+
+	for _, args := range [][]string{nil, {"unknown"}, {"suppressions"}, {"send", "--bogus"}} {
+		var stdout, stderr bytes.Buffer
+		if err := run(context.Background(), args, &stdout, &stderr); err == nil {
+			t.Errorf("run(%q) error = nil", args)
+		}
+		if stderr.Len() == 0 {
+			t.Errorf("run(%q) printed no usage", args)
+		}
+	}
+}
+
+func TestRunHelpWithoutAPIKey(t *testing.T) {
+	t.Setenv("SHOUTBOX_API_KEY", "")
+
+	for _, args := range [][]string{{"send", "-h"}, {"templates", "render", "-h"}, {"suppressions", "list", "-h"}, {"logs", "-h"}} {
+		var stdout, stderr bytes.Buffer
+		if err := run(context.Background(), args, &stdout, &stderr); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("run(%q) error = %v, want %v", args, err, flag.ErrHelp)
+		}
+		if !strings.Contains(stderr.String(), "Usage") {
+			t.Errorf("run(%q) stderr = %q, want the flags", args, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+func runSend(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("send", stderr)
+	var to, cc, bcc, attach listFlag
+	vars := varsFlag{}
+	from := fs.String("from", "", "sender address")
+	name := fs.String("name", "", "sender display name")
+	replyTo := fs.String("reply-to", "", "reply-to address")
+	fs.Var(&to, "to", "recipient address; repeat or separate with commas")
+	fs.Var(&cc, "cc", "cc address; repeat or separate with commas")
+	fs.Var(&bcc, "bcc", "bcc address; repeat or separate with commas")
+	subject := fs.String("subject", "", "subject line")
+	html := fs.String("html", "", "HTML body")
+	htmlFile := fs.String("html-file", "", "file containing the HTML body")
+	text := fs.String("text", "", "plain-text body")
+	textFile := fs.String("text-file", "", "file containing the plain-text body")
+	fs.Var(&attach, "attach", "file to attach; repeat for several")
+	template := fs.String("template", "", "ID of a hosted template to send instead of a body")
+	fs.Var(vars, "var", "template variable as key=value; repeat for several")
+	category := fs.String("category", "", "subscription category, e.g. newsletter")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if len(to) == 0 {
+		return errors.New("send requires --to")
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	req := &shoutbox.EmailRequest{
+		From:       *from,
+		Name:       *name,
+		ReplyTo:    *replyTo,
+		To:         shoutbox.Recipients(to),
+		Cc:         shoutbox.Recipients(cc),
+		Bcc:        shoutbox.Recipients(bcc),
+		Subject:    *subject,
+		TemplateID: *template,
+		Category:   *category,
+	}
+	if len(vars) > 0 {
+		req.Variables = vars
+	}
+	if req.HTML, err = readFlagFile(*html, *htmlFile); err != nil {
+		return err
+	}
+	if req.Text, err = readFlagFile(*text, *textFile); err != nil {
+		return err
+	}
+	for _, path := range attach {
+		attachment, err := shoutbox.NewStreamingAttachmentFromFile(path)
+		if err != nil {
+			return err
+		}
+		req.Attachments = append(req.Attachments, attachment)
+	}
+
+	resp, err := client.SendEmail(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, resp.MessageID)
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+func runSuppressions(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: shoutbox suppressions list [--reason r] [--limit n] [--cursor c]")
+		fmt.Fprintln(stderr, "       shoutbox suppressions add [--reason r] <email>...")
+		fmt.Fprintln(stderr, "       shoutbox suppressions remove <email>...")
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		fs := newFlagSet("suppressions list", stderr)
+		q := &shoutbox.SuppressionQuery{}
+		fs.StringVar(&q.Reason, "reason", "", "only list suppressions for reason, e.g. bounce")
+		fs.IntVar(&q.Limit, "limit", 0, "maximum number of suppressions")
+		fs.StringVar(&q.Cursor, "cursor", "", "cursor of the page to list")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		list, err := client.Suppressions().List(ctx, q)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		for _, s := range list.Suppressions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Email, s.Reason, s.CreatedAt.Format(time.RFC3339))
+		}
+		tw.Flush()
+		if list.NextCursor != "" {
+			fmt.Fprintf(stderr, "more results: --cursor %s\n", list.NextCursor)
+		}
+		return nil
+
+	case "add":
+		fs := newFlagSet("suppressions add", stderr)
+		reason := fs.String("reason", shoutbox.SuppressionManual, "reason for the suppression")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		for _, email := range fs.Args() {
+			if err := client.Suppressions().Add(ctx, email, *reason); err != nil {
+				return fmt.Errorf("error suppressing %s: %w", email, err)
+			}
+		}
+		return nil
+
+	case "remove":
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		for _, email := range args[1:] {
+			if err := client.Suppressions().Remove(ctx, email); err != nil {
+				return fmt.Errorf("error removing %s: %w", email, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown suppressions command %q", args[0])
+}
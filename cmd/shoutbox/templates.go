@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+func runTemplates(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "render" {
+		fmt.Fprintln(stderr, "Usage: shoutbox templates render --id <template> [--var key=value] [--text]")
+		return errUsage
+	}
+
+	fs := newFlagSet("templates render", stderr)
+	vars := varsFlag{}
+	id := fs.String("id", "", "template ID")
+	fs.Var(vars, "var", "template variable as key=value; repeat for several")
+	text := fs.Bool("text", false, "print the plain-text body instead of the HTML")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *id == "" {
+		return errors.New("templates render requires --id")
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	preview, err := client.RenderPreview(ctx, &shoutbox.PreviewRequest{TemplateID: *id, Variables: vars})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Subject: %s\n\n", preview.Subject)
+	if *text {
+		fmt.Fprintln(stdout, preview.Text)
+	} else {
+		fmt.Fprintln(stdout, preview.HTML)
+	}
+	return nil
+}
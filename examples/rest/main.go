@@ -21,7 +21,7 @@ func main() {
 	// Create an email request
 	req := &shoutbox.EmailRequest{
 		From:    os.Getenv("SHOUTBOX_FROM"),
-		To:      os.Getenv("SHOUTBOX_TO"),
+		To:      shoutbox.ParseRecipients(os.Getenv("SHOUTBOX_TO")),
 		Subject: "Hello from Shoutbox REST API",
 		HTML:    "<h1>Hello!</h1><p>This email was sent using the Shoutbox REST API client.</p>",
 		Name:    "Shoutbox Test",
@@ -32,10 +32,10 @@ func main() {
 	}
 
 	// Send the email
-	err := client.SendEmail(context.Background(), req)
+	resp, err := client.SendEmail(context.Background(), req)
 	if err != nil {
 		log.Fatalf("Failed to send email: %v", err)
 	}
 
-	log.Println("Email sent successfully!")
+	log.Printf("Email sent successfully! Message ID: %s", resp.MessageID)
 }
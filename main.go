@@ -20,16 +20,16 @@ func main() {
 	restClient := shoutbox.NewClient(apiKey)
 	restReq := &shoutbox.EmailRequest{
 		From:    os.Getenv("SHOUTBOX_FROM"),
-		To:      os.Getenv("SHOUTBOX_TO"),
+		To:      shoutbox.ParseRecipients(os.Getenv("SHOUTBOX_TO")),
 		Subject: "Test from REST API",
 		HTML:    "<h1>REST API Test</h1><p>This email was sent using the REST API client.</p>",
 	}
 
-	err := restClient.SendEmail(context.Background(), restReq)
+	resp, err := restClient.SendEmail(context.Background(), restReq)
 	if err != nil {
 		log.Printf("REST API error: %v", err)
 	} else {
-		log.Println("REST API email sent successfully!")
+		log.Printf("REST API email sent successfully! Message ID: %s", resp.MessageID)
 	}
 
 	// Example using SMTP client
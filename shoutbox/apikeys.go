@@ -0,0 +1,108 @@
+package shoutbox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Scopes limiting what an API key can do
+const (
+	ScopeFullAccess   = "full_access"
+	ScopeSend         = "send"
+	ScopeRead         = "read"
+	ScopeSuppressions = "suppressions"
+	ScopeContacts     = "contacts"
+	ScopeDomains      = "domains"
+	ScopeAPIKeys      = "api_keys"
+)
+
+// APIKey is an API key of the account
+type APIKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Scopes are what the key may do, e.g. ScopeSend
+	Scopes []string `json:"scopes"`
+	// Secret is the key itself. It is only returned when the key is
+	// created; store it then.
+	Secret     string     `json:"secret,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// APIKeysService manages the account's API keys. The client's own key
+// needs ScopeAPIKeys or ScopeFullAccess.
+type APIKeysService struct {
+	client *Client
+}
+
+// APIKeys returns the API key management API
+func (c *Client) APIKeys() *APIKeysService {
+	return &APIKeysService{client: c}
+}
+
+// Create issues a key named name, e.g. after the service that uses it,
+// limited to scopes. The returned key carries its Secret.
+func (s *APIKeysService) Create(ctx context.Context, name string, scopes ...string) (*APIKey, error) {
+	var key APIKey
+	if err := s.client.do(ctx, http.MethodPost, "/api-keys", APIKey{Name: name, Scopes: scopes}, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Get returns the key with the given ID, without its secret
+func (s *APIKeysService) Get(ctx context.Context, id string) (*APIKey, error) {
+	var key APIKey
+	if err := s.client.do(ctx, http.MethodGet, "/api-keys/"+url.PathEscape(id), nil, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns every key of the account, without their secrets
+func (s *APIKeysService) List(ctx context.Context) ([]APIKey, error) {
+	var resp struct {
+		Keys []APIKey `json:"keys"`
+	}
+	if err := s.client.do(ctx, http.MethodGet, "/api-keys", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// SetScopes replaces the scopes of the key with the given ID
+func (s *APIKeysService) SetScopes(ctx context.Context, id string, scopes ...string) (*APIKey, error) {
+	var key APIKey
+	body := map[string][]string{"scopes": scopes}
+	if err := s.client.do(ctx, http.MethodPut, "/api-keys/"+url.PathEscape(id)+"/scopes", body, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Revoke permanently disables the key with the given ID
+func (s *APIKeysService) Revoke(ctx context.Context, id string) error {
+	return s.client.do(ctx, http.MethodDelete, "/api-keys/"+url.PathEscape(id), nil, nil)
+}
+
+// Rotate replaces the key with the given ID: it creates a key with the
+// same name and scopes, then revokes the old one. Deploy the returned
+// key's Secret wherever the old key was used. If revoking fails, the new
+// key is returned along with the error.
+func (s *APIKeysService) Rotate(ctx context.Context, id string) (*APIKey, error) {
+	old, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.Create(ctx, old.Name, old.Scopes...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Revoke(ctx, id); err != nil {
+		return key, fmt.Errorf("error revoking key %s: %w", id, err)
+	}
+	return key, nil
+}
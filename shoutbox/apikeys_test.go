@@ -0,0 +1,81 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestAPIKeysService(t *testing.T) {
+	var requests []string
+	var created APIKey
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == http.MethodPost:
+			json.NewDecoder(r.Body).Decode(&created)
+			created.ID, created.Secret = "key-2", "sk_new"
+			json.NewEncoder(w).Encode(created)
+		case r.Method == http.MethodGet && r.URL.Path == "/api-keys":
+			json.NewEncoder(w).Encode(map[string]any{"keys": []APIKey{{ID: "key-1", Name: "billing"}}})
+		case r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(APIKey{ID: "key-1", Name: "billing", Scopes: []string{ScopeSend, ScopeRead}})
+		case r.Method == http.MethodPut:
+			var body struct {
+				Scopes []string `json:"scopes"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			json.NewEncoder(w).Encode(APIKey{ID: "key-1", Scopes: body.Scopes})
+		case r.URL.Path == "/api-keys/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	keys := client.APIKeys()
+	ctx := context.Background()
+
+	list, err := keys.List(ctx)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+	key, err := keys.SetScopes(ctx, "key-1", ScopeSend)
+	if err != nil {
+		t.Fatalf("SetScopes() error = %v", err)
+	}
+	if !slices.Equal(key.Scopes, []string{ScopeSend}) {
+		t.Errorf("SetScopes() scopes = %v", key.Scopes)
+	}
+
+	key, err = keys.Rotate(ctx, "key-1")
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if key.Secret != "sk_new" || created.Name != "billing" || !slices.Equal(created.Scopes, []string{ScopeSend, ScopeRead}) {
+		t.Errorf("Rotate() = %+v, created %+v", key, created)
+	}
+
+	err = keys.Revoke(ctx, "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Revoke() error = %v, want 404", err)
+	}
+
+	want := []string{
+		"GET /api-keys",
+		"PUT /api-keys/key-1/scopes",
+		"GET /api-keys/key-1",
+		"POST /api-keys",
+		"DELETE /api-keys/key-1",
+		"DELETE /api-keys/missing",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,263 @@
+package shoutbox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Authentication verdicts of SPF, DKIM and DMARC checks
+const (
+	AuthPass      = "pass"
+	AuthFail      = "fail"
+	AuthSoftFail  = "softfail"
+	AuthNeutral   = "neutral"
+	AuthNone      = "none"
+	AuthTempError = "temperror"
+	AuthPermError = "permerror"
+)
+
+// AuthResult is a single method result from an Authentication-Results
+// header, e.g. "dkim=pass header.d=example.com"
+type AuthResult struct {
+	Method string
+	Result string
+	Reason string
+	// Properties holds ptype.property values such as "header.d" or
+	// "smtp.mailfrom"
+	Properties map[string]string
+}
+
+// AuthenticationResults is a parsed Authentication-Results header
+// (RFC 8601)
+type AuthenticationResults struct {
+	// AuthServID identifies the server that performed the checks
+	AuthServID string
+	Results    []AuthResult
+}
+
+// Result returns the first result for method, e.g. "spf"
+func (a *AuthenticationResults) Result(method string) (AuthResult, bool) {
+	for _, r := range a.Results {
+		if r.Method == method {
+			return r, true
+		}
+	}
+	return AuthResult{}, false
+}
+
+// AuthVerdicts are the SPF, DKIM and DMARC results of a message
+type AuthVerdicts struct {
+	SPF   string
+	DKIM  string
+	DMARC string
+}
+
+// Aligned reports whether the message passed DMARC, meaning the From
+// domain is authenticated by an aligned SPF or DKIM pass
+func (v AuthVerdicts) Aligned() bool {
+	return v.DMARC == AuthPass
+}
+
+// ARCSet is one instance of the ARC headers added by an intermediary
+type ARCSet struct {
+	Instance int
+	// Results are the authentication results the intermediary observed
+	Results *AuthenticationResults
+	// ChainValidation is the cv= tag of the ARC-Seal: none, pass or fail
+	ChainValidation string
+	// Domain is the d= tag of the ARC-Seal
+	Domain string
+}
+
+// ParseAuthenticationResults parses the value of an Authentication-Results
+// or ARC-Authentication-Results header
+func ParseAuthenticationResults(value string) (*AuthenticationResults, error) {
+	parts := splitOutsideQuotes(stripComments(value), ';')
+	if len(parts) == 0 || strings.TrimSpace(parts[0]) == "" {
+		return nil, fmt.Errorf("invalid Authentication-Results header: %q", value)
+	}
+
+	fields := strings.Fields(parts[0])
+	results := &AuthenticationResults{AuthServID: fields[0]}
+
+	for _, part := range parts[1:] {
+		tokens := strings.Fields(part)
+		if len(tokens) == 0 || tokens[0] == "none" {
+			continue
+		}
+		method, result, ok := strings.Cut(tokens[0], "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid result %q", part)
+		}
+		// Strip a method version such as "dkim/1"
+		method, _, _ = strings.Cut(method, "/")
+
+		r := AuthResult{
+			Method:     strings.ToLower(method),
+			Result:     strings.ToLower(result),
+			Properties: make(map[string]string),
+		}
+		for _, token := range tokens[1:] {
+			key, val, ok := strings.Cut(token, "=")
+			if !ok {
+				continue
+			}
+			val = strings.Trim(val, `"`)
+			if strings.EqualFold(key, "reason") {
+				r.Reason = val
+			} else {
+				r.Properties[strings.ToLower(key)] = val
+			}
+		}
+		results.Results = append(results.Results, r)
+	}
+	return results, nil
+}
+
+// AuthResults returns the Authentication-Results headers added by the
+// server identified by authServID. Headers from other servers may have
+// been added by the sender and must not be trusted.
+func (msg *InboundMessage) AuthResults(authServID string) []*AuthenticationResults {
+	var trusted []*AuthenticationResults
+	for _, value := range msg.Header["Authentication-Results"] {
+		results, err := ParseAuthenticationResults(value)
+		if err != nil || !strings.EqualFold(results.AuthServID, authServID) {
+			continue
+		}
+		trusted = append(trusted, results)
+	}
+	return trusted
+}
+
+// Verdicts returns the SPF, DKIM and DMARC results reported by the
+// server identified by authServID. Methods without a result are AuthNone.
+// When several DKIM signatures were checked, any pass counts as a pass.
+func (msg *InboundMessage) Verdicts(authServID string) AuthVerdicts {
+	v := AuthVerdicts{SPF: AuthNone, DKIM: AuthNone, DMARC: AuthNone}
+	for _, results := range msg.AuthResults(authServID) {
+		for _, r := range results.Results {
+			switch r.Method {
+			case "spf":
+				if v.SPF == AuthNone {
+					v.SPF = r.Result
+				}
+			case "dkim":
+				if v.DKIM == AuthNone || r.Result == AuthPass {
+					v.DKIM = r.Result
+				}
+			case "dmarc":
+				if v.DMARC == AuthNone {
+					v.DMARC = r.Result
+				}
+			}
+		}
+	}
+	return v
+}
+
+// ARC returns the ARC sets of the message ordered by instance
+func (msg *InboundMessage) ARC() ([]ARCSet, error) {
+	sets := make(map[int]*ARCSet)
+	get := func(i int) *ARCSet {
+		if sets[i] == nil {
+			sets[i] = &ARCSet{Instance: i}
+		}
+		return sets[i]
+	}
+
+	for _, value := range msg.Header["Arc-Authentication-Results"] {
+		instance, rest, err := arcInstance(value)
+		if err != nil {
+			return nil, err
+		}
+		results, err := ParseAuthenticationResults(rest)
+		if err != nil {
+			return nil, err
+		}
+		get(instance).Results = results
+	}
+	for _, value := range msg.Header["Arc-Seal"] {
+		tags := parseTagList(strings.Join(strings.Fields(value), ""))
+		instance, err := strconv.Atoi(tags["i"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ARC-Seal instance: %q", tags["i"])
+		}
+		set := get(instance)
+		set.ChainValidation = tags["cv"]
+		set.Domain = tags["d"]
+	}
+
+	ordered := make([]ARCSet, 0, len(sets))
+	for i := 1; i <= len(sets); i++ {
+		set, ok := sets[i]
+		if !ok {
+			return nil, fmt.Errorf("ARC chain is missing instance %d", i)
+		}
+		ordered = append(ordered, *set)
+	}
+	return ordered, nil
+}
+
+// arcInstance splits the leading "i=N;" tag from an ARC header value
+func arcInstance(value string) (int, string, error) {
+	tag, rest, _ := strings.Cut(value, ";")
+	key, val, ok := strings.Cut(strings.TrimSpace(tag), "=")
+	if !ok || strings.TrimSpace(key) != "i" {
+		return 0, "", fmt.Errorf("invalid ARC header: %q", value)
+	}
+	instance, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid ARC instance: %q", val)
+	}
+	return instance, rest, nil
+}
+
+// stripComments removes RFC 5322 comments, which may nest, from value
+func stripComments(value string) string {
+	var b strings.Builder
+	depth := 0
+	quoted := false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && i+1 < len(value):
+			if depth == 0 {
+				b.WriteByte(c)
+				b.WriteByte(value[i+1])
+			}
+			i++
+		case c == '"' && depth == 0:
+			quoted = !quoted
+			b.WriteByte(c)
+		case c == '(' && !quoted:
+			depth++
+		case c == ')' && !quoted && depth > 0:
+			depth--
+			if depth == 0 {
+				b.WriteByte(' ')
+			}
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
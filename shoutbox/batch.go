@@ -0,0 +1,125 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// MaxBatchSize is the number of messages sent per batch API call. Larger
+// batches are split into several calls.
+const MaxBatchSize = 500
+
+// SendResult is the outcome of one message sent by SendBatch, SendAll or
+// SendPersonalized. Retry a partial failure by resending the messages of
+// the failed results.
+type SendResult struct {
+	// Index is the position of the message in the request
+	Index int
+	// MessageID identifies the message if it was sent
+	MessageID string
+	Response  *SendResponse
+	// Err is set if the message was rejected, e.g. an *APIError, a
+	// *SpamError or ErrRecipientOptedOut
+	Err error
+}
+
+// newSendResults returns n results, indexed in order
+func newSendResults(n int) []SendResult {
+	results := make([]SendResult, n)
+	for i := range results {
+		results[i].Index = i
+	}
+	return results
+}
+
+// set records the outcome of sending the message
+func (r *SendResult) set(resp *SendResponse, err error) {
+	r.Response, r.Err = resp, err
+	if resp != nil {
+		r.MessageID = resp.MessageID
+	}
+}
+
+// FailedResults returns the results of the messages that weren't sent
+func FailedResults(results []SendResult) []SendResult {
+	var failed []SendResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+type batchPayload struct {
+	Messages []*sendPayload `json:"messages"`
+}
+
+type batchResponse struct {
+	Results []struct {
+		SendResponse
+		Status int    `json:"status,omitempty"`
+		Error  string `json:"error,omitempty"`
+		Code   string `json:"code,omitempty"`
+	} `json:"results"`
+}
+
+// SendBatch sends many messages in as few API calls as possible and
+// returns a result per message, in the order of reqs. An error is returned
+// only if a batch call itself failed; results of messages in earlier
+// batches are still filled in, and those of the failed and later batches
+// hold the error.
+func (c *Client) SendBatch(ctx context.Context, reqs []*EmailRequest) ([]SendResult, error) {
+	results := newSendResults(len(reqs))
+
+	var payloads []*sendPayload
+	var index []int
+	for i, req := range reqs {
+		prepared, err := c.prepare(ctx, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if c.sandbox {
+			results[i].set(c.sendSandboxed(prepared))
+			continue
+		}
+		if err := c.checkSpamThreshold(ctx, prepared); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if c.seedList != nil {
+			results[i].set(c.sendToSeedList(ctx, prepared))
+			continue
+		}
+		payloads = append(payloads, c.newSendPayload(prepared))
+		index = append(index, i)
+	}
+
+	for start := 0; start < len(payloads); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(payloads))
+		var resp batchResponse
+		if err := c.do(ctx, http.MethodPost, "/send/batch", batchPayload{Messages: payloads[start:end]}, &resp); err != nil {
+			for _, i := range index[start:] {
+				results[i].Err = err
+			}
+			return results, err
+		}
+		for j := range end - start {
+			result := &results[index[start+j]]
+			if j >= len(resp.Results) {
+				result.Err = errors.New("no result returned for message")
+				continue
+			}
+			item := resp.Results[j]
+			if item.Error != "" {
+				result.Err = &APIError{StatusCode: item.Status, Code: item.Code, Message: item.Error}
+				continue
+			}
+			response := item.SendResponse
+			result.set(&response, nil)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,135 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_SendBatch(t *testing.T) {
+	var calls, sent int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/send/batch" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var payload struct {
+			Messages []EmailRequest `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		calls++
+
+		type result struct {
+			MessageID string `json:"message_id,omitempty"`
+			Error     string `json:"error,omitempty"`
+			Status    int    `json:"status,omitempty"`
+		}
+		var results []result
+		for _, msg := range payload.Messages {
+			sent++
+			if msg.To.String() == "bad@example.com" {
+				results = append(results, result{Error: "invalid recipient", Status: 422})
+				continue
+			}
+			results = append(results, result{MessageID: "msg_" + msg.To.String()})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": results})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	client.preferences = optedOut{"out@example.com": true}
+
+	reqs := []*EmailRequest{
+		{To: Recipients{"bad@example.com"}},
+		{To: Recipients{"out@example.com"}, Category: "marketing"},
+	}
+	for i := range MaxBatchSize + 1 {
+		reqs = append(reqs, &EmailRequest{To: Recipients{fmt.Sprintf("%d@example.com", i)}})
+	}
+
+	results, err := client.SendBatch(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("SendBatch() error = %v", err)
+	}
+	if calls != 2 || sent != MaxBatchSize+2 {
+		t.Errorf("calls = %d, sent = %d", calls, sent)
+	}
+
+	var apiErr *APIError
+	if !errors.As(results[0].Err, &apiErr) || apiErr.StatusCode != 422 {
+		t.Errorf("results[0] = %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, ErrRecipientOptedOut) {
+		t.Errorf("results[1] = %+v", results[1])
+	}
+	if last := results[len(results)-1]; last.Err != nil || last.Index != len(reqs)-1 || last.MessageID != fmt.Sprintf("msg_%d@example.com", MaxBatchSize) {
+		t.Errorf("last result = %+v", last)
+	}
+
+	failed := FailedResults(results)
+	if len(failed) != 2 || failed[0].Index != 0 || failed[1].Index != 1 {
+		t.Errorf("FailedResults() = %+v, want the first two messages", failed)
+	}
+}
+
+func TestClient_SendBatchCallError(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var payload struct {
+			Messages []EmailRequest `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		results := make([]SendResponse, len(payload.Messages))
+		for i, msg := range payload.Messages {
+			results[i].MessageID = "msg_" + msg.To.String()
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": results})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithoutRetries())
+	client.baseURL = srv.URL
+
+	var reqs []*EmailRequest
+	for i := range 2*MaxBatchSize + 1 {
+		reqs = append(reqs, &EmailRequest{To: Recipients{fmt.Sprintf("%d@example.com", i)}})
+	}
+
+	results, err := client.SendBatch(context.Background(), reqs)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("SendBatch() error = %v, want *APIError", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+	for i, result := range results {
+		if i < MaxBatchSize {
+			if result.Err != nil || result.MessageID == "" {
+				t.Fatalf("results[%d] = %+v, want sent", i, result)
+			}
+			continue
+		}
+		if result.Err != err {
+			t.Fatalf("results[%d].Err = %v, want the batch call error", i, result.Err)
+		}
+	}
+}
+
+// optedOut is a PreferenceChecker where the listed recipients opted out of
+// every category
+type optedOut map[string]bool
+
+func (o optedOut) Allows(ctx context.Context, email, category string) (bool, error) {
+	return !o[email], nil
+}
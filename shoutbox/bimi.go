@@ -0,0 +1,163 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// TXTResolver looks up DNS TXT records; *net.Resolver implements it
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// BIMIConfig describes the brand logo published for a sending domain
+type BIMIConfig struct {
+	// Selector defaults to "default"
+	Selector string
+	// LogoURL is the HTTPS URL of the SVG Tiny PS logo
+	LogoURL string
+	// CertificateURL is the HTTPS URL of the Verified Mark Certificate
+	// (PEM). Optional, but required by Gmail and Apple Mail.
+	CertificateURL string
+}
+
+// RecordName returns the DNS name of the BIMI record for domain
+func (c BIMIConfig) RecordName(domain string) string {
+	selector := c.Selector
+	if selector == "" {
+		selector = "default"
+	}
+	return selector + "._bimi." + strings.TrimSuffix(domain, ".")
+}
+
+// RecordValue returns the TXT record value to publish
+func (c BIMIConfig) RecordValue() (string, error) {
+	if err := checkHTTPSURL(c.LogoURL, ".svg"); err != nil {
+		return "", fmt.Errorf("invalid logo URL: %w", err)
+	}
+	value := "v=BIMI1; l=" + c.LogoURL
+	if c.CertificateURL != "" {
+		if err := checkHTTPSURL(c.CertificateURL, ".pem"); err != nil {
+			return "", fmt.Errorf("invalid certificate URL: %w", err)
+		}
+		value += "; a=" + c.CertificateURL
+	}
+	return value, nil
+}
+
+// BIMIStatus is the current DNS state of a domain's BIMI setup
+type BIMIStatus struct {
+	// Record is the published BIMI record, if any
+	Record string
+	// RecordMatches reports whether Record equals the configured value
+	RecordMatches bool
+	// DMARCPolicy is the p= tag of the domain's DMARC record
+	DMARCPolicy string
+	// Problems lists what prevents the logo from being displayed
+	Problems []string
+}
+
+// OK reports whether no problems were found
+func (s *BIMIStatus) OK() bool {
+	return len(s.Problems) == 0
+}
+
+// CheckBIMI validates the published BIMI and DMARC records of domain
+// against cfg. A nil resolver uses net.DefaultResolver.
+func CheckBIMI(ctx context.Context, resolver TXTResolver, domain string, cfg BIMIConfig) (*BIMIStatus, error) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	want, err := cfg.RecordValue()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &BIMIStatus{}
+	records, err := lookupTXT(ctx, resolver, cfg.RecordName(domain))
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if strings.HasPrefix(record, "v=BIMI1") {
+			status.Record = record
+		}
+	}
+	switch {
+	case status.Record == "":
+		status.Problems = append(status.Problems, "no BIMI record published at "+cfg.RecordName(domain))
+	case normalizeTagList(status.Record) != normalizeTagList(want):
+		status.Problems = append(status.Problems, "published BIMI record does not match: want "+want)
+	default:
+		status.RecordMatches = true
+	}
+
+	records, err = lookupTXT(ctx, resolver, "_dmarc."+strings.TrimSuffix(domain, "."))
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if strings.HasPrefix(record, "v=DMARC1") {
+			status.DMARCPolicy = parseTagList(record)["p"]
+		}
+	}
+	if status.DMARCPolicy != "quarantine" && status.DMARCPolicy != "reject" {
+		status.Problems = append(status.Problems, "DMARC policy must be quarantine or reject for BIMI")
+	}
+
+	return status, nil
+}
+
+// lookupTXT returns no records, rather than an error, for names that
+// don't exist
+func lookupTXT(ctx context.Context, resolver TXTResolver, name string) ([]string, error) {
+	records, err := resolver.LookupTXT(ctx, name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error looking up %s: %w", name, err)
+	}
+	return records, nil
+}
+
+// parseTagList parses a DNS tag list such as "v=DMARC1; p=reject"
+func parseTagList(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if ok {
+			tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return tags
+}
+
+func normalizeTagList(record string) string {
+	var parts []string
+	for _, part := range strings.Split(record, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func checkHTTPSURL(raw, ext string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return errors.New("must be an absolute https URL")
+	}
+	if !strings.HasSuffix(strings.ToLower(u.Path), ext) {
+		return fmt.Errorf("must point to a %s file", ext)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package shoutbox
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+type fakeTXTResolver map[string][]string
+
+func (r fakeTXTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	records, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestBIMIConfig_RecordValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     BIMIConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "logo only",
+			cfg:  BIMIConfig{LogoURL: "https://example.com/logo.svg"},
+			want: "v=BIMI1; l=https://example.com/logo.svg",
+		},
+		{
+			name: "logo and certificate",
+			cfg:  BIMIConfig{LogoURL: "https://example.com/logo.svg", CertificateURL: "https://example.com/vmc.pem"},
+			want: "v=BIMI1; l=https://example.com/logo.svg; a=https://example.com/vmc.pem",
+		},
+		{name: "http logo", cfg: BIMIConfig{LogoURL: "http://example.com/logo.svg"}, wantErr: true},
+		{name: "png logo", cfg: BIMIConfig{LogoURL: "https://example.com/logo.png"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.RecordValue()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RecordValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RecordValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBIMI(t *testing.T) {
+	cfg := BIMIConfig{LogoURL: "https://example.com/logo.svg"}
+
+	tests := []struct {
+		name         string
+		resolver     fakeTXTResolver
+		wantOK       bool
+		wantProblems int
+	}{
+		{
+			name: "valid",
+			resolver: fakeTXTResolver{
+				"default._bimi.example.com": {"v=BIMI1;l=https://example.com/logo.svg;"},
+				"_dmarc.example.com":        {"v=DMARC1; p=reject; rua=mailto:d@example.com"},
+			},
+			wantOK: true,
+		},
+		{
+			name: "missing record and weak DMARC",
+			resolver: fakeTXTResolver{
+				"_dmarc.example.com": {"v=DMARC1; p=none"},
+			},
+			wantProblems: 2,
+		},
+		{
+			name: "mismatched record",
+			resolver: fakeTXTResolver{
+				"default._bimi.example.com": {"v=BIMI1; l=https://example.com/old.svg"},
+				"_dmarc.example.com":        {"v=DMARC1; p=quarantine"},
+			},
+			wantProblems: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := CheckBIMI(context.Background(), tt.resolver, "example.com", cfg)
+			if err != nil {
+				t.Fatalf("CheckBIMI() error = %v", err)
+			}
+			if status.OK() != tt.wantOK || len(status.Problems) != tt.wantProblems {
+				t.Errorf("CheckBIMI() = %+v", status)
+			}
+		})
+	}
+}
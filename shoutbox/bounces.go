@@ -0,0 +1,186 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BounceType tells permanent from temporary delivery failures
+type BounceType string
+
+const (
+	// BounceHard is a permanent failure; the address should not be mailed
+	// again
+	BounceHard BounceType = "hard"
+	// BounceSoft is a temporary failure, e.g. a full mailbox
+	BounceSoft BounceType = "soft"
+)
+
+// BounceReason is the classified cause of a bounce
+type BounceReason string
+
+const (
+	BounceUnknownUser BounceReason = "unknown_user"
+	BounceBadDomain   BounceReason = "bad_domain"
+	BounceMailboxFull BounceReason = "mailbox_full"
+	BounceBlocked     BounceReason = "blocked"
+	BounceContent     BounceReason = "content"
+	BounceMessageSize BounceReason = "message_size"
+	BounceUnavailable BounceReason = "unavailable"
+	BounceOtherReason BounceReason = "other"
+)
+
+// Bounce is a failed delivery to one recipient
+type Bounce struct {
+	Email          string       `json:"email"`
+	MessageID      string       `json:"message_id"`
+	Type           BounceType   `json:"type"`
+	Reason         BounceReason `json:"reason"`
+	DiagnosticCode string       `json:"diagnostic_code,omitempty"`
+	Timestamp      time.Time    `json:"timestamp"`
+}
+
+// IsHard reports whether the bounce is permanent
+func (b *Bounce) IsHard() bool {
+	return b.Type == BounceHard
+}
+
+// ClassifyBounce derives the bounce reason from an SMTP diagnostic code,
+// e.g. "smtp; 550 5.1.1 user unknown"
+func ClassifyBounce(diagnostic string) BounceReason {
+	d := strings.ToLower(diagnostic)
+	switch {
+	case strings.Contains(d, "5.1.1") || strings.Contains(d, "user unknown") || strings.Contains(d, "no such user"):
+		return BounceUnknownUser
+	case strings.Contains(d, "5.1.2") || strings.Contains(d, "host not found"):
+		return BounceBadDomain
+	case strings.Contains(d, "5.2.2") || strings.Contains(d, "4.2.2") || strings.Contains(d, "mailbox full") || strings.Contains(d, "quota"):
+		return BounceMailboxFull
+	case strings.Contains(d, "5.3.4") || strings.Contains(d, "too large"):
+		return BounceMessageSize
+	case strings.Contains(d, "5.7.1") || strings.Contains(d, "blocked") || strings.Contains(d, "blacklist"):
+		return BounceBlocked
+	case strings.Contains(d, "5.6.") || strings.Contains(d, "spam") || strings.Contains(d, "content"):
+		return BounceContent
+	case strings.Contains(d, "4.4.") || strings.Contains(d, "try again"):
+		return BounceUnavailable
+	}
+	return BounceOtherReason
+}
+
+// BounceQuery filters the bounces returned by List
+type BounceQuery struct {
+	Type   BounceType
+	Reason BounceReason
+	Email  string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Cursor string
+}
+
+// BounceList is one page of bounces
+type BounceList struct {
+	Bounces    []Bounce `json:"bounces"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// BounceHook is notified of bounces, e.g. to stop mailing an address in
+// the application's own database
+type BounceHook interface {
+	HandleBounce(ctx context.Context, b *Bounce) error
+}
+
+// BounceHookFunc adapts a function to a BounceHook
+type BounceHookFunc func(ctx context.Context, b *Bounce) error
+
+// HandleBounce calls f(ctx, b)
+func (f BounceHookFunc) HandleBounce(ctx context.Context, b *Bounce) error {
+	return f(ctx, b)
+}
+
+// DisableOnHardBounce returns a hook that calls disable with the address
+// of every hard bounce and ignores soft bounces
+func DisableOnHardBounce(disable func(ctx context.Context, email string) error) BounceHook {
+	return BounceHookFunc(func(ctx context.Context, b *Bounce) error {
+		if !b.IsHard() {
+			return nil
+		}
+		return disable(ctx, b.Email)
+	})
+}
+
+// BouncesService reads the account's bounces
+type BouncesService struct {
+	client *Client
+}
+
+// Bounces returns the bounces API
+func (c *Client) Bounces() *BouncesService {
+	return &BouncesService{client: c}
+}
+
+// List returns one page of bounces matching q. Pass NextCursor as
+// q.Cursor to fetch the following page.
+func (s *BouncesService) List(ctx context.Context, q *BounceQuery) (*BounceList, error) {
+	var list BounceList
+	if err := s.client.do(ctx, http.MethodGet, "/bounces?"+q.values().Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Process pages through every bounce matching q and passes each to hook,
+// stopping at the first error. It returns the cursor after the last page,
+// which can be stored to resume later.
+func (s *BouncesService) Process(ctx context.Context, q *BounceQuery, hook BounceHook) (string, error) {
+	query := BounceQuery{}
+	if q != nil {
+		query = *q
+	}
+	for {
+		list, err := s.List(ctx, &query)
+		if err != nil {
+			return query.Cursor, err
+		}
+		for i := range list.Bounces {
+			if err := hook.HandleBounce(ctx, &list.Bounces[i]); err != nil {
+				return query.Cursor, err
+			}
+		}
+		if list.NextCursor == "" {
+			return query.Cursor, nil
+		}
+		query.Cursor = list.NextCursor
+	}
+}
+
+func (q *BounceQuery) values() url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+	setIf := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	setIf("type", string(q.Type))
+	setIf("reason", string(q.Reason))
+	setIf("email", q.Email)
+	setIf("cursor", q.Cursor)
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		v.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
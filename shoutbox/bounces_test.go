@@ -0,0 +1,78 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestClassifyBounce(t *testing.T) {
+	tests := []struct {
+		diagnostic string
+		want       BounceReason
+	}{
+		{diagnostic: "smtp; 550 5.1.1 <ada@example.com>: Recipient address rejected: User unknown", want: BounceUnknownUser},
+		{diagnostic: "smtp; 552 5.2.2 Mailbox full", want: BounceMailboxFull},
+		{diagnostic: "smtp; 452 4.2.2 Over quota", want: BounceMailboxFull},
+		{diagnostic: "smtp; 554 5.7.1 Service unavailable; client host blocked", want: BounceBlocked},
+		{diagnostic: "smtp; 552 5.3.4 Message too large", want: BounceMessageSize},
+		{diagnostic: "smtp; 421 4.4.2 Connection timed out", want: BounceUnavailable},
+		{diagnostic: "smtp; 550 something odd", want: BounceOtherReason},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.want), func(t *testing.T) {
+			if got := ClassifyBounce(tt.diagnostic); got != tt.want {
+				t.Errorf("ClassifyBounce(%q) = %v, want %v", tt.diagnostic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBouncesService_Process(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			json.NewEncoder(w).Encode(BounceList{
+				Bounces: []Bounce{
+					{Email: "gone@example.com", Type: BounceHard, Reason: BounceUnknownUser},
+					{Email: "full@example.com", Type: BounceSoft, Reason: BounceMailboxFull},
+				},
+				NextCursor: "page2",
+			})
+		case "page2":
+			json.NewEncoder(w).Encode(BounceList{
+				Bounces: []Bounce{{Email: "blocked@example.com", Type: BounceHard, Reason: BounceBlocked}},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	var disabled []string
+	hook := DisableOnHardBounce(func(ctx context.Context, email string) error {
+		disabled = append(disabled, email)
+		return nil
+	})
+	cursor, err := client.Bounces().Process(context.Background(), &BounceQuery{Limit: 2}, hook)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	if want := []string{"gone@example.com", "blocked@example.com"}; !slices.Equal(disabled, want) {
+		t.Errorf("disabled = %v, want %v", disabled, want)
+	}
+	if cursor != "page2" {
+		t.Errorf("cursor = %q, want page2", cursor)
+	}
+	if want := []string{"/bounces?limit=2", "/bounces?cursor=page2&limit=2"}; !slices.Equal(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,148 @@
+package shoutbox
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// iCalendar methods of an invite
+const (
+	CalendarRequest = "REQUEST"
+	CalendarCancel  = "CANCEL"
+)
+
+// CalendarEvent is a meeting invitation sent as an iCalendar (RFC 5545)
+// part, which mail clients render with accept/decline buttons
+type CalendarEvent struct {
+	// UID identifies the event and is required. Send updates and
+	// cancellations with the same UID and a higher Sequence.
+	UID      string
+	Sequence int
+	// Method is CalendarRequest (the default) or CalendarCancel
+	Method string
+
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+
+	Organizer     string
+	OrganizerName string
+	Attendees     []string
+
+	// Timestamp is when the invite was created; defaults to now
+	Timestamp time.Time
+}
+
+func (e *CalendarEvent) method() string {
+	if e.Method == "" {
+		return CalendarRequest
+	}
+	return e.Method
+}
+
+// ContentType returns the MIME type of the iCalendar part
+func (e *CalendarEvent) ContentType() string {
+	return fmt.Sprintf("text/calendar; method=%s; charset=UTF-8", e.method())
+}
+
+// ICS renders the event as an iCalendar object
+func (e *CalendarEvent) ICS() []byte {
+	stamp := e.Timestamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	status := "CONFIRMED"
+	if e.method() == CalendarCancel {
+		status = "CANCELLED"
+	}
+
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldICSLine(s))
+		b.WriteString("\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("PRODID:-//Shoutbox//shoutbox-go//EN")
+	line("VERSION:2.0")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:" + e.method())
+	line("BEGIN:VEVENT")
+	line("UID:" + e.UID)
+	line("SEQUENCE:" + fmt.Sprint(e.Sequence))
+	line("DTSTAMP:" + icsTime(stamp))
+	line("DTSTART:" + icsTime(e.Start))
+	line("DTEND:" + icsTime(e.End))
+	line("SUMMARY:" + escapeICSText(e.Summary))
+	if e.Description != "" {
+		line("DESCRIPTION:" + escapeICSText(e.Description))
+	}
+	if e.Location != "" {
+		line("LOCATION:" + escapeICSText(e.Location))
+	}
+	if e.Organizer != "" {
+		organizer := "ORGANIZER"
+		if e.OrganizerName != "" {
+			organizer += ";CN=" + icsParam(e.OrganizerName)
+		}
+		line(organizer + ":mailto:" + e.Organizer)
+	}
+	for _, attendee := range e.Attendees {
+		line("ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:" + attendee)
+	}
+	line("STATUS:" + status)
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// NewCalendarAttachment returns the event as an invite.ics attachment, for
+// clients that offer the invite as a file
+func NewCalendarAttachment(event *CalendarEvent) Attachment {
+	return Attachment{
+		Filename:    "invite.ics",
+		Content:     event.ICS(),
+		ContentType: event.ContentType(),
+	}
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICSText(s string) string {
+	return icsTextEscaper.Replace(s)
+}
+
+// icsParam quotes a parameter value if it contains separators
+func icsParam(s string) string {
+	s = strings.ReplaceAll(s, `"`, "'")
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// foldICSLine splits lines longer than 75 octets, without breaking UTF-8
+// sequences, by continuing them on lines starting with a space
+func foldICSLine(s string) string {
+	var b strings.Builder
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74
+	}
+	b.WriteString(s)
+	return b.String()
+}
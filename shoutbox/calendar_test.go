@@ -0,0 +1,90 @@
+package shoutbox
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendarEvent_ICS(t *testing.T) {
+	start := time.Date(2024, 5, 1, 15, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	event := &CalendarEvent{
+		UID:           "kickoff-1@yourdomain.com",
+		Summary:       "Kickoff; agenda, notes",
+		Description:   "Line one\nLine two",
+		Start:         start,
+		End:           start.Add(time.Hour),
+		Organizer:     "pm@yourdomain.com",
+		OrganizerName: "Doe, Jane",
+		Attendees:     []string{"ada@example.com"},
+		Timestamp:     time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	ics := strings.ReplaceAll(string(event.ICS()), "\r\n ", "")
+	for _, want := range []string{
+		"METHOD:REQUEST\r\n",
+		"DTSTART:20240501T130000Z\r\n",
+		"DTEND:20240501T140000Z\r\n",
+		"DTSTAMP:20240401T000000Z\r\n",
+		`SUMMARY:Kickoff\; agenda\, notes` + "\r\n",
+		`DESCRIPTION:Line one\nLine two` + "\r\n",
+		"ORGANIZER;CN=\"Doe, Jane\":mailto:pm@yourdomain.com\r\n",
+		"RSVP=TRUE:mailto:ada@example.com\r\n",
+		"STATUS:CONFIRMED\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("ICS() missing %q:\n%s", want, ics)
+		}
+	}
+	if got := event.ContentType(); got != "text/calendar; method=REQUEST; charset=UTF-8" {
+		t.Errorf("ContentType() = %q", got)
+	}
+
+	event.Method = CalendarCancel
+	if !strings.Contains(string(event.ICS()), "STATUS:CANCELLED\r\n") {
+		t.Error("cancelled event should have STATUS:CANCELLED")
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short", line: "SUMMARY:Standup"},
+		{name: "ascii", line: "DESCRIPTION:" + strings.Repeat("a", 200)},
+		{name: "multibyte", line: "DESCRIPTION:" + strings.Repeat("é", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := foldICSLine(tt.line)
+			for _, l := range strings.Split(folded, "\r\n") {
+				if len(l) > 75 {
+					t.Errorf("line of %d octets: %q", len(l), l)
+				}
+			}
+			if got := strings.ReplaceAll(folded, "\r\n ", ""); got != tt.line {
+				t.Errorf("unfolded = %q, want %q", got, tt.line)
+			}
+		})
+	}
+}
+
+func TestSMTPClient_buildMessageCalendar(t *testing.T) {
+	client := &SMTPClient{}
+	data, err := client.buildMessage(&EmailMessage{
+		From:     "pm@yourdomain.com",
+		To:       []string{"ada@example.com"},
+		HTML:     "<p>Join us</p>",
+		Calendar: &CalendarEvent{UID: "kickoff-1", Summary: "Kickoff"},
+	})
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	if !bytes.Contains(data, []byte("Content-Type: multipart/alternative; boundary=")) ||
+		!bytes.Contains(data, []byte("Content-Type: text/calendar; method=REQUEST; charset=UTF-8")) {
+		t.Errorf("missing calendar part:\n%s", data)
+	}
+}
@@ -0,0 +1,172 @@
+package shoutbox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// ChartKind selects how a Chart is drawn
+type ChartKind int
+
+const (
+	// LineChart draws each series as a line, e.g. for time series
+	LineChart ChartKind = iota
+	// BarChart draws each series as bars grouped by index
+	BarChart
+)
+
+// ChartSeries is one data series of a Chart. Values are plotted at evenly
+// spaced positions along the x axis.
+type ChartSeries struct {
+	Values []float64
+	Color  color.Color
+}
+
+// Chart is a simple line or bar chart rendered to an image
+type Chart struct {
+	Kind   ChartKind
+	Width  int
+	Height int
+	Series []ChartSeries
+}
+
+var (
+	chartBackground = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	chartAxis       = color.RGBA{0x66, 0x66, 0x66, 0xFF}
+	chartGrid       = color.RGBA{0xE5, 0xE5, 0xE5, 0xFF}
+	chartPalette    = []color.Color{
+		color.RGBA{0x25, 0x63, 0xEB, 0xFF},
+		color.RGBA{0xF5, 0x9E, 0x0B, 0xFF},
+		color.RGBA{0x10, 0xB9, 0x81, 0xFF},
+		color.RGBA{0xEF, 0x44, 0x44, 0xFF},
+	}
+)
+
+const chartPadding = 16
+
+// Render draws the chart
+func (c Chart) Render() (image.Image, error) {
+	if c.Width <= 2*chartPadding || c.Height <= 2*chartPadding {
+		return nil, fmt.Errorf("chart size %dx%d is too small", c.Width, c.Height)
+	}
+	points := 0
+	low, high := 0.0, 0.0
+	for _, s := range c.Series {
+		points = max(points, len(s.Values))
+		for _, v := range s.Values {
+			low, high = math.Min(low, v), math.Max(high, v)
+		}
+	}
+	if points == 0 {
+		return nil, errors.New("chart has no data")
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(chartBackground), image.Point{}, draw.Src)
+
+	plot := image.Rect(chartPadding, chartPadding, c.Width-chartPadding, c.Height-chartPadding)
+	y := func(v float64) int {
+		return plot.Max.Y - int(math.Round((v-low)/(high-low)*float64(plot.Dy())))
+	}
+
+	for i := 1; i <= 4; i++ {
+		gy := plot.Max.Y - plot.Dy()*i/4
+		drawLine(img, plot.Min.X, gy, plot.Max.X, gy, chartGrid)
+	}
+
+	slot := float64(plot.Dx()) / float64(points)
+	for si, s := range c.Series {
+		col := s.Color
+		if col == nil {
+			col = chartPalette[si%len(chartPalette)]
+		}
+
+		switch c.Kind {
+		case BarChart:
+			barWidth := slot * 0.8 / float64(len(c.Series))
+			for i, v := range s.Values {
+				x0 := plot.Min.X + int(slot*float64(i)+slot*0.1+barWidth*float64(si))
+				x1 := max(x0+1, x0+int(barWidth))
+				y0, y1 := y(v), y(0)
+				if y0 > y1 {
+					y0, y1 = y1, y0
+				}
+				draw.Draw(img, image.Rect(x0, y0, x1, max(y1, y0+1)), image.NewUniform(col), image.Point{}, draw.Src)
+			}
+		default:
+			for i := 1; i < len(s.Values); i++ {
+				x0 := plot.Min.X + int(slot*(float64(i-1)+0.5))
+				x1 := plot.Min.X + int(slot*(float64(i)+0.5))
+				drawThickLine(img, x0, y(s.Values[i-1]), x1, y(s.Values[i]), col)
+			}
+		}
+	}
+
+	drawLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, chartAxis)
+	drawLine(img, plot.Min.X, y(0), plot.Max.X, y(0), chartAxis)
+
+	return img, nil
+}
+
+// NewChartInline renders chart as a PNG and returns it as an inline
+// attachment. Reference it from the HTML body as <img src="cid:name">.
+func NewChartInline(name string, chart Chart) (Attachment, error) {
+	img, err := chart.Render()
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return Attachment{}, fmt.Errorf("error encoding chart: %w", err)
+	}
+
+	return Attachment{
+		Filename:    name + ".png",
+		Content:     buf.Bytes(),
+		ContentType: "image/png",
+		ContentID:   name,
+		Inline:      true,
+	}, nil
+}
+
+// drawLine draws a one pixel wide line using Bresenham's algorithm
+func drawLine(img draw.Image, x0, y0, x1, y1 int, col color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawThickLine(img draw.Image, x0, y0, x1, y1 int, col color.Color) {
+	for _, d := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		drawLine(img, x0+d[0], y0+d[1], x1+d[0], y1+d[1], col)
+	}
+}
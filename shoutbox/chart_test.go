@@ -0,0 +1,66 @@
+package shoutbox
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestChart_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		chart   Chart
+		wantErr bool
+	}{
+		{
+			name: "line chart",
+			chart: Chart{Kind: LineChart, Width: 400, Height: 200, Series: []ChartSeries{
+				{Values: []float64{3, 5, 2, 8, 6, 9, 7}},
+			}},
+		},
+		{
+			name: "grouped bar chart with negatives",
+			chart: Chart{Kind: BarChart, Width: 400, Height: 200, Series: []ChartSeries{
+				{Values: []float64{3, -2, 4}},
+				{Values: []float64{1, 2, 3}},
+			}},
+		},
+		{
+			name:    "no data",
+			chart:   Chart{Width: 400, Height: 200},
+			wantErr: true,
+		},
+		{
+			name:    "too small",
+			chart:   Chart{Width: 10, Height: 10, Series: []ChartSeries{{Values: []float64{1}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := tt.chart.Render()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (img.Bounds().Dx() != tt.chart.Width || img.Bounds().Dy() != tt.chart.Height) {
+				t.Errorf("Render() bounds = %v", img.Bounds())
+			}
+		})
+	}
+}
+
+func TestNewChartInline(t *testing.T) {
+	att, err := NewChartInline("weekly-sends", Chart{Kind: BarChart, Width: 300, Height: 150, Series: []ChartSeries{
+		{Values: []float64{120, 340, 280}},
+	}})
+	if err != nil {
+		t.Fatalf("NewChartInline() error = %v", err)
+	}
+	if att.ContentID != "weekly-sends" || !att.Inline || att.Filename != "weekly-sends.png" {
+		t.Errorf("NewChartInline() = %+v", att)
+	}
+	if _, err := png.Decode(bytes.NewReader(att.Content)); err != nil {
+		t.Errorf("png.Decode() error = %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package shoutbox
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending a request while the circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Circuit breaker defaults, used for zero CircuitBreakerOptions fields
+const (
+	DefaultCircuitFailureRate = 0.5
+	DefaultCircuitMinRequests = 10
+	DefaultCircuitWindow      = 30 * time.Second
+	DefaultCircuitOpenTimeout = 30 * time.Second
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through, counting failures
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen lets probe requests through to test whether the API
+	// has recovered
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreakerOptions configures a CircuitBreaker. Zero fields use the
+// defaults.
+type CircuitBreakerOptions struct {
+	// FailureRate is the fraction of failed requests, between 0 and 1,
+	// that opens the circuit. Network errors and 5xx responses are
+	// failures.
+	FailureRate float64
+	// MinRequests is the number of requests in a window below which the
+	// circuit doesn't open
+	MinRequests int
+	// Window is the period the failure rate is measured over
+	Window time.Duration
+	// OpenTimeout is how long the circuit stays open before letting
+	// probe requests through
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of probe requests let through while
+	// half-open. The circuit closes when all of them succeed and opens
+	// again when one fails. The default is 1.
+	HalfOpenProbes int
+}
+
+// CircuitBreaker fails API requests fast while the API is failing, instead
+// of letting them wait for timeouts. A single breaker can be shared by
+// several clients.
+type CircuitBreaker struct {
+	mu   sync.Mutex
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	state CircuitState
+	// generation changes with every state change, so outcomes of requests
+	// let through in an earlier state are ignored
+	generation  uint64
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+// NewCircuitBreaker returns a closed circuit breaker
+func NewCircuitBreaker(opts CircuitBreakerOptions) *CircuitBreaker {
+	if opts.FailureRate <= 0 {
+		opts.FailureRate = DefaultCircuitFailureRate
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = DefaultCircuitMinRequests
+	}
+	if opts.Window <= 0 {
+		opts.Window = DefaultCircuitWindow
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = DefaultCircuitOpenTimeout
+	}
+	if opts.HalfOpenProbes <= 0 {
+		opts.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{opts: opts, now: time.Now}
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if a request can't be sent now, or the
+// generation to pass to record with its outcome
+func (b *CircuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireOpen()
+	switch b.state {
+	case CircuitOpen:
+		return 0, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probes >= b.opts.HalfOpenProbes {
+			return 0, ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// record counts the outcome of a request let through in generation. A
+// request that neither succeeded nor failed, e.g. because it was
+// cancelled, only frees its probe.
+func (b *CircuitBreaker) record(generation uint64, success, failure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case CircuitClosed:
+		if !success && !failure {
+			return
+		}
+		now := b.now()
+		if now.Sub(b.windowStart) >= b.opts.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		b.requests++
+		if failure {
+			b.failures++
+		}
+		if b.requests >= b.opts.MinRequests && float64(b.failures) >= b.opts.FailureRate*float64(b.requests) {
+			b.open()
+		}
+	case CircuitHalfOpen:
+		switch {
+		case failure:
+			b.open()
+		case success:
+			b.successes++
+			if b.successes >= b.opts.HalfOpenProbes {
+				b.setState(CircuitClosed)
+				b.windowStart, b.requests, b.failures = b.now(), 0, 0
+			}
+		default:
+			b.probes--
+		}
+	}
+}
+
+// expireOpen moves an open breaker to half-open once OpenTimeout passed
+func (b *CircuitBreaker) expireOpen() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.opts.OpenTimeout {
+		b.setState(CircuitHalfOpen)
+		b.probes, b.successes = 0, 0
+	}
+}
+
+func (b *CircuitBreaker) open() {
+	b.setState(CircuitOpen)
+	b.openedAt = b.now()
+}
+
+func (b *CircuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.generation++
+}
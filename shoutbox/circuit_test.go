@@ -0,0 +1,123 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(CircuitBreakerOptions{MinRequests: 4, OpenTimeout: time.Minute})
+	b.now = func() time.Time { return now }
+
+	// request lets a request through, if allowed, with the given outcome
+	request := func(success, failure bool) error {
+		generation, err := b.allow()
+		if err == nil {
+			b.record(generation, success, failure)
+		}
+		return err
+	}
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		success   bool
+		failure   bool
+		wantErr   error
+		wantState CircuitState
+	}{
+		{name: "success", success: true, wantState: CircuitClosed},
+		{name: "failure", failure: true, wantState: CircuitClosed},
+		{name: "cancelled", wantState: CircuitClosed},
+		{name: "below min requests", failure: true, wantState: CircuitClosed},
+		{name: "failure rate reached", failure: true, wantState: CircuitOpen},
+		{name: "fails fast", success: true, wantErr: ErrCircuitOpen, wantState: CircuitOpen},
+		{name: "cancelled probe", advance: time.Minute, wantState: CircuitHalfOpen},
+		{name: "failed probe", failure: true, wantState: CircuitOpen},
+		{name: "reopened", advance: 30 * time.Second, success: true, wantErr: ErrCircuitOpen, wantState: CircuitOpen},
+		{name: "successful probe", advance: 30 * time.Second, success: true, wantState: CircuitClosed},
+		{name: "counts reset", failure: true, wantState: CircuitClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if err := request(tt.success, tt.failure); err != tt.wantErr {
+				t.Errorf("allow() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := b.State(); got != tt.wantState {
+				t.Errorf("State() = %v, want %v", got, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(CircuitBreakerOptions{MinRequests: 1, HalfOpenProbes: 2})
+	b.now = func() time.Time { return now }
+
+	generation, _ := b.allow()
+	b.record(generation, false, true)
+	now = now.Add(DefaultCircuitOpenTimeout)
+
+	first, err1 := b.allow()
+	second, err2 := b.allow()
+	if _, err := b.allow(); err1 != nil || err2 != nil || err != ErrCircuitOpen {
+		t.Fatalf("allow() errors = %v, %v, %v, want two probes", err1, err2, err)
+	}
+	b.record(first, true, false)
+	if got := b.State(); got != CircuitHalfOpen {
+		t.Errorf("State() after one probe = %v, want %v", got, CircuitHalfOpen)
+	}
+	b.record(second, true, false)
+	if got := b.State(); got != CircuitClosed {
+		t.Errorf("State() after both probes = %v, want %v", got, CircuitClosed)
+	}
+
+	// Outcomes of probes from before the circuit closed are ignored
+	b.record(first, false, true)
+	if got := b.State(); got != CircuitClosed {
+		t.Errorf("State() after a stale failure = %v, want %v", got, CircuitClosed)
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	breaker := NewCircuitBreaker(CircuitBreakerOptions{MinRequests: 2})
+	client := NewClient("test-key", WithCircuitBreaker(breaker), WithRetryPolicy(RetryPolicy{
+		MaxAttempts:        4,
+		RetryStatuses:      []int{http.StatusServiceUnavailable},
+		RetryNetworkErrors: true,
+	}))
+	client.baseURL = srv.URL
+	req := &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}
+
+	_, err := client.SendEmail(context.Background(), req)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("SendEmail() error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2 before the circuit opened", n)
+	}
+
+	_, err = client.SendEmail(context.Background(), req)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("SendEmail() error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want none while open", n)
+	}
+}
@@ -4,8 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 )
 
 // Client represents a Shoutbox API client
@@ -13,63 +21,520 @@ type Client struct {
 	apiKey     string
 	httpClient *http.Client
 	baseURL    string
+	proxy      *url.URL
+	tracer     Tracer
+	logger     Logger
+	logLevel   slog.Level
+
+	interceptors []Interceptor
+
+	environment     Environment
+	region          Region
+	retry           RetryPolicy
+	rateLimiter     *RateLimiter
+	breaker         *CircuitBreaker
+	trackOpens      *bool
+	trackClicks     *bool
+	trackingDomain  string
+	utm             *UTMParams
+	darkMode        *DarkModeOptions
+	emojiShortcodes bool
+	seedList        *SeedList
+	preferences     PreferenceChecker
+	sandbox         bool
+
+	attachmentStore  AttachmentStore
+	offloadThreshold int
+	imageOptimizer   *ImageOptimizer
+	mjmlCompiler     MJMLCompiler
+	sizeLimits       SizeLimits
+	spamThreshold    *float64
+
+	compressThreshold int
+	uploadThreshold   int
 }
 
 // EmailRequest represents an email request to the Shoutbox API
 type EmailRequest struct {
-	From    string   `json:"from"`
-	To      string   `json:"to"`
-	Subject string   `json:"subject"`
-	HTML    string   `json:"html"`
-	Name    string   `json:"name,omitempty"`
-	ReplyTo string   `json:"reply_to,omitempty"`
+	From    string            `json:"from"`
+	To      Recipients        `json:"to"`
+	Cc      Recipients        `json:"cc,omitempty"`
+	Bcc     Recipients        `json:"bcc,omitempty"`
+	Subject string            `json:"subject"`
+	HTML    string            `json:"html"`
+	Text    string            `json:"text,omitempty"`
+	Name    string            `json:"name,omitempty"`
+	ReplyTo string            `json:"reply_to,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
+
+	// ReturnPath, when set, is the envelope sender bounces go to instead
+	// of From, e.g. a VERP bounce address
+	ReturnPath string `json:"return_path,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// MJML, when set, is compiled by the client's MJML compiler into HTML,
+	// and Text if it is empty, before sending
+	MJML string `json:"-"`
+
+	// TemplateID sends a Shoutbox-hosted template rendered with Variables
+	// instead of Subject and HTML
+	TemplateID string         `json:"template_id,omitempty"`
+	Variables  map[string]any `json:"variables,omitempty"`
+
+	// Personalizations sends a separate copy of the message to each entry,
+	// in addition to any To, Cc and Bcc recipients, with the {{name}}
+	// placeholders in Subject, HTML and Text replaced by the entry's
+	// Variables on the server
+	Personalizations []Personalization `json:"personalizations,omitempty"`
+
+	// TrackOpens and TrackClicks override the account tracking settings for
+	// this message. Leave nil to use the account default.
+	TrackOpens  *bool `json:"track_opens,omitempty"`
+	TrackClicks *bool `json:"track_clicks,omitempty"`
+	// TrackingDomain overrides the client's tracking domain for this
+	// message, e.g. to brand links per tenant
+	TrackingDomain string `json:"-"`
+
+	// Direction sets the text direction of the HTML body, e.g. RTL for
+	// Arabic or Hebrew content
+	Direction Direction `json:"-"`
+
+	// SendAt schedules delivery for a later time. Leave nil to send
+	// immediately.
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// Category is the subscription category of the message, e.g.
+	// "newsletter", used for preference-center opt-outs
+	Category string `json:"category,omitempty"`
+
+	// Tags categorize the message in stats and events, e.g. "welcome".
+	// Metadata correlates it with internal entities, e.g. an order ID;
+	// both are echoed back in webhook events.
+	Tags     []string          `json:"tags,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// MessageID sets the Message-ID, e.g. "order-1234@yourdomain.com", so
+	// later messages can reply to this one. InReplyTo and References
+	// thread the message with earlier ones; see SetParent. They are sent
+	// as headers.
+	MessageID  string   `json:"-"`
+	InReplyTo  string   `json:"-"`
+	References []string `json:"-"`
+
+	// ReadReceiptTo asks the recipient's mail client to send a read
+	// receipt to the address (Disposition-Notification-To). It is sent as
+	// a header.
+	ReadReceiptTo string `json:"-"`
+
+	// Timeout, when set, limits each API request of this send instead of
+	// the client's timeout set with WithTimeout
+	Timeout time.Duration `json:"-"`
+}
+
+// requestTimeoutKey is the context key of the timeout of a send's
+// requests, overriding the HTTP client's
+type requestTimeoutKey struct{}
+
+// SendResponse is the result of a successful send
+type SendResponse struct {
+	// MessageID identifies the message in webhook events and the events API
+	MessageID string `json:"message_id"`
+	// Accepted are the recipients the API accepted for delivery
+	Accepted []string `json:"accepted,omitempty"`
+	// Metadata holds any additional details returned by the API
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// sendPayload is the JSON body of a send request, carrying client-level
+// settings alongside the message
+type sendPayload struct {
+	*EmailRequest
+	TrackingDomain string `json:"tracking_domain,omitempty"`
+	Test           bool   `json:"test,omitempty"`
 }
 
-// NewClient creates a new Shoutbox API client
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey:     apiKey,
-		httpClient: &http.Client{},
-		baseURL:    "https://api.shoutbox.net",
+// NewClient creates a new Shoutbox API client. The environment is read
+// from SHOUTBOX_ENV unless WithEnvironment is used.
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		apiKey:      apiKey,
+		httpClient:  &http.Client{},
+		environment: EnvironmentFromEnv(),
+		retry:       DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	if c.baseURL == "" {
+		c.baseURL = c.environment.RegionBaseURL(c.region)
+	}
+	if c.proxy != nil {
+		c.httpClient = proxyHTTPClient(c.httpClient, c.proxy)
+	}
+	return c
 }
 
 // SendEmail sends an email using the Shoutbox API
-func (c *Client) SendEmail(ctx context.Context, req *EmailRequest) error {
-	jsonData, err := json.Marshal(req)
+func (c *Client) SendEmail(ctx context.Context, req *EmailRequest) (*SendResponse, error) {
+	if req.Timeout > 0 {
+		ctx = context.WithValue(ctx, requestTimeoutKey{}, req.Timeout)
+	}
+	req, err := c.prepare(ctx, req)
 	if err != nil {
-		return fmt.Errorf("error marshaling request: %w", err)
+		return nil, err
+	}
+	if c.sandbox {
+		return c.sendSandboxed(req)
+	}
+	if err := c.checkSpamThreshold(ctx, req); err != nil {
+		return nil, err
+	}
+	if c.seedList != nil {
+		return c.sendToSeedList(ctx, req)
+	}
+	var resp SendResponse
+	if err := c.do(ctx, http.MethodPost, "/send", c.newSendPayload(req), &resp); err != nil {
+		return nil, err
 	}
+	return &resp, nil
+}
+
+// prepare applies the client's preference checks and attachment handling
+// to req, returning a copy if anything changed. Streamed attachments are
+// read into memory.
+func (c *Client) prepare(ctx context.Context, req *EmailRequest) (*EmailRequest, error) {
+	if err := req.validateHeaders(); err != nil {
+		return nil, err
+	}
+	if err := req.validateAddresses(); err != nil {
+		return nil, err
+	}
+	if c.preferences != nil && req.Category != "" {
+		filtered := *req
+		for _, list := range []*Recipients{&filtered.To, &filtered.Cc, &filtered.Bcc} {
+			allowed, err := filterRecipients(ctx, c.preferences, req.Category, *list)
+			if err != nil {
+				return nil, fmt.Errorf("error checking preferences: %w", err)
+			}
+			*list = allowed
+		}
+		if len(req.Personalizations) > 0 {
+			allowed, err := filterPersonalizations(ctx, c.preferences, req.Category, req.Personalizations)
+			if err != nil {
+				return nil, fmt.Errorf("error checking preferences: %w", err)
+			}
+			filtered.Personalizations = allowed
+		}
+		if len(filtered.To)+len(filtered.Cc)+len(filtered.Bcc)+len(filtered.Personalizations) == 0 {
+			return nil, ErrRecipientOptedOut
+		}
+		req = &filtered
+	}
+	if req.MJML != "" {
+		compiled := *req
+		if err := compileMJML(ctx, c.mjmlCompiler, req.MJML, &compiled.HTML, &compiled.Text); err != nil {
+			return nil, err
+		}
+		req = &compiled
+	}
+	if slices.ContainsFunc(req.Attachments, func(a Attachment) bool { return a.Compress != "" }) {
+		compressed := *req
+		var err error
+		if compressed.Attachments, err = compressAttachments(req.Attachments); err != nil {
+			return nil, err
+		}
+		req = &compressed
+	}
+	if c.uploadThreshold > 0 && !c.sandbox {
+		var err error
+		if req, err = c.uploadAttachments(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	// Without a store nothing is offloaded, so oversized messages fail
+	// before streamed attachments are read
+	if c.attachmentStore == nil {
+		if err := c.sizeLimits.check(req.Attachments, len(req.HTML)+len(req.Text)); err != nil {
+			return nil, err
+		}
+	}
+	if slices.ContainsFunc(req.Attachments, func(a Attachment) bool { return a.Open != nil }) {
+		loaded := *req
+		var err error
+		if loaded.Attachments, err = loadAttachments(req.Attachments); err != nil {
+			return nil, err
+		}
+		req = &loaded
+	}
+	if c.attachmentStore != nil || c.imageOptimizer != nil {
+		var err error
+		if req, err = c.prepareAttachments(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	if c.attachmentStore != nil {
+		if err := c.sizeLimits.check(req.Attachments, len(req.HTML)+len(req.Text)); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// prepareAttachments returns a copy of req with large attachments
+// offloaded and inline images optimized
+func (c *Client) prepareAttachments(ctx context.Context, req *EmailRequest) (*EmailRequest, error) {
+	prepared := *req
+	if c.attachmentStore != nil {
+		if err := offloadAttachments(ctx, c.attachmentStore, c.offloadThreshold, &prepared.HTML, &prepared.Attachments); err != nil {
+			return nil, err
+		}
+	}
+	if c.imageOptimizer != nil {
+		attachments, err := c.imageOptimizer.optimizeAttachments(prepared.Attachments)
+		if err != nil {
+			return nil, err
+		}
+		prepared.Attachments = attachments
+	}
+	return &prepared, nil
+}
 
-	httpReq, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		c.baseURL+"/send",
-		bytes.NewBuffer(jsonData),
+// sendToSeedList sends a copy of req to each seed list address instead of
+// its recipients, one per personalization if it has any. The response has the message ID of the first seed and
+// the recipients accepted across all seeds.
+func (c *Client) sendToSeedList(ctx context.Context, req *EmailRequest) (*SendResponse, error) {
+	original := slices.Concat(req.To, req.Cc, req.Bcc)
+	for _, p := range req.Personalizations {
+		original = append(original, p.To)
+	}
+	combined := &SendResponse{}
+	for _, seed := range c.seedList.Addresses {
+		r := *req
+		r.To = Recipients{seed}
+		r.Cc, r.Bcc = nil, nil
+		r.Subject = c.seedList.subject(req.Subject)
+		r.Headers = c.seedList.headers(req.Headers, original)
+		if len(req.Personalizations) > 0 {
+			// The seed gets each personalized copy instead
+			r.To = nil
+			r.Personalizations = make([]Personalization, len(req.Personalizations))
+			for i, p := range req.Personalizations {
+				r.Personalizations[i] = Personalization{To: seed, Variables: p.Variables}
+			}
+		}
+		var resp SendResponse
+		if err := c.do(ctx, http.MethodPost, "/send", c.newSendPayload(&r), &resp); err != nil {
+			return nil, fmt.Errorf("error sending to seed %s: %w", seed, err)
+		}
+		if combined.MessageID == "" {
+			combined.MessageID = resp.MessageID
+		}
+		combined.Accepted = append(combined.Accepted, resp.Accepted...)
+	}
+	return combined, nil
+}
+
+// do sends a JSON request to the API and decodes the response into out,
+// if out is non-nil. Requests are retried according to the retry policy.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var jsonData []byte
+	if body != nil {
+		var err error
+		if jsonData, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("error marshaling request: %w", err)
+		}
+	}
+
+	payload, encoding, err := c.encodeBody(jsonData)
+	if err != nil {
+		return err
+	}
+	// Retries of a POST carry the same key, so the API can recognize a
+	// request it already processed, e.g. a send whose response was lost
+	var idempotencyKey string
+	if method == http.MethodPost {
+		if idempotencyKey, err = randomKey(); err != nil {
+			return fmt.Errorf("error generating idempotency key: %w", err)
+		}
+	}
+
+	urlPath, _, _ := strings.Cut(path, "?")
+	ctx, span := startSpan(ctx, c.tracer, "shoutbox "+method+" "+urlPath)
+	defer span.End()
+	span.SetAttributes(
+		Attribute{AttrHTTPMethod, method},
+		Attribute{AttrURLPath, urlPath},
+		Attribute{AttrMessageSize, len(jsonData)},
 	)
+	if n, ok := payloadRecipients(body); ok {
+		span.SetAttributes(Attribute{AttrRecipients, n})
+	}
+	log := c.eventLogger()
+	// finish records the outcome of the last attempt on the span and log
+	finish := func(attempt, status int, err error) error {
+		span.SetAttributes(Attribute{AttrRetries, attempt - 1})
+		if status != 0 {
+			span.SetAttributes(Attribute{AttrHTTPStatusCode, status})
+		}
+		if err != nil {
+			span.RecordError(err)
+			log.log(ctx, slog.LevelError, "shoutbox: request failed", "method", method, "path", urlPath, "attempts", attempt, "error", err)
+		} else {
+			log.log(ctx, slog.LevelDebug, "shoutbox: request completed", "method", method, "path", urlPath, "status", status)
+		}
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		canRetry := attempt < c.retry.MaxAttempts
+
+		log.log(ctx, slog.LevelDebug, "shoutbox: sending request", "method", method, "path", urlPath, "attempt", attempt)
+		resp, err := c.send(ctx, method, path, payload, encoding, idempotencyKey)
+		if err != nil {
+			if !canRetry || !c.retry.RetryNetworkErrors || ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+				return finish(attempt, 0, err)
+			}
+			wait := c.retry.backoff(attempt)
+			log.log(ctx, slog.LevelWarn, "shoutbox: retrying request", "method", method, "path", urlPath, "attempt", attempt, "error", err, "backoff", wait)
+			if err := sleepContext(ctx, wait); err != nil {
+				return finish(attempt, 0, err)
+			}
+			continue
+		}
+
+		if canRetry && c.retry.retryStatus(resp.StatusCode) {
+			wait, ok := retryDelay(resp, time.Now())
+			if !ok {
+				wait = c.retry.backoff(attempt)
+			} else if limit := c.retry.maxRetryAfter(); limit > 0 && wait > limit {
+				defer drainBody(resp.Body)
+				return finish(attempt, resp.StatusCode, decodeResponse(resp, out))
+			}
+			log.log(ctx, slog.LevelWarn, "shoutbox: retrying request", "method", method, "path", urlPath, "attempt", attempt, "status", resp.StatusCode, "backoff", wait)
+			drainBody(resp.Body)
+			if err := sleepContext(ctx, wait); err != nil {
+				return finish(attempt, resp.StatusCode, err)
+			}
+			continue
+		}
+
+		defer drainBody(resp.Body)
+		return finish(attempt, resp.StatusCode, decodeResponse(resp, out))
+	}
+}
+
+func (c *Client) eventLogger() eventLogger {
+	return eventLogger{logger: c.logger, level: c.logLevel, secret: c.apiKey}
+}
+
+// send makes a single request to the API. jsonData is encoded with
+// contentEncoding, and idempotencyKey sent in the Idempotency-Key header,
+// if set.
+func (c *Client) send(ctx context.Context, method, path string, jsonData []byte, contentEncoding, idempotencyKey string) (*http.Response, error) {
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var reqBody io.Reader
+	if jsonData != nil {
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
 	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-	httpReq.Header.Set("Content-Type", "application/json")
+	if jsonData != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if contentEncoding != "" {
+		httpReq.Header.Set("Content-Encoding", contentEncoding)
+	}
+	if idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	var generation uint64
+	if c.breaker != nil {
+		if generation, err = c.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := c.roundTrip(httpReq)
+	if c.breaker != nil {
+		// Cancelled requests say nothing about the API's health
+		failed := err != nil || resp.StatusCode >= 500
+		c.breaker.record(generation, !failed, failed && ctx.Err() == nil)
+	}
 	if err != nil {
-		return fmt.Errorf("error sending request: %w", err)
+		return nil, fmt.Errorf("error sending request: %w", err)
 	}
-	defer resp.Body.Close()
+	if err := decompressResponse(resp); err != nil {
+		drainBody(resp.Body)
+		return nil, err
+	}
+	return resp, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		var errResp struct {
-			Error string `json:"error"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-			return fmt.Errorf("error response with status %d", resp.StatusCode)
+// decodeResponse returns an *APIError for a non-2xx response, or decodes
+// the body into out, if out is non-nil. An empty body leaves out unchanged.
+func decodeResponse(resp *http.Response, out any) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newAPIError(resp)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+			return fmt.Errorf("error decoding response: %w", err)
 		}
-		return fmt.Errorf("api error: %s", errResp.Error)
 	}
 
 	return nil
 }
+
+// newSendPayload applies the client defaults to req without modifying it
+func (c *Client) newSendPayload(req *EmailRequest) *sendPayload {
+	r := *req
+	if r.TrackOpens == nil {
+		r.TrackOpens = c.trackOpens
+	}
+	if r.TrackClicks == nil {
+		r.TrackClicks = c.trackClicks
+	}
+	if c.emojiShortcodes {
+		r.Subject = ExpandEmoji(r.Subject)
+		r.HTML = ExpandEmoji(r.HTML)
+		r.Text = ExpandEmoji(r.Text)
+	}
+	r.HTML = ApplyDirection(r.HTML, r.Direction)
+	if c.utm != nil {
+		r.HTML = AppendUTM(r.HTML, c.utm.forTags(r.Tags))
+	}
+	if c.darkMode != nil {
+		r.HTML = ApplyDarkMode(r.HTML, *c.darkMode)
+	}
+	if r.MessageID != "" || r.InReplyTo != "" || len(r.References) > 0 {
+		r.Headers = threadHeaders(r.Headers, r.MessageID, r.InReplyTo, r.References)
+	}
+	if r.ReadReceiptTo != "" {
+		r.Headers = maps.Clone(r.Headers)
+		if r.Headers == nil {
+			r.Headers = make(map[string]string)
+		}
+		r.Headers[headerReadReceiptTo] = r.ReadReceiptTo
+	}
+	trackingDomain := r.TrackingDomain
+	if trackingDomain == "" {
+		trackingDomain = c.trackingDomain
+	}
+	return &sendPayload{
+		EmailRequest:   &r,
+		TrackingDomain: trackingDomain,
+		Test:           c.environment.IsTest(),
+	}
+}
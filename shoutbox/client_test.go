@@ -1,9 +1,15 @@
 package shoutbox
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestClient_SendEmail(t *testing.T) {
@@ -33,7 +39,7 @@ func TestClient_SendEmail(t *testing.T) {
 			name: "basic email",
 			req: &EmailRequest{
 				From:    from,
-				To:      to,
+				To:      Recipients{to},
 				Subject: "Test Email",
 				HTML:    "<h1>Test</h1><p>This is a test email from the Shoutbox Go client.</p>",
 			},
@@ -43,7 +49,7 @@ func TestClient_SendEmail(t *testing.T) {
 			name: "email with name and reply-to",
 			req: &EmailRequest{
 				From:    from,
-				To:      to,
+				To:      Recipients{to},
 				Subject: "Test Email with Name",
 				HTML:    "<h1>Test</h1><p>This is a test email with sender name and reply-to.</p>",
 				Name:    "Test Sender",
@@ -55,7 +61,7 @@ func TestClient_SendEmail(t *testing.T) {
 			name: "email with custom headers",
 			req: &EmailRequest{
 				From:    from,
-				To:      to,
+				To:      Recipients{to},
 				Subject: "Test Email with Headers",
 				HTML:    "<h1>Test</h1><p>This is a test email with custom headers.</p>",
 				Headers: map[string]string{
@@ -68,7 +74,202 @@ func TestClient_SendEmail(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := client.SendEmail(context.Background(), tt.req)
+			_, err := client.SendEmail(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SendEmail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmailRequest_TrackingJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *EmailRequest
+		want string
+	}{
+		{
+			name: "account default",
+			req:  &EmailRequest{},
+			want: `{"from":"","to":"","subject":"","html":""}`,
+		},
+		{
+			name: "tracking disabled",
+			req: &EmailRequest{
+				TrackOpens:  Bool(false),
+				TrackClicks: Bool(false),
+			},
+			want: `{"from":"","to":"","subject":"","html":"","track_opens":false,"track_clicks":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_TrackingDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		req  *EmailRequest
+		want map[string]any
+	}{
+		{
+			name: "no defaults",
+			req:  &EmailRequest{},
+			want: map[string]any{},
+		},
+		{
+			name: "client defaults",
+			opts: []Option{WithTrackOpens(true), WithTrackClicks(true), WithTrackingDomain("links.example.com")},
+			req:  &EmailRequest{},
+			want: map[string]any{"track_opens": true, "track_clicks": true, "tracking_domain": "links.example.com"},
+		},
+		{
+			name: "message overrides client",
+			opts: []Option{WithTrackOpens(true), WithTrackClicks(true)},
+			req:  &EmailRequest{TrackClicks: Bool(false)},
+			want: map[string]any{"track_opens": true, "track_clicks": false},
+		},
+		{
+			name: "message tracking domain",
+			opts: []Option{WithTrackingDomain("links.example.com")},
+			req:  &EmailRequest{TrackingDomain: "click.tenant.example"},
+			want: map[string]any{"tracking_domain": "click.tenant.example"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&got)
+			}))
+			defer srv.Close()
+
+			client := NewClient("test-key", tt.opts...)
+			client.baseURL = srv.URL
+			if _, err := client.SendEmail(context.Background(), tt.req); err != nil {
+				t.Fatalf("SendEmail() error = %v", err)
+			}
+			for _, key := range []string{"track_opens", "track_clicks", "tracking_domain"} {
+				if got[key] != tt.want[key] {
+					t.Errorf("%s = %v, want %v", key, got[key], tt.want[key])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_SendEmailAttachments(t *testing.T) {
+	var got EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	store := &FileSystemStore{Dir: t.TempDir(), Secret: []byte("secret"), TTL: time.Hour, BaseURL: "https://files.example.com"}
+	client := NewClient("test-key", WithAttachmentStore(store, 1024))
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{
+		To:   Recipients{"ada@example.com"},
+		HTML: "<p>Report</p>",
+		Attachments: []Attachment{
+			{Filename: "notes.txt", Content: []byte("hello"), ContentType: "text/plain"},
+			{Filename: "large.csv", Content: bytes.Repeat([]byte("x"), 2048), ContentType: "text/csv"},
+			NewStreamingAttachmentFromReader(strings.NewReader("streamed"), "streamed.txt"),
+		},
+	}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	if len(got.Attachments) != 2 || string(got.Attachments[0].Content) != "hello" || got.Attachments[0].ContentType != "text/plain" {
+		t.Errorf("Attachments = %+v", got.Attachments)
+	}
+	if string(got.Attachments[1].Content) != "streamed" {
+		t.Errorf("streamed attachment = %+v", got.Attachments[1])
+	}
+	if !strings.Contains(got.HTML, "large.csv</a>") {
+		t.Errorf("HTML = %s", got.HTML)
+	}
+	if len(req.Attachments) != 3 || req.Attachments[2].Content != nil {
+		t.Error("SendEmail() modified the request")
+	}
+}
+
+func TestClient_SendEmailResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message_id": "msg_123", "accepted": ["ada@example.com"], "metadata": {"queue": "transactional"}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	resp, err := client.SendEmail(context.Background(), &EmailRequest{To: Recipients{"ada@example.com"}})
+	if err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if resp.MessageID != "msg_123" || len(resp.Accepted) != 1 || resp.Metadata["queue"] != "transactional" {
+		t.Errorf("SendEmail() = %+v", resp)
+	}
+}
+
+func TestEmailRequest_TemplateJSON(t *testing.T) {
+	req := &EmailRequest{
+		From:       "sender@example.com",
+		To:         Recipients{"ada@example.com"},
+		TemplateID: "welcome",
+		Variables:  map[string]any{"name": "Ada", "trial_days": 14},
+	}
+	got, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"from":"sender@example.com","to":"ada@example.com","subject":"","html":"","template_id":"welcome","variables":{"name":"Ada","trial_days":14}}`
+	if string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestClient_SendEmailTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(`{"message_id":"msg-1"}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		client  time.Duration
+		send    time.Duration
+		wantErr bool
+	}{
+		{name: "client timeout", client: 20 * time.Millisecond, wantErr: true},
+		{name: "shorter send timeout", client: time.Minute, send: 20 * time.Millisecond, wantErr: true},
+		{name: "longer send timeout", client: 20 * time.Millisecond, send: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("test-key", WithTimeout(tt.client), WithoutRetries())
+			client.baseURL = srv.URL
+			req := &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>", Timeout: tt.send}
+			_, err := client.SendEmail(context.Background(), req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SendEmail() error = %v, wantErr %v", err, tt.wantErr)
 			}
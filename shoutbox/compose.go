@@ -0,0 +1,104 @@
+package shoutbox
+
+// MessageOption sets part of an EmailMessage, so a message can be composed
+// by layers that each decide some of its fields. Message options are named
+// Msg*, apart from the client's With* options.
+type MessageOption func(*EmailMessage)
+
+// NewEmailMessage returns a message from from to the recipients in to,
+// with opts applied in order
+func NewEmailMessage(from string, to []string, subject string, opts ...MessageOption) *EmailMessage {
+	msg := &EmailMessage{From: from, To: to, Subject: subject}
+	return msg.Apply(opts...)
+}
+
+// Apply applies opts to msg in order and returns it
+func (msg *EmailMessage) Apply(opts ...MessageOption) *EmailMessage {
+	for _, opt := range opts {
+		opt(msg)
+	}
+	return msg
+}
+
+// MsgHTML sets the HTML body
+func MsgHTML(html string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.HTML = html
+	}
+}
+
+// MsgText sets the plain-text body
+func MsgText(text string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.Text = text
+	}
+}
+
+// MsgSenderName sets the display name of the sender
+func MsgSenderName(name string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.Name = name
+	}
+}
+
+// MsgReplyTo sets the Reply-To address
+func MsgReplyTo(address string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.ReplyTo = address
+	}
+}
+
+// MsgCc adds Cc recipients
+func MsgCc(addresses ...string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.Cc = append(msg.Cc, addresses...)
+	}
+}
+
+// MsgBcc adds Bcc recipients
+func MsgBcc(addresses ...string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.Bcc = append(msg.Bcc, addresses...)
+	}
+}
+
+// MsgAttachment adds an attachment
+func MsgAttachment(a Attachment) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.Attachments = append(msg.Attachments, a)
+	}
+}
+
+// MsgHeader sets a custom header, replacing an earlier value of it
+func MsgHeader(name, value string) MessageOption {
+	return func(msg *EmailMessage) {
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]string)
+		}
+		msg.Headers[name] = value
+	}
+}
+
+// MsgCategory sets the subscription category, used for opt-outs
+func MsgCategory(category string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.Category = category
+	}
+}
+
+// MsgTags adds tags
+func MsgTags(tags ...string) MessageOption {
+	return func(msg *EmailMessage) {
+		msg.Tags = append(msg.Tags, tags...)
+	}
+}
+
+// MsgMetadata sets a metadata key, replacing an earlier value of it
+func MsgMetadata(key, value string) MessageOption {
+	return func(msg *EmailMessage) {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]string)
+		}
+		msg.Metadata[key] = value
+	}
+}
@@ -0,0 +1,43 @@
+package shoutbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewEmailMessage(t *testing.T) {
+	report := Attachment{Filename: "report.pdf", ContentType: "application/pdf", Content: []byte("%PDF")}
+
+	msg := NewEmailMessage("news@example.com", []string{"ada@example.com"}, "Report",
+		MsgHTML("<p>Attached</p>"),
+		MsgSenderName("Example News"),
+		MsgHeader("X-Campaign", "spring"),
+		MsgTags("report"),
+	)
+	// A later layer adds to the message
+	msg.Apply(
+		MsgReplyTo("support@example.com"),
+		MsgBcc("archive@example.com"),
+		MsgAttachment(report),
+		MsgHeader("X-Campaign", "summer"),
+		MsgTags("monthly"),
+		MsgMetadata("account", "42"),
+	)
+
+	want := &EmailMessage{
+		From:        "news@example.com",
+		To:          []string{"ada@example.com"},
+		Bcc:         []string{"archive@example.com"},
+		Subject:     "Report",
+		HTML:        "<p>Attached</p>",
+		Name:        "Example News",
+		ReplyTo:     "support@example.com",
+		Attachments: []Attachment{report},
+		Headers:     map[string]string{"X-Campaign": "summer"},
+		Tags:        []string{"report", "monthly"},
+		Metadata:    map[string]string{"account": "42"},
+	}
+	if !reflect.DeepEqual(msg, want) {
+		t.Errorf("NewEmailMessage() = %+v, want %+v", msg, want)
+	}
+}
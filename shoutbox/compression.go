@@ -0,0 +1,148 @@
+package shoutbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// DefaultCompressionThreshold is the request body size from which
+// WithCompression gzips requests by default. Smaller bodies gain too
+// little to be worth compressing.
+const DefaultCompressionThreshold = 1024
+
+// encodeBody returns the JSON body of a request and its Content-Encoding,
+// gzipped if the client compresses bodies of its size
+func (c *Client) encodeBody(jsonData []byte) ([]byte, string, error) {
+	if c.compressThreshold <= 0 || len(jsonData) < c.compressThreshold {
+		return jsonData, "", nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(jsonData); err != nil {
+		return nil, "", fmt.Errorf("error compressing request: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, "", fmt.Errorf("error compressing request: %w", err)
+	}
+	return buf.Bytes(), "gzip", nil
+}
+
+// decompressResponse replaces the body of a gzip-encoded response with its
+// decoded content. The transport already does this for responses to the
+// requests it asked to be compressed, so this only handles responses
+// compressed regardless, or transports with compression disabled.
+func decompressResponse(resp *http.Response) error {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err == io.EOF {
+		// An empty body, e.g. of a 204 response
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error decompressing response: %w", err)
+	}
+	resp.Body = &gzipBody{Reader: zr, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return nil
+}
+
+// gzipBody reads a decompressed response body and closes the underlying
+// one
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// AttachmentCompression is how an attachment is compressed before sending
+type AttachmentCompression string
+
+const (
+	// CompressZip sends the attachment as a zip archive containing it,
+	// named after it with a .zip extension
+	CompressZip AttachmentCompression = "zip"
+	// CompressGzip sends the attachment gzipped, with a .gz extension
+	CompressGzip AttachmentCompression = "gzip"
+)
+
+// compressAttachments returns a copy of attachments with those that set
+// Compress compressed. Streamed attachments among them are read into
+// memory.
+func compressAttachments(attachments []Attachment) ([]Attachment, error) {
+	compressed := slices.Clone(attachments)
+	for i, a := range compressed {
+		if a.Compress == "" {
+			continue
+		}
+		if a.Inline {
+			return nil, fmt.Errorf("error compressing attachment %s: inline attachments can't be compressed", a.Filename)
+		}
+		var err error
+		if compressed[i], err = a.compressed(); err != nil {
+			return nil, err
+		}
+	}
+	return compressed, nil
+}
+
+// compressed returns a with its content compressed as a.Compress says
+func (a Attachment) compressed() (Attachment, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	out := Attachment{Filename: a.Filename}
+	switch a.Compress {
+	case CompressZip:
+		zw := zip.NewWriter(&buf)
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: a.Filename, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return Attachment{}, fmt.Errorf("error compressing attachment %s: %w", a.Filename, err)
+		}
+		w = zipEntryWriter{Writer: entry, zw: zw}
+		out.Filename = a.Filename + ".zip"
+		out.ContentType = "application/zip"
+	case CompressGzip:
+		w = gzip.NewWriter(&buf)
+		out.Filename = a.Filename + ".gz"
+		out.ContentType = "application/gzip"
+	default:
+		return Attachment{}, fmt.Errorf("error compressing attachment %s: unknown compression %q", a.Filename, a.Compress)
+	}
+
+	if a.Open != nil {
+		if err := a.copyTo(w); err != nil {
+			return Attachment{}, err
+		}
+	} else if _, err := w.Write(a.Content); err != nil {
+		return Attachment{}, fmt.Errorf("error compressing attachment %s: %w", a.Filename, err)
+	}
+	if err := w.Close(); err != nil {
+		return Attachment{}, fmt.Errorf("error compressing attachment %s: %w", a.Filename, err)
+	}
+	out.Content = buf.Bytes()
+	return out, nil
+}
+
+// zipEntryWriter writes a zip archive's only entry; closing it closes the
+// archive
+type zipEntryWriter struct {
+	io.Writer
+	zw *zip.Writer
+}
+
+func (w zipEntryWriter) Close() error {
+	return w.zw.Close()
+}
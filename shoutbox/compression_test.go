@@ -0,0 +1,205 @@
+package shoutbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Compression(t *testing.T) {
+	var gotEncoding string
+	var got EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		body := r.Body
+		if gotEncoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("reading gzip body: %v", err)
+				return
+			}
+			body = zr
+		}
+		json.NewDecoder(body).Decode(&got)
+
+		// Compress the response even though the client didn't ask for it
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		json.NewEncoder(zw).Encode(SendResponse{MessageID: "msg-1"})
+		zw.Close()
+	}))
+	defer srv.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true
+	client := NewClient("test-key", WithHTTPClient(&http.Client{Transport: transport}), WithCompression(0))
+	client.baseURL = srv.URL
+
+	tests := []struct {
+		name         string
+		html         string
+		wantEncoding string
+	}{
+		{name: "small body", html: "<p>Hi</p>"},
+		{name: "large body", html: "<p>" + strings.Repeat("Hello ", 1000) + "</p>", wantEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Hi", HTML: tt.html}
+			resp, err := client.SendEmail(context.Background(), req)
+			if err != nil {
+				t.Fatalf("SendEmail() error = %v", err)
+			}
+			if resp.MessageID != "msg-1" {
+				t.Errorf("MessageID = %q, want msg-1", resp.MessageID)
+			}
+			if gotEncoding != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", gotEncoding, tt.wantEncoding)
+			}
+			if got.HTML != tt.html {
+				t.Errorf("received HTML of %d bytes, want %d", len(got.HTML), len(tt.html))
+			}
+		})
+	}
+}
+
+func TestDecompressResponse_EmptyBody(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": {"gzip"}},
+		Body:   io.NopCloser(strings.NewReader("")),
+	}
+	if err := decompressResponse(resp); err != nil {
+		t.Fatalf("decompressResponse() error = %v", err)
+	}
+	if err := decodeResponse(&http.Response{StatusCode: http.StatusNoContent, Body: resp.Body}, &SendResponse{}); err != nil {
+		t.Errorf("decodeResponse() error = %v", err)
+	}
+}
+
+func TestCompressAttachments(t *testing.T) {
+	csv := []byte(strings.Repeat("id,email\n1,ada@example.com\n", 100))
+
+	tests := []struct {
+		name            string
+		attachment      Attachment
+		wantFilename    string
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			name:            "zip",
+			attachment:      Attachment{Filename: "export.csv", Content: csv, ContentType: "text/csv", Compress: CompressZip},
+			wantFilename:    "export.csv.zip",
+			wantContentType: "application/zip",
+		},
+		{
+			name:            "gzip",
+			attachment:      Attachment{Filename: "export.csv", Content: csv, ContentType: "text/csv", Compress: CompressGzip},
+			wantFilename:    "export.csv.gz",
+			wantContentType: "application/gzip",
+		},
+		{
+			name: "streamed",
+			attachment: Attachment{
+				Filename: "export.csv",
+				Open:     func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(csv)), nil },
+				Compress: CompressGzip,
+			},
+			wantFilename:    "export.csv.gz",
+			wantContentType: "application/gzip",
+		},
+		{
+			name:            "uncompressed",
+			attachment:      Attachment{Filename: "export.csv", Content: csv, ContentType: "text/csv"},
+			wantFilename:    "export.csv",
+			wantContentType: "text/csv",
+		},
+		{
+			name:       "inline",
+			attachment: Attachment{Filename: "logo.png", Content: csv, Inline: true, Compress: CompressZip},
+			wantErr:    true,
+		},
+		{
+			name:       "unknown",
+			attachment: Attachment{Filename: "export.csv", Content: csv, Compress: "brotli"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := compressAttachments([]Attachment{tt.attachment})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compressAttachments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			a := got[0]
+			if a.Filename != tt.wantFilename || a.ContentType != tt.wantContentType || a.Open != nil {
+				t.Errorf("compressAttachments() = %s (%s), want %s (%s)", a.Filename, a.ContentType, tt.wantFilename, tt.wantContentType)
+			}
+
+			var content []byte
+			switch tt.attachment.Compress {
+			case CompressZip:
+				zr, err := zip.NewReader(bytes.NewReader(a.Content), int64(len(a.Content)))
+				if err != nil || len(zr.File) != 1 || zr.File[0].Name != "export.csv" {
+					t.Fatalf("zip archive = %v, %v, want a single export.csv", zr, err)
+				}
+				f, _ := zr.File[0].Open()
+				content, _ = io.ReadAll(f)
+			case CompressGzip:
+				zr, err := gzip.NewReader(bytes.NewReader(a.Content))
+				if err != nil {
+					t.Fatalf("gzip.NewReader() error = %v", err)
+				}
+				content, _ = io.ReadAll(zr)
+			default:
+				content = a.Content
+			}
+			if !bytes.Equal(content, csv) {
+				t.Errorf("decompressed content differs from the original")
+			}
+			if tt.attachment.Compress != "" && len(a.Content) >= len(csv) {
+				t.Errorf("compressed size = %d, want less than %d", len(a.Content), len(csv))
+			}
+		})
+	}
+}
+
+func TestClient_CompressedAttachment(t *testing.T) {
+	var got EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	msg := &EmailMessage{
+		From:        "reports@example.com",
+		To:          []string{"ada@example.com"},
+		Subject:     "Logs",
+		Text:        "Attached",
+		Attachments: []Attachment{{Filename: "app.log", Content: []byte("started\n"), Compress: CompressZip}},
+	}
+	if _, err := client.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].Filename != "app.log.zip" {
+		t.Errorf("attachments = %+v, want app.log.zip", got.Attachments)
+	}
+	if msg.Attachments[0].Filename != "app.log" {
+		t.Error("Send() modified the message's attachments")
+	}
+}
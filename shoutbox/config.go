@@ -0,0 +1,261 @@
+package shoutbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMissingAPIKey is returned when a configuration has no API key
+var ErrMissingAPIKey = errors.New("missing API key")
+
+// Config holds the settings of a client, loaded with ConfigFromEnv or
+// LoadConfig. Zero fields keep the client defaults.
+type Config struct {
+	APIKey      string
+	BaseURL     string
+	Environment Environment
+	Region      Region
+	// Timeout limits each API request, including reading the response
+	Timeout time.Duration
+	// RateLimit is the maximum number of API requests per second
+	RateLimit float64
+	Proxy     *url.URL
+	Sandbox   bool
+
+	SMTPHost string
+	SMTPPort int
+}
+
+// configKeys are the keys of configuration files. The environment variable
+// of each key is SHOUTBOX_ followed by the key in upper case; the
+// environment itself is read from SHOUTBOX_ENV by NewClient.
+var configKeys = []string{
+	"api_key", "base_url", "environment", "region", "timeout",
+	"rate_limit", "proxy", "sandbox", "smtp_host", "smtp_port",
+}
+
+// ConfigFromEnv reads the configuration from SHOUTBOX_API_KEY,
+// SHOUTBOX_BASE_URL, SHOUTBOX_REGION, SHOUTBOX_TIMEOUT (e.g. "30s"),
+// SHOUTBOX_RATE_LIMIT, SHOUTBOX_PROXY, SHOUTBOX_SANDBOX, SHOUTBOX_SMTP_HOST
+// and SHOUTBOX_SMTP_PORT
+func ConfigFromEnv() (Config, error) {
+	var cfg Config
+	for _, key := range configKeys {
+		if key == "environment" {
+			continue
+		}
+		name := "SHOUTBOX_" + strings.ToUpper(key)
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			if err := cfg.set(key, value); err != nil {
+				return Config{}, fmt.Errorf("error reading %s: %w", name, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// LoadConfig reads the configuration from a JSON file or, if path ends in
+// .yaml or .yml, a YAML file of "key: value" lines. Keys are api_key,
+// base_url, environment, region, timeout, rate_limit, proxy, sandbox,
+// smtp_host and smtp_port.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading config: %w", err)
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		values, err = parseFlatYAML(data)
+	default:
+		values, err = parseFlatJSON(data)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+
+	var cfg Config
+	for key, value := range values {
+		if value == "" && slices.Contains(configKeys, key) {
+			continue
+		}
+		if err := cfg.set(key, value); err != nil {
+			return Config{}, fmt.Errorf("error parsing config %s: %s: %w", path, key, err)
+		}
+	}
+	return cfg, nil
+}
+
+// NewClientFromEnv creates a client configured by ConfigFromEnv. opts are
+// applied after the configuration.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientFromConfig(cfg, opts...)
+}
+
+// NewClientFromConfig creates a client configured by cfg. opts are applied
+// after the configuration.
+func NewClientFromConfig(cfg Config, opts ...Option) (*Client, error) {
+	if cfg.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+	return NewClient(cfg.APIKey, append(cfg.Options(), opts...)...), nil
+}
+
+// NewSMTPClientFromConfig creates an SMTP client configured by cfg
+func NewSMTPClientFromConfig(cfg Config) (*SMTPClient, error) {
+	if cfg.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+	host := cfg.SMTPHost
+	if host == "" && cfg.Environment != "" {
+		host = cfg.Environment.SMTPHost()
+	}
+	c := NewSMTPClientWithConfig(SMTPConfig{
+		Host:     host,
+		Port:     cfg.SMTPPort,
+		Password: cfg.APIKey,
+		Proxy:    cfg.Proxy,
+	})
+	if cfg.Environment != "" {
+		c.Environment = cfg.Environment
+	}
+	c.Sandbox = cfg.Sandbox
+	if cfg.RateLimit > 0 {
+		c.RateLimiter = NewRateLimiter(cfg.RateLimit, 1)
+	}
+	return c, nil
+}
+
+// Options returns the client options of the REST settings of cfg
+func (cfg Config) Options() []Option {
+	var opts []Option
+	if cfg.Environment != "" {
+		opts = append(opts, WithEnvironment(cfg.Environment))
+	}
+	if cfg.Region != RegionDefault {
+		opts = append(opts, WithRegion(cfg.Region))
+	}
+	if cfg.BaseURL != "" {
+		opts = append(opts, WithBaseURL(cfg.BaseURL))
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, WithTimeout(cfg.Timeout))
+	}
+	if cfg.RateLimit > 0 {
+		opts = append(opts, WithRateLimit(cfg.RateLimit))
+	}
+	if cfg.Proxy != nil {
+		opts = append(opts, WithProxy(cfg.Proxy))
+	}
+	if cfg.Sandbox {
+		opts = append(opts, WithSandbox())
+	}
+	return opts
+}
+
+// set parses value into the field named by key
+func (cfg *Config) set(key, value string) error {
+	var err error
+	switch key {
+	case "api_key":
+		cfg.APIKey = value
+	case "base_url":
+		cfg.BaseURL = value
+	case "environment":
+		switch env := Environment(strings.ToLower(value)); env {
+		case Production, Staging, Test:
+			cfg.Environment = env
+		default:
+			return fmt.Errorf("unknown environment %q", value)
+		}
+	case "region":
+		cfg.Region = Region(strings.ToLower(value))
+	case "timeout":
+		cfg.Timeout, err = time.ParseDuration(value)
+	case "rate_limit":
+		cfg.RateLimit, err = strconv.ParseFloat(value, 64)
+	case "proxy":
+		cfg.Proxy, err = url.Parse(value)
+	case "sandbox":
+		cfg.Sandbox, err = strconv.ParseBool(value)
+	case "smtp_host":
+		cfg.SMTPHost = value
+	case "smtp_port":
+		cfg.SMTPPort, err = strconv.Atoi(value)
+	default:
+		return errors.New("unknown key")
+	}
+	return err
+}
+
+// parseFlatJSON parses a JSON object of scalar values
+func parseFlatJSON(data []byte) (map[string]string, error) {
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch value.(type) {
+		case string, json.Number, bool:
+			values[key] = fmt.Sprint(value)
+		case nil:
+		default:
+			return nil, fmt.Errorf("%s: expected a string, number or boolean", key)
+		}
+	}
+	return values, nil
+}
+
+// parseFlatYAML parses YAML of "key: value" lines, the subset needed for
+// configuration files. Values may be quoted; # starts a comment.
+func parseFlatYAML(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("line %d: expected key: value", n)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0]:
+			if value[0] == '"' {
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", n, err)
+				}
+				value = unquoted
+			} else {
+				value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+			}
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, scanner.Err()
+}
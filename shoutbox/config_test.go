@@ -0,0 +1,110 @@
+package shoutbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SHOUTBOX_API_KEY", "env-key")
+	t.Setenv("SHOUTBOX_BASE_URL", "https://proxy.example.com/shoutbox")
+	t.Setenv("SHOUTBOX_TIMEOUT", "15s")
+	t.Setenv("SHOUTBOX_RATE_LIMIT", "2.5")
+	t.Setenv("SHOUTBOX_SMTP_PORT", "2525")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	if cfg.APIKey != "env-key" || cfg.BaseURL != "https://proxy.example.com/shoutbox" || cfg.Timeout != 15*time.Second ||
+		cfg.RateLimit != 2.5 || cfg.SMTPPort != 2525 {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv() error = %v", err)
+	}
+	if client.apiKey != "env-key" || client.baseURL != cfg.BaseURL || client.httpClient.Timeout != 15*time.Second || client.rateLimiter == nil {
+		t.Errorf("NewClientFromEnv() = %+v", client)
+	}
+
+	t.Setenv("SHOUTBOX_TIMEOUT", "soon")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() with invalid timeout succeeded")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	want := Config{APIKey: "file-key", Environment: Staging, Region: RegionEU, Timeout: 30 * time.Second, Sandbox: true, SMTPPort: 465}
+
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		wantErr  bool
+	}{
+		{
+			name:     "json",
+			file:     "shoutbox.json",
+			contents: `{"api_key": "file-key", "environment": "staging", "region": "eu", "timeout": "30s", "sandbox": true, "smtp_port": 465, "proxy": ""}`,
+		},
+		{
+			name: "yaml",
+			file: "shoutbox.yaml",
+			contents: "# Shoutbox settings\n" +
+				"api_key: \"file-key\"\n" +
+				"environment: staging\n" +
+				"region: 'eu'\n" +
+				"timeout: 30s # per request\n" +
+				"sandbox: true\n" +
+				"smtp_port: 465\n",
+		},
+		{name: "unknown key", file: "shoutbox.json", contents: `{"api_kye": "file-key"}`, wantErr: true},
+		{name: "nested", file: "shoutbox.json", contents: `{"smtp": {"port": 465}}`, wantErr: true},
+		{name: "unknown environment", file: "shoutbox.yml", contents: "environment: prod\n", wantErr: true},
+		{name: "invalid yaml", file: "shoutbox.yml", contents: "api_key\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != want {
+				t.Errorf("LoadConfig() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	if _, err := NewClientFromConfig(Config{}); !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("NewClientFromConfig() error = %v, want ErrMissingAPIKey", err)
+	}
+
+	cfg := Config{APIKey: "key", Environment: Staging, Region: RegionEU, SMTPPort: SMTPAlternatePort}
+	client, err := NewClientFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() error = %v", err)
+	}
+	if client.baseURL != "https://api.eu.staging.shoutbox.net" {
+		t.Errorf("baseURL = %q", client.baseURL)
+	}
+
+	smtpClient, err := NewSMTPClientFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewSMTPClientFromConfig() error = %v", err)
+	}
+	if smtpClient.Host != "mail.staging.shoutbox.net" || smtpClient.Port != SMTPAlternatePort || smtpClient.Password != "key" {
+		t.Errorf("NewSMTPClientFromConfig() = %+v", smtpClient)
+	}
+}
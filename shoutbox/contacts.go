@@ -0,0 +1,118 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Contact is a subscriber in the account's address book
+type Contact struct {
+	Email     string `json:"email"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	// Attributes are custom fields, e.g. "plan" or "city", available as
+	// variables in hosted templates
+	Attributes map[string]any `json:"attributes,omitempty"`
+	// Lists are the IDs of the lists the contact belongs to
+	Lists     []string  `json:"lists,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ContactQuery filters the contacts returned by List
+type ContactQuery struct {
+	// List returns only members of the list with this ID
+	List   string
+	Limit  int
+	Cursor string
+}
+
+// ContactList is one page of contacts
+type ContactList struct {
+	Contacts   []Contact `json:"contacts"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ContactsService manages the account's address book
+type ContactsService struct {
+	client *Client
+}
+
+// Contacts returns the address book API
+func (c *Client) Contacts() *ContactsService {
+	return &ContactsService{client: c}
+}
+
+// Create adds a contact to the address book and the lists in
+// contact.Lists
+func (s *ContactsService) Create(ctx context.Context, contact *Contact) (*Contact, error) {
+	var created Contact
+	if err := s.client.do(ctx, http.MethodPost, "/contacts", contact, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Get returns the contact with the given email
+func (s *ContactsService) Get(ctx context.Context, email string) (*Contact, error) {
+	var contact Contact
+	if err := s.client.do(ctx, http.MethodGet, "/contacts/"+url.PathEscape(email), nil, &contact); err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// Update replaces the names, attributes and list membership of
+// contact.Email
+func (s *ContactsService) Update(ctx context.Context, contact *Contact) (*Contact, error) {
+	var updated Contact
+	if err := s.client.do(ctx, http.MethodPut, "/contacts/"+url.PathEscape(contact.Email), contact, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete removes the contact with the given email from the address book
+// and all its lists
+func (s *ContactsService) Delete(ctx context.Context, email string) error {
+	return s.client.do(ctx, http.MethodDelete, "/contacts/"+url.PathEscape(email), nil, nil)
+}
+
+// List returns one page of contacts matching q. Pass NextCursor as
+// q.Cursor to fetch the following page.
+func (s *ContactsService) List(ctx context.Context, q *ContactQuery) (*ContactList, error) {
+	v := url.Values{}
+	if q != nil {
+		if q.List != "" {
+			v.Set("list", q.List)
+		}
+		if q.Limit > 0 {
+			v.Set("limit", strconv.Itoa(q.Limit))
+		}
+		if q.Cursor != "" {
+			v.Set("cursor", q.Cursor)
+		}
+	}
+
+	var list ContactList
+	if err := s.client.do(ctx, http.MethodGet, "/contacts?"+v.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// AddToList adds the contact with the given email to a list, keeping its
+// other memberships
+func (s *ContactsService) AddToList(ctx context.Context, email, listID string) error {
+	path := "/contacts/" + url.PathEscape(email) + "/lists"
+	return s.client.do(ctx, http.MethodPost, path, map[string]string{"list": listID}, nil)
+}
+
+// RemoveFromList removes the contact with the given email from a list
+func (s *ContactsService) RemoveFromList(ctx context.Context, email, listID string) error {
+	path := "/contacts/" + url.PathEscape(email) + "/lists/" + url.PathEscape(listID)
+	return s.client.do(ctx, http.MethodDelete, path, nil, nil)
+}
@@ -0,0 +1,88 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestContactsService(t *testing.T) {
+	var requests []string
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		var body map[string]any
+		if json.NewDecoder(r.Body).Decode(&body) == nil {
+			bodies = append(bodies, body)
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/contacts":
+			json.NewEncoder(w).Encode(ContactList{
+				Contacts:   []Contact{{Email: "ada@example.com", Lists: []string{"newsletter"}}},
+				NextCursor: "page2",
+			})
+		case r.Method != http.MethodDelete:
+			json.NewEncoder(w).Encode(Contact{Email: "ada@example.com", FirstName: "Ada", Attributes: map[string]any{"plan": "pro"}})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	s := client.Contacts()
+	ctx := context.Background()
+
+	contact := &Contact{Email: "ada@example.com", FirstName: "Ada", Attributes: map[string]any{"plan": "pro"}, Lists: []string{"newsletter"}}
+	created, err := s.Create(ctx, contact)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.FirstName != "Ada" || created.Attributes["plan"] != "pro" {
+		t.Errorf("Create() = %+v", created)
+	}
+	if _, err := s.Get(ctx, "ada+news@example.com"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := s.Update(ctx, contact); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	list, err := s.List(ctx, &ContactQuery{List: "newsletter", Limit: 50})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list.Contacts) != 1 || list.NextCursor != "page2" {
+		t.Errorf("List() = %+v", list)
+	}
+	if err := s.AddToList(ctx, "ada@example.com", "beta"); err != nil {
+		t.Fatalf("AddToList() error = %v", err)
+	}
+	if err := s.RemoveFromList(ctx, "ada@example.com", "beta"); err != nil {
+		t.Fatalf("RemoveFromList() error = %v", err)
+	}
+	if err := s.Delete(ctx, "ada@example.com"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	want := []string{
+		"POST /contacts",
+		"GET /contacts/ada+news@example.com",
+		"PUT /contacts/ada@example.com",
+		"GET /contacts?limit=50&list=newsletter",
+		"POST /contacts/ada@example.com/lists",
+		"DELETE /contacts/ada@example.com/lists/beta",
+		"DELETE /contacts/ada@example.com",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+	if got := bodies[0]["attributes"]; !reflect.DeepEqual(got, map[string]any{"plan": "pro"}) {
+		t.Errorf("Create() attributes = %v", got)
+	}
+	if got := bodies[2]["list"]; got != "beta" {
+		t.Errorf("AddToList() list = %v, want beta", got)
+	}
+}
@@ -0,0 +1,149 @@
+package shoutbox
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DarkModeOptions is the palette applied when the recipient's client
+// prefers a dark color scheme
+type DarkModeOptions struct {
+	Background string
+	Text       string
+	Link       string
+}
+
+// DefaultDarkMode is a neutral dark palette
+var DefaultDarkMode = DarkModeOptions{
+	Background: "#121212",
+	Text:       "#e5e5e5",
+	Link:       "#8ab4f8",
+}
+
+// DarkModeWarning flags a hard-coded color that is likely to be unreadable
+// or inverted badly by dark-mode clients
+type DarkModeWarning struct {
+	Property string
+	Color    string
+	Reason   string
+}
+
+func (w DarkModeWarning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Property, w.Color, w.Reason)
+}
+
+var (
+	headOpenPattern  = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+	htmlOpenPattern  = regexp.MustCompile(`(?i)<html(\s[^>]*)?>`)
+	styleAttrPattern = regexp.MustCompile(`(?i)\sstyle\s*=\s*("[^"]*"|'[^']*')`)
+	colorAttrPattern = regexp.MustCompile(`(?i)\s(bgcolor|color)\s*=\s*["']?(#?[0-9a-z]+)`)
+	cssColorPattern  = regexp.MustCompile(`(?i)(^|;)\s*(color|background-color|background)\s*:\s*(#[0-9a-f]{3,6}\b|rgb\([^)]*\)|[a-z]+)`)
+)
+
+// ApplyDarkMode injects the color-scheme meta tags and a
+// prefers-color-scheme stylesheet into body so that clients supporting it
+// render the message with opts' palette
+func ApplyDarkMode(body string, opts DarkModeOptions) string {
+	head := `<meta name="color-scheme" content="light dark">` +
+		`<meta name="supported-color-schemes" content="light dark">` +
+		`<style>:root{color-scheme:light dark;supported-color-schemes:light dark}` +
+		`@media (prefers-color-scheme: dark){body,table,td{background-color:` + opts.Background + ` !important;color:` + opts.Text + ` !important}` +
+		`a{color:` + opts.Link + ` !important}}` +
+		`[data-ogsc] body,[data-ogsc] table,[data-ogsc] td{color:` + opts.Text + ` !important}` +
+		`[data-ogsb] body,[data-ogsb] table,[data-ogsb] td{background-color:` + opts.Background + ` !important}</style>`
+
+	if loc := headOpenPattern.FindStringIndex(body); loc != nil {
+		return body[:loc[1]] + head + body[loc[1]:]
+	}
+	if loc := htmlOpenPattern.FindStringIndex(body); loc != nil {
+		return body[:loc[1]] + "<head>" + head + "</head>" + body[loc[1]:]
+	}
+	return "<head>" + head + "</head>" + body
+}
+
+// CheckDarkMode returns warnings for hard-coded text and background colors
+// in body that invert badly in dark-mode clients
+func CheckDarkMode(body string) []DarkModeWarning {
+	var warnings []DarkModeWarning
+	check := func(property, value string) {
+		lum, ok := colorLuminance(value)
+		if !ok {
+			return
+		}
+		switch {
+		case property == "color" && lum < 0.15:
+			warnings = append(warnings, DarkModeWarning{property, value, "dark text may be unreadable on a dark background"})
+		case property != "color" && lum > 0.85:
+			warnings = append(warnings, DarkModeWarning{property, value, "light background may clash with dark mode or be inverted"})
+		}
+	}
+
+	for _, m := range styleAttrPattern.FindAllStringSubmatch(body, -1) {
+		style := m[1][1 : len(m[1])-1]
+		for _, c := range cssColorPattern.FindAllStringSubmatch(style, -1) {
+			check(strings.ToLower(c[2]), c[3])
+		}
+	}
+	for _, m := range colorAttrPattern.FindAllStringSubmatch(body, -1) {
+		property := strings.ToLower(m[1])
+		if property == "bgcolor" {
+			property = "background-color"
+		}
+		check(property, m[2])
+	}
+	return warnings
+}
+
+var namedColors = map[string][3]float64{
+	"white": {255, 255, 255},
+	"black": {0, 0, 0},
+	"ivory": {255, 255, 240},
+	"snow":  {255, 250, 250},
+	"navy":  {0, 0, 128},
+}
+
+// colorLuminance returns the relative luminance (0 to 1) of a CSS color
+func colorLuminance(value string) (float64, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	var rgb [3]float64
+
+	switch {
+	case strings.HasPrefix(value, "#"):
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return 0, false
+		}
+		for i := range rgb {
+			v, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+			if err != nil {
+				return 0, false
+			}
+			rgb[i] = float64(v)
+		}
+	case strings.HasPrefix(value, "rgb("):
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "rgb("), ")"), ",")
+		if len(parts) != 3 {
+			return 0, false
+		}
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return 0, false
+			}
+			rgb[i] = v
+		}
+	default:
+		named, ok := namedColors[value]
+		if !ok {
+			return 0, false
+		}
+		rgb = named
+	}
+
+	return (0.2126*rgb[0] + 0.7152*rgb[1] + 0.0722*rgb[2]) / 255, true
+}
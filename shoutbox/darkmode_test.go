@@ -0,0 +1,46 @@
+package shoutbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyDarkMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		wantPrefix string
+	}{
+		{name: "existing head", html: `<html><head><title>x</title></head><body></body></html>`, wantPrefix: `<html><head><meta name="color-scheme"`},
+		{name: "no head", html: `<html lang="en"><body></body></html>`, wantPrefix: `<html lang="en"><head><meta name="color-scheme"`},
+		{name: "fragment", html: `<p>Hello</p>`, wantPrefix: `<head><meta name="color-scheme"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyDarkMode(tt.html, DefaultDarkMode)
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("ApplyDarkMode() = %s", got)
+			}
+			if !strings.Contains(got, "@media (prefers-color-scheme: dark)") {
+				t.Errorf("ApplyDarkMode() missing media query")
+			}
+		})
+	}
+}
+
+func TestCheckDarkMode(t *testing.T) {
+	html := `<table bgcolor="#ffffff"><tr><td style="color: #000; padding: 4px">Hi</td>` +
+		`<td style="background-color:rgb(250, 250, 250)">x</td><td style="color:#777777">ok</td></tr></table>`
+
+	got := CheckDarkMode(html)
+	want := []string{"color: #000", "background-color: rgb(250, 250, 250)", "background-color: #ffffff"}
+	if len(got) != len(want) {
+		t.Fatalf("CheckDarkMode() = %v", got)
+	}
+	for i, w := range got {
+		if !strings.HasPrefix(w.String(), want[i]) {
+			t.Errorf("warning %d = %s, want %s", i, w, want[i])
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUndeliverableDomain is returned for addresses whose domain has no
+// mail server
+var ErrUndeliverableDomain = errors.New("domain does not accept email")
+
+// Resolver looks up the DNS records used to check deliverability.
+// *net.Resolver implements it.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DefaultDeliverabilityTTL is how long ValidateEmailDeliverable caches the
+// result for a domain
+const DefaultDeliverabilityTTL = 10 * time.Minute
+
+// DeliverabilityChecker checks that address domains have MX records, or
+// A/AAAA records as a fallback, caching the result per domain. It is safe
+// for concurrent use.
+type DeliverabilityChecker struct {
+	resolver Resolver
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]deliverability
+}
+
+type deliverability struct {
+	err     error
+	expires time.Time
+}
+
+// NewDeliverabilityChecker creates a checker that uses resolver and caches
+// results for ttl. A nil resolver uses net.DefaultResolver.
+func NewDeliverabilityChecker(resolver Resolver, ttl time.Duration) *DeliverabilityChecker {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &DeliverabilityChecker{
+		resolver: resolver,
+		ttl:      ttl,
+		cache:    make(map[string]deliverability),
+	}
+}
+
+var defaultDeliverabilityChecker = NewDeliverabilityChecker(nil, DefaultDeliverabilityTTL)
+
+// ValidateEmailDeliverable validates email like ValidateEmail and checks
+// that its domain accepts email, returning ErrUndeliverableDomain if not.
+// DNS failures other than a missing domain are returned as is, so callers
+// can decide whether to accept the address anyway.
+func ValidateEmailDeliverable(ctx context.Context, email string) error {
+	return defaultDeliverabilityChecker.Check(ctx, email)
+}
+
+// Check validates email and checks that its domain accepts email
+func (c *DeliverabilityChecker) Check(ctx context.Context, email string) error {
+	if err := ValidateEmail(email); err != nil {
+		return err
+	}
+	addr, _ := mail.ParseAddress(email)
+	domain, err := DomainToASCII(addr.Address[strings.LastIndex(addr.Address, "@")+1:])
+	if err != nil {
+		return &AddressError{Address: email, Reason: "invalid domain"}
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.cache[domain]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.err
+	}
+
+	err = c.lookup(ctx, domain)
+	var dnsErr *net.DNSError
+	if err != nil && !errors.Is(err, ErrUndeliverableDomain) && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		// Temporary failures aren't cached
+		return fmt.Errorf("error looking up %s: %w", domain, err)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrUndeliverableDomain, domain)
+	}
+
+	c.mu.Lock()
+	c.cache[domain] = deliverability{err: err, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return err
+}
+
+// lookup checks the MX records of domain, falling back to its address
+// records as RFC 5321 requires. A null MX (RFC 7505) means the domain
+// accepts no email.
+func (c *DeliverabilityChecker) lookup(ctx context.Context, domain string) error {
+	mx, err := c.resolver.LookupMX(ctx, domain)
+	if err == nil && len(mx) > 0 {
+		if len(mx) == 1 && (mx[0].Host == "." || mx[0].Host == "") {
+			return ErrUndeliverableDomain
+		}
+		return nil
+	}
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return err
+	}
+
+	hosts, err := c.resolver.LookupHost(ctx, domain)
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		return ErrUndeliverableDomain
+	}
+	return nil
+}
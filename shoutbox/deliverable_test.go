@@ -0,0 +1,90 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers from maps; missing names are not found
+type fakeResolver struct {
+	mx      map[string][]*net.MX
+	hosts   map[string][]string
+	failing map[string]bool
+	lookups int
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.lookups++
+	if r.failing[name] {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+	}
+	if mx, ok := r.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if hosts, ok := r.hosts[host]; ok {
+		return hosts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestDeliverabilityChecker_Check(t *testing.T) {
+	resolver := &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.com": {{Host: "mx1.example.com.", Pref: 10}},
+			"null.test":   {{Host: ".", Pref: 0}},
+		},
+		hosts:   map[string][]string{"a-only.test": {"192.0.2.1"}},
+		failing: map[string]bool{"flaky.test": true},
+	}
+	checker := NewDeliverabilityChecker(resolver, time.Minute)
+
+	tests := []struct {
+		name    string
+		email   string
+		wantErr error
+	}{
+		{name: "mx", email: "ada@Example.com"},
+		{name: "a record fallback", email: "ada@a-only.test"},
+		{name: "null mx", email: "ada@null.test", wantErr: ErrUndeliverableDomain},
+		{name: "no such domain", email: "ada@missing.test", wantErr: ErrUndeliverableDomain},
+		{name: "invalid address", email: "ada", wantErr: ErrInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checker.Check(context.Background(), tt.email)
+			if tt.wantErr == nil && err != nil || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Check(%q) error = %v, want %v", tt.email, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		before := resolver.lookups
+		checker.Check(context.Background(), "grace@example.com")
+		checker.Check(context.Background(), "grace@missing.test")
+		if resolver.lookups != before {
+			t.Errorf("made %d lookups, want cached results", resolver.lookups-before)
+		}
+	})
+
+	t.Run("temporary failure", func(t *testing.T) {
+		before := resolver.lookups
+		for range 2 {
+			err := checker.Check(context.Background(), "ada@flaky.test")
+			if err == nil || errors.Is(err, ErrUndeliverableDomain) {
+				t.Errorf("Check() error = %v, want lookup error", err)
+			}
+		}
+		if resolver.lookups-before != 2 {
+			t.Error("temporary failure was cached")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"sync"
+	"time"
+)
+
+// SendFunc sends a single email message
+type SendFunc func(ctx context.Context, msg *EmailMessage) error
+
+// DigestConfig configures a Digest
+type DigestConfig struct {
+	// Window is how long events are collected for a recipient after the
+	// first one arrives before the digest is sent
+	Window time.Duration
+	// From and Name identify the sender of digest emails
+	From string
+	Name string
+	// Subject returns the subject for a digest of count items. Defaults to
+	// "You have N new notifications".
+	Subject func(recipient string, count int) string
+	// ItemTemplate renders a single item; the rendered items are
+	// concatenated to form the HTML body
+	ItemTemplate *template.Template
+	// Send delivers the composed digest
+	Send SendFunc
+	// OnError is called when a digest sent at the end of its window fails
+	OnError func(recipient string, err error)
+	// Direction is the text direction of the composed body
+	Direction Direction
+}
+
+// Digest collects events per recipient over a window and sends them as one
+// combined email instead of one email per event
+type Digest struct {
+	cfg DigestConfig
+
+	mu      sync.Mutex
+	pending map[string]*digestBatch
+	closed  bool
+	// timers tracks window timers that haven't finished their flush
+	timers sync.WaitGroup
+}
+
+type digestBatch struct {
+	items []any
+	timer *time.Timer
+}
+
+// NewDigest creates a new Digest
+func NewDigest(cfg DigestConfig) *Digest {
+	if cfg.Subject == nil {
+		cfg.Subject = func(recipient string, count int) string {
+			return fmt.Sprintf("You have %d new notifications", count)
+		}
+	}
+	return &Digest{
+		cfg:     cfg,
+		pending: make(map[string]*digestBatch),
+	}
+}
+
+// Add queues item for recipient. The first item for a recipient starts
+// that recipient's window.
+func (d *Digest) Add(recipient string, item any) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return errors.New("digest is closed")
+	}
+
+	batch, ok := d.pending[recipient]
+	if !ok {
+		batch = &digestBatch{}
+		d.timers.Add(1)
+		batch.timer = time.AfterFunc(d.cfg.Window, func() {
+			defer d.timers.Done()
+			if err := d.flushBatch(context.Background(), recipient, batch); err != nil && d.cfg.OnError != nil {
+				d.cfg.OnError(recipient, err)
+			}
+		})
+		d.pending[recipient] = batch
+	}
+	batch.items = append(batch.items, item)
+	return nil
+}
+
+// Flush sends all pending digests immediately
+func (d *Digest) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	batches := make(map[string]*digestBatch, len(d.pending))
+	for recipient, batch := range d.pending {
+		batches[recipient] = batch
+	}
+	d.mu.Unlock()
+
+	var errs []error
+	for recipient, batch := range batches {
+		if err := d.flushBatch(ctx, recipient, batch); err != nil {
+			errs = append(errs, fmt.Errorf("error sending digest to %s: %w", recipient, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops accepting items, flushes all pending digests and waits for
+// digests already being sent at the end of their window, for use on
+// shutdown
+func (d *Digest) Close(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	err := d.Flush(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		d.timers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Join(err, ctx.Err())
+	}
+}
+
+// flushBatch sends batch if it is still the pending batch of recipient, so
+// a late window timer doesn't send a newer batch early
+func (d *Digest) flushBatch(ctx context.Context, recipient string, batch *digestBatch) error {
+	d.mu.Lock()
+	ok := d.pending[recipient] == batch
+	if ok {
+		if batch.timer.Stop() {
+			d.timers.Done()
+		}
+		delete(d.pending, recipient)
+	}
+	d.mu.Unlock()
+
+	if !ok || len(batch.items) == 0 {
+		return nil
+	}
+
+	msg, err := d.compose(recipient, batch.items)
+	if err != nil {
+		return err
+	}
+	return d.cfg.Send(ctx, msg)
+}
+
+func (d *Digest) compose(recipient string, items []any) (*EmailMessage, error) {
+	var body bytes.Buffer
+	for _, item := range items {
+		if err := d.cfg.ItemTemplate.Execute(&body, item); err != nil {
+			return nil, fmt.Errorf("error rendering digest item: %w", err)
+		}
+	}
+
+	return &EmailMessage{
+		From:    d.cfg.From,
+		Name:    d.cfg.Name,
+		To:      []string{recipient},
+		Subject: d.cfg.Subject(recipient, len(items)),
+		HTML:    ApplyDirection(body.String(), d.cfg.Direction),
+	}, nil
+}
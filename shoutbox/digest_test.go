@@ -0,0 +1,141 @@
+package shoutbox
+
+import (
+	"context"
+	"html/template"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDigest(t *testing.T) {
+	var mu sync.Mutex
+	var sent []*EmailMessage
+	d := NewDigest(DigestConfig{
+		Window:       time.Hour,
+		From:         "notify@example.com",
+		ItemTemplate: template.Must(template.New("item").Parse(`<p>{{.}}</p>`)),
+		Send: func(ctx context.Context, msg *EmailMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, msg)
+			return nil
+		},
+	})
+
+	d.Add("a@example.com", "first")
+	d.Add("a@example.com", "<second>")
+	d.Add("b@example.com", "only")
+
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := d.Add("a@example.com", "late"); err == nil {
+		t.Errorf("Add() after Close() error = nil")
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("sent %d digests, want 2", len(sent))
+	}
+	for _, msg := range sent {
+		switch msg.To[0] {
+		case "a@example.com":
+			if msg.HTML != "<p>first</p><p>&lt;second&gt;</p>" {
+				t.Errorf("HTML = %q", msg.HTML)
+			}
+			if msg.Subject != "You have 2 new notifications" {
+				t.Errorf("Subject = %q", msg.Subject)
+			}
+		case "b@example.com":
+			if msg.HTML != "<p>only</p>" {
+				t.Errorf("HTML = %q", msg.HTML)
+			}
+		}
+	}
+}
+
+func TestDigest_Window(t *testing.T) {
+	done := make(chan *EmailMessage, 1)
+	d := NewDigest(DigestConfig{
+		Window:       10 * time.Millisecond,
+		ItemTemplate: template.Must(template.New("item").Parse(`{{.}}`)),
+		Send: func(ctx context.Context, msg *EmailMessage) error {
+			done <- msg
+			return nil
+		},
+	})
+
+	d.Add("a@example.com", "x")
+	d.Add("a@example.com", "y")
+
+	select {
+	case msg := <-done:
+		if msg.HTML != "xy" {
+			t.Errorf("HTML = %q, want %q", msg.HTML, "xy")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("digest not sent after window")
+	}
+}
+
+func TestDigest_CloseWaitsForWindowSends(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var sent []*EmailMessage
+	d := NewDigest(DigestConfig{
+		Window:       time.Millisecond,
+		ItemTemplate: template.Must(template.New("item").Parse(`{{.}}`)),
+		Send: func(ctx context.Context, msg *EmailMessage) error {
+			close(started)
+			<-release
+			sent = append(sent, msg)
+			return nil
+		},
+	})
+
+	d.Add("a@example.com", "x")
+	<-started
+	closed := make(chan error, 1)
+	go func() { closed <- d.Close(context.Background()) }()
+
+	select {
+	case <-closed:
+		t.Fatal("Close() returned while a digest was being sent")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if err := <-closed; err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("sent %d digests, want 1", len(sent))
+	}
+}
+
+func TestDigest_LateTimerKeepsNextBatch(t *testing.T) {
+	var sent []string
+	d := NewDigest(DigestConfig{
+		Window:       time.Hour,
+		ItemTemplate: template.Must(template.New("item").Parse(`{{.}}`)),
+		Send: func(ctx context.Context, msg *EmailMessage) error {
+			sent = append(sent, msg.HTML)
+			return nil
+		},
+	})
+
+	d.Add("a@example.com", "x")
+	first := d.pending["a@example.com"]
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	d.Add("a@example.com", "y")
+
+	// The first batch's timer firing after the flush must not send the
+	// second batch
+	if err := d.flushBatch(context.Background(), "a@example.com", first); err != nil {
+		t.Fatalf("flushBatch() error = %v", err)
+	}
+	if len(sent) != 1 || sent[0] != "x" || len(d.pending) != 1 {
+		t.Errorf("sent = %q, pending = %d, want the second batch still pending", sent, len(d.pending))
+	}
+	d.Close(context.Background())
+}
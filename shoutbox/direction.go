@@ -0,0 +1,75 @@
+package shoutbox
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Direction is the text direction of a message body
+type Direction string
+
+const (
+	// LTR is left-to-right text, the default
+	LTR Direction = "ltr"
+	// RTL is right-to-left text, e.g. Arabic or Hebrew
+	RTL Direction = "rtl"
+)
+
+// rtlLanguages are the ISO 639-1 codes of languages written right to left
+var rtlLanguages = map[string]bool{
+	"ar": true, "dv": true, "fa": true, "he": true, "iw": true,
+	"ku": true, "ps": true, "sd": true, "ug": true, "ur": true, "yi": true,
+}
+
+// DirectionForLocale returns the text direction of a locale such as
+// "ar-EG" or "he_IL"
+func DirectionForLocale(locale string) Direction {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	if rtlLanguages[lang] {
+		return RTL
+	}
+	return LTR
+}
+
+// Start returns the CSS/HTML alignment of the start edge
+func (d Direction) Start() string {
+	if d == RTL {
+		return "right"
+	}
+	return "left"
+}
+
+// End returns the CSS/HTML alignment of the end edge
+func (d Direction) End() string {
+	if d == RTL {
+		return "left"
+	}
+	return "right"
+}
+
+func (d Direction) String() string {
+	if d == RTL {
+		return string(RTL)
+	}
+	return string(LTR)
+}
+
+var dirAttrPattern = regexp.MustCompile(`(?i)\sdir\s*=`)
+
+// ApplyDirection sets the text direction of body. Documents get a dir
+// attribute on their <html> tag and fragments are wrapped in a <div>.
+// Bodies that already declare a direction are left unchanged.
+func ApplyDirection(body string, dir Direction) string {
+	if dir != RTL {
+		return body
+	}
+	if loc := htmlOpenPattern.FindStringIndex(body); loc != nil {
+		if dirAttrPattern.MatchString(body[loc[0]:loc[1]]) {
+			return body
+		}
+		at := loc[0] + len("<html")
+		return body[:at] + ` dir="rtl"` + body[at:]
+	}
+	return `<div dir="rtl" style="direction:rtl;text-align:right">` + body + "</div>"
+}
@@ -0,0 +1,54 @@
+package shoutbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDirectionForLocale(t *testing.T) {
+	tests := map[string]Direction{
+		"ar-EG": RTL,
+		"he_IL": RTL,
+		"fa":    RTL,
+		"en-US": LTR,
+		"de":    LTR,
+		"":      LTR,
+	}
+	for locale, want := range tests {
+		if got := DirectionForLocale(locale); got != want {
+			t.Errorf("DirectionForLocale(%q) = %s, want %s", locale, got, want)
+		}
+	}
+}
+
+func TestApplyDirection(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		dir  Direction
+		want string
+	}{
+		{name: "ltr unchanged", html: "<p>Hi</p>", dir: LTR, want: "<p>Hi</p>"},
+		{name: "fragment", html: "<p>مرحبا</p>", dir: RTL, want: `<div dir="rtl" style="direction:rtl;text-align:right"><p>مرحبا</p></div>`},
+		{name: "document", html: `<html lang="he"><body>שלום</body></html>`, dir: RTL, want: `<html dir="rtl" lang="he"><body>שלום</body></html>`},
+		{name: "existing dir", html: `<html dir="ltr"><body></body></html>`, dir: RTL, want: `<html dir="ltr"><body></body></html>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyDirection(tt.html, tt.dir); got != tt.want {
+				t.Errorf("ApplyDirection() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceipt_RenderRTL(t *testing.T) {
+	got, err := Receipt{Title: "إيصال", Direction: RTL, Items: []LineItem{{Description: "x", Quantity: 1}}}.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(string(got), `dir="rtl"`) || !strings.Contains(string(got), `<td align="right" style="padding:8px;border-bottom:1px solid #eeeeee">x</td>`) {
+		t.Errorf("Render() = %s", got)
+	}
+}
@@ -0,0 +1,215 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+)
+
+// Defaults of NewDispatcher
+const (
+	DefaultDispatcherConcurrency = 4
+	DefaultDispatcherQueueSize   = 100
+)
+
+// DefaultDispatcherRetryPolicy retries each message up to twice after
+// temporary failures: rate limiting, server errors, SMTP 4xx replies and
+// network errors
+var DefaultDispatcherRetryPolicy = RetryPolicy{
+	MaxAttempts:        3,
+	InitialBackoff:     time.Second,
+	MaxBackoff:         30 * time.Second,
+	RetryNetworkErrors: true,
+}
+
+// ErrDispatcherStopped is returned when enqueueing on a stopped Dispatcher
+var ErrDispatcherStopped = errors.New("dispatcher is stopped")
+
+// DispatcherConfig configures a Dispatcher
+type DispatcherConfig struct {
+	// Sender sends the queued messages
+	Sender EmailSender
+	// Concurrency is the number of messages sent at once. Defaults to 4.
+	Concurrency int
+	// QueueSize is the number of messages waiting to be sent before
+	// Enqueue blocks. Defaults to 100.
+	QueueSize int
+	// Retry controls how temporary failures of a message are retried.
+	// Defaults to DefaultDispatcherRetryPolicy, or to a single attempt if
+	// Sender is a *Client, which retries requests itself; set MaxAttempts
+	// to 1 to disable retries.
+	Retry RetryPolicy
+	// OnResult, when set, is called from the sending goroutine after each
+	// message is sent or has failed its last attempt
+	OnResult func(msg *EmailMessage, resp *SendResponse, err error)
+}
+
+// Dispatcher sends queued messages in the background with a bounded pool
+// of goroutines, retrying temporary failures. It is safe for concurrent
+// use.
+type Dispatcher struct {
+	cfg   DispatcherConfig
+	queue chan dispatchItem
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopping  chan struct{}
+	mu        sync.RWMutex
+	stopped   bool
+	wg        sync.WaitGroup
+}
+
+// dispatchItem is a queued message and its completion callback, if any
+type dispatchItem struct {
+	msg  *EmailMessage
+	done func(*SendResponse, error)
+}
+
+// NewDispatcher creates a dispatcher. Call Start to begin sending.
+func NewDispatcher(cfg DispatcherConfig) *Dispatcher {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultDispatcherConcurrency
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultDispatcherQueueSize
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		cfg.Retry = DefaultDispatcherRetryPolicy
+		if _, ok := cfg.Sender.(*Client); ok {
+			cfg.Retry = RetryPolicy{MaxAttempts: 1}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		cfg:      cfg,
+		queue:    make(chan dispatchItem, cfg.QueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+	}
+}
+
+// Start starts the sending goroutines. Messages enqueued before Start
+// wait in the queue.
+func (d *Dispatcher) Start() {
+	d.startOnce.Do(func() {
+		for range d.cfg.Concurrency {
+			d.wg.Add(1)
+			go d.work()
+		}
+	})
+}
+
+// Enqueue queues msg for sending, waiting while the queue is full until
+// ctx is done. It returns ErrDispatcherStopped once Stop has been called.
+func (d *Dispatcher) Enqueue(ctx context.Context, msg *EmailMessage) error {
+	return d.enqueue(ctx, dispatchItem{msg: msg})
+}
+
+// SendAsync is like Enqueue but also calls done with the outcome of msg,
+// after OnResult, from the sending goroutine. done isn't called if msg
+// couldn't be queued.
+func (d *Dispatcher) SendAsync(ctx context.Context, msg *EmailMessage, done func(*SendResponse, error)) error {
+	return d.enqueue(ctx, dispatchItem{msg: msg, done: done})
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, item dispatchItem) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return ErrDispatcherStopped
+	}
+	select {
+	case d.queue <- item:
+		return nil
+	case <-d.stopping:
+		return ErrDispatcherStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop stops accepting messages and waits until the queued ones are sent.
+// If ctx is done first, in-flight sends and retries are canceled, the
+// remaining messages fail with the context's error and ctx.Err() is
+// returned.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.stopOnce.Do(func() {
+		close(d.stopping)
+		d.mu.Lock()
+		d.stopped = true
+		close(d.queue)
+		d.mu.Unlock()
+	})
+	d.Start()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for item := range d.queue {
+		resp, err := d.send(item.msg)
+		if d.cfg.OnResult != nil {
+			d.cfg.OnResult(item.msg, resp, err)
+		}
+		if item.done != nil {
+			item.done(resp, err)
+		}
+	}
+}
+
+// send sends msg, retrying temporary failures
+func (d *Dispatcher) send(msg *EmailMessage) (*SendResponse, error) {
+	for attempt := 1; ; attempt++ {
+		if err := d.ctx.Err(); err != nil {
+			return nil, err
+		}
+		resp, err := d.cfg.Sender.Send(d.ctx, msg)
+		if err == nil || attempt >= d.cfg.Retry.MaxAttempts || !d.retryable(err) {
+			return resp, err
+		}
+
+		wait := d.cfg.Retry.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		if err := sleepContext(d.ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable reports whether a failed send may succeed if retried
+func (d *Dispatcher) retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	return d.cfg.Retry.RetryNetworkErrors && errors.As(err, &netErr)
+}
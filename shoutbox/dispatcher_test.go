@@ -0,0 +1,224 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakySender fails each message with the errors in fail before sending
+// it, tracking the most concurrent sends
+type flakySender struct {
+	fail []error
+
+	mu       sync.Mutex
+	attempts map[string]int
+	active   int
+	peak     int
+}
+
+func (s *flakySender) Send(ctx context.Context, msg *EmailMessage) (*SendResponse, error) {
+	s.mu.Lock()
+	attempt := s.attempts[msg.Subject]
+	s.attempts[msg.Subject]++
+	s.active++
+	s.peak = max(s.peak, s.active)
+	s.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	if attempt < len(s.fail) {
+		return nil, s.fail[attempt]
+	}
+	return &SendResponse{MessageID: msg.Subject}, nil
+}
+
+func TestDispatcher(t *testing.T) {
+	quickRetry := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	tests := []struct {
+		name        string
+		fail        []error
+		wantErr     bool
+		wantAttempt int
+	}{
+		{name: "sent", wantAttempt: 1},
+		{name: "retried api error", fail: []error{&APIError{StatusCode: 503}, &APIError{StatusCode: 429}}, wantAttempt: 3},
+		{name: "retried smtp reply", fail: []error{&textproto.Error{Code: 451, Msg: "try again later"}}, wantAttempt: 2},
+		{name: "attempts exhausted", fail: []error{&APIError{StatusCode: 500}, &APIError{StatusCode: 500}, &APIError{StatusCode: 500}}, wantErr: true, wantAttempt: 3},
+		{name: "permanent failure", fail: []error{&APIError{StatusCode: 422}}, wantErr: true, wantAttempt: 1},
+		{name: "rejected recipient", fail: []error{&textproto.Error{Code: 550, Msg: "no such user"}}, wantErr: true, wantAttempt: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &flakySender{fail: tt.fail, attempts: map[string]int{}}
+			var failed atomic.Int32
+			d := NewDispatcher(DispatcherConfig{
+				Sender:      sender,
+				Concurrency: 2,
+				Retry:       quickRetry,
+				OnResult: func(msg *EmailMessage, resp *SendResponse, err error) {
+					if err != nil {
+						failed.Add(1)
+					}
+				},
+			})
+			d.Start()
+			for i := range 5 {
+				if err := d.Enqueue(context.Background(), &EmailMessage{Subject: fmt.Sprint(i)}); err != nil {
+					t.Fatalf("Enqueue() error = %v", err)
+				}
+			}
+			if err := d.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+
+			if sender.peak > 2 {
+				t.Errorf("%d concurrent sends, want at most 2", sender.peak)
+			}
+			if got := sender.attempts["4"]; got != tt.wantAttempt {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempt)
+			}
+			wantFailed := int32(0)
+			if tt.wantErr {
+				wantFailed = 5
+			}
+			if failed.Load() != wantFailed {
+				t.Errorf("%d failed, want %d", failed.Load(), wantFailed)
+			}
+			if err := d.Enqueue(context.Background(), &EmailMessage{}); !errors.Is(err, ErrDispatcherStopped) {
+				t.Errorf("Enqueue() after Stop error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDispatcher_stopTimeout(t *testing.T) {
+	sender := &flakySender{attempts: map[string]int{}}
+	for range 10 {
+		sender.fail = append(sender.fail, &APIError{StatusCode: 503})
+	}
+	var canceled atomic.Int32
+	d := NewDispatcher(DispatcherConfig{
+		Sender: sender,
+		Retry:  RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour},
+		OnResult: func(msg *EmailMessage, resp *SendResponse, err error) {
+			if errors.Is(err, context.Canceled) {
+				canceled.Add(1)
+			}
+		},
+	})
+	d.Start()
+	d.Enqueue(context.Background(), &EmailMessage{Subject: "slow"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want context.DeadlineExceeded", err)
+	}
+	if canceled.Load() != 1 {
+		t.Errorf("%d sends canceled, want 1", canceled.Load())
+	}
+}
+
+func TestDispatcher_enqueueFull(t *testing.T) {
+	d := NewDispatcher(DispatcherConfig{Sender: &MockSender{}, QueueSize: 1})
+	if err := d.Enqueue(context.Background(), &EmailMessage{}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.Enqueue(ctx, &EmailMessage{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Enqueue() on a full queue error = %v", err)
+	}
+	if err := d.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+}
+
+func TestDispatcher_ClientRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		RetryStatuses:  []int{http.StatusServiceUnavailable},
+	}))
+	client.baseURL = srv.URL
+
+	d := NewDispatcher(DispatcherConfig{Sender: client})
+	if d.cfg.Retry.MaxAttempts != 1 {
+		t.Errorf("Retry.MaxAttempts = %d, want 1 for a *Client sender", d.cfg.Retry.MaxAttempts)
+	}
+	d.Start()
+	errs := make(chan error, 1)
+	msg := &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Hi", Text: "Hi"}
+	if err := d.SendAsync(context.Background(), msg, func(_ *SendResponse, err error) { errs <- err }); err != nil {
+		t.Fatalf("SendAsync() error = %v", err)
+	}
+	if err := <-errs; err == nil {
+		t.Error("send succeeded, want the 503")
+	}
+	d.Stop(context.Background())
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want only the client's 2 attempts", n)
+	}
+}
+
+func TestDispatcher_SendAsync(t *testing.T) {
+	tests := []struct {
+		name    string
+		sender  EmailSender
+		wantErr bool
+	}{
+		{name: "sent", sender: &MockSender{}},
+		{name: "failed", sender: &MockSender{Err: &APIError{StatusCode: 422}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(DispatcherConfig{Sender: tt.sender})
+			d.Start()
+
+			type result struct {
+				resp *SendResponse
+				err  error
+			}
+			results := make(chan result, 1)
+			err := d.SendAsync(context.Background(), &EmailMessage{To: []string{"ada@example.com"}}, func(resp *SendResponse, err error) {
+				results <- result{resp, err}
+			})
+			if err != nil {
+				t.Fatalf("SendAsync() error = %v", err)
+			}
+			got := <-results
+			if (got.err != nil) != tt.wantErr || (got.err == nil && got.resp.MessageID == "") {
+				t.Errorf("callback got %+v, %v", got.resp, got.err)
+			}
+
+			d.Stop(context.Background())
+			err = d.SendAsync(context.Background(), &EmailMessage{}, func(*SendResponse, error) {
+				t.Error("callback called for a message that wasn't queued")
+			})
+			if !errors.Is(err, ErrDispatcherStopped) {
+				t.Errorf("SendAsync() after Stop error = %v", err)
+			}
+		})
+	}
+}
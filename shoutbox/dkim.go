@@ -0,0 +1,202 @@
+package shoutbox
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dkimHeaders are the header fields signed when present, RFC 6376
+// section 5.4.1. Shoutbox's X- headers are left out as the relay consumes
+// them.
+var dkimHeaders = []string{
+	"From", "Reply-To", "Subject", "Date", "To", "Cc", "Message-ID",
+	"In-Reply-To", "References", "MIME-Version", "Content-Type",
+	"Content-Transfer-Encoding", "List-Id", "List-Unsubscribe",
+	"List-Unsubscribe-Post",
+}
+
+// dkimSpacePattern matches runs of whitespace, reduced to a single space
+// by relaxed canonicalization
+var dkimSpacePattern = regexp.MustCompile(`[ \t]+`)
+
+// DKIMSigner signs messages with DKIM (RFC 6376) using relaxed
+// canonicalization, so receivers can authenticate them with the public key
+// published at <selector>._domainkey.<domain>. Set it as
+// SMTPClient.DKIMSigner or call Sign on raw messages.
+//
+// Open and click tracking rewrite the body after signing, which breaks
+// the signature; disable them for signed messages.
+type DKIMSigner struct {
+	domain   string
+	selector string
+	key      crypto.Signer
+	now      func() time.Time
+}
+
+// NewDKIMSigner creates a signer for domain and selector. key must be an
+// RSA key, signing with rsa-sha256, or an Ed25519 key, signing with
+// ed25519-sha256 (RFC 8463).
+func NewDKIMSigner(domain, selector string, key crypto.Signer) (*DKIMSigner, error) {
+	if domain == "" || selector == "" {
+		return nil, errors.New("DKIM signing requires a domain and selector")
+	}
+	switch key.Public().(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, errors.New("DKIM signing requires an RSA or Ed25519 key")
+	}
+	return &DKIMSigner{domain: domain, selector: selector, key: key, now: time.Now}, nil
+}
+
+// LoadDKIMSigner creates a signer for domain and selector with the private
+// key in a PEM file, in PKCS #1 or PKCS #8 form
+func LoadDKIMSigner(domain, selector, keyFile string) (*DKIMSigner, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading DKIM key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("error reading DKIM key: no PEM data found")
+	}
+	var key any
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DKIM key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("error parsing DKIM key: key can't sign")
+	}
+	return NewDKIMSigner(domain, selector, signer)
+}
+
+// Sign returns message, an RFC 5322 message with CRLF line endings,
+// with a DKIM-Signature header field prepended
+func (s *DKIMSigner) Sign(message []byte) ([]byte, error) {
+	header, body, ok := bytes.Cut(message, []byte("\r\n\r\n"))
+	if !ok {
+		// A message without a body ends after its header
+		header, body = bytes.TrimSuffix(message, []byte("\r\n")), nil
+	}
+	fields := splitHeaderFields(string(header))
+
+	// Fields are signed from the bottom up when a name repeats
+	var names []string
+	var signed []string
+	used := make(map[int]bool)
+	for _, name := range dkimHeaders {
+		for i := len(fields) - 1; i >= 0; i-- {
+			fieldName, _, _ := strings.Cut(fields[i], ":")
+			if !used[i] && strings.EqualFold(strings.TrimSpace(fieldName), name) {
+				used[i] = true
+				names = append(names, strings.ToLower(name))
+				signed = append(signed, dkimCanonicalHeader(fields[i]))
+				break
+			}
+		}
+	}
+	if len(names) == 0 || names[0] != "from" {
+		return nil, errors.New("error signing message: DKIM requires a From header")
+	}
+
+	bodyHash := sha256.Sum256(dkimCanonicalBody(body))
+	algorithm := "rsa-sha256"
+	if _, ok := s.key.Public().(ed25519.PublicKey); ok {
+		algorithm = "ed25519-sha256"
+	}
+	field := fmt.Sprintf("DKIM-Signature: v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s;\r\n\tt=%d; h=%s;\r\n\tbh=%s;\r\n\tb=",
+		algorithm, s.domain, s.selector, s.now().Unix(), strings.Join(names, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	// The signature covers the signed fields and its own field with an
+	// empty b= tag, without a trailing CRLF
+	h := sha256.New()
+	for _, f := range signed {
+		h.Write([]byte(f + "\r\n"))
+	}
+	h.Write([]byte(dkimCanonicalHeader(field)))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	var err error
+	if algorithm == "ed25519-sha256" {
+		signature, err = s.key.Sign(rand.Reader, digest, crypto.Hash(0))
+	} else {
+		signature, err = s.key.Sign(rand.Reader, digest, crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error signing message: %w", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(field)
+	encoded := base64.StdEncoding.EncodeToString(signature)
+	for i := 0; i < len(encoded); i += 72 {
+		if i > 0 {
+			out.WriteString("\r\n\t")
+		}
+		out.WriteString(encoded[i:min(i+72, len(encoded))])
+	}
+	out.WriteString("\r\n")
+	out.Write(message)
+	return out.Bytes(), nil
+}
+
+// splitHeaderFields splits a message header into fields, keeping folded
+// continuation lines with their field
+func splitHeaderFields(header string) []string {
+	var fields []string
+	for _, line := range strings.Split(header, "\r\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(fields) > 0 {
+			fields[len(fields)-1] += "\r\n" + line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+// dkimCanonicalHeader returns a header field in relaxed canonical form:
+// the name in lower case, unfolded, with runs of whitespace reduced to a
+// single space and none around the colon or at the end
+func dkimCanonicalHeader(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = dkimSpacePattern.ReplaceAllString(value, " ")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(value)
+}
+
+// dkimCanonicalBody returns a body in relaxed canonical form: whitespace
+// at line ends removed, other runs of whitespace reduced to a single space
+// and empty lines at the end removed
+func dkimCanonicalBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(dkimSpacePattern.ReplaceAllString(line, " "), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
@@ -0,0 +1,156 @@
+package shoutbox
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDKIMCanonicalization(t *testing.T) {
+	// The example of RFC 6376 section 3.4.5
+	fields := splitHeaderFields("A: X\r\nB : Y\t\r\n\tZ  ")
+	var got []string
+	for _, field := range fields {
+		got = append(got, dkimCanonicalHeader(field))
+	}
+	if strings.Join(got, "\r\n") != "a:X\r\nb:Y Z" {
+		t.Errorf("canonical header = %q", got)
+	}
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: " C \r\nD \t E\r\n\r\n\r\n", want: " C\r\nD E\r\n"},
+		{body: "no newline", want: "no newline\r\n"},
+		{body: "\r\n\r\n", want: ""},
+		{body: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := string(dkimCanonicalBody([]byte(tt.body))); got != tt.want {
+			t.Errorf("dkimCanonicalBody(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestSMTPClient_DKIMSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		key           crypto.Signer
+		wantAlgorithm string
+	}{
+		{name: "rsa", key: rsaKey, wantAlgorithm: "rsa-sha256"},
+		{name: "ed25519", key: edKey, wantAlgorithm: "ed25519-sha256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewDKIMSigner("example.com", "sb1", tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer.now = func() time.Time { return time.Unix(1700000000, 0) }
+			client := &SMTPClient{DKIMSigner: signer}
+			raw, err := client.buildMessage(&EmailMessage{
+				From:    "sender@example.com",
+				To:      []string{"recipient@example.com"},
+				Subject: "Signed",
+				HTML:    "<p>Hello</p>",
+				Headers: map[string]string{"Message-ID": "<1@example.com>"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			header, body, _ := strings.Cut(string(raw), "\r\n\r\n")
+			fields := splitHeaderFields(header)
+			if !strings.HasPrefix(fields[0], "DKIM-Signature:") {
+				t.Fatalf("first field = %q", fields[0])
+			}
+			tags := make(map[string]string)
+			_, value, _ := strings.Cut(fields[0], ":")
+			for _, tag := range strings.Split(value, ";") {
+				name, v, _ := strings.Cut(tag, "=")
+				tags[strings.TrimSpace(name)] = strings.Join(strings.Fields(v), "")
+			}
+			if tags["a"] != tt.wantAlgorithm || tags["d"] != "example.com" || tags["s"] != "sb1" || tags["t"] != "1700000000" {
+				t.Errorf("tags = %v", tags)
+			}
+			if tags["h"] != "from:subject:to:message-id:mime-version:content-type" {
+				t.Errorf("h = %q", tags["h"])
+			}
+			bodyHash := sha256.Sum256(dkimCanonicalBody([]byte(body)))
+			if tags["bh"] != base64.StdEncoding.EncodeToString(bodyHash[:]) {
+				t.Errorf("bh = %q", tags["bh"])
+			}
+
+			// Verify the signature as a receiver would
+			h := sha256.New()
+			for _, name := range strings.Split(tags["h"], ":") {
+				for _, field := range fields[1:] {
+					if strings.HasPrefix(strings.ToLower(field), name+":") {
+						h.Write([]byte(dkimCanonicalHeader(field) + "\r\n"))
+					}
+				}
+			}
+			unsigned := fields[0][:strings.LastIndex(fields[0], "b=")+2]
+			h.Write([]byte(dkimCanonicalHeader(unsigned)))
+			signature, err := base64.StdEncoding.DecodeString(tags["b"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch key := tt.key.Public().(type) {
+			case *rsa.PublicKey:
+				err = rsa.VerifyPKCS1v15(key, crypto.SHA256, h.Sum(nil), signature)
+			case ed25519.PublicKey:
+				if !ed25519.Verify(key, h.Sum(nil), signature) {
+					err = errors.New("invalid signature")
+				}
+			}
+			if err != nil {
+				t.Errorf("signature verification error = %v", err)
+			}
+			if !bytes.HasSuffix(raw, []byte(body)) {
+				t.Error("body changed by signing")
+			}
+		})
+	}
+}
+
+func TestNewDKIMSigner(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 1024)
+	tests := []struct {
+		name     string
+		domain   string
+		selector string
+		wantErr  bool
+	}{
+		{name: "valid", domain: "example.com", selector: "sb1"},
+		{name: "missing domain", selector: "sb1", wantErr: true},
+		{name: "missing selector", domain: "example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDKIMSigner(tt.domain, tt.selector, key); (err != nil) != tt.wantErr {
+				t.Errorf("NewDKIMSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
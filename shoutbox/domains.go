@@ -0,0 +1,115 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Verification statuses of a sending domain
+const (
+	DomainPending  = "pending"
+	DomainVerified = "verified"
+	DomainFailed   = "failed"
+)
+
+// ErrDomainVerificationFailed is returned by WaitForVerification when the
+// domain's verification failed
+var ErrDomainVerificationFailed = errors.New("domain verification failed")
+
+// DNSRecord is a DNS record required to send from a domain
+type DNSRecord struct {
+	// Type is the record type, e.g. "TXT" or "CNAME"
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Purpose is what the record is for: "spf", "dkim", "return_path" or
+	// "dmarc"
+	Purpose  string `json:"purpose"`
+	Verified bool   `json:"verified"`
+}
+
+// Domain is a sending domain
+type Domain struct {
+	Name       string      `json:"name"`
+	Status     string      `json:"status"`
+	Records    []DNSRecord `json:"records"`
+	CreatedAt  time.Time   `json:"created_at"`
+	VerifiedAt *time.Time  `json:"verified_at,omitempty"`
+}
+
+// DomainsService manages the account's sending domains
+type DomainsService struct {
+	client *Client
+}
+
+// Domains returns the sending domain API
+func (c *Client) Domains() *DomainsService {
+	return &DomainsService{client: c}
+}
+
+// Create registers a sending domain. The returned domain lists the DNS
+// records to publish before verifying it.
+func (s *DomainsService) Create(ctx context.Context, name string) (*Domain, error) {
+	var domain Domain
+	if err := s.client.do(ctx, http.MethodPost, "/domains", map[string]string{"name": name}, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// Get returns a sending domain and the status of its DNS records
+func (s *DomainsService) Get(ctx context.Context, name string) (*Domain, error) {
+	var domain Domain
+	if err := s.client.do(ctx, http.MethodGet, "/domains/"+url.PathEscape(name), nil, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// List returns every sending domain of the account
+func (s *DomainsService) List(ctx context.Context) ([]Domain, error) {
+	var resp struct {
+		Domains []Domain `json:"domains"`
+	}
+	if err := s.client.do(ctx, http.MethodGet, "/domains", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Domains, nil
+}
+
+// Verify asks Shoutbox to check the domain's DNS records now
+func (s *DomainsService) Verify(ctx context.Context, name string) (*Domain, error) {
+	var domain Domain
+	if err := s.client.do(ctx, http.MethodPost, "/domains/"+url.PathEscape(name)+"/verify", nil, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// Delete removes a sending domain
+func (s *DomainsService) Delete(ctx context.Context, name string) error {
+	return s.client.do(ctx, http.MethodDelete, "/domains/"+url.PathEscape(name), nil, nil)
+}
+
+// WaitForVerification verifies the domain every interval until it is
+// verified, verification fails or ctx is done
+func (s *DomainsService) WaitForVerification(ctx context.Context, name string, interval time.Duration) (*Domain, error) {
+	for {
+		domain, err := s.Verify(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		switch domain.Status {
+		case DomainVerified:
+			return domain, nil
+		case DomainFailed:
+			return domain, ErrDomainVerificationFailed
+		}
+		if err := sleepContext(ctx, interval); err != nil {
+			return domain, err
+		}
+	}
+}
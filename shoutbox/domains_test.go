@@ -0,0 +1,73 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDomainsService_WaitForVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     string
+		wantErr  error
+	}{
+		{name: "verified", statuses: []string{DomainPending, DomainPending, DomainVerified}, want: DomainVerified},
+		{name: "failed", statuses: []string{DomainPending, DomainFailed}, want: DomainFailed, wantErr: ErrDomainVerificationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/domains/mail.example.com/verify" {
+					t.Errorf("request = %s %s", r.Method, r.URL.Path)
+				}
+				json.NewEncoder(w).Encode(Domain{Name: "mail.example.com", Status: tt.statuses[calls]})
+				calls++
+			}))
+			defer srv.Close()
+
+			client := NewClient("test-key")
+			client.baseURL = srv.URL
+			domain, err := client.Domains().WaitForVerification(context.Background(), "mail.example.com", time.Millisecond)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WaitForVerification() error = %v, want %v", err, tt.wantErr)
+			}
+			if domain.Status != tt.want || calls != len(tt.statuses) {
+				t.Errorf("status = %q after %d calls", domain.Status, calls)
+			}
+		})
+	}
+}
+
+func TestDomainsService_Create(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(Domain{
+			Name:   body["name"],
+			Status: DomainPending,
+			Records: []DNSRecord{
+				{Type: "TXT", Name: body["name"], Value: "v=spf1 include:spf.shoutbox.net ~all", Purpose: "spf"},
+				{Type: "CNAME", Name: "sb._domainkey." + body["name"], Value: "dkim.shoutbox.net", Purpose: "dkim"},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	domain, err := client.Domains().Create(context.Background(), "mail.example.com")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if domain.Name != "mail.example.com" || len(domain.Records) != 2 || domain.Records[1].Purpose != "dkim" {
+		t.Errorf("Create() = %+v", domain)
+	}
+}
@@ -0,0 +1,151 @@
+package shoutbox
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// ErrDSNUnsupported is returned when a message requests delivery status
+// notifications and the server doesn't support the DSN extension
+var ErrDSNUnsupported = errors.New("smtp: server doesn't support DSN")
+
+// headerReadReceiptTo requests a read receipt, RFC 8098
+const headerReadReceiptTo = "Disposition-Notification-To"
+
+// DSNNotify is a condition for sending a delivery status notification
+type DSNNotify string
+
+// Conditions of RFC 3461. DSNNever can't be combined with the others.
+const (
+	DSNNever   DSNNotify = "NEVER"
+	DSNSuccess DSNNotify = "SUCCESS"
+	DSNFailure DSNNotify = "FAILURE"
+	DSNDelay   DSNNotify = "DELAY"
+)
+
+// DSNReturn selects how much of the message a notification includes
+type DSNReturn string
+
+const (
+	// DSNReturnHeaders returns only the headers of the message
+	DSNReturnHeaders DSNReturn = "HDRS"
+	// DSNReturnFull returns the whole message
+	DSNReturnFull DSNReturn = "FULL"
+)
+
+// DSN requests delivery status notifications for a message, sent to the
+// envelope sender by the servers along the delivery path (RFC 3461). It
+// applies to SMTP sends only.
+type DSN struct {
+	// Notify lists when notifications are sent. Empty leaves it to the
+	// server, usually failures only.
+	Notify []DSNNotify
+	// Return selects what notifications include of the message
+	Return DSNReturn
+	// EnvelopeID is included in notifications to identify the message
+	EnvelopeID string
+}
+
+// mailParams returns the DSN parameters of the MAIL command
+func (d *DSN) mailParams() string {
+	var params string
+	if d.Return != "" {
+		params += " RET=" + string(d.Return)
+	}
+	if d.EnvelopeID != "" {
+		params += " ENVID=" + xtext(d.EnvelopeID)
+	}
+	return params
+}
+
+// rcptParams returns the DSN parameters of the RCPT command of to
+func (d *DSN) rcptParams(to string) string {
+	var params string
+	if len(d.Notify) > 0 {
+		notify := make([]string, len(d.Notify))
+		for i, n := range d.Notify {
+			notify[i] = string(n)
+		}
+		params += " NOTIFY=" + strings.Join(notify, ",")
+	}
+	return params + " ORCPT=rfc822;" + xtext(to)
+}
+
+func (d *DSN) validate() error {
+	for _, n := range d.Notify {
+		switch n {
+		case DSNSuccess, DSNFailure, DSNDelay:
+		case DSNNever:
+			if len(d.Notify) > 1 {
+				return errors.New("DSN NEVER can't be combined with other conditions")
+			}
+		default:
+			return fmt.Errorf("unknown DSN condition %q", n)
+		}
+	}
+	switch d.Return {
+	case "", DSNReturnHeaders, DSNReturnFull:
+	default:
+		return fmt.Errorf("unknown DSN return %q", d.Return)
+	}
+	if len(d.EnvelopeID) > 100 {
+		return errors.New("DSN envelope ID longer than 100 characters")
+	}
+	return nil
+}
+
+// xtext encodes s as RFC 3461 xtext: "+", "=" and characters outside
+// printable ASCII are written as "+XX"
+func xtext(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < '!' || c > '~' || c == '+' || c == '=' {
+			fmt.Fprintf(&b, "+%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// mailWithDSN issues MAIL and RCPT commands with the DSN parameters of
+// dsn, which net/smtp can't send
+func mailWithDSN(client *smtp.Client, from string, to []string, dsn *DSN) error {
+	if ok, _ := client.Extension("DSN"); !ok {
+		return ErrDSNUnsupported
+	}
+	if strings.ContainsAny(from+strings.Join(to, ""), "\r\n") {
+		return errors.New("smtp: a line must not contain CR or LF")
+	}
+
+	params := dsn.mailParams()
+	if ok, _ := client.Extension("8BITMIME"); ok {
+		params += " BODY=8BITMIME"
+	}
+	if ok, _ := client.Extension("SMTPUTF8"); ok {
+		params += " SMTPUTF8"
+	}
+	if err := smtpCmd(client, 250, "MAIL FROM:<%s>%s", from, params); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := smtpCmd(client, 25, "RCPT TO:<%s>%s", rcpt, dsn.rcptParams(rcpt)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// smtpCmd sends a command and reads its reply, as net/smtp does
+func smtpCmd(client *smtp.Client, expectCode int, format string, args ...any) error {
+	id, err := client.Text.Cmd(format, args...)
+	if err != nil {
+		return err
+	}
+	client.Text.StartResponse(id)
+	defer client.Text.EndResponse(id)
+	_, _, err = client.Text.ReadResponse(expectCode)
+	return err
+}
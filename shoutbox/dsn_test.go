@@ -0,0 +1,41 @@
+package shoutbox
+
+import "testing"
+
+func TestDSN_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     DSN
+		wantErr bool
+	}{
+		{name: "empty", dsn: DSN{}},
+		{name: "success and failure", dsn: DSN{Notify: []DSNNotify{DSNSuccess, DSNFailure, DSNDelay}, Return: DSNReturnFull}},
+		{name: "never", dsn: DSN{Notify: []DSNNotify{DSNNever}}},
+		{name: "never combined", dsn: DSN{Notify: []DSNNotify{DSNNever, DSNFailure}}, wantErr: true},
+		{name: "unknown condition", dsn: DSN{Notify: []DSNNotify{"ALWAYS"}}, wantErr: true},
+		{name: "unknown return", dsn: DSN{Return: "BODY"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dsn.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestXtext(t *testing.T) {
+	tests := map[string]string{
+		"order-42":        "order-42",
+		"a+b=c":           "a+2Bb+3Dc",
+		"two words":       "two+20words",
+		"müller@example":  "m+C3+BCller@example",
+		"ada@example.com": "ada@example.com",
+	}
+	for in, want := range tests {
+		if got := xtext(in); got != want {
+			t.Errorf("xtext(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package shoutbox
+
+import (
+	"context"
+	"io"
+)
+
+// Render returns the RFC 5322 message Send would deliver for msg, with the
+// client's settings applied and a Message-ID added, without sending it.
+// The result can be saved as an .eml file.
+func (c *SMTPClient) Render(ctx context.Context, msg *EmailMessage) ([]byte, error) {
+	msg, _, err := c.prepare(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildMessage(msg)
+}
+
+// Bytes renders msg as an RFC 5322 message, as an SMTPClient without
+// options would send it. No Message-ID is added unless msg sets one; use
+// SMTPClient.Render to apply a client's settings.
+func (msg *EmailMessage) Bytes() ([]byte, error) {
+	if err := msg.validateHeaders(); err != nil {
+		return nil, err
+	}
+	return (&SMTPClient{}).buildMessage(msg)
+}
+
+// WriteTo writes msg as an RFC 5322 message to w, as rendered by Bytes
+func (msg *EmailMessage) WriteTo(w io.Writer) (int64, error) {
+	data, err := msg.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
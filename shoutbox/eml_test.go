@@ -0,0 +1,74 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestEmailMessage_WriteTo(t *testing.T) {
+	msg := &EmailMessage{
+		From:    "news@example.com",
+		To:      []string{"ada@example.com"},
+		Subject: "Hello",
+		HTML:    "<p>Hello</p>",
+		Text:    "Hello",
+		Attachments: []Attachment{
+			{Filename: "notes.txt", Content: []byte("notes"), ContentType: "text/plain"},
+		},
+	}
+
+	var buf bytes.Buffer
+	n, err := msg.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, wrote %d bytes", n, buf.Len())
+	}
+
+	parsed, err := ParseInbound(&buf)
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+	if parsed.Subject != "Hello" || parsed.HTML != "<p>Hello</p>" || parsed.Text != "Hello" || len(parsed.Attachments) != 1 {
+		t.Errorf("parsed = %+v", parsed)
+	}
+	if parsed.MessageID != "" {
+		t.Errorf("MessageID = %q, want none", parsed.MessageID)
+	}
+}
+
+func TestSMTPClient_Render(t *testing.T) {
+	tests := []struct {
+		name        string
+		client      *SMTPClient
+		headers     map[string]string
+		wantSubject string
+		wantID      string
+	}{
+		{name: "generated message ID", client: &SMTPClient{}, wantSubject: "Hello"},
+		{name: "own message ID", client: &SMTPClient{}, headers: map[string]string{"Message-ID": "<abc@example.com>"}, wantSubject: "Hello", wantID: "abc@example.com"},
+		{name: "seed list", client: &SMTPClient{SeedList: &SeedList{Addresses: []string{"qa@example.com"}}}, wantSubject: "[TEST] Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Hello", Text: "Hello", Headers: tt.headers}
+			data, err := tt.client.Render(context.Background(), msg)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			parsed, err := ParseInbound(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("ParseInbound() error = %v", err)
+			}
+			if parsed.Subject != tt.wantSubject {
+				t.Errorf("Subject = %q, want %q", parsed.Subject, tt.wantSubject)
+			}
+			if parsed.MessageID == "" || tt.wantID != "" && parsed.MessageID != tt.wantID {
+				t.Errorf("MessageID = %q, want %q", parsed.MessageID, tt.wantID)
+			}
+		})
+	}
+}
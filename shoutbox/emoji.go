@@ -0,0 +1,116 @@
+package shoutbox
+
+import "regexp"
+
+// emojiShortcodes maps the most common :shortcode: names to Unicode emoji
+var emojiShortcodes = map[string]string{
+	"+1":                         "👍",
+	"-1":                         "👎",
+	"100":                        "💯",
+	"alarm_clock":                "⏰",
+	"warning":                    "⚠️",
+	"bell":                       "🔔",
+	"birthday":                   "🎂",
+	"blue_heart":                 "💙",
+	"bookmark":                   "🔖",
+	"bulb":                       "💡",
+	"calendar":                   "📆",
+	"camera":                     "📷",
+	"chart_with_upwards_trend":   "📈",
+	"chart_with_downwards_trend": "📉",
+	"check":                      "✔️",
+	"white_check_mark":           "✅",
+	"heavy_check_mark":           "✔️",
+	"clap":                       "👏",
+	"clipboard":                  "📋",
+	"clock":                      "🕒",
+	"coffee":                     "☕",
+	"construction":               "🚧",
+	"credit_card":                "💳",
+	"cry":                        "😢",
+	"email":                      "📧",
+	"envelope":                   "✉️",
+	"exclamation":                "❗",
+	"eyes":                       "👀",
+	"fire":                       "🔥",
+	"gift":                       "🎁",
+	"globe_with_meridians":       "🌐",
+	"grin":                       "😁",
+	"grinning":                   "😀",
+	"hammer_and_wrench":          "🛠️",
+	"handshake":                  "🤝",
+	"heart":                      "❤️",
+	"heart_eyes":                 "😍",
+	"hourglass":                  "⌛",
+	"house":                      "🏠",
+	"inbox_tray":                 "📥",
+	"information_source":         "ℹ️",
+	"joy":                        "😂",
+	"key":                        "🔑",
+	"laptop":                     "💻",
+	"link":                       "🔗",
+	"lock":                       "🔒",
+	"unlock":                     "🔓",
+	"loudspeaker":                "📢",
+	"mag":                        "🔍",
+	"mailbox":                    "📫",
+	"medal":                      "🏅",
+	"memo":                       "📝",
+	"money_with_wings":           "💸",
+	"moneybag":                   "💰",
+	"muscle":                     "💪",
+	"new":                        "🆕",
+	"no_entry":                   "⛔",
+	"ok_hand":                    "👌",
+	"outbox_tray":                "📤",
+	"package":                    "📦",
+	"paperclip":                  "📎",
+	"partying_face":              "🥳",
+	"pencil":                     "📝",
+	"phone":                      "☎️",
+	"pray":                       "🙏",
+	"point_right":                "👉",
+	"point_down":                 "👇",
+	"pushpin":                    "📌",
+	"question":                   "❓",
+	"raised_hands":               "🙌",
+	"recycle":                    "♻️",
+	"red_circle":                 "🔴",
+	"rocket":                     "🚀",
+	"rotating_light":             "🚨",
+	"shopping_cart":              "🛒",
+	"shield":                     "🛡️",
+	"smile":                      "😄",
+	"smiley":                     "😃",
+	"sparkles":                   "✨",
+	"speech_balloon":             "💬",
+	"star":                       "⭐",
+	"star2":                      "🌟",
+	"sunglasses":                 "😎",
+	"sunny":                      "☀️",
+	"tada":                       "🎉",
+	"thinking":                   "🤔",
+	"thumbsup":                   "👍",
+	"thumbsdown":                 "👎",
+	"ticket":                     "🎫",
+	"trophy":                     "🏆",
+	"truck":                      "🚚",
+	"wave":                       "👋",
+	"wink":                       "😉",
+	"wrench":                     "🔧",
+	"x":                          "❌",
+	"zap":                        "⚡",
+}
+
+var emojiShortcodePattern = regexp.MustCompile(`:[a-z0-9_+\-]+:`)
+
+// ExpandEmoji replaces known :shortcode: sequences in s with their Unicode
+// emoji. Unknown shortcodes are left as they are.
+func ExpandEmoji(s string) string {
+	return emojiShortcodePattern.ReplaceAllStringFunc(s, func(code string) string {
+		if emoji, ok := emojiShortcodes[code[1:len(code)-1]]; ok {
+			return emoji
+		}
+		return code
+	})
+}
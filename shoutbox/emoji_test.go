@@ -0,0 +1,21 @@
+package shoutbox
+
+import "testing"
+
+func TestExpandEmoji(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Launch day :rocket:", "Launch day 🚀"},
+		{":tada::tada: Congrats :+1:", "🎉🎉 Congrats 👍"},
+		{"Unknown :not_an_emoji: kept", "Unknown :not_an_emoji: kept"},
+		{"Time 10:30:00", "Time 10:30:00"},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandEmoji(tt.in); got != tt.want {
+			t.Errorf("ExpandEmoji(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
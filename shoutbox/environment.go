@@ -0,0 +1,82 @@
+package shoutbox
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvironmentVar is the environment variable that selects the Shoutbox
+// environment when none is configured explicitly
+const EnvironmentVar = "SHOUTBOX_ENV"
+
+// headerTest marks SMTP messages as test traffic
+const headerTest = "X-Shoutbox-Test"
+
+// Environment is a Shoutbox environment
+type Environment string
+
+const (
+	// Production delivers mail to recipients
+	Production Environment = "production"
+	// Staging sends to the Shoutbox staging API and SMTP relay
+	Staging Environment = "staging"
+	// Test sends to production endpoints but marks every message as test
+	// traffic, which is accepted and recorded but never delivered
+	Test Environment = "test"
+)
+
+// EnvironmentFromEnv returns the environment named by SHOUTBOX_ENV.
+// Production is used when the variable is unset. Unrecognized values are
+// treated as Test so a misconfigured deployment never delivers real mail.
+func EnvironmentFromEnv() Environment {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(EnvironmentVar)))
+	switch Environment(value) {
+	case "":
+		return Production
+	case Production, Staging, Test:
+		return Environment(value)
+	default:
+		return Test
+	}
+}
+
+// Region is a data-residency region of the REST API
+type Region string
+
+const (
+	// RegionDefault is the global endpoint
+	RegionDefault Region = ""
+	// RegionEU keeps message data within the European Union
+	RegionEU Region = "eu"
+)
+
+// BaseURL returns the REST API base URL of the environment
+func (e Environment) BaseURL() string {
+	return e.RegionBaseURL(RegionDefault)
+}
+
+// RegionBaseURL returns the REST API base URL of the environment in region
+func (e Environment) RegionBaseURL(region Region) string {
+	host := "api."
+	if region != RegionDefault {
+		host += string(region) + "."
+	}
+	if e == Staging {
+		host += "staging."
+	}
+	return "https://" + host + "shoutbox.net"
+}
+
+// SMTPHost returns the SMTP relay host of the environment
+func (e Environment) SMTPHost() string {
+	if e == Staging {
+		return "mail.staging.shoutbox.net"
+	}
+	return "mail.shoutbox.net"
+}
+
+// IsTest reports whether messages sent in the environment are marked as
+// test traffic
+func (e Environment) IsTest() bool {
+	return e == Test
+}
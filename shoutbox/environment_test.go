@@ -0,0 +1,83 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnvironmentFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     Environment
+		wantURL  string
+		wantHost string
+	}{
+		{value: "", want: Production, wantURL: "https://api.shoutbox.net", wantHost: "mail.shoutbox.net"},
+		{value: "Staging", want: Staging, wantURL: "https://api.staging.shoutbox.net", wantHost: "mail.staging.shoutbox.net"},
+		{value: "test", want: Test, wantURL: "https://api.shoutbox.net", wantHost: "mail.shoutbox.net"},
+		{value: "prodution", want: Test, wantURL: "https://api.shoutbox.net", wantHost: "mail.shoutbox.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(EnvironmentVar, tt.value)
+			if got := EnvironmentFromEnv(); got != tt.want {
+				t.Errorf("EnvironmentFromEnv() = %q, want %q", got, tt.want)
+			}
+			if got := NewClient("key").baseURL; got != tt.wantURL {
+				t.Errorf("baseURL = %q, want %q", got, tt.wantURL)
+			}
+			if got := NewSMTPClient("key").Host; got != tt.wantHost {
+				t.Errorf("Host = %q, want %q", got, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestClient_SendEmailTestEnvironment(t *testing.T) {
+	t.Setenv(EnvironmentVar, "staging")
+
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithEnvironment(Test))
+	if client.baseURL != Test.BaseURL() {
+		t.Errorf("baseURL = %q", client.baseURL)
+	}
+	client.baseURL = srv.URL
+	if _, err := client.SendEmail(context.Background(), &EmailRequest{To: Recipients{"ada@example.com"}}); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if got["test"] != true {
+		t.Errorf("test = %v, want true", got["test"])
+	}
+}
+
+func TestNewClient_BaseURL(t *testing.T) {
+	t.Setenv(EnvironmentVar, "")
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default", want: "https://api.shoutbox.net"},
+		{name: "eu", opts: []Option{WithRegion(RegionEU)}, want: "https://api.eu.shoutbox.net"},
+		{name: "eu staging", opts: []Option{WithRegion(RegionEU), WithEnvironment(Staging)}, want: "https://api.eu.staging.shoutbox.net"},
+		{name: "proxy", opts: []Option{WithBaseURL("https://proxy.internal/shoutbox/"), WithRegion(RegionEU)}, want: "https://proxy.internal/shoutbox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewClient("key", tt.opts...).baseURL; got != tt.want {
+				t.Errorf("baseURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package shoutbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// APIError is returned when the API responds with a non-2xx status.
+// Use errors.As to inspect it.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code, e.g. "invalid_recipient",
+	// if the API returned one
+	Code    string
+	Message string
+	// RequestID identifies the request when contacting support
+	RequestID string
+	// RetryAfter is how long the API asked to wait before retrying, from
+	// the Retry-After header or, when rate limited, X-RateLimit-Reset
+	RetryAfter time.Duration
+	// RateLimit is the quota reported with the response, if any
+	RateLimit RateLimit
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("error response with status %d", e.StatusCode)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("api error (%s): %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("api error: %s", e.Message)
+}
+
+// Retryable reports whether the request may succeed if retried
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// newAPIError builds an APIError from a non-2xx response
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+	now := time.Now()
+	apiErr.RetryAfter, _ = retryDelay(resp, now)
+	apiErr.RateLimit, _ = parseRateLimit(resp.Header, now)
+
+	var body struct {
+		Error     string `json:"error"`
+		Code      string `json:"code"`
+		RequestID string `json:"request_id"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil {
+		apiErr.Message = body.Error
+		apiErr.Code = body.Code
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = body.RequestID
+		}
+	}
+	return apiErr
+}
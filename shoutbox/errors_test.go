@@ -0,0 +1,83 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_APIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		body    string
+		want    APIError
+		wantMsg string
+	}{
+		{
+			name:    "coded error",
+			status:  http.StatusUnprocessableEntity,
+			headers: map[string]string{"X-Request-Id": "req_1"},
+			body:    `{"error": "recipient is invalid", "code": "invalid_recipient"}`,
+			want:    APIError{StatusCode: 422, Code: "invalid_recipient", Message: "recipient is invalid", RequestID: "req_1"},
+			wantMsg: "api error (invalid_recipient): recipient is invalid",
+		},
+		{
+			name:    "rate limited",
+			status:  http.StatusTooManyRequests,
+			headers: map[string]string{"Retry-After": "30"},
+			body:    `{"error": "slow down", "request_id": "req_2"}`,
+			want:    APIError{StatusCode: 429, Message: "slow down", RequestID: "req_2", RetryAfter: 30 * time.Second},
+			wantMsg: "api error: slow down",
+		},
+		{
+			name:    "quota exhausted",
+			status:  http.StatusTooManyRequests,
+			headers: map[string]string{"X-RateLimit-Limit": "60", "X-RateLimit-Remaining": "0"},
+			body:    `{"error": "quota exhausted"}`,
+			want:    APIError{StatusCode: 429, Message: "quota exhausted", RateLimit: RateLimit{Limit: 60}},
+			wantMsg: "api error: quota exhausted",
+		},
+		{
+			name:    "no body",
+			status:  http.StatusBadGateway,
+			want:    APIError{StatusCode: 502},
+			wantMsg: "error response with status 502",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.headers {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			client := NewClient("test-key", WithoutRetries())
+			client.baseURL = srv.URL
+			_, err := client.SendEmail(context.Background(), &EmailRequest{To: Recipients{"ada@example.com"}})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("SendEmail() error = %v, want *APIError", err)
+			}
+			if *apiErr != tt.want {
+				t.Errorf("APIError = %+v, want %+v", *apiErr, tt.want)
+			}
+			if err.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantMsg)
+			}
+			if apiErr.Retryable() != (tt.status == 429 || tt.status >= 500) {
+				t.Errorf("Retryable() = %v", apiErr.Retryable())
+			}
+		})
+	}
+}
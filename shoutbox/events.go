@@ -0,0 +1,92 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Event types reported by the events API
+const (
+	EventDelivered    = "delivered"
+	EventDeferred     = "deferred"
+	EventBounced      = "bounced"
+	EventOpened       = "opened"
+	EventClicked      = "clicked"
+	EventComplained   = "complained"
+	EventUnsubscribed = "unsubscribed"
+)
+
+// Event is a delivery or engagement event for a sent message
+type Event struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	MessageID string            `json:"message_id"`
+	Recipient string            `json:"recipient"`
+	Timestamp time.Time         `json:"timestamp"`
+	Tags      []string          `json:"tags,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// EventQuery filters the events returned by ListEvents
+type EventQuery struct {
+	Type      string
+	Recipient string
+	MessageID string
+	// Tag matches events whose message carried the tag
+	Tag string
+	// Metadata matches events whose message carried these metadata values
+	Metadata map[string]string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+	Cursor   string
+}
+
+// EventList is one page of events
+type EventList struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// ListEvents returns one page of events matching q. Pass NextCursor as
+// q.Cursor to fetch the following page.
+func (c *Client) ListEvents(ctx context.Context, q *EventQuery) (*EventList, error) {
+	var list EventList
+	if err := c.do(ctx, http.MethodGet, "/events?"+q.values().Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (q *EventQuery) values() url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+	setIf := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	setIf("type", q.Type)
+	setIf("recipient", q.Recipient)
+	setIf("message_id", q.MessageID)
+	setIf("tag", q.Tag)
+	setIf("cursor", q.Cursor)
+	for key, value := range q.Metadata {
+		v.Set("metadata["+key+"]", value)
+	}
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		v.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
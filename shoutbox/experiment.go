@@ -0,0 +1,185 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"maps"
+)
+
+// ErrNoVariants is returned when an Experiment has no variants to assign
+var ErrNoVariants = errors.New("experiment has no variants")
+
+// Headers identifying the experiment and variant of a send
+const (
+	headerExperiment = "X-Shoutbox-Experiment"
+	headerVariant    = "X-Shoutbox-Variant"
+)
+
+// Variant is one arm of an Experiment. Non-empty fields replace the
+// corresponding fields of the base message.
+type Variant struct {
+	Name    string
+	Subject string
+	HTML    string
+	// Text is the plain-text body; set it with HTML so the two parts of
+	// the variant agree
+	Text string
+	// TemplateID, when set, sends the variant as a hosted template
+	TemplateID string
+	// Weight is the relative share of recipients; zero or less counts as 1
+	Weight int
+}
+
+// Experiment splits recipients between variants of a message for A/B
+// testing. Assignment is deterministic, so a recipient always receives the
+// same variant of a given experiment.
+type Experiment struct {
+	Name     string
+	Variants []Variant
+}
+
+// VariantStats are the aggregated engagement counts of one variant
+type VariantStats struct {
+	Delivered int
+	Opened    int
+	Clicked   int
+}
+
+// OpenRate returns the share of delivered recipients who opened
+func (s VariantStats) OpenRate() float64 {
+	if s.Delivered == 0 {
+		return 0
+	}
+	return float64(s.Opened) / float64(s.Delivered)
+}
+
+// ClickRate returns the share of delivered recipients who clicked
+func (s VariantStats) ClickRate() float64 {
+	if s.Delivered == 0 {
+		return 0
+	}
+	return float64(s.Clicked) / float64(s.Delivered)
+}
+
+// Assign returns the variant for recipient
+func (e *Experiment) Assign(recipient string) (Variant, error) {
+	if len(e.Variants) == 0 {
+		return Variant{}, ErrNoVariants
+	}
+	total := 0
+	for _, v := range e.Variants {
+		total += variantWeight(v)
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(e.Name + "\x00" + recipient))
+	n := int(h.Sum32() % uint32(total))
+	for _, v := range e.Variants {
+		if n < variantWeight(v) {
+			return v, nil
+		}
+		n -= variantWeight(v)
+	}
+	return e.Variants[len(e.Variants)-1], nil
+}
+
+// Split groups recipients by their assigned variant name
+func (e *Experiment) Split(recipients []string) (map[string][]string, error) {
+	if len(e.Variants) == 0 {
+		return nil, ErrNoVariants
+	}
+	groups := make(map[string][]string, len(e.Variants))
+	for _, recipient := range recipients {
+		v, _ := e.Assign(recipient)
+		groups[v.Name] = append(groups[v.Name], recipient)
+	}
+	return groups, nil
+}
+
+// Apply returns a copy of req with the recipient's variant applied and
+// tagged with the experiment and variant names, in headers and in the
+// "experiment" and "variant" metadata that Results reads back from events.
+// The variant is assigned by the first recipient; use Split to send each
+// variant separately.
+func (e *Experiment) Apply(req *EmailRequest) (*EmailRequest, error) {
+	var recipient string
+	if len(req.To) > 0 {
+		recipient = req.To[0]
+	}
+	v, err := e.Assign(recipient)
+	if err != nil {
+		return nil, err
+	}
+	r := *req
+	if v.Subject != "" {
+		r.Subject = v.Subject
+	}
+	if v.HTML != "" {
+		r.HTML = v.HTML
+	}
+	if v.Text != "" {
+		r.Text = v.Text
+	}
+	if v.TemplateID != "" {
+		r.TemplateID = v.TemplateID
+	}
+	r.Headers = maps.Clone(req.Headers)
+	if r.Headers == nil {
+		r.Headers = make(map[string]string)
+	}
+	r.Headers[headerExperiment] = e.Name
+	r.Headers[headerVariant] = v.Name
+	r.Metadata = maps.Clone(req.Metadata)
+	if r.Metadata == nil {
+		r.Metadata = make(map[string]string)
+	}
+	r.Metadata["experiment"] = e.Name
+	r.Metadata["variant"] = v.Name
+	return &r, nil
+}
+
+// Results aggregates delivery, open and click events per variant from the
+// events API. Opens and clicks are counted once per recipient.
+func (e *Experiment) Results(ctx context.Context, client *Client) (map[string]VariantStats, error) {
+	results := make(map[string]VariantStats, len(e.Variants))
+	seen := make(map[[3]string]bool)
+
+	q := &EventQuery{Metadata: map[string]string{"experiment": e.Name}}
+	for {
+		list, err := client.ListEvents(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range list.Events {
+			variant := event.Metadata["variant"]
+			key := [3]string{variant, event.Type, event.Recipient}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			stats := results[variant]
+			switch event.Type {
+			case EventDelivered:
+				stats.Delivered++
+			case EventOpened:
+				stats.Opened++
+			case EventClicked:
+				stats.Clicked++
+			}
+			results[variant] = stats
+		}
+		if list.NextCursor == "" {
+			return results, nil
+		}
+		q.Cursor = list.NextCursor
+	}
+}
+
+func variantWeight(v Variant) int {
+	if v.Weight <= 0 {
+		return 1
+	}
+	return v.Weight
+}
@@ -0,0 +1,173 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExperiment_Split(t *testing.T) {
+	e := &Experiment{Name: "subject-test", Variants: []Variant{
+		{Name: "a", Subject: "Save 20%"},
+		{Name: "b", Subject: "Your discount inside", Weight: 3},
+	}}
+
+	recipients := make([]string, 4000)
+	for i := range recipients {
+		recipients[i] = fmt.Sprintf("user%d@example.com", i)
+	}
+	groups, err := e.Split(recipients)
+	if err != nil {
+		t.Fatalf("Split() error = %v", err)
+	}
+	if a, b := len(groups["a"]), len(groups["b"]); a < 800 || a > 1200 || a+b != len(recipients) {
+		t.Errorf("split a=%d b=%d, want about 1000/3000", a, b)
+	}
+
+	req := &EmailRequest{To: Recipients{recipients[0]}, Subject: "Base", HTML: "<p>Base</p>"}
+	got, err := e.Apply(req)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want, _ := e.Assign(recipients[0])
+	if got.Subject != want.Subject || got.HTML != "<p>Base</p>" {
+		t.Errorf("Apply() = %+v", got)
+	}
+	if got.Headers[headerVariant] != want.Name || got.Headers[headerExperiment] != "subject-test" {
+		t.Errorf("Apply() headers = %v", got.Headers)
+	}
+	if again, _ := e.Assign(recipients[0]); again.Name != want.Name {
+		t.Errorf("Assign() is not deterministic")
+	}
+
+	body := &Experiment{Name: "body-test", Variants: []Variant{{Name: "a", HTML: "<p>New</p>", Text: "New"}}}
+	got, err = body.Apply(&EmailRequest{To: Recipients{recipients[0]}, HTML: "<p>Base</p>", Text: "Base"})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got.HTML != "<p>New</p>" || got.Text != "New" {
+		t.Errorf("Apply() bodies = %q, %q, want the variant's HTML and text", got.HTML, got.Text)
+	}
+}
+
+func TestExperiment_NoVariants(t *testing.T) {
+	e := &Experiment{Name: "empty"}
+	if _, err := e.Assign("ada@example.com"); !errors.Is(err, ErrNoVariants) {
+		t.Errorf("Assign() error = %v, want %v", err, ErrNoVariants)
+	}
+	if _, err := e.Split([]string{"ada@example.com"}); !errors.Is(err, ErrNoVariants) {
+		t.Errorf("Split() error = %v, want %v", err, ErrNoVariants)
+	}
+	if _, err := e.Apply(&EmailRequest{To: Recipients{"ada@example.com"}}); !errors.Is(err, ErrNoVariants) {
+		t.Errorf("Apply() error = %v, want %v", err, ErrNoVariants)
+	}
+
+	e.Variants = []Variant{{Name: "a", Weight: 0}, {Name: "b", Weight: -1}}
+	if v, err := e.Assign("ada@example.com"); err != nil || v.Name == "" {
+		t.Errorf("Assign() with zero weights = %+v, %v, want an evenly weighted variant", v, err)
+	}
+}
+
+func TestExperiment_Results(t *testing.T) {
+	pages := map[string]EventList{
+		"": {Events: []Event{
+			{Type: EventDelivered, Recipient: "1", Metadata: map[string]string{"variant": "a"}},
+			{Type: EventDelivered, Recipient: "2", Metadata: map[string]string{"variant": "a"}},
+			{Type: EventOpened, Recipient: "1", Metadata: map[string]string{"variant": "a"}},
+			{Type: EventOpened, Recipient: "1", Metadata: map[string]string{"variant": "a"}},
+		}, NextCursor: "p2"},
+		"p2": {Events: []Event{
+			{Type: EventDelivered, Recipient: "3", Metadata: map[string]string{"variant": "b"}},
+			{Type: EventClicked, Recipient: "3", Metadata: map[string]string{"variant": "b"}},
+		}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("metadata[experiment]") != "subject-test" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(pages[r.URL.Query().Get("cursor")])
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	e := &Experiment{Name: "subject-test", Variants: []Variant{{Name: "a"}, {Name: "b"}}}
+	results, err := e.Results(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Results() error = %v", err)
+	}
+	if got := results["a"]; got != (VariantStats{Delivered: 2, Opened: 1}) || got.OpenRate() != 0.5 {
+		t.Errorf("results[a] = %+v", got)
+	}
+	if got := results["b"]; got != (VariantStats{Delivered: 1, Clicked: 1}) || got.ClickRate() != 1 {
+		t.Errorf("results[b] = %+v", got)
+	}
+}
+
+func TestExperiment_ApplyResults(t *testing.T) {
+	var events []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/send":
+			var req EmailRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			events = append(events, Event{Type: EventDelivered, Recipient: req.To.String(), Metadata: req.Metadata})
+			if req.Subject == "B" {
+				events = append(events, Event{Type: EventOpened, Recipient: req.To.String(), Metadata: req.Metadata})
+			}
+			json.NewEncoder(w).Encode(SendResponse{MessageID: "msg-1"})
+		case "/events":
+			var list EventList
+			for _, event := range events {
+				if event.Metadata["experiment"] == r.URL.Query().Get("metadata[experiment]") {
+					list.Events = append(list.Events, event)
+				}
+			}
+			json.NewEncoder(w).Encode(list)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	e := &Experiment{Name: "subject-test", Variants: []Variant{{Name: "a", Subject: "A"}, {Name: "b", Subject: "B"}}}
+	metadata := map[string]string{"list": "news"}
+	want := make(map[string]VariantStats)
+	for i := range 20 {
+		to := fmt.Sprintf("user%d@example.com", i)
+		req, err := e.Apply(&EmailRequest{From: "news@example.com", To: Recipients{to}, Subject: "Base", HTML: "<p>Hi</p>", Metadata: metadata})
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		if req.Metadata["list"] != "news" || len(metadata) != 1 {
+			t.Fatalf("Apply() metadata = %v, base = %v, want a copy with existing metadata kept", req.Metadata, metadata)
+		}
+		if _, err := client.SendEmail(context.Background(), req); err != nil {
+			t.Fatalf("SendEmail() error = %v", err)
+		}
+
+		v, _ := e.Assign(to)
+		stats := want[v.Name]
+		stats.Delivered++
+		if v.Name == "b" {
+			stats.Opened++
+		}
+		want[v.Name] = stats
+	}
+
+	results, err := e.Results(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Results() error = %v", err)
+	}
+	if len(results) != 2 || results["a"] != want["a"] || results["b"] != want["b"] {
+		t.Errorf("Results() = %+v, want %+v", results, want)
+	}
+}
@@ -0,0 +1,75 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// UnsubscribeGroup is a subscription category recipients can opt out of
+// in the preference center. Messages are assigned to a group by setting
+// their Category to its Name, and recipients' choices are the Categories
+// of their Preferences.
+type UnsubscribeGroup struct {
+	Name string `json:"name"`
+	// Description tells recipients what the group sends, e.g. "Monthly
+	// product news"
+	Description string `json:"description,omitempty"`
+	// Unsubscribes is the number of recipients who opted out of the group
+	Unsubscribes int       `json:"unsubscribes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UnsubscribeGroupsService manages the account's unsubscribe groups
+type UnsubscribeGroupsService struct {
+	client *Client
+}
+
+// UnsubscribeGroups returns the unsubscribe group API
+func (c *Client) UnsubscribeGroups() *UnsubscribeGroupsService {
+	return &UnsubscribeGroupsService{client: c}
+}
+
+// Create adds an unsubscribe group, shown in the preference center
+func (s *UnsubscribeGroupsService) Create(ctx context.Context, group *UnsubscribeGroup) (*UnsubscribeGroup, error) {
+	var created UnsubscribeGroup
+	if err := s.client.do(ctx, http.MethodPost, "/unsubscribe-groups", group, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Get returns the unsubscribe group with the given name
+func (s *UnsubscribeGroupsService) Get(ctx context.Context, name string) (*UnsubscribeGroup, error) {
+	var group UnsubscribeGroup
+	if err := s.client.do(ctx, http.MethodGet, "/unsubscribe-groups/"+url.PathEscape(name), nil, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// List returns every unsubscribe group of the account
+func (s *UnsubscribeGroupsService) List(ctx context.Context) ([]UnsubscribeGroup, error) {
+	var resp struct {
+		Groups []UnsubscribeGroup `json:"groups"`
+	}
+	if err := s.client.do(ctx, http.MethodGet, "/unsubscribe-groups", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Groups, nil
+}
+
+// Update replaces the description of group.Name
+func (s *UnsubscribeGroupsService) Update(ctx context.Context, group *UnsubscribeGroup) (*UnsubscribeGroup, error) {
+	var updated UnsubscribeGroup
+	if err := s.client.do(ctx, http.MethodPut, "/unsubscribe-groups/"+url.PathEscape(group.Name), group, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete removes an unsubscribe group
+func (s *UnsubscribeGroupsService) Delete(ctx context.Context, name string) error {
+	return s.client.do(ctx, http.MethodDelete, "/unsubscribe-groups/"+url.PathEscape(name), nil, nil)
+}
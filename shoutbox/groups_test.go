@@ -0,0 +1,83 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestUnsubscribeGroupsService(t *testing.T) {
+	var requests []string
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		var body map[string]any
+		if json.NewDecoder(r.Body).Decode(&body) == nil {
+			bodies = append(bodies, body)
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/unsubscribe-groups":
+			json.NewEncoder(w).Encode(map[string]any{"groups": []UnsubscribeGroup{{Name: "newsletter"}, {Name: "product"}}})
+		case r.Method != http.MethodDelete:
+			json.NewEncoder(w).Encode(UnsubscribeGroup{Name: "newsletter", Description: "Monthly news", Unsubscribes: 3})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	groups := client.UnsubscribeGroups()
+	ctx := context.Background()
+
+	group, err := groups.Create(ctx, &UnsubscribeGroup{Name: "newsletter", Description: "Monthly news"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if group.Unsubscribes != 3 {
+		t.Errorf("Create() = %+v", group)
+	}
+	if _, err := groups.Get(ctx, "newsletter"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	list, err := groups.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 2 || list[1].Name != "product" {
+		t.Errorf("List() = %+v", list)
+	}
+	if _, err := groups.Update(ctx, &UnsubscribeGroup{Name: "newsletter", Description: "Weekly news"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := groups.Delete(ctx, "newsletter"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := client.Preferences().Unsubscribe(ctx, "ada@example.com", "product"); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	if err := client.Preferences().Subscribe(ctx, "ada@example.com", "product"); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	want := []string{
+		"POST /unsubscribe-groups",
+		"GET /unsubscribe-groups/newsletter",
+		"GET /unsubscribe-groups",
+		"PUT /unsubscribe-groups/newsletter",
+		"DELETE /unsubscribe-groups/newsletter",
+		"PUT /preferences/ada@example.com/categories/product",
+		"PUT /preferences/ada@example.com/categories/product",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+	if got := bodies[len(bodies)-2]["subscribed"]; got != false {
+		t.Errorf("Unsubscribe() subscribed = %v, want false", got)
+	}
+	if got := bodies[len(bodies)-1]["subscribed"]; got != true {
+		t.Errorf("Subscribe() subscribed = %v, want true", got)
+	}
+}
@@ -0,0 +1,154 @@
+package shoutbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidHeader is matched by every *HeaderError using errors.Is
+var ErrInvalidHeader = errors.New("invalid header")
+
+// HeaderError reports a header name or value that could inject other
+// headers or MIME parts, e.g. a subject containing CR or LF
+type HeaderError struct {
+	// Field is the message field or header name, e.g. "subject"
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("invalid %s header %q: %s", e.Field, e.Value, e.Reason)
+}
+
+// Is reports whether target is ErrInvalidHeader
+func (e *HeaderError) Is(target error) bool {
+	return target == ErrInvalidHeader
+}
+
+// validateHeaderValue rejects control characters other than tab, which
+// would end the header line or corrupt the message
+func validateHeaderValue(field, value string) error {
+	for _, r := range value {
+		if r == '\r' || r == '\n' {
+			return &HeaderError{Field: field, Value: value, Reason: "contains a line break"}
+		}
+		if r < ' ' && r != '\t' || r == 0x7f {
+			return &HeaderError{Field: field, Value: value, Reason: "contains a control character"}
+		}
+	}
+	return nil
+}
+
+// validateHeaderName allows the printable ASCII characters other than
+// colon, as RFC 5322 requires
+func validateHeaderName(name string) error {
+	if name == "" {
+		return &HeaderError{Field: "custom", Value: name, Reason: "empty name"}
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c <= ' ' || c >= 0x7f || c == ':' {
+			return &HeaderError{Field: "custom", Value: name, Reason: "invalid character in name"}
+		}
+	}
+	return nil
+}
+
+// validateHeaders checks every value that is written into a header of the
+// message or of an attachment part
+func validateHeaders(fields [][2]string, headers map[string]string, attachments []Attachment) error {
+	for _, f := range fields {
+		if err := validateHeaderValue(f[0], f[1]); err != nil {
+			return err
+		}
+	}
+	for name, value := range headers {
+		if err := validateHeaderName(name); err != nil {
+			return err
+		}
+		if err := validateHeaderValue(name, value); err != nil {
+			return err
+		}
+	}
+	for _, a := range attachments {
+		for _, f := range [][2]string{{"filename", a.Filename}, {"content_type", a.ContentType}, {"content_id", a.ContentID}} {
+			if err := validateHeaderValue(f[0], f[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateHeaders checks the header fields of req
+func (req *EmailRequest) validateHeaders() error {
+	fields := [][2]string{{"from", req.From}, {"reply_to", req.ReplyTo}, {"subject", req.Subject}, {"name", req.Name}, {"category", req.Category}, {"read_receipt_to", req.ReadReceiptTo}}
+	for _, list := range []Recipients{req.To, req.Cc, req.Bcc} {
+		for _, address := range list {
+			fields = append(fields, [2]string{"recipient", address})
+		}
+	}
+	if err := validateHeaders(fields, req.Headers, req.Attachments); err != nil {
+		return err
+	}
+	if err := validateTags(req.Tags, req.Metadata); err != nil {
+		return err
+	}
+	if err := validateMessageIDs(req.MessageID, req.InReplyTo, req.References); err != nil {
+		return err
+	}
+	return validateTrackingDomain(req.TrackingDomain)
+}
+
+// validateHeaders checks the header fields of msg
+func (msg *EmailMessage) validateHeaders() error {
+	fields := [][2]string{{"from", msg.From}, {"reply_to", msg.ReplyTo}, {"subject", msg.Subject}, {"name", msg.Name}, {"category", msg.Category}, {"read_receipt_to", msg.ReadReceiptTo}}
+	for _, address := range msg.recipients() {
+		fields = append(fields, [2]string{"recipient", address})
+	}
+	if err := validateHeaders(fields, msg.Headers, msg.Attachments); err != nil {
+		return err
+	}
+	if err := validateTags(msg.Tags, msg.Metadata); err != nil {
+		return err
+	}
+	if err := validateMessageIDs(msg.MessageID, msg.InReplyTo, msg.References); err != nil {
+		return err
+	}
+	return validateTrackingDomain(msg.TrackingDomain)
+}
+
+// validateTags checks tags and metadata, which the SMTP client sends in
+// headers. Tags are comma-separated there, so they may not contain commas.
+func validateTags(tags []string, metadata map[string]string) error {
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" || strings.Contains(tag, ",") {
+			return &HeaderError{Field: "tags", Value: tag, Reason: "empty or contains a comma"}
+		}
+		if err := validateHeaderValue("tags", tag); err != nil {
+			return err
+		}
+	}
+	for key := range metadata {
+		if key == "" {
+			return &HeaderError{Field: "metadata", Value: key, Reason: "empty key"}
+		}
+	}
+	return nil
+}
+
+// validateTrackingDomain checks that a non-empty tracking domain is a bare
+// host name such as "links.example.com", without a scheme, port or path
+func validateTrackingDomain(domain string) error {
+	if domain == "" {
+		return nil
+	}
+	if strings.ContainsAny(domain, ":/ \t\r\n?#@") || !strings.Contains(strings.Trim(domain, "."), ".") {
+		return &HeaderError{Field: "tracking_domain", Value: domain, Reason: "not a host name"}
+	}
+	if _, err := DomainToASCII(domain); err != nil {
+		return &HeaderError{Field: "tracking_domain", Value: domain, Reason: "invalid domain"}
+	}
+	return nil
+}
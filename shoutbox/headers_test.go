@@ -0,0 +1,71 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	valid := func() *EmailMessage {
+		return &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Hello", Text: "Hello"}
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(msg *EmailMessage)
+		wantField string
+	}{
+		{name: "valid", modify: func(msg *EmailMessage) { msg.Headers = map[string]string{"X-Campaign": "spring\tsale"} }},
+		{name: "subject line break", modify: func(msg *EmailMessage) { msg.Subject = "Hello\r\nBcc: victim@example.com" }, wantField: "subject"},
+		{name: "bare newline in name", modify: func(msg *EmailMessage) { msg.Name = "News\nX-Spam: no" }, wantField: "name"},
+		{name: "null byte in category", modify: func(msg *EmailMessage) { msg.Category = "news\x00" }, wantField: "category"},
+		{name: "custom header value", modify: func(msg *EmailMessage) { msg.Headers = map[string]string{"X-Campaign": "a\r\n\r\n<h1>injected</h1>"} }, wantField: "X-Campaign"},
+		{name: "custom header name", modify: func(msg *EmailMessage) { msg.Headers = map[string]string{"X-Campaign: a\r\nBcc": "b"} }, wantField: "custom"},
+		{name: "attachment filename", modify: func(msg *EmailMessage) {
+			msg.Attachments = []Attachment{{Filename: "a.txt\"\r\nContent-Type: text/html", ContentType: "text/plain"}}
+		}, wantField: "filename"},
+		{name: "tags and metadata", modify: func(msg *EmailMessage) {
+			msg.Tags, msg.Metadata = []string{"welcome"}, map[string]string{"order_id": "1"}
+		}},
+		{name: "tag with comma", modify: func(msg *EmailMessage) { msg.Tags = []string{"a,b"} }, wantField: "tags"},
+		{name: "tag with line break", modify: func(msg *EmailMessage) { msg.Tags = []string{"a\r\nBcc: x"} }, wantField: "tags"},
+		{name: "empty metadata key", modify: func(msg *EmailMessage) { msg.Metadata = map[string]string{"": "x"} }, wantField: "metadata"},
+		{name: "message IDs", modify: func(msg *EmailMessage) { msg.MessageID, msg.InReplyTo = "<a@example.com>", "b@example.com" }},
+		{name: "message ID without domain", modify: func(msg *EmailMessage) { msg.MessageID = "order-1234" }, wantField: "message_id"},
+		{name: "reference with line break", modify: func(msg *EmailMessage) { msg.References = []string{"a@example.com\r\nBcc: x"} }, wantField: "references"},
+		{name: "tracking domain", modify: func(msg *EmailMessage) { msg.TrackingDomain = "links.example.com" }},
+		{name: "tracking domain with scheme", modify: func(msg *EmailMessage) { msg.TrackingDomain = "https://links.example.com" }, wantField: "tracking_domain"},
+		{name: "tracking domain with path", modify: func(msg *EmailMessage) { msg.TrackingDomain = "example.com/links" }, wantField: "tracking_domain"},
+		{name: "tracking domain without dot", modify: func(msg *EmailMessage) { msg.TrackingDomain = "localhost" }, wantField: "tracking_domain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := valid()
+			tt.modify(msg)
+
+			smtpErr := msg.validateHeaders()
+			restErr := msg.request().validateHeaders()
+			for _, err := range []error{smtpErr, restErr} {
+				var headerErr *HeaderError
+				if tt.wantField == "" {
+					if err != nil {
+						t.Errorf("validateHeaders() error = %v", err)
+					}
+					continue
+				}
+				if !errors.As(err, &headerErr) || headerErr.Field != tt.wantField || !errors.Is(err, ErrInvalidHeader) {
+					t.Errorf("validateHeaders() error = %v, want %s header error", err, tt.wantField)
+				}
+			}
+		})
+	}
+}
+
+func TestSMTPClient_RenderRejectsHeaderInjection(t *testing.T) {
+	msg := &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Hi\r\nBcc: victim@example.com", Text: "Hi"}
+	if _, err := (&SMTPClient{}).Render(context.Background(), msg); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Render() error = %v, want ErrInvalidHeader", err)
+	}
+}
@@ -1,12 +1,17 @@
 package shoutbox
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
+	"net/mail"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 )
 
 // NewAttachmentFromFile creates a new attachment from a file
@@ -16,16 +21,10 @@ func NewAttachmentFromFile(filePath string) (Attachment, error) {
 		return Attachment{}, fmt.Errorf("error reading file: %w", err)
 	}
 
-	// Detect content type
-	contentType := mime.TypeByExtension(filepath.Ext(filePath))
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-
 	return Attachment{
 		Filename:    filepath.Base(filePath),
 		Content:     content,
-		ContentType: contentType,
+		ContentType: contentTypeOf(filePath),
 	}, nil
 }
 
@@ -36,27 +35,129 @@ func NewAttachmentFromReader(reader io.Reader, filename string) (Attachment, err
 		return Attachment{}, fmt.Errorf("error reading content: %w", err)
 	}
 
-	contentType := mime.TypeByExtension(filepath.Ext(filename))
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-
 	return Attachment{
 		Filename:    filename,
 		Content:     content,
-		ContentType: contentType,
+		ContentType: contentTypeOf(filename),
 	}, nil
 }
 
-// ValidateEmail validates an email address format
-func ValidateEmail(email string) error {
-	// Simple validation for demonstration
-	if !strings.Contains(email, "@") {
-		return fmt.Errorf("invalid email address: %s", email)
+// contentTypeOf detects the content type of a file from its extension
+func contentTypeOf(filename string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// NewStreamingAttachment creates an attachment whose content is read from
+// open when the message is written, instead of being held in memory
+func NewStreamingAttachment(filename string, open func() (io.ReadCloser, error)) Attachment {
+	return Attachment{
+		Filename:    filename,
+		ContentType: contentTypeOf(filename),
+		Open:        open,
+	}
+}
+
+// NewStreamingAttachmentFromFile creates an attachment that streams the
+// file from disk each time the message is written
+func NewStreamingAttachmentFromFile(filePath string) (Attachment, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("error reading file: %w", err)
+	}
+	if info.IsDir() {
+		return Attachment{}, fmt.Errorf("error reading file: %s is a directory", filePath)
+	}
+	attachment := NewStreamingAttachment(filepath.Base(filePath), func() (io.ReadCloser, error) {
+		return os.Open(filePath)
+	})
+	attachment.Size = int(info.Size())
+	return attachment, nil
+}
+
+// copyTo copies the streamed content of a to w
+func (a Attachment) copyTo(w io.Writer) error {
+	r, err := a.Open()
+	if err != nil {
+		return fmt.Errorf("error opening attachment %s: %w", a.Filename, err)
+	}
+	defer r.Close()
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("error reading attachment %s: %w", a.Filename, err)
 	}
 	return nil
 }
 
+// NewStreamingAttachmentFromReader creates an attachment streamed from r.
+// r can only be read once, so a message with it can only be sent once and
+// isn't retried.
+func NewStreamingAttachmentFromReader(r io.Reader, filename string) Attachment {
+	var once sync.Once
+	return NewStreamingAttachment(filename, func() (io.ReadCloser, error) {
+		opened := false
+		once.Do(func() { opened = true })
+		if !opened {
+			return nil, errors.New("reader was already consumed")
+		}
+		if rc, ok := r.(io.ReadCloser); ok {
+			return rc, nil
+		}
+		return io.NopCloser(r), nil
+	})
+}
+
+// loadAttachments returns a copy of attachments with streamed content read
+// into Content, for the REST API which needs it in the request body
+func loadAttachments(attachments []Attachment) ([]Attachment, error) {
+	loaded := slices.Clone(attachments)
+	for i, a := range loaded {
+		if a.Open == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := a.copyTo(&buf); err != nil {
+			return nil, err
+		}
+		loaded[i].Content = buf.Bytes()
+		loaded[i].Open = nil
+	}
+	return loaded, nil
+}
+
+// ErrInvalidAddress is matched by every *AddressError using errors.Is
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// AddressError reports an address that isn't a valid RFC 5322 address
+type AddressError struct {
+	// Field is the message field holding the address, e.g. "to", or empty
+	// when the address was validated on its own
+	Field   string
+	Address string
+	Reason  string
+}
+
+func (e *AddressError) Error() string {
+	field := "email"
+	if e.Field != "" {
+		field = e.Field
+	}
+	return fmt.Sprintf("invalid %s address %q: %s", field, e.Address, e.Reason)
+}
+
+// Is reports whether target is ErrInvalidAddress
+func (e *AddressError) Is(target error) bool {
+	return target == ErrInvalidAddress
+}
+
+// ValidateEmail validates that email is a single RFC 5322 address, e.g.
+// "ada@example.com" or "Ada Lovelace <ada@example.com>". The error is an
+// *AddressError.
+func ValidateEmail(email string) error {
+	return validateAddress("", email, false)
+}
+
 // ValidateEmailList validates a list of email addresses
 func ValidateEmailList(emails []string) error {
 	for _, email := range emails {
@@ -66,3 +167,55 @@ func ValidateEmailList(emails []string) error {
 	}
 	return nil
 }
+
+// validateAddress validates the address in field. A bare address may not
+// have a display name, as required for the SMTP envelope.
+func validateAddress(field, email string, bare bool) error {
+	if strings.TrimSpace(email) == "" {
+		return &AddressError{Field: field, Address: email, Reason: "empty address"}
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return &AddressError{Field: field, Address: email, Reason: strings.TrimPrefix(err.Error(), "mail: ")}
+	}
+	if _, err := AddressToASCII(addr.Address); err != nil {
+		return &AddressError{Field: field, Address: email, Reason: "invalid domain"}
+	}
+	if bare && addr.Address != strings.TrimSpace(email) {
+		return &AddressError{Field: field, Address: email, Reason: "display name not allowed"}
+	}
+	return nil
+}
+
+// validateAddresses validates the non-empty From and ReplyTo and every
+// recipient of a message. A bare From may not have a display name.
+func validateAddresses(from, replyTo string, to, cc, bcc []string, bareFrom bool) error {
+	if from != "" {
+		if err := validateAddress("from", from, bareFrom); err != nil {
+			return err
+		}
+	}
+	if replyTo != "" {
+		if err := validateAddress("reply_to", replyTo, false); err != nil {
+			return err
+		}
+	}
+	fields := []struct {
+		name string
+		list []string
+	}{{"to", to}, {"cc", cc}, {"bcc", bcc}}
+	for _, field := range fields {
+		for _, address := range field.list {
+			if err := validateAddress(field.name, address, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Bool returns a pointer to v, for use with optional message settings
+// such as TrackOpens and TrackClicks
+func Bool(v bool) *bool {
+	return &v
+}
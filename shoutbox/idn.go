@@ -0,0 +1,162 @@
+package shoutbox
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DomainToASCII converts an internationalized domain name to its ASCII
+// form, e.g. "bücher.example" to "xn--bcher-kva.example". ASCII domains
+// are returned lowercased.
+func DomainToASCII(domain string) (string, error) {
+	labels := strings.Split(strings.ToLower(domain), ".")
+	for i, label := range labels {
+		if label == "" && i == len(labels)-1 && i > 0 {
+			// Trailing dot of a fully qualified name
+			continue
+		}
+		if label == "" {
+			return "", fmt.Errorf("empty label in domain %q", domain)
+		}
+		if !isASCII(label) {
+			encoded, err := punycodeEncode(label)
+			if err != nil {
+				return "", fmt.Errorf("error encoding domain %q: %w", domain, err)
+			}
+			label = "xn--" + encoded
+			labels[i] = label
+		}
+		if len(label) > 63 {
+			return "", fmt.Errorf("label too long in domain %q", domain)
+		}
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// AddressToASCII converts the domain of email to ASCII with DomainToASCII.
+// The local part is kept as is; if it isn't ASCII the address can only be
+// delivered over SMTPUTF8.
+func AddressToASCII(email string) (string, error) {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return "", &AddressError{Address: email, Reason: "missing @"}
+	}
+	domain, err := DomainToASCII(email[at+1:])
+	if err != nil {
+		return "", &AddressError{Address: email, Reason: err.Error()}
+	}
+	return email[:at+1] + domain, nil
+}
+
+// asciiAddress is AddressToASCII for addresses that were already
+// validated, returning email unchanged if it can't be converted
+func asciiAddress(email string) string {
+	if isASCII(email) {
+		return email
+	}
+	if converted, err := AddressToASCII(email); err == nil {
+		return converted
+	}
+	return email
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Punycode parameters from RFC 3492
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+// punycodeEncode encodes label as RFC 3492 Punycode, without the "xn--"
+// prefix
+func punycodeEncode(label string) (string, error) {
+	if !utf8.ValidString(label) {
+		return "", errors.New("invalid UTF-8")
+	}
+	runes := []rune(label)
+
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(punyInitialN), 0, punyInitialBias
+	for handled < len(runes) {
+		m := rune(utf8.MaxRune + 1)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := min(max(k-bias, punyTMin), punyTMax)
+				if q < t {
+					break
+				}
+				out = append(out, punycodeDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punycodeDigit(q))
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out), nil
+}
+
+func punycodeAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > ((punyBase-punyTMin)*punyTMax)/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
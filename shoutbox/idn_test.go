@@ -0,0 +1,52 @@
+package shoutbox
+
+import "testing"
+
+func TestDomainToASCII(t *testing.T) {
+	tests := []struct {
+		domain  string
+		want    string
+		wantErr bool
+	}{
+		{domain: "Example.COM", want: "example.com"},
+		{domain: "bücher.example", want: "xn--bcher-kva.example"},
+		{domain: "München.de", want: "xn--mnchen-3ya.de"},
+		{domain: "日本.jp", want: "xn--wgv71a.jp"},
+		{domain: "例え.テスト", want: "xn--r8jz45g.xn--zckzah"},
+		{domain: "example.com.", want: "example.com."},
+		{domain: "example..com", wantErr: true},
+		{domain: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			got, err := DomainToASCII(tt.domain)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DomainToASCII() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DomainToASCII() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddressToASCII(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "ada@example.com", want: "ada@example.com"},
+		{email: "müller@beispiel.de", want: "müller@beispiel.de"},
+		{email: "user@日本.jp", want: "user@xn--wgv71a.jp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			got, err := AddressToASCII(tt.email)
+			if err != nil || got != tt.want {
+				t.Errorf("AddressToASCII() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package shoutbox
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register GIF decoding for inline images
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ImageEncoder encodes an optimized image, e.g. as WebP
+type ImageEncoder struct {
+	ContentType string
+	Extension   string
+	Encode      func(w io.Writer, img image.Image) error
+}
+
+// ImageOptimizer downscales and re-encodes inline images at send time.
+// Images decode with any format registered with the image package, so
+// importing a WebP decoder enables WebP input.
+type ImageOptimizer struct {
+	// MaxWidth and MaxHeight bound the image dimensions; larger images are
+	// downscaled preserving the aspect ratio. Zero means no limit.
+	MaxWidth  int
+	MaxHeight int
+	// MaxBytes is the size above which images are re-encoded even if
+	// within the dimension limits. Zero means only oversized images are
+	// processed.
+	MaxBytes int
+	// JPEGQuality is used for opaque images; defaults to 80
+	JPEGQuality int
+	// Encoder, when set, replaces the default JPEG/PNG output
+	Encoder *ImageEncoder
+}
+
+// OptimizeMessage optimizes every inline image attachment of msg
+func (o *ImageOptimizer) OptimizeMessage(msg *EmailMessage) error {
+	attachments, err := o.optimizeAttachments(msg.Attachments)
+	if err != nil {
+		return err
+	}
+	msg.Attachments = attachments
+	return nil
+}
+
+func (o *ImageOptimizer) optimizeAttachments(attachments []Attachment) ([]Attachment, error) {
+	optimized := make([]Attachment, len(attachments))
+	for i, attachment := range attachments {
+		if attachment.Inline && strings.HasPrefix(attachment.ContentType, "image/") {
+			var err error
+			if attachment, err = o.Optimize(attachment); err != nil {
+				return nil, err
+			}
+		}
+		optimized[i] = attachment
+	}
+	return optimized, nil
+}
+
+// Optimize returns the attachment downscaled and re-encoded if it exceeds
+// the configured limits. The original is returned if optimizing doesn't
+// make it smaller.
+func (o *ImageOptimizer) Optimize(attachment Attachment) (Attachment, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(attachment.Content))
+	if err != nil {
+		// Formats without a registered decoder are sent unchanged
+		return attachment, nil
+	}
+	width, height := fitWithin(cfg.Width, cfg.Height, o.MaxWidth, o.MaxHeight)
+	resize := width != cfg.Width || height != cfg.Height
+	if !resize && (o.MaxBytes <= 0 || len(attachment.Content) <= o.MaxBytes) {
+		return attachment, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(attachment.Content))
+	if err != nil {
+		return Attachment{}, fmt.Errorf("error decoding image %s: %w", attachment.Filename, err)
+	}
+	if resize {
+		img = downscale(img, width, height)
+	}
+
+	encoder := o.Encoder
+	if encoder == nil {
+		encoder = o.defaultEncoder(img)
+	}
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, img); err != nil {
+		return Attachment{}, fmt.Errorf("error encoding image %s: %w", attachment.Filename, err)
+	}
+	if buf.Len() >= len(attachment.Content) {
+		return attachment, nil
+	}
+
+	attachment.Content = buf.Bytes()
+	attachment.ContentType = encoder.ContentType
+	attachment.Filename = strings.TrimSuffix(attachment.Filename, filepath.Ext(attachment.Filename)) + encoder.Extension
+	return attachment, nil
+}
+
+func (o *ImageOptimizer) defaultEncoder(img image.Image) *ImageEncoder {
+	if !isOpaque(img) {
+		return &ImageEncoder{
+			ContentType: "image/png",
+			Extension:   ".png",
+			Encode: func(w io.Writer, img image.Image) error {
+				return (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(w, img)
+			},
+		}
+	}
+	quality := o.JPEGQuality
+	if quality <= 0 {
+		quality = 80
+	}
+	return &ImageEncoder{
+		ContentType: "image/jpeg",
+		Extension:   ".jpg",
+		Encode: func(w io.Writer, img image.Image) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+	}
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// fitWithin scales width and height down to fit within maxWidth and
+// maxHeight, preserving the aspect ratio
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = min(scale, float64(maxHeight)/float64(height))
+	}
+	if scale == 1 {
+		return width, height
+	}
+	return max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+}
+
+// downscale resizes img to width x height by averaging the source pixels
+// covered by each destination pixel
+func downscale(img image.Image, width, height int) image.Image {
+	src := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := max(src.Min.Y+(y+1)*src.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := max(src.Min.X+(x+1)*src.Dx()/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,89 @@
+package shoutbox
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func testImage(t *testing.T, width, height int, alpha uint8) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: alpha})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageOptimizer_Optimize(t *testing.T) {
+	tests := []struct {
+		name       string
+		optimizer  ImageOptimizer
+		content    []byte
+		wantType   string
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "opaque photo downscaled to jpeg",
+			optimizer:  ImageOptimizer{MaxWidth: 200},
+			content:    testImage(t, 800, 400, 0xFF),
+			wantType:   "image/jpeg",
+			wantWidth:  200,
+			wantHeight: 100,
+		},
+		{
+			name:       "transparent image stays png",
+			optimizer:  ImageOptimizer{MaxHeight: 100},
+			content:    testImage(t, 400, 400, 0x80),
+			wantType:   "image/png",
+			wantWidth:  100,
+			wantHeight: 100,
+		},
+		{
+			name:       "within limits unchanged",
+			optimizer:  ImageOptimizer{MaxWidth: 1000},
+			content:    testImage(t, 300, 200, 0xFF),
+			wantType:   "image/png",
+			wantWidth:  300,
+			wantHeight: 200,
+		},
+		{
+			name:      "undecodable content unchanged",
+			optimizer: ImageOptimizer{MaxWidth: 10},
+			content:   []byte("not an image"),
+			wantType:  "image/png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			att := Attachment{Filename: "photo.png", Content: tt.content, ContentType: "image/png", Inline: true}
+			got, err := tt.optimizer.Optimize(att)
+			if err != nil {
+				t.Fatalf("Optimize() error = %v", err)
+			}
+			if got.ContentType != tt.wantType {
+				t.Errorf("ContentType = %s, want %s", got.ContentType, tt.wantType)
+			}
+			if tt.wantWidth == 0 {
+				return
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(got.Content))
+			if err != nil {
+				t.Fatalf("DecodeConfig() error = %v", err)
+			}
+			if cfg.Width != tt.wantWidth || cfg.Height != tt.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package shoutbox
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// InboundMessage is a parsed inbound email, e.g. a reply received by a
+// reply-by-email pipeline
+type InboundMessage struct {
+	From        *mail.Address
+	To          []*mail.Address
+	Subject     string
+	MessageID   string
+	InReplyTo   string
+	References  []string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	Header      mail.Header
+}
+
+var headerDecoder = &mime.WordDecoder{}
+
+// ParseInbound parses a raw RFC 5322 message
+func ParseInbound(r io.Reader) (*InboundMessage, error) {
+	m, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading message: %w", err)
+	}
+
+	msg := &InboundMessage{
+		MessageID:  strings.Trim(m.Header.Get("Message-ID"), "<> "),
+		InReplyTo:  strings.Trim(m.Header.Get("In-Reply-To"), "<> "),
+		References: parseMessageIDList(m.Header.Get("References")),
+		Header:     m.Header,
+	}
+	if subject, err := headerDecoder.DecodeHeader(m.Header.Get("Subject")); err == nil {
+		msg.Subject = subject
+	} else {
+		msg.Subject = m.Header.Get("Subject")
+	}
+	if from, err := m.Header.AddressList("From"); err == nil && len(from) > 0 {
+		msg.From = from[0]
+	}
+	if to, err := m.Header.AddressList("To"); err == nil {
+		msg.To = to
+	}
+
+	if err := msg.readPart(textproto.MIMEHeader(m.Header), m.Body); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (msg *InboundMessage) readPart(header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("error reading multipart body: %w", err)
+			}
+			if err := msg.readPart(part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("error decoding body: %w", err)
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+
+	switch {
+	case disposition != "attachment" && filename == "" && mediaType == "text/plain" && msg.Text == "":
+		msg.Text = string(content)
+	case disposition != "attachment" && filename == "" && mediaType == "text/html" && msg.HTML == "":
+		msg.HTML = string(content)
+	default:
+		if decoded, err := headerDecoder.DecodeHeader(filename); err == nil {
+			filename = decoded
+		}
+		msg.Attachments = append(msg.Attachments, Attachment{
+			Filename:    filename,
+			Content:     content,
+			ContentType: mediaType,
+			ContentID:   strings.Trim(header.Get("Content-ID"), "<> "),
+			Inline:      disposition == "inline",
+		})
+	}
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+func parseMessageIDList(value string) []string {
+	var ids []string
+	for _, field := range strings.Fields(value) {
+		if id := strings.Trim(field, "<>,"); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,125 @@
+package shoutbox
+
+import (
+	"strings"
+	"testing"
+)
+
+const testInboundMessage = "Authentication-Results: mx.shoutbox.net;\r\n" +
+	"  dkim=pass (2048-bit key) header.d=example.com header.s=sel1;\r\n" +
+	"  spf=pass (mx.shoutbox.net: domain of ada@example.com designates 192.0.2.1 as permitted sender) smtp.mailfrom=ada@example.com;\r\n" +
+	"  dmarc=pass (p=REJECT sp=REJECT dis=NONE) header.from=example.com\r\n" +
+	"Authentication-Results: spoofed.example; dmarc=pass header.from=bank.example\r\n" +
+	"ARC-Seal: i=1; a=rsa-sha256; t=1700000000; cv=none;\r\n" +
+	"  d=google.com; s=arc-20160816; b=abc\r\n" +
+	"ARC-Authentication-Results: i=1; mx.google.com; spf=softfail smtp.mailfrom=ada@example.com\r\n" +
+	"From: =?UTF-8?Q?Ada_L=C3=B6velace?= <ada@example.com>\r\n" +
+	"To: support@yourdomain.com\r\n" +
+	"Subject: =?UTF-8?Q?Re:_Caf=C3=A9?=\r\n" +
+	"Message-ID: <reply-1@example.com>\r\n" +
+	"In-Reply-To: <orig-1@yourdomain.com>\r\n" +
+	"References: <root@yourdomain.com> <orig-1@yourdomain.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=UTF-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Thanks, caf=C3=A9 works.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=UTF-8\r\n" +
+	"\r\n" +
+	"<p>Thanks</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"log.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"log.txt\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"aGVsbG8g\r\n" +
+	"d29ybGQ=\r\n" +
+	"--outer--\r\n"
+
+func TestParseInbound(t *testing.T) {
+	msg, err := ParseInbound(strings.NewReader(testInboundMessage))
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+
+	if msg.From.Name != "Ada Lövelace" || msg.From.Address != "ada@example.com" {
+		t.Errorf("From = %v", msg.From)
+	}
+	if msg.Subject != "Re: Café" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	if msg.InReplyTo != "orig-1@yourdomain.com" || len(msg.References) != 2 {
+		t.Errorf("InReplyTo = %q, References = %v", msg.InReplyTo, msg.References)
+	}
+	if strings.TrimSpace(msg.Text) != "Thanks, café works." || strings.TrimSpace(msg.HTML) != "<p>Thanks</p>" {
+		t.Errorf("Text = %q, HTML = %q", msg.Text, msg.HTML)
+	}
+	if len(msg.Attachments) != 1 || string(msg.Attachments[0].Content) != "hello world" {
+		t.Errorf("Attachments = %+v", msg.Attachments)
+	}
+}
+
+func TestInboundMessage_Verdicts(t *testing.T) {
+	msg, err := ParseInbound(strings.NewReader(testInboundMessage))
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+
+	got := msg.Verdicts("mx.shoutbox.net")
+	if got != (AuthVerdicts{SPF: AuthPass, DKIM: AuthPass, DMARC: AuthPass}) || !got.Aligned() {
+		t.Errorf("Verdicts() = %+v", got)
+	}
+	if got := msg.Verdicts("other.example"); got.Aligned() {
+		t.Errorf("Verdicts() from untrusted server = %+v", got)
+	}
+
+	results := msg.AuthResults("mx.shoutbox.net")
+	dkim, _ := results[0].Result("dkim")
+	if dkim.Properties["header.d"] != "example.com" || dkim.Properties["header.s"] != "sel1" {
+		t.Errorf("dkim properties = %v", dkim.Properties)
+	}
+
+	arc, err := msg.ARC()
+	if err != nil {
+		t.Fatalf("ARC() error = %v", err)
+	}
+	if len(arc) != 1 || arc[0].ChainValidation != "none" || arc[0].Domain != "google.com" {
+		t.Fatalf("ARC() = %+v", arc)
+	}
+	if spf, _ := arc[0].Results.Result("spf"); spf.Result != AuthSoftFail || arc[0].Results.AuthServID != "mx.google.com" {
+		t.Errorf("ARC results = %+v", arc[0].Results)
+	}
+}
+
+func TestParseAuthenticationResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "none", value: "mx.example.com 1; none", want: 0},
+		{name: "versioned method", value: "mx.example.com; dkim/1=fail reason=\"bad; signature\" header.d=example.com", want: 1},
+		{name: "empty", value: "  ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuthenticationResults(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAuthenticationResults() error = %v", err)
+			}
+			if err == nil && len(got.Results) != tt.want {
+				t.Errorf("Results = %+v", got.Results)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package shoutbox
+
+import (
+	"net/http"
+	"time"
+)
+
+// RoundTripFunc sends an API request and returns its response
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Interceptor wraps every API request, e.g. to add headers, audit
+// responses or fake the API in tests. It calls next to continue the
+// request, or returns a response of its own.
+type Interceptor func(next RoundTripFunc) RoundTripFunc
+
+// roundTrip sends req through the interceptors. The first interceptor
+// registered is the outermost. A send's Timeout overrides the HTTP
+// client's.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	httpClient := c.httpClient
+	if timeout, ok := req.Context().Value(requestTimeoutKey{}).(time.Duration); ok {
+		withTimeout := *c.httpClient
+		withTimeout.Timeout = timeout
+		httpClient = &withTimeout
+	}
+	next := RoundTripFunc(httpClient.Do)
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		next = c.interceptors[i](next)
+	}
+	return next(req)
+}
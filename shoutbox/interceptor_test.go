@@ -0,0 +1,69 @@
+package shoutbox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_WithInterceptors(t *testing.T) {
+	var gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Tenant")
+		w.Write([]byte(`{"message_id":"msg_1"}`))
+	}))
+	defer srv.Close()
+
+	var order []string
+	trace := func(name string) Interceptor {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" before")
+				resp, err := next(req)
+				order = append(order, name+" after")
+				return resp, err
+			}
+		}
+	}
+	tenant := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Tenant", "acme")
+			return next(req)
+		}
+	}
+
+	client := NewClient("test-key", WithInterceptors(trace("outer"), tenant), WithInterceptors(trace("inner")))
+	client.baseURL = srv.URL
+
+	resp, err := client.SendEmail(context.Background(), &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}})
+	if err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if resp.MessageID != "msg_1" || gotHeader != "acme" {
+		t.Errorf("MessageID = %q, X-Tenant = %q", resp.MessageID, gotHeader)
+	}
+	if got, want := strings.Join(order, ", "), "outer before, inner before, inner after, outer after"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestClient_InterceptorShortCircuit(t *testing.T) {
+	fake := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(`{"message_id":"fake"}`)),
+			}, nil
+		}
+	}
+
+	client := NewClient("test-key", WithInterceptors(fake), WithBaseURL("http://api.shoutbox.invalid"))
+	resp, err := client.SendEmail(context.Background(), &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}})
+	if err != nil || resp.MessageID != "fake" {
+		t.Errorf("SendEmail() = %+v, %v", resp, err)
+	}
+}
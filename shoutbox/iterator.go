@@ -0,0 +1,148 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrIteratorDone is returned by Iterator.Next when there are no more
+// items
+var ErrIteratorDone = errors.New("no more items in iterator")
+
+// Iterator pages through the results of a list endpoint, fetching the
+// next page when the current one is used up. It isn't safe for concurrent
+// use.
+type Iterator[T any] struct {
+	fetch  func(ctx context.Context, cursor string) ([]T, string, error)
+	items  []T
+	cursor string
+	last   bool
+}
+
+// newIterator creates an iterator starting at cursor. fetch returns the
+// page at a cursor and the cursor of the following page, empty after the
+// last page.
+func newIterator[T any](cursor string, fetch func(ctx context.Context, cursor string) ([]T, string, error)) *Iterator[T] {
+	return &Iterator[T]{fetch: fetch, cursor: cursor}
+}
+
+// Next returns the next item, or ErrIteratorDone after the last one. When
+// fetching a page fails, the error is returned and the next call fetches
+// the page again.
+func (it *Iterator[T]) Next(ctx context.Context) (T, error) {
+	for len(it.items) == 0 {
+		if it.last {
+			var zero T
+			return zero, ErrIteratorDone
+		}
+		items, next, err := it.fetch(ctx, it.cursor)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		it.items, it.cursor, it.last = items, next, next == ""
+	}
+	item := it.items[0]
+	it.items = it.items[1:]
+	return item, nil
+}
+
+// All returns the remaining items
+func (it *Iterator[T]) All(ctx context.Context) ([]T, error) {
+	var all []T
+	for {
+		item, err := it.Next(ctx)
+		if err == ErrIteratorDone {
+			return all, nil
+		}
+		if err != nil {
+			return all, err
+		}
+		all = append(all, item)
+	}
+}
+
+// Iterate returns an iterator over every message matching filter, starting
+// at filter.Cursor
+func (s *MessagesService) Iterate(filter *MessageFilter) *Iterator[Message] {
+	f := MessageFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	return newIterator(f.Cursor, func(ctx context.Context, cursor string) ([]Message, string, error) {
+		f.Cursor = cursor
+		list, err := s.List(ctx, &f)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Messages, list.NextCursor, nil
+	})
+}
+
+// IterateEvents returns an iterator over every event matching q, starting
+// at q.Cursor
+func (c *Client) IterateEvents(q *EventQuery) *Iterator[Event] {
+	query := EventQuery{}
+	if q != nil {
+		query = *q
+	}
+	return newIterator(query.Cursor, func(ctx context.Context, cursor string) ([]Event, string, error) {
+		query.Cursor = cursor
+		list, err := c.ListEvents(ctx, &query)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Events, list.NextCursor, nil
+	})
+}
+
+// Iterate returns an iterator over every contact matching q, starting at
+// q.Cursor
+func (s *ContactsService) Iterate(q *ContactQuery) *Iterator[Contact] {
+	query := ContactQuery{}
+	if q != nil {
+		query = *q
+	}
+	return newIterator(query.Cursor, func(ctx context.Context, cursor string) ([]Contact, string, error) {
+		query.Cursor = cursor
+		list, err := s.List(ctx, &query)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Contacts, list.NextCursor, nil
+	})
+}
+
+// Iterate returns an iterator over every suppression matching q, starting
+// at q.Cursor
+func (s *SuppressionsService) Iterate(q *SuppressionQuery) *Iterator[Suppression] {
+	query := SuppressionQuery{}
+	if q != nil {
+		query = *q
+	}
+	return newIterator(query.Cursor, func(ctx context.Context, cursor string) ([]Suppression, string, error) {
+		query.Cursor = cursor
+		list, err := s.List(ctx, &query)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Suppressions, list.NextCursor, nil
+	})
+}
+
+// Iterate returns an iterator over every bounce matching q, starting at
+// q.Cursor
+func (s *BouncesService) Iterate(q *BounceQuery) *Iterator[Bounce] {
+	query := BounceQuery{}
+	if q != nil {
+		query = *q
+	}
+	return newIterator(query.Cursor, func(ctx context.Context, cursor string) ([]Bounce, string, error) {
+		query.Cursor = cursor
+		list, err := s.List(ctx, &query)
+		if err != nil {
+			return nil, "", err
+		}
+		return list.Bounces, list.NextCursor, nil
+	})
+}
@@ -0,0 +1,87 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestIterator(t *testing.T) {
+	pages := map[string]struct {
+		items []int
+		next  string
+	}{
+		"":   {items: []int{1, 2}, next: "p2"},
+		"p2": {next: "p3"},
+		"p3": {items: []int{3}},
+	}
+	fail := true
+	fetch := func(ctx context.Context, cursor string) ([]int, string, error) {
+		if cursor == "p3" && fail {
+			fail = false
+			return nil, "", errors.New("temporary failure")
+		}
+		return pages[cursor].items, pages[cursor].next, nil
+	}
+
+	it := newIterator("", fetch)
+	ctx := context.Background()
+	var got []int
+	for {
+		item, err := it.Next(ctx)
+		if err == ErrIteratorDone {
+			break
+		}
+		if err != nil {
+			// The failed page is fetched again
+			continue
+		}
+		got = append(got, item)
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("Next() items = %v, want %v", got, want)
+	}
+	if _, err := it.Next(ctx); err != ErrIteratorDone {
+		t.Errorf("Next() after the end error = %v, want ErrIteratorDone", err)
+	}
+
+	all, err := newIterator("p2", fetch).All(ctx)
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	if want := []int{3}; !slices.Equal(all, want) {
+		t.Errorf("All() from cursor = %v, want %v", all, want)
+	}
+}
+
+func TestContactsService_Iterate(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if r.URL.Query().Get("cursor") == "" {
+			json.NewEncoder(w).Encode(ContactList{Contacts: []Contact{{Email: "a@example.com"}}, NextCursor: "page2"})
+			return
+		}
+		json.NewEncoder(w).Encode(ContactList{Contacts: []Contact{{Email: "b@example.com"}}})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	contacts, err := client.Contacts().Iterate(&ContactQuery{List: "newsletter"}).All(context.Background())
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	if len(contacts) != 2 || contacts[1].Email != "b@example.com" {
+		t.Errorf("All() = %+v", contacts)
+	}
+	want := []string{"/contacts?list=newsletter", "/contacts?cursor=page2&list=newsletter"}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
@@ -0,0 +1,82 @@
+package shoutbox
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Size limits of the Shoutbox API and SMTP relay
+const (
+	DefaultMaxAttachmentSize = 10 << 20
+	DefaultMaxMessageSize    = 25 << 20
+)
+
+// ErrTooLarge is matched by every *SizeLimitError using errors.Is
+var ErrTooLarge = errors.New("message too large")
+
+// SizeLimits caps the size of attachments and messages, checked before
+// sending so oversized messages fail without being uploaded. Sizes are
+// measured before encoding. Zero fields use the defaults above; negative
+// fields disable the limit.
+type SizeLimits struct {
+	MaxAttachmentSize int
+	// MaxMessageSize limits the bodies and attachments together
+	MaxMessageSize int
+}
+
+// SizeLimitError reports an attachment or message over its size limit
+type SizeLimitError struct {
+	// Attachment is the filename of the attachment, or empty when the
+	// whole message is too large
+	Attachment string
+	Size       int
+	Limit      int
+}
+
+func (e *SizeLimitError) Error() string {
+	if e.Attachment != "" {
+		return fmt.Sprintf("attachment %s is %s, over the limit of %s", e.Attachment, formatSize(e.Size), formatSize(e.Limit))
+	}
+	return fmt.Sprintf("message is %s, over the limit of %s", formatSize(e.Size), formatSize(e.Limit))
+}
+
+// Is reports whether target is ErrTooLarge
+func (e *SizeLimitError) Is(target error) bool {
+	return target == ErrTooLarge
+}
+
+// check returns a *SizeLimitError if an attachment or the message, with
+// bodies of bodySize bytes, is over the limits. Streamed attachments count
+// with their Size, if known.
+func (l SizeLimits) check(attachments []Attachment, bodySize int) error {
+	maxAttachment := limitOrDefault(l.MaxAttachmentSize, DefaultMaxAttachmentSize)
+	maxMessage := limitOrDefault(l.MaxMessageSize, DefaultMaxMessageSize)
+
+	total := bodySize
+	for _, a := range attachments {
+		size := a.size()
+		if maxAttachment > 0 && size > maxAttachment {
+			return &SizeLimitError{Attachment: a.Filename, Size: size, Limit: maxAttachment}
+		}
+		total += size
+	}
+	if maxMessage > 0 && total > maxMessage {
+		return &SizeLimitError{Size: total, Limit: maxMessage}
+	}
+	return nil
+}
+
+func limitOrDefault(limit, def int) int {
+	if limit == 0 {
+		return def
+	}
+	return limit
+}
+
+// size returns the length of the content of a
+func (a Attachment) size() int {
+	if a.Open != nil {
+		return a.Size
+	}
+	return len(a.Content)
+}
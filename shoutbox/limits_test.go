@@ -0,0 +1,71 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSizeLimits_check(t *testing.T) {
+	streamed := NewStreamingAttachment("video.mp4", func() (io.ReadCloser, error) { return nil, errors.New("not read") })
+	streamed.Size = 11 << 20
+
+	tests := []struct {
+		name           string
+		limits         SizeLimits
+		attachments    []Attachment
+		bodySize       int
+		wantAttachment string
+		wantErr        bool
+	}{
+		{name: "within defaults", attachments: []Attachment{{Filename: "a.pdf", Content: make([]byte, 1<<20)}}, bodySize: 1000},
+		{name: "attachment over default", attachments: []Attachment{{Filename: "big.pdf", Content: make([]byte, 11<<20)}}, wantAttachment: "big.pdf", wantErr: true},
+		{name: "streamed over default", attachments: []Attachment{streamed}, wantAttachment: "video.mp4", wantErr: true},
+		{name: "message over default", attachments: []Attachment{{Filename: "a", Content: make([]byte, 9<<20)}, {Filename: "b", Content: make([]byte, 9<<20)}, {Filename: "c", Content: make([]byte, 9<<20)}}, wantErr: true},
+		{name: "custom attachment limit", limits: SizeLimits{MaxAttachmentSize: 1024}, attachments: []Attachment{{Filename: "a.txt", Content: make([]byte, 2048)}}, wantAttachment: "a.txt", wantErr: true},
+		{name: "custom message limit", limits: SizeLimits{MaxMessageSize: 100}, bodySize: 101, wantErr: true},
+		{name: "disabled", limits: SizeLimits{MaxAttachmentSize: -1, MaxMessageSize: -1}, attachments: []Attachment{streamed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.check(tt.attachments, tt.bodySize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var sizeErr *SizeLimitError
+			if !errors.As(err, &sizeErr) || !errors.Is(err, ErrTooLarge) || sizeErr.Attachment != tt.wantAttachment {
+				t.Errorf("check() error = %v, want attachment %q", err, tt.wantAttachment)
+			}
+		})
+	}
+}
+
+func TestClient_SizeLimits(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithSizeLimits(SizeLimits{MaxAttachmentSize: 10}))
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{
+		To:          Recipients{"ada@example.com"},
+		HTML:        "<p>Report</p>",
+		Attachments: []Attachment{{Filename: "report.csv", Content: []byte("more than ten bytes")}},
+	}
+	if _, err := client.SendEmail(context.Background(), req); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("SendEmail() error = %v, want ErrTooLarge", err)
+	}
+	if requests != 0 {
+		t.Errorf("made %d requests", requests)
+	}
+}
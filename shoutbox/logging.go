@@ -0,0 +1,61 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+)
+
+// Logger receives events about API requests and SMTP sends: attempts at
+// slog.LevelDebug, retries at slog.LevelWarn and failures at
+// slog.LevelError. *slog.Logger implements it; use LoggerFunc to adapt
+// other loggers.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+// LoggerFunc adapts a function to a Logger
+type LoggerFunc func(ctx context.Context, level slog.Level, msg string, args ...any)
+
+// Log calls f(ctx, level, msg, args...)
+func (f LoggerFunc) Log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	f(ctx, level, msg, args...)
+}
+
+// redacted replaces credentials in logged values
+const redacted = "[REDACTED]"
+
+// eventLogger filters events below level and redacts secret from the
+// values of the rest before passing them to Logger. The zero value logs
+// nothing.
+type eventLogger struct {
+	logger Logger
+	level  slog.Level
+	secret string
+}
+
+func (l eventLogger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if l.logger == nil || level < l.level {
+		return
+	}
+	if l.secret != "" {
+		for i, arg := range args {
+			args[i] = redact(arg, l.secret)
+		}
+	}
+	l.logger.Log(ctx, level, msg, args...)
+}
+
+// redact replaces secret in string and error values
+func redact(value any, secret string) any {
+	switch v := value.(type) {
+	case string:
+		return strings.ReplaceAll(v, secret, redacted)
+	case error:
+		if strings.Contains(v.Error(), secret) {
+			return errors.New(strings.ReplaceAll(v.Error(), secret, redacted))
+		}
+	}
+	return value
+}
@@ -0,0 +1,82 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_WithLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		level slog.Level
+		want  []string
+	}{
+		{
+			name:  "default level",
+			level: slog.LevelInfo,
+			want:  []string{"shoutbox: retrying request", "shoutbox: request failed"},
+		},
+		{
+			name:  "debug",
+			level: slog.LevelDebug,
+			want:  []string{"shoutbox: sending request", "shoutbox: retrying request", "shoutbox: sending request", "shoutbox: request failed"},
+		},
+		{
+			name:  "errors only",
+			level: slog.LevelError,
+			want:  []string{"shoutbox: request failed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			logger := LoggerFunc(func(ctx context.Context, level slog.Level, msg string, args ...any) {
+				got = append(got, msg)
+			})
+			client := NewClient("test-key", WithLogger(logger), WithLogLevel(tt.level), WithRetryPolicy(RetryPolicy{
+				MaxAttempts:    2,
+				InitialBackoff: time.Millisecond,
+				RetryStatuses:  []int{http.StatusServiceUnavailable},
+			}))
+			client.baseURL = srv.URL
+
+			if _, err := client.SendEmail(context.Background(), &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}}); err == nil {
+				t.Fatal("SendEmail() error = nil")
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("logged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventLogger_redact(t *testing.T) {
+	var buf bytes.Buffer
+	log := eventLogger{logger: slog.New(slog.NewTextHandler(&buf, nil)), secret: "sk_live_123"}
+
+	log.log(context.Background(), slog.LevelError, "shoutbox: request failed",
+		"url", "https://api.shoutbox.net/send?key=sk_live_123",
+		"error", errors.New("auth sk_live_123 rejected"),
+		"attempts", 2)
+
+	out := buf.String()
+	if strings.Contains(out, "sk_live_123") {
+		t.Errorf("secret logged: %s", out)
+	}
+	if !strings.Contains(out, "key=[REDACTED]") || !strings.Contains(out, "auth [REDACTED] rejected") || !strings.Contains(out, "attempts=2") {
+		t.Errorf("logged %s", out)
+	}
+}
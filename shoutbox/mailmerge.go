@@ -0,0 +1,156 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// DefaultMailMergeEmailColumn is the column holding recipients unless
+// MailMergeConfig.EmailColumn is set
+const DefaultMailMergeEmailColumn = "email"
+
+// ErrMissingRecipient is the error of mail merge records without a
+// recipient
+var ErrMissingRecipient = errors.New("missing recipient")
+
+// MailMergeConfig configures a MailMerge
+type MailMergeConfig struct {
+	// Message is sent to every record, with its {{name}} placeholders
+	// replaced by the record's variables as by EmailMessage.Personalize
+	Message *EmailMessage
+	// Sender sends the personalized messages
+	Sender EmailSender
+	// EmailColumn is the column holding the recipient. Defaults to "email".
+	EmailColumn string
+	// Columns renames columns to template variables; other columns are
+	// available under their own name
+	Columns map[string]string
+	// OnResult, when set, is called after each record is sent or fails
+	OnResult func(MailMergeResult)
+}
+
+// MailMergeResult is the outcome of one record of a mail merge
+type MailMergeResult struct {
+	// Record is the 1-based number of the record, excluding the CSV header
+	Record   int
+	To       string
+	Response *SendResponse
+	Err      error
+}
+
+// MailMergeSummary counts the outcomes of a mail merge
+type MailMergeSummary struct {
+	Sent   int
+	Failed int
+}
+
+// MailMerge sends a personalized copy of a message to each record of a
+// CSV file or other record source
+type MailMerge struct {
+	cfg MailMergeConfig
+}
+
+// NewMailMerge creates a new MailMerge
+func NewMailMerge(cfg MailMergeConfig) *MailMerge {
+	if cfg.EmailColumn == "" {
+		cfg.EmailColumn = DefaultMailMergeEmailColumn
+	}
+	return &MailMerge{cfg: cfg}
+}
+
+// SendCSV sends to each row of the CSV in r, whose first row names the
+// columns. Failed sends are counted and reported to OnResult; an error is
+// returned only if the CSV can't be read or ctx is done.
+func (m *MailMerge) SendCSV(ctx context.Context, r io.Reader) (MailMergeSummary, error) {
+	return m.Send(ctx, CSVRecords(r))
+}
+
+// Send sends to each record of records, a map from column to value. Failed
+// sends are counted and reported to OnResult; an error is returned only if
+// records yields one or ctx is done.
+func (m *MailMerge) Send(ctx context.Context, records iter.Seq2[map[string]string, error]) (MailMergeSummary, error) {
+	var summary MailMergeSummary
+	n := 0
+	for record, err := range records {
+		if err != nil {
+			return summary, err
+		}
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		n++
+		result := m.send(ctx, n, record)
+		if result.Err != nil {
+			summary.Failed++
+		} else {
+			summary.Sent++
+		}
+		if m.cfg.OnResult != nil {
+			m.cfg.OnResult(result)
+		}
+	}
+	return summary, nil
+}
+
+func (m *MailMerge) send(ctx context.Context, n int, record map[string]string) MailMergeResult {
+	result := MailMergeResult{Record: n, To: strings.TrimSpace(record[m.cfg.EmailColumn])}
+	if result.To == "" {
+		result.Err = ErrMissingRecipient
+		return result
+	}
+	variables := make(map[string]any, len(record))
+	for column, value := range record {
+		if name, ok := m.cfg.Columns[column]; ok {
+			column = name
+		}
+		variables[column] = value
+	}
+	msg := m.cfg.Message.Personalize(Personalization{To: result.To, Variables: variables})
+	result.Response, result.Err = m.cfg.Sender.Send(ctx, msg)
+	return result
+}
+
+// CSVRecords returns the rows of the CSV in r as maps from column to
+// value, taking the column names from the first row
+func CSVRecords(r io.Reader) iter.Seq2[map[string]string, error] {
+	return func(yield func(map[string]string, error) bool) {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			yield(nil, fmt.Errorf("error reading CSV header: %w", err))
+			return
+		}
+		// Spreadsheet exports may start with a byte order mark
+		for i, column := range header {
+			header[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		}
+		for {
+			row, err := reader.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("error reading CSV: %w", err))
+				return
+			}
+			record := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(row) {
+					record[column] = row[i]
+				}
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
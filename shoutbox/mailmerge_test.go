@@ -0,0 +1,68 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMailMerge_SendCSV(t *testing.T) {
+	csv := "\ufeffEmail,first_name,plan\n" +
+		"ada@example.com,Ada,Pro\n" +
+		",Nobody,Free\n" +
+		"grace@example.com,Grace,Team\n"
+
+	sender := &MockSender{}
+	var results []MailMergeResult
+	merge := NewMailMerge(MailMergeConfig{
+		Message: &EmailMessage{
+			From:    "news@example.com",
+			Subject: "Hello {{name}}",
+			HTML:    "<p>Your plan: {{plan}}</p>",
+		},
+		Sender:      sender,
+		EmailColumn: "Email",
+		Columns:     map[string]string{"first_name": "name"},
+		OnResult:    func(r MailMergeResult) { results = append(results, r) },
+	})
+
+	summary, err := merge.SendCSV(context.Background(), strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("SendCSV() error = %v", err)
+	}
+	if summary != (MailMergeSummary{Sent: 2, Failed: 1}) {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(results) != 3 || !errors.Is(results[1].Err, ErrMissingRecipient) || results[2].Record != 3 {
+		t.Errorf("results = %+v", results)
+	}
+
+	messages := sender.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("sent %d messages", len(messages))
+	}
+	if msg := messages[1]; msg.To[0] != "grace@example.com" || msg.Subject != "Hello Grace" || msg.HTML != "<p>Your plan: Team</p>" {
+		t.Errorf("second message = %+v", msg)
+	}
+}
+
+func TestMailMerge_errors(t *testing.T) {
+	sender := &MockSender{Err: errors.New("rejected")}
+	merge := NewMailMerge(MailMergeConfig{Message: &EmailMessage{Subject: "Hi"}, Sender: sender})
+
+	summary, err := merge.SendCSV(context.Background(), strings.NewReader("email\na@example.com\nb@example.com\n"))
+	if err != nil || summary.Failed != 2 {
+		t.Errorf("SendCSV() = %+v, %v", summary, err)
+	}
+
+	if _, err := merge.SendCSV(context.Background(), strings.NewReader("email\n\"unterminated\n")); err == nil {
+		t.Error("SendCSV() with malformed CSV succeeded")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := merge.SendCSV(ctx, strings.NewReader("email\na@example.com\n")); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendCSV() with canceled context error = %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package shoutbox
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// mailMessageSkipped are the headers FromMailMessage doesn't copy into
+// EmailMessage.Headers: those mapped to fields, those regenerated when the
+// message is rendered and trace fields added along its original delivery
+var mailMessageSkipped = map[string]bool{
+	"From": true, "To": true, "Cc": true, "Bcc": true, "Subject": true,
+	"Reply-To": true, "Message-Id": true, "In-Reply-To": true,
+	"References": true, headerReadReceiptTo: true, "Date": true,
+	"Mime-Version": true, "Content-Type": true,
+	"Content-Transfer-Encoding": true, "Content-Disposition": true,
+	"Received": true, "Return-Path": true, "Delivered-To": true,
+	"Dkim-Signature": true, "Authentication-Results": true,
+	"Arc-Seal": true, "Arc-Message-Signature": true,
+	"Arc-Authentication-Results": true, "Received-Spf": true,
+}
+
+// FromMailMessage converts a parsed message, e.g. from an mbox archive,
+// into an EmailMessage that can be sent again. Addresses, subject,
+// threading fields, bodies and attachments are mapped to their fields;
+// other headers are kept in Headers, except trace fields such as Received
+// and Date, which are replaced when the message is sent. m.Body is read to
+// the end.
+func FromMailMessage(m *mail.Message) (*EmailMessage, error) {
+	from, err := m.Header.AddressList("From")
+	if err == nil && len(from) == 0 {
+		err = mail.ErrHeaderNotPresent
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading From: %w", err)
+	}
+	msg := &EmailMessage{
+		From:       from[0].Address,
+		Name:       from[0].Name,
+		MessageID:  strings.Trim(m.Header.Get("Message-ID"), "<> "),
+		InReplyTo:  strings.Trim(m.Header.Get("In-Reply-To"), "<> "),
+		References: parseMessageIDList(m.Header.Get("References")),
+	}
+	for _, field := range []struct {
+		name string
+		list *[]string
+	}{{"To", &msg.To}, {"Cc", &msg.Cc}, {"Bcc", &msg.Bcc}} {
+		addresses, err := m.Header.AddressList(field.name)
+		if err == mail.ErrHeaderNotPresent {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", field.name, err)
+		}
+		for _, address := range addresses {
+			*field.list = append(*field.list, mailAddressString(address))
+		}
+	}
+	if replyTo, err := m.Header.AddressList("Reply-To"); err == nil && len(replyTo) > 0 {
+		msg.ReplyTo = replyTo[0].Address
+	}
+	if readReceiptTo, err := m.Header.AddressList(headerReadReceiptTo); err == nil && len(readReceiptTo) > 0 {
+		msg.ReadReceiptTo = readReceiptTo[0].Address
+	}
+	if subject, err := headerDecoder.DecodeHeader(m.Header.Get("Subject")); err == nil {
+		msg.Subject = subject
+	} else {
+		msg.Subject = m.Header.Get("Subject")
+	}
+
+	for name, values := range m.Header {
+		if mailMessageSkipped[textproto.CanonicalMIMEHeaderKey(name)] {
+			continue
+		}
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]string)
+		}
+		msg.Headers[name] = values[0]
+	}
+
+	body := &InboundMessage{}
+	if err := body.readPart(textproto.MIMEHeader(m.Header), m.Body); err != nil {
+		return nil, err
+	}
+	msg.HTML, msg.Text, msg.Attachments = body.HTML, body.Text, body.Attachments
+	return msg, nil
+}
+
+// MailMessage returns msg as a *mail.Message, as rendered by Bytes
+func (msg *EmailMessage) MailMessage() (*mail.Message, error) {
+	data, err := msg.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return mail.ReadMessage(bytes.NewReader(data))
+}
+
+// mailAddressString formats address for a recipient field, with its
+// display name if it has one
+func mailAddressString(address *mail.Address) string {
+	if address.Name == "" {
+		return address.Address
+	}
+	return address.String()
+}
@@ -0,0 +1,88 @@
+package shoutbox
+
+import (
+	"net/mail"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFromMailMessage(t *testing.T) {
+	m, err := mail.ReadMessage(strings.NewReader("X-Campaign: spring\r\nReply-To: help@example.com\r\nCc: Bob <bob@example.com>\r\n" + testInboundMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := FromMailMessage(m)
+	if err != nil {
+		t.Fatalf("FromMailMessage() error = %v", err)
+	}
+
+	if msg.From != "ada@example.com" || msg.Name != "Ada Lövelace" || msg.ReplyTo != "help@example.com" {
+		t.Errorf("From = %q, Name = %q, ReplyTo = %q", msg.From, msg.Name, msg.ReplyTo)
+	}
+	if !slices.Equal(msg.To, []string{"support@yourdomain.com"}) || !slices.Equal(msg.Cc, []string{`"Bob" <bob@example.com>`}) {
+		t.Errorf("To = %q, Cc = %q", msg.To, msg.Cc)
+	}
+	if msg.Subject != "Re: Café" || msg.MessageID != "reply-1@example.com" || msg.InReplyTo != "orig-1@yourdomain.com" || len(msg.References) != 2 {
+		t.Errorf("Subject = %q, MessageID = %q, InReplyTo = %q, References = %q", msg.Subject, msg.MessageID, msg.InReplyTo, msg.References)
+	}
+	if msg.Text != "Thanks, café works." || msg.HTML != "<p>Thanks</p>" {
+		t.Errorf("Text = %q, HTML = %q", msg.Text, msg.HTML)
+	}
+	if len(msg.Attachments) != 1 || string(msg.Attachments[0].Content) != "hello world" {
+		t.Errorf("Attachments = %+v", msg.Attachments)
+	}
+	// Trace and authentication fields are dropped
+	if len(msg.Headers) != 1 || msg.Headers["X-Campaign"] != "spring" {
+		t.Errorf("Headers = %v", msg.Headers)
+	}
+	if _, err := msg.Bytes(); err != nil {
+		t.Errorf("Bytes() error = %v", err)
+	}
+}
+
+func TestFromMailMessage_missingFrom(t *testing.T) {
+	m, err := mail.ReadMessage(strings.NewReader("To: ada@example.com\r\nSubject: Hi\r\n\r\nHello\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromMailMessage(m); err == nil {
+		t.Error("FromMailMessage() without From: expected error")
+	}
+}
+
+func TestEmailMessage_MailMessage(t *testing.T) {
+	original := &EmailMessage{
+		From:        "sender@example.com",
+		Name:        "Shop",
+		To:          []string{"ada@example.com"},
+		Subject:     "Your receipt",
+		HTML:        "<p>Thanks</p>",
+		Text:        "Thanks",
+		MessageID:   "receipt-1@example.com",
+		Headers:     map[string]string{"X-Campaign": "spring"},
+		Attachments: []Attachment{{Filename: "receipt.pdf", Content: []byte("%PDF"), ContentType: "application/pdf"}},
+	}
+	m, err := original.MailMessage()
+	if err != nil {
+		t.Fatalf("MailMessage() error = %v", err)
+	}
+	if got := m.Header.Get("Subject"); got != "Your receipt" {
+		t.Errorf("Subject = %q", got)
+	}
+
+	// Converting back yields the same message
+	msg, err := FromMailMessage(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.From != original.From || msg.Name != original.Name || msg.Subject != original.Subject || msg.MessageID != original.MessageID {
+		t.Errorf("round trip = %+v", msg)
+	}
+	if msg.HTML != original.HTML || msg.Text != original.Text || msg.Headers["X-Campaign"] != "spring" {
+		t.Errorf("HTML = %q, Text = %q, Headers = %v", msg.HTML, msg.Text, msg.Headers)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Filename != "receipt.pdf" || string(msg.Attachments[0].Content) != "%PDF" {
+		t.Errorf("Attachments = %+v", msg.Attachments)
+	}
+}
@@ -0,0 +1,259 @@
+package shoutbox
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Inline styles of Markdown elements that email clients render
+// inconsistently without them
+const (
+	markdownCodeStyle       = "font-family:Menlo,Consolas,monospace;font-size:13px;background-color:#f4f4f4;padding:2px 4px;border-radius:3px"
+	markdownPreStyle        = "font-family:Menlo,Consolas,monospace;font-size:13px;line-height:18px;background-color:#f4f4f4;padding:12px;border-radius:4px;white-space:pre-wrap"
+	markdownBlockquoteStyle = "margin:0 0 16px;padding:0 0 0 12px;border-left:4px solid #dddddd;color:#555555"
+	markdownRuleStyle       = "border:0;border-top:1px solid #dddddd;margin:24px 0"
+)
+
+var (
+	markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownRulePattern    = regexp.MustCompile(`^([-*_])(\s*[-*_]){2,}$`)
+	markdownBulletPattern  = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	markdownNumberPattern  = regexp.MustCompile(`^(\d{1,9})[.)]\s+(.*)$`)
+	markdownImagePattern   = regexp.MustCompile(`!\[([^\]]*)\]\(((?:[^()\s]|\([^()\s]*\))+)(?:\s+&#34;([^)]*)&#34;)?\)`)
+	markdownLinkPattern    = regexp.MustCompile(`\[([^\]]+)\]\(((?:[^()\s]|\([^()\s]*\))+)(?:\s+&#34;([^)]*)&#34;)?\)`)
+	markdownAutoLink       = regexp.MustCompile(`&lt;((?:https?|mailto):[^\s&]+)&gt;`)
+	markdownStrongPattern  = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	markdownEmPattern      = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*|\b_(\S(?:.*?\S)?)_\b`)
+	markdownStrikePattern  = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	markdownSafeURLPattern = regexp.MustCompile(`(?i)^(https?:|mailto:|tel:|cid:|[^:]*$)`)
+)
+
+// RenderMarkdown converts Markdown to email-safe HTML and a plain-text
+// fallback. Headings, paragraphs, emphasis, links, images, lists, block
+// quotes, code and rules are supported; raw HTML is escaped and links with
+// schemes other than http, https, mailto, tel and cid are dropped.
+func RenderMarkdown(md string) (htmlBody, textBody string) {
+	htmlBody = MarkdownToHTML(md)
+	return htmlBody, htmlToText(htmlBody)
+}
+
+// SetMarkdown sets the HTML and plain-text bodies of msg from md, as
+// rendered by RenderMarkdown
+func (msg *EmailMessage) SetMarkdown(md string) {
+	msg.HTML, msg.Text = RenderMarkdown(md)
+}
+
+// SetMarkdown sets the HTML and plain-text bodies of req from md, as
+// rendered by RenderMarkdown
+func (req *EmailRequest) SetMarkdown(md string) {
+	req.HTML, req.Text = RenderMarkdown(md)
+}
+
+// MarkdownToHTML converts Markdown to email-safe HTML. See RenderMarkdown.
+func MarkdownToHTML(md string) string {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	writeMarkdownBlocks(&b, lines)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeMarkdownBlocks(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence := trimmed[:3]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			i++
+			fmt.Fprintf(b, "<pre style=\"%s\"><code>%s</code></pre>\n", markdownPreStyle, html.EscapeString(strings.Join(code, "\n")))
+
+		case markdownHeadingPattern.MatchString(trimmed):
+			m := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			fmt.Fprintf(b, "<h%d>%s</h%d>\n", len(m[1]), markdownInline(m[2]), len(m[1]))
+			i++
+
+		case markdownRulePattern.MatchString(trimmed):
+			fmt.Fprintf(b, "<hr style=\"%s\">\n", markdownRuleStyle)
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				line := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(line, " "))
+			}
+			fmt.Fprintf(b, "<blockquote style=\"%s\">\n", markdownBlockquoteStyle)
+			writeMarkdownBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case markdownListItem(trimmed) != "":
+			i = writeMarkdownList(b, lines, i)
+
+		default:
+			var paragraph []string
+			for ; i < len(lines) && !markdownBlockStart(lines[i]); i++ {
+				paragraph = append(paragraph, lines[i])
+			}
+			fmt.Fprintf(b, "<p>%s</p>\n", markdownInline(strings.Join(paragraph, "\n")))
+		}
+	}
+}
+
+// writeMarkdownList writes the list starting at lines[i] and returns the
+// index of the line after it
+func writeMarkdownList(b *strings.Builder, lines []string, i int) int {
+	kind := markdownListItem(strings.TrimSpace(lines[i]))
+	if kind == "ol" {
+		start := markdownNumberPattern.FindStringSubmatch(strings.TrimSpace(lines[i]))[1]
+		if n, _ := strconv.Atoi(start); n != 1 {
+			fmt.Fprintf(b, "<ol start=\"%d\">\n", n)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	var item []string
+	flush := func() {
+		if item != nil {
+			fmt.Fprintf(b, "<li>%s</li>\n", markdownInline(strings.Join(item, "\n")))
+		}
+	}
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i])
+		switch {
+		case markdownListItem(trimmed) == kind:
+			flush()
+			if kind == "ol" {
+				item = []string{markdownNumberPattern.FindStringSubmatch(trimmed)[2]}
+			} else {
+				item = []string{markdownBulletPattern.FindStringSubmatch(trimmed)[1]}
+			}
+			i++
+			continue
+		case trimmed == "":
+			// A blank line ends the list unless another item follows
+			next := i + 1
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next < len(lines) && markdownListItem(strings.TrimSpace(lines[next])) == kind {
+				i = next
+				continue
+			}
+		case !markdownBlockStart(lines[i]):
+			// Lazy continuation of the current item
+			item = append(item, trimmed)
+			i++
+			continue
+		}
+		break
+	}
+	flush()
+	fmt.Fprintf(b, "</%s>\n", kind)
+	return i
+}
+
+// markdownListItem returns "ul" or "ol" if line starts a list item
+func markdownListItem(line string) string {
+	switch {
+	case markdownRulePattern.MatchString(line):
+		return ""
+	case markdownBulletPattern.MatchString(line):
+		return "ul"
+	case markdownNumberPattern.MatchString(line):
+		return "ol"
+	}
+	return ""
+}
+
+// markdownBlockStart reports whether line ends a paragraph
+func markdownBlockStart(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" ||
+		strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") ||
+		strings.HasPrefix(trimmed, ">") ||
+		markdownHeadingPattern.MatchString(trimmed) ||
+		markdownRulePattern.MatchString(trimmed) ||
+		markdownListItem(trimmed) != ""
+}
+
+// markdownInline converts inline Markdown: code spans, images, links,
+// emphasis and hard line breaks
+func markdownInline(s string) string {
+	var b strings.Builder
+	// Odd segments are code spans, which are escaped but not formatted
+	for i, segment := range strings.Split(s, "`") {
+		if i%2 == 1 && i < strings.Count(s, "`") {
+			fmt.Fprintf(&b, "<code style=\"%s\">%s</code>", markdownCodeStyle, html.EscapeString(segment))
+			continue
+		}
+		if i%2 == 1 {
+			b.WriteString("`")
+		}
+		b.WriteString(markdownText(html.EscapeString(segment)))
+	}
+	return b.String()
+}
+
+// markdownText formats escaped text. Links and images are replaced by
+// placeholders while emphasis is applied so their URLs are left intact.
+func markdownText(s string) string {
+	var links []string
+	placeholder := func(tag string) string {
+		links = append(links, tag)
+		return "\x00" + strconv.Itoa(len(links)-1) + "\x00"
+	}
+
+	s = markdownImagePattern.ReplaceAllStringFunc(s, func(image string) string {
+		m := markdownImagePattern.FindStringSubmatch(image)
+		if !markdownSafeURLPattern.MatchString(html.UnescapeString(m[2])) {
+			return m[1]
+		}
+		tag := fmt.Sprintf(`<img src="%s" alt="%s" style="max-width:100%%;height:auto;border:0"`, m[2], m[1])
+		if m[3] != "" {
+			tag += fmt.Sprintf(` title="%s"`, m[3])
+		}
+		return placeholder(tag + ">")
+	})
+	s = markdownLinkPattern.ReplaceAllStringFunc(s, func(link string) string {
+		m := markdownLinkPattern.FindStringSubmatch(link)
+		text := markdownEmphasis(m[1])
+		if !markdownSafeURLPattern.MatchString(html.UnescapeString(m[2])) {
+			return text
+		}
+		tag := fmt.Sprintf(`<a href="%s"`, m[2])
+		if m[3] != "" {
+			tag += fmt.Sprintf(` title="%s"`, m[3])
+		}
+		return placeholder(tag + ">" + text + "</a>")
+	})
+	s = markdownAutoLink.ReplaceAllStringFunc(s, func(link string) string {
+		url := markdownAutoLink.FindStringSubmatch(link)[1]
+		return placeholder(fmt.Sprintf(`<a href="%s">%s</a>`, url, strings.TrimPrefix(url, "mailto:")))
+	})
+
+	s = markdownEmphasis(s)
+	s = strings.ReplaceAll(s, "  \n", "<br>\n")
+	s = strings.ReplaceAll(s, "\\\n", "<br>\n")
+	for i, tag := range links {
+		s = strings.Replace(s, "\x00"+strconv.Itoa(i)+"\x00", tag, 1)
+	}
+	return s
+}
+
+func markdownEmphasis(s string) string {
+	s = markdownStrongPattern.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = markdownEmPattern.ReplaceAllString(s, "<em>$1$2</em>")
+	return markdownStrikePattern.ReplaceAllString(s, "<del>$1</del>")
+}
@@ -0,0 +1,76 @@
+package shoutbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			name: "heading and paragraph",
+			md:   "# Your order ##\n\nHi **Ada**, your *3 items*\nshipped.",
+			want: "<h1>Your order</h1>\n<p>Hi <strong>Ada</strong>, your <em>3 items</em>\nshipped.</p>",
+		},
+		{
+			name: "links",
+			md:   "[Track](https://example.com/t?a=1&b=2 \"Tracking\") or <https://example.com/help_center>",
+			want: `<p><a href="https://example.com/t?a=1&amp;b=2" title="Tracking">Track</a> or <a href="https://example.com/help_center">https://example.com/help_center</a></p>`,
+		},
+		{
+			name: "unsafe link",
+			md:   "[click](javascript:alert(1)) <b>now</b>",
+			want: "<p>click &lt;b&gt;now&lt;/b&gt;</p>",
+		},
+		{
+			name: "image",
+			md:   "![Logo](cid:logo)",
+			want: `<p><img src="cid:logo" alt="Logo" style="max-width:100%;height:auto;border:0"></p>`,
+		},
+		{
+			name: "lists",
+			md:   "- Tea\n- Cake\n  with cream\n\n3. three\n4. four",
+			want: "<ul>\n<li>Tea</li>\n<li>Cake\nwith cream</li>\n</ul>\n<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>",
+		},
+		{
+			name: "code",
+			md:   "Run `go_test <pkg>`:\n\n```\nif a < b {\n}\n```",
+			want: "<p>Run <code style=\"" + markdownCodeStyle + "\">go_test &lt;pkg&gt;</code>:</p>\n<pre style=\"" + markdownPreStyle + "\"><code>if a &lt; b {\n}</code></pre>",
+		},
+		{
+			name: "blockquote and rule",
+			md:   "> Quoted\n> text\n\n---",
+			want: "<blockquote style=\"" + markdownBlockquoteStyle + "\">\n<p>Quoted\ntext</p>\n</blockquote>\n<hr style=\"" + markdownRuleStyle + "\">",
+		},
+		{
+			name: "snake case and hard break",
+			md:   "keep my_var_name  \nnext ~~line~~",
+			want: "<p>keep my_var_name<br>\nnext <del>line</del></p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToHTML(tt.md); got != tt.want {
+				t.Errorf("MarkdownToHTML() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailMessage_SetMarkdown(t *testing.T) {
+	msg := &EmailMessage{}
+	msg.SetMarkdown("# Shipped\n\nTrack it [here](https://example.com/t).\n\n1. Open the app\n2. Tap *Orders*\n\n```\nORDER-1\n  ORDER-2\n```")
+
+	if !strings.HasPrefix(msg.HTML, "<h1>Shipped</h1>") {
+		t.Errorf("HTML = %q", msg.HTML)
+	}
+	want := "Shipped\n\nTrack it here (https://example.com/t).\n\n1. Open the app\n2. Tap Orders\n\nORDER-1\n  ORDER-2"
+	if msg.Text != want {
+		t.Errorf("Text = %q, want %q", msg.Text, want)
+	}
+}
@@ -0,0 +1,105 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Message statuses in the activity log
+const (
+	MessageQueued    = "queued"
+	MessageSent      = "sent"
+	MessageDelivered = "delivered"
+	MessageDeferred  = "deferred"
+	MessageBounced   = "bounced"
+	MessageRejected  = "rejected"
+)
+
+// Message is a sent message in the activity log
+type Message struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	From        string     `json:"from"`
+	To          Recipients `json:"to"`
+	Subject     string     `json:"subject"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	ClickedAt   *time.Time `json:"clicked_at,omitempty"`
+}
+
+// MessageFilter filters the messages returned by List
+type MessageFilter struct {
+	Since     time.Time
+	Until     time.Time
+	Recipient string
+	Tag       string
+	Status    string
+	Limit     int
+	Cursor    string
+}
+
+// MessageList is one page of messages
+type MessageList struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// MessagesService reads the sent-message activity log
+type MessagesService struct {
+	client *Client
+}
+
+// Messages returns the message activity API
+func (c *Client) Messages() *MessagesService {
+	return &MessagesService{client: c}
+}
+
+// List returns one page of messages matching filter, newest first. Pass
+// NextCursor as filter.Cursor to fetch the following page.
+func (s *MessagesService) List(ctx context.Context, filter *MessageFilter) (*MessageList, error) {
+	var list MessageList
+	if err := s.client.do(ctx, http.MethodGet, "/messages?"+filter.values().Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Get returns a single message by ID
+func (s *MessagesService) Get(ctx context.Context, id string) (*Message, error) {
+	var msg Message
+	if err := s.client.do(ctx, http.MethodGet, "/messages/"+url.PathEscape(id), nil, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (f *MessageFilter) values() url.Values {
+	v := url.Values{}
+	if f == nil {
+		return v
+	}
+	setIf := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	setIf("recipient", f.Recipient)
+	setIf("tag", f.Tag)
+	setIf("status", f.Status)
+	setIf("cursor", f.Cursor)
+	if !f.Since.IsZero() {
+		v.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		v.Set("until", f.Until.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		v.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return v
+}
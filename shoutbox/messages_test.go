@@ -0,0 +1,40 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMessagesService_List(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{
+			"messages": [{"id": "msg_1", "status": "delivered", "to": "ada@example.com", "subject": "Welcome", "tags": ["onboarding"]}],
+			"next_cursor": "c2"
+		}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	list, err := client.Messages().List(context.Background(), &MessageFilter{
+		Since:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Recipient: "ada@example.com",
+		Tag:       "onboarding",
+		Limit:     50,
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	if want := "limit=50&recipient=ada%40example.com&since=2024-03-01T00%3A00%3A00Z&tag=onboarding"; query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+	if len(list.Messages) != 1 || list.Messages[0].Status != MessageDelivered || list.Messages[0].To[0] != "ada@example.com" || list.NextCursor != "c2" {
+		t.Errorf("List() = %+v", list)
+	}
+}
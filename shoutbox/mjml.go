@@ -0,0 +1,133 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoMJMLCompiler is returned when a message has an MJML body but the
+// client has no MJMLCompiler
+var ErrNoMJMLCompiler = errors.New("no MJML compiler configured")
+
+// DefaultMJMLAPIEndpoint is the render endpoint of the MJML API
+const DefaultMJMLAPIEndpoint = "https://api.mjml.io/v1/render"
+
+// MJMLCompiler compiles MJML markup into responsive HTML
+type MJMLCompiler interface {
+	CompileMJML(ctx context.Context, mjml string) (string, error)
+}
+
+// MJMLCompilerFunc adapts a function to an MJMLCompiler
+type MJMLCompilerFunc func(ctx context.Context, mjml string) (string, error)
+
+// CompileMJML calls f(ctx, mjml)
+func (f MJMLCompilerFunc) CompileMJML(ctx context.Context, mjml string) (string, error) {
+	return f(ctx, mjml)
+}
+
+// MJMLCommand compiles MJML with the mjml command-line tool, installed with
+// "npm install -g mjml". An empty path runs mjml from PATH.
+func MJMLCommand(path string) MJMLCompiler {
+	if path == "" {
+		path = "mjml"
+	}
+	return MJMLCompilerFunc(func(ctx context.Context, mjml string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, path, "--stdin", "--stdout")
+		cmd.Stdin = strings.NewReader(mjml)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("%w: %s", err, msg)
+			}
+			return "", err
+		}
+		return stdout.String(), nil
+	})
+}
+
+// MJMLAPI compiles MJML with the MJML HTTP API
+type MJMLAPI struct {
+	AppID     string
+	SecretKey string
+	// Endpoint defaults to DefaultMJMLAPIEndpoint
+	Endpoint string
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+var _ MJMLCompiler = (*MJMLAPI)(nil)
+
+// CompileMJML renders mjml with the API. Markup the API reports errors for
+// is rejected.
+func (a *MJMLAPI) CompileMJML(ctx context.Context, mjml string) (string, error) {
+	body, err := json.Marshal(map[string]string{"mjml": mjml})
+	if err != nil {
+		return "", err
+	}
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultMJMLAPIEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.SetBasicAuth(a.AppID, a.SecretKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		HTML    string `json:"html"`
+		Message string `json:"message"`
+		Errors  []struct {
+			Line    int    `json:"line"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("error decoding response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("MJML API responded %s: %s", resp.Status, result.Message)
+	}
+	if len(result.Errors) > 0 {
+		return "", fmt.Errorf("line %d: %s", result.Errors[0].Line, result.Errors[0].Message)
+	}
+	return result.HTML, nil
+}
+
+// compileMJML sets the HTML body from mjml, and the plain-text body if it
+// is empty. Nothing is done if mjml is empty.
+func compileMJML(ctx context.Context, compiler MJMLCompiler, mjml string, htmlBody, textBody *string) error {
+	if mjml == "" {
+		return nil
+	}
+	if compiler == nil {
+		return ErrNoMJMLCompiler
+	}
+	compiled, err := compiler.CompileMJML(ctx, mjml)
+	if err != nil {
+		return fmt.Errorf("error compiling MJML: %w", err)
+	}
+	*htmlBody = compiled
+	if *textBody == "" {
+		*textBody = htmlToText(compiled)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMJML = `<mjml><mj-body><mj-section><mj-column><mj-text>Hello Ada</mj-text></mj-column></mj-section></mj-body></mjml>`
+
+func TestClient_SendEmailMJML(t *testing.T) {
+	var got EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	compiler := MJMLCompilerFunc(func(ctx context.Context, mjml string) (string, error) {
+		if mjml != testMJML {
+			t.Errorf("compiled %q", mjml)
+		}
+		return "<html><head><style>td{padding:0}</style></head><body><div><p>Hello Ada</p></div></body></html>", nil
+	})
+	client := NewClient("test-key", WithMJMLCompiler(compiler))
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Hi", MJML: testMJML}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if !strings.Contains(got.HTML, "<p>Hello Ada</p>") || got.Text != "Hello Ada" {
+		t.Errorf("sent HTML = %q, Text = %q", got.HTML, got.Text)
+	}
+	if req.HTML != "" {
+		t.Error("SendEmail() modified the request")
+	}
+
+	if _, err := NewClient("test-key").SendEmail(context.Background(), req); !errors.Is(err, ErrNoMJMLCompiler) {
+		t.Errorf("SendEmail() without compiler error = %v, want ErrNoMJMLCompiler", err)
+	}
+}
+
+func TestMJMLAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "app" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"invalid credentials"}`))
+			return
+		}
+		var body struct{ MJML string }
+		json.NewDecoder(r.Body).Decode(&body)
+		if strings.Contains(body.MJML, "mj-bogus") {
+			w.Write([]byte(`{"html":"","errors":[{"line":1,"message":"mj-bogus is not a valid tag"}]}`))
+			return
+		}
+		w.Write([]byte(`{"html":"<p>compiled</p>","errors":[]}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		api     *MJMLAPI
+		mjml    string
+		want    string
+		wantErr string
+	}{
+		{name: "compiled", api: &MJMLAPI{AppID: "app", SecretKey: "secret", Endpoint: srv.URL}, mjml: testMJML, want: "<p>compiled</p>"},
+		{name: "invalid markup", api: &MJMLAPI{AppID: "app", SecretKey: "secret", Endpoint: srv.URL}, mjml: "<mjml><mj-bogus/></mjml>", wantErr: "not a valid tag"},
+		{name: "unauthorized", api: &MJMLAPI{AppID: "app", Endpoint: srv.URL}, mjml: testMJML, wantErr: "invalid credentials"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.api.CompileMJML(context.Background(), tt.mjml)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CompileMJML() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("CompileMJML() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMJMLCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	// The fake compiler wraps its input, showing it was read from stdin
+	script := filepath.Join(t.TempDir(), "mjml")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"<html>$(cat)</html>\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := MJMLCommand(script).CompileMJML(context.Background(), testMJML)
+	if err != nil || strings.TrimSpace(got) != "<html>"+testMJML+"</html>" {
+		t.Errorf("CompileMJML() = %q, %v", got, err)
+	}
+
+	if _, err := MJMLCommand(filepath.Join(t.TempDir(), "missing")).CompileMJML(context.Background(), testMJML); err == nil {
+		t.Error("CompileMJML() with missing command succeeded")
+	}
+}
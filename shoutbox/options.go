@@ -0,0 +1,270 @@
+package shoutbox
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for API requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithInterceptors wraps every API request in interceptors, in order, after
+// any added earlier. Interceptors run once per attempt, so retried requests
+// pass through them again.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithProxy sends API requests through the HTTP proxy at proxyURL. Without
+// it, the proxy named by HTTPS_PROXY, if any, is used.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *Client) {
+		c.proxy = proxyURL
+	}
+}
+
+// WithTracer records a span for every API request with tracer
+func WithTracer(tracer Tracer) Option {
+	return func(c *Client) {
+		c.tracer = tracer
+	}
+}
+
+// WithLogger logs request attempts, retries and failures to logger. The
+// API key is redacted from logged values.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithLogLevel drops log events below level. The default is
+// slog.LevelInfo, which logs retries and failures.
+func WithLogLevel(level slog.Level) Option {
+	return func(c *Client) {
+		c.logLevel = level
+	}
+}
+
+// WithTimeout limits each API request, including reading the response, to
+// timeout. The HTTP client is copied, not modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		client := *c.httpClient
+		client.Timeout = timeout
+		c.httpClient = &client
+	}
+}
+
+// WithCompression gzips request bodies of threshold bytes or more, such
+// as messages with large HTML bodies or attachments. A threshold of zero
+// or less uses DefaultCompressionThreshold. Compressed responses are
+// decoded either way.
+func WithCompression(threshold int) Option {
+	return func(c *Client) {
+		if threshold <= 0 {
+			threshold = DefaultCompressionThreshold
+		}
+		c.compressThreshold = threshold
+	}
+}
+
+// WithEnvironment selects the Shoutbox environment, overriding
+// SHOUTBOX_ENV
+func WithEnvironment(env Environment) Option {
+	return func(c *Client) {
+		c.environment = env
+	}
+}
+
+// WithRegion sends requests to the API endpoint of region
+func WithRegion(region Region) Option {
+	return func(c *Client) {
+		c.region = region
+	}
+}
+
+// WithBaseURL sends requests to baseURL, e.g. a proxy, instead of the
+// endpoint of the environment and region
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithRetryPolicy sets how failed requests are retried. The default is
+// DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// WithoutRetries disables retrying failed requests
+func WithoutRetries() Option {
+	return func(c *Client) {
+		c.retry = RetryPolicy{}
+	}
+}
+
+// WithRateLimit limits the client to perSecond API requests
+func WithRateLimit(perSecond float64) Option {
+	return func(c *Client) {
+		c.rateLimiter = NewRateLimiter(perSecond, 1)
+	}
+}
+
+// WithRateLimiter shares limiter with other clients, e.g. an SMTPClient
+// sending on the same account
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// WithCircuitBreaker fails requests with ErrCircuitOpen, without retrying
+// them, while breaker is open. Share a breaker between clients on the same
+// API.
+func WithCircuitBreaker(breaker *CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// WithTrackOpens sets the default open tracking for messages that don't
+// set TrackOpens themselves
+func WithTrackOpens(enabled bool) Option {
+	return func(c *Client) {
+		c.trackOpens = &enabled
+	}
+}
+
+// WithTrackClicks sets the default click tracking for messages that don't
+// set TrackClicks themselves
+func WithTrackClicks(enabled bool) Option {
+	return func(c *Client) {
+		c.trackClicks = &enabled
+	}
+}
+
+// WithTrackingDomain sets a custom domain used for click and open tracking
+// links, e.g. "links.yourdomain.com"
+func WithTrackingDomain(domain string) Option {
+	return func(c *Client) {
+		c.trackingDomain = domain
+	}
+}
+
+// WithUTM appends the given UTM parameters to every link in the HTML body
+// of outgoing messages. An unset Campaign defaults to each message's first
+// tag.
+func WithUTM(params UTMParams) Option {
+	return func(c *Client) {
+		c.utm = &params
+	}
+}
+
+// WithDarkMode injects dark-mode meta tags and styles using opts into the
+// HTML body of outgoing messages
+func WithDarkMode(opts DarkModeOptions) Option {
+	return func(c *Client) {
+		c.darkMode = &opts
+	}
+}
+
+// WithEmojiShortcodes expands :shortcode: emoji in the subject, HTML body
+// and text body of outgoing messages
+func WithEmojiShortcodes() Option {
+	return func(c *Client) {
+		c.emojiShortcodes = true
+	}
+}
+
+// WithSeedList sends every message only to the seed list addresses
+func WithSeedList(seeds SeedList) Option {
+	return func(c *Client) {
+		c.seedList = &seeds
+	}
+}
+
+// WithSandbox validates and builds every message but doesn't send it.
+// SendEmail returns a response with a "sandbox-" message ID, so staging
+// environments can exercise the full code path without delivering mail.
+func WithSandbox() Option {
+	return func(c *Client) {
+		c.sandbox = true
+	}
+}
+
+// WithPreferenceCheck skips sending messages with a Category to recipients
+// who opted out of it, returning ErrRecipientOptedOut. The preferences of
+// each recipient are fetched before every such send.
+func WithPreferenceCheck() Option {
+	return func(c *Client) {
+		c.preferences = c.Preferences()
+	}
+}
+
+// WithAttachmentStore uploads attachments larger than threshold bytes to
+// store and replaces them with download links. A threshold of 0 uses
+// DefaultOffloadThreshold.
+func WithAttachmentStore(store AttachmentStore, threshold int) Option {
+	return func(c *Client) {
+		c.attachmentStore = store
+		c.offloadThreshold = threshold
+	}
+}
+
+// WithChunkedUploads uploads attachments larger than threshold bytes in
+// chunks before sending, instead of inlining them in the send request.
+// Streamed attachments are uploaded without being read into memory if
+// their Size is set. Sandboxed clients don't upload.
+func WithChunkedUploads(threshold int) Option {
+	return func(c *Client) {
+		c.uploadThreshold = threshold
+	}
+}
+
+// WithSizeLimits overrides the default attachment and message size limits
+// checked before sending
+func WithSizeLimits(limits SizeLimits) Option {
+	return func(c *Client) {
+		c.sizeLimits = limits
+	}
+}
+
+// WithMJMLCompiler compiles the MJML body of messages with compiler before
+// sending
+func WithMJMLCompiler(compiler MJMLCompiler) Option {
+	return func(c *Client) {
+		c.mjmlCompiler = compiler
+	}
+}
+
+// WithSpamCheck scores every message with the API's spam filter before
+// sending it, and returns a *SpamError instead of sending messages scoring
+// threshold or more. Sandboxed clients skip the check.
+func WithSpamCheck(threshold float64) Option {
+	return func(c *Client) {
+		c.spamThreshold = &threshold
+	}
+}
+
+// WithImageOptimizer downscales and re-encodes inline images before sending
+func WithImageOptimizer(o *ImageOptimizer) Option {
+	return func(c *Client) {
+		c.imageOptimizer = o
+	}
+}
@@ -0,0 +1,63 @@
+package shoutbox
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+)
+
+// Personalization is one recipient of a personalized message and the
+// values of the message's {{name}} placeholders for that recipient
+type Personalization struct {
+	// To is the recipient, optionally with a display name
+	To        string         `json:"to"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// placeholderPattern matches {{name}} placeholders, capturing the name
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// substitute replaces the placeholders in s with vars; values are escaped
+// if s is HTML. Placeholders without a value are removed.
+func substitute(s string, vars map[string]any, escape bool) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		value, ok := vars[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+		if !ok || value == nil {
+			return ""
+		}
+		if escape {
+			return html.EscapeString(fmt.Sprint(value))
+		}
+		return fmt.Sprint(value)
+	})
+}
+
+// Personalize returns a copy of msg addressed to p.To alone, with the
+// placeholders in Subject, HTML, Text and MJML replaced by p.Variables.
+// Cc and Bcc recipients are dropped so they don't receive every copy.
+func (msg *EmailMessage) Personalize(p Personalization) *EmailMessage {
+	personalized := *msg
+	personalized.To = []string{p.To}
+	personalized.Cc, personalized.Bcc = nil, nil
+	personalized.Subject = substitute(msg.Subject, p.Variables, false)
+	personalized.HTML = substitute(msg.HTML, p.Variables, true)
+	personalized.Text = substitute(msg.Text, p.Variables, false)
+	personalized.MJML = substitute(msg.MJML, p.Variables, true)
+	return &personalized
+}
+
+// SendPersonalized sends a copy of msg to each recipient in personalizations,
+// personalized as by Personalize, and returns a result per recipient in
+// order. An error is returned only if ctx is done; later recipients are
+// not sent to.
+func (c *SMTPClient) SendPersonalized(ctx context.Context, msg *EmailMessage, personalizations []Personalization) ([]SendResult, error) {
+	results := newSendResults(len(personalizations))
+	for i, p := range personalizations {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results[i].set(c.Send(ctx, msg.Personalize(p)))
+	}
+	return results, nil
+}
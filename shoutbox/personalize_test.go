@@ -0,0 +1,115 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	vars := map[string]any{"name": "Ada & Co", "count": 3, "user.plan": "pro"}
+	tests := []struct {
+		name   string
+		s      string
+		escape bool
+		want   string
+	}{
+		{name: "text", s: "Hi {{name}}, you have {{ count }} items", want: "Hi Ada & Co, you have 3 items"},
+		{name: "html escaped", s: "<p>Hi {{name}}</p>", escape: true, want: "<p>Hi Ada &amp; Co</p>"},
+		{name: "dotted name", s: "Plan: {{user.plan}}", want: "Plan: pro"},
+		{name: "missing variable", s: "Hi {{first_name}}!", want: "Hi !"},
+		{name: "not a placeholder", s: "{{ two words }} {name}", want: "{{ two words }} {name}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := substitute(tt.s, vars, tt.escape); got != tt.want {
+				t.Errorf("substitute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMTPClient_SendPersonalized(t *testing.T) {
+	client := NewSMTPClient("test-key")
+	client.Sandbox = true
+	client.Preferences = optedOut{"out@example.com": true}
+
+	msg := &EmailMessage{
+		From:     "news@example.com",
+		Cc:       []string{"archive@example.com"},
+		Subject:  "Hello {{name}}",
+		HTML:     "<p>Hi {{name}}</p>",
+		Category: "newsletter",
+	}
+	personalizations := []Personalization{
+		{To: "ada@example.com", Variables: map[string]any{"name": "Ada"}},
+		{To: "out@example.com", Variables: map[string]any{"name": "Out"}},
+		{To: "grace@example.com", Variables: map[string]any{"name": "Grace"}},
+	}
+
+	results, err := client.SendPersonalized(context.Background(), msg, personalizations)
+	if err != nil {
+		t.Fatalf("SendPersonalized() error = %v", err)
+	}
+	if got := results[0].Response.Accepted; len(got) != 1 || got[0] != "ada@example.com" {
+		t.Errorf("results[0] accepted = %v", got)
+	}
+	if !errors.Is(results[1].Err, ErrRecipientOptedOut) {
+		t.Errorf("results[1] error = %v, want ErrRecipientOptedOut", results[1].Err)
+	}
+	if results[2].Err != nil {
+		t.Errorf("results[2] error = %v", results[2].Err)
+	}
+
+	personalized := msg.Personalize(personalizations[2])
+	if personalized.Subject != "Hello Grace" || personalized.HTML != "<p>Hi Grace</p>" || personalized.Cc != nil {
+		t.Errorf("Personalize() = %+v", personalized)
+	}
+	if msg.Subject != "Hello {{name}}" {
+		t.Error("Personalize() modified the message")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.SendPersonalized(ctx, msg, personalizations); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendPersonalized() with canceled context error = %v", err)
+	}
+}
+
+func TestClient_SendEmailPersonalizations(t *testing.T) {
+	var got EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	client.preferences = optedOut{"out@example.com": true}
+
+	req := &EmailRequest{
+		From:     "news@example.com",
+		Subject:  "Hello {{name}}",
+		HTML:     "<p>Hi {{name}}</p>",
+		Category: "newsletter",
+		Personalizations: []Personalization{
+			{To: "ada@example.com", Variables: map[string]any{"name": "Ada"}},
+			{To: "out@example.com", Variables: map[string]any{"name": "Out"}},
+		},
+	}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if len(got.Personalizations) != 1 || got.Personalizations[0].To != "ada@example.com" || got.Personalizations[0].Variables["name"] != "Ada" {
+		t.Errorf("sent personalizations = %+v", got.Personalizations)
+	}
+
+	req.Personalizations = []Personalization{{To: "not an address"}}
+	if _, err := client.SendEmail(context.Background(), req); err == nil {
+		t.Error("SendEmail() with an invalid personalization succeeded")
+	}
+}
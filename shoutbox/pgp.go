@@ -0,0 +1,1114 @@
+package shoutbox
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"mime/multipart"
+	"strings"
+	"time"
+)
+
+// ErrPGPUnsupportedKey is returned for OpenPGP keys that can't be used:
+// only version 4 RSA, Ed25519 and Curve25519 keys are supported
+var ErrPGPUnsupportedKey = errors.New("unsupported OpenPGP key")
+
+// ErrPGPKeyRevoked is returned for OpenPGP keys revoked by their owner
+var ErrPGPKeyRevoked = errors.New("OpenPGP key is revoked")
+
+// ErrPGPKeyExpired is returned for OpenPGP keys past their expiration
+var ErrPGPKeyExpired = errors.New("OpenPGP key is expired")
+
+// OpenPGP packet tags, RFC 4880 section 4.3
+const (
+	pgpTagPKESK        = 1
+	pgpTagSignature    = 2
+	pgpTagOnePass      = 4
+	pgpTagSecretKey    = 5
+	pgpTagPublicKey    = 6
+	pgpTagSecretSubkey = 7
+	pgpTagLiteral      = 11
+	pgpTagUserID       = 13
+	pgpTagPublicSubkey = 14
+	pgpTagSEIPD        = 18
+	pgpTagMDC          = 19
+)
+
+// Signature types, RFC 4880 section 5.2.1
+const (
+	pgpSigCertGeneric      = 0x10
+	pgpSigCertPositive     = 0x13
+	pgpSigSubkeyBinding    = 0x18
+	pgpSigDirectKey        = 0x1f
+	pgpSigKeyRevocation    = 0x20
+	pgpSigSubkeyRevocation = 0x28
+)
+
+// Signature subpacket types and key flags, RFC 4880 section 5.2.3
+const (
+	pgpSubpacketCreated           = 2
+	pgpSubpacketSigExpires        = 3
+	pgpSubpacketKeyExpires        = 9
+	pgpSubpacketIssuer            = 16
+	pgpSubpacketFlags             = 27
+	pgpSubpacketIssuerFingerprint = 33
+	pgpKeyFlagSign                = 0x02
+	pgpKeyFlagsEncrypt            = 0x0c
+)
+
+// OpenPGP algorithm identifiers, RFC 4880 section 9
+const (
+	pgpAlgoRSA        = 1
+	pgpAlgoRSAEncrypt = 2
+	pgpAlgoRSASign    = 3
+	pgpAlgoECDH       = 18
+	pgpAlgoEdDSA      = 22
+	pgpHashSHA1       = 2
+	pgpHashSHA256     = 8
+	pgpHashSHA384     = 9
+	pgpHashSHA512     = 10
+	pgpCipherAES128   = 7
+	pgpCipherAES192   = 8
+	pgpCipherAES256   = 9
+)
+
+// Curve OIDs of OpenPGP ECC keys, RFC 6637 and the GnuPG extensions
+var (
+	pgpOIDCurve25519 = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0x97, 0x55, 0x01, 0x05, 0x01}
+	pgpOIDEd25519    = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0xda, 0x47, 0x0f, 0x01}
+)
+
+// PGPKey is a recipient's OpenPGP public key, read with ReadPGPKey. Set
+// keys as EmailMessage.EncryptTo to encrypt a message with PGP/MIME.
+type PGPKey struct {
+	primary *pgpPublicKey
+	subkeys []*pgpPublicKey
+}
+
+// pgpPublicKey is a primary key or subkey of an OpenPGP key
+type pgpPublicKey struct {
+	fingerprint [20]byte
+	created     time.Time
+	algo        byte
+	flags       byte
+	// packet is the public part of the key packet, which signatures over
+	// the key hash
+	packet []byte
+
+	rsa     *rsa.PublicKey
+	ed25519 ed25519.PublicKey
+	x25519  *ecdh.PublicKey
+	// kdfHash and kdfCipher derive the key wrapping key of ECDH keys
+	kdfHash   byte
+	kdfCipher byte
+}
+
+// ReadPGPKey reads an OpenPGP public key, ASCII-armored or binary, as
+// exported by gpg --export. Messages are encrypted to its first subkey
+// that can encrypt, or to the primary key if none can. Only subkeys bound
+// to the primary key by a valid signature are used; revoked or expired
+// keys are rejected with ErrPGPKeyRevoked or ErrPGPKeyExpired.
+func ReadPGPKey(r io.Reader) (*PGPKey, error) {
+	packets, err := readPGPPackets(r)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	var primary *pgpPublicKey
+	var primaryRevoked bool
+	var selfSig *pgpSignature
+	var subkeys []*pgpPublicKey
+	bindings := make(map[*pgpPublicKey]*pgpSignature)
+	revoked := make(map[*pgpPublicKey]bool)
+	// The user ID or subkey that following signatures are over
+	var userID []byte
+	var subkey *pgpPublicKey
+read:
+	for _, packet := range packets {
+		switch packet.tag {
+		case pgpTagPublicKey:
+			if primary != nil {
+				// Only the first key of a keyring is read
+				break read
+			}
+			if primary, _, err = parsePGPPublicKey(packet.body); err != nil {
+				return nil, err
+			}
+		case pgpTagUserID:
+			userID, subkey = packet.body, nil
+		case pgpTagPublicSubkey:
+			public, _, err := parsePGPPublicKey(packet.body)
+			if err != nil {
+				return nil, err
+			}
+			subkeys = append(subkeys, public)
+			userID, subkey = nil, public
+		case pgpTagSignature:
+			if primary == nil {
+				continue
+			}
+			sig, err := parsePGPSignature(packet.body)
+			if err != nil || !sig.validAt(now) {
+				continue
+			}
+			switch {
+			case sig.sigType == pgpSigKeyRevocation && userID == nil && subkey == nil:
+				primaryRevoked = primaryRevoked || sig.verify(primary)
+			case sig.sigType == pgpSigDirectKey && userID == nil && subkey == nil,
+				sig.sigType >= pgpSigCertGeneric && sig.sigType <= pgpSigCertPositive && userID != nil:
+				var targets [][]byte
+				if userID != nil {
+					targets = append(targets, pgpUserIDHashPrefix(userID))
+				}
+				if sig.newerThan(selfSig) && sig.verify(primary, targets...) {
+					selfSig = sig
+				}
+			case sig.sigType == pgpSigSubkeyBinding && subkey != nil:
+				if sig.newerThan(bindings[subkey]) && sig.verify(primary, subkey.hashPrefix()) {
+					bindings[subkey] = sig
+				}
+			case sig.sigType == pgpSigSubkeyRevocation && subkey != nil:
+				revoked[subkey] = revoked[subkey] || sig.verify(primary, subkey.hashPrefix())
+			}
+		}
+	}
+	if primary == nil {
+		return nil, errors.New("no OpenPGP public key found")
+	}
+	key := &PGPKey{primary: primary}
+	if primaryRevoked {
+		return nil, fmt.Errorf("%w: %s", ErrPGPKeyRevoked, key.Fingerprint())
+	}
+	if selfSig != nil {
+		if selfSig.keyExpired(primary, now) {
+			return nil, fmt.Errorf("%w: %s", ErrPGPKeyExpired, key.Fingerprint())
+		}
+		primary.flags = selfSig.flags
+	}
+
+	// unusable records why the last unusable subkey was skipped
+	var unusable error
+	for _, public := range subkeys {
+		binding := bindings[public]
+		switch {
+		case binding == nil:
+			continue
+		case revoked[public]:
+			unusable = fmt.Errorf("%w: subkey of %s", ErrPGPKeyRevoked, key.Fingerprint())
+		case binding.keyExpired(public, now):
+			unusable = fmt.Errorf("%w: subkey of %s", ErrPGPKeyExpired, key.Fingerprint())
+		default:
+			public.flags = binding.flags
+			key.subkeys = append(key.subkeys, public)
+		}
+	}
+	if key.encryptionKey() == nil {
+		if unusable != nil {
+			return nil, unusable
+		}
+		return nil, fmt.Errorf("%w: key %s can't encrypt", ErrPGPUnsupportedKey, key.Fingerprint())
+	}
+	return key, nil
+}
+
+// Fingerprint returns the fingerprint of the primary key in hexadecimal
+func (k *PGPKey) Fingerprint() string {
+	return strings.ToUpper(hex.EncodeToString(k.primary.fingerprint[:]))
+}
+
+// encryptionKey returns the key that messages are encrypted to
+func (k *PGPKey) encryptionKey() *pgpPublicKey {
+	for _, key := range k.subkeys {
+		if key.canEncrypt() {
+			return key
+		}
+	}
+	if k.primary.canEncrypt() {
+		return k.primary
+	}
+	return nil
+}
+
+func (k *pgpPublicKey) keyID() []byte {
+	return k.fingerprint[12:]
+}
+
+func (k *pgpPublicKey) canEncrypt() bool {
+	if k.flags != 0 && k.flags&pgpKeyFlagsEncrypt == 0 {
+		return false
+	}
+	return (k.algo == pgpAlgoRSA || k.algo == pgpAlgoRSAEncrypt) && k.rsa != nil ||
+		k.algo == pgpAlgoECDH && k.x25519 != nil
+}
+
+func (k *pgpPublicKey) canSign() bool {
+	if k.flags != 0 && k.flags&pgpKeyFlagSign == 0 {
+		return false
+	}
+	return (k.algo == pgpAlgoRSA || k.algo == pgpAlgoRSASign) && k.rsa != nil ||
+		k.algo == pgpAlgoEdDSA && k.ed25519 != nil
+}
+
+// parsePGPPublicKey parses the public part of a key packet, returning the
+// key and the length of the public part
+func parsePGPPublicKey(body []byte) (*pgpPublicKey, int, error) {
+	if len(body) < 6 || body[0] != 4 {
+		return nil, 0, fmt.Errorf("%w: only version 4 keys are supported", ErrPGPUnsupportedKey)
+	}
+	key := &pgpPublicKey{algo: body[5]}
+	r := &pgpReader{data: body[6:]}
+	switch key.algo {
+	case pgpAlgoRSA, pgpAlgoRSAEncrypt, pgpAlgoRSASign:
+		n, e := r.mpi(), r.mpi()
+		if r.err == nil {
+			key.rsa = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		}
+	case pgpAlgoEdDSA:
+		oid, point := r.oid(), r.mpi()
+		if r.err == nil && bytes.Equal(oid, pgpOIDEd25519) && len(point) == 33 && point[0] == 0x40 {
+			key.ed25519 = ed25519.PublicKey(point[1:])
+		}
+	case pgpAlgoECDH:
+		oid, point, kdf := r.oid(), r.mpi(), r.bytes(4)
+		if r.err == nil && bytes.Equal(oid, pgpOIDCurve25519) && len(point) == 33 && point[0] == 0x40 && kdf[0] == 3 {
+			key.x25519, _ = ecdh.X25519().NewPublicKey(point[1:])
+			key.kdfHash, key.kdfCipher = kdf[2], kdf[3]
+		}
+	default:
+		// The key is kept for its fingerprint but can't be used
+		r.data = nil
+	}
+	if r.err != nil {
+		return nil, 0, fmt.Errorf("error parsing OpenPGP key: %w", r.err)
+	}
+
+	n := len(body) - len(r.data)
+	key.packet = body[:n]
+	key.created = time.Unix(int64(binary.BigEndian.Uint32(body[1:5])), 0)
+	h := sha1.New()
+	h.Write(key.hashPrefix())
+	copy(key.fingerprint[:], h.Sum(nil))
+	return key, n, nil
+}
+
+// hashPrefix returns the key as hashed by fingerprints and signatures
+// over it
+func (k *pgpPublicKey) hashPrefix() []byte {
+	n := len(k.packet)
+	return append([]byte{0x99, byte(n >> 8), byte(n)}, k.packet...)
+}
+
+// pgpUserIDHashPrefix returns a user ID as hashed by certifications
+func pgpUserIDHashPrefix(userID []byte) []byte {
+	return append(binary.BigEndian.AppendUint32([]byte{0xb4}, uint32(len(userID))), userID...)
+}
+
+// pgpSignature is a version 4 signature over a key, RFC 4880 section 5.2.3
+type pgpSignature struct {
+	sigType  byte
+	algo     byte
+	hashAlgo byte
+	// hashed is the part of the packet covered by the signature
+	hashed []byte
+	left16 []byte
+	values [][]byte
+
+	created time.Time
+	// expires and keyExpires are the lifetimes of the signature and of
+	// the key it is over, zero if unlimited
+	expires    time.Duration
+	keyExpires time.Duration
+	flags      byte
+}
+
+// parsePGPSignature parses a version 4 signature packet
+func parsePGPSignature(body []byte) (*pgpSignature, error) {
+	if len(body) < 6 || body[0] != 4 {
+		return nil, fmt.Errorf("%w: only version 4 signatures are supported", ErrPGPUnsupportedKey)
+	}
+	sig := &pgpSignature{sigType: body[1], algo: body[2], hashAlgo: body[3]}
+	r := &pgpReader{data: body[4:]}
+	hashedSubpackets := r.bytes(int(r.uint16()))
+	sig.hashed = body[:len(body)-len(r.data)]
+	r.bytes(int(r.uint16()))
+	sig.left16 = r.bytes(2)
+	switch sig.algo {
+	case pgpAlgoRSA, pgpAlgoRSASign:
+		sig.values = [][]byte{r.mpi()}
+	case pgpAlgoEdDSA:
+		sig.values = [][]byte{r.mpi(), r.mpi()}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("error parsing OpenPGP signature: %w", r.err)
+	}
+
+	// Only hashed subpackets are trusted
+	for sr := (&pgpReader{data: hashedSubpackets}); len(sr.data) > 0 && sr.err == nil; {
+		subpacket := sr.bytes(sr.subpacketLength())
+		if len(subpacket) == 0 {
+			continue
+		}
+		value := subpacket[1:]
+		switch subpacket[0] & 0x7f {
+		case pgpSubpacketCreated:
+			if len(value) == 4 {
+				sig.created = time.Unix(int64(binary.BigEndian.Uint32(value)), 0)
+			}
+		case pgpSubpacketSigExpires:
+			if len(value) == 4 {
+				sig.expires = time.Duration(binary.BigEndian.Uint32(value)) * time.Second
+			}
+		case pgpSubpacketKeyExpires:
+			if len(value) == 4 {
+				sig.keyExpires = time.Duration(binary.BigEndian.Uint32(value)) * time.Second
+			}
+		case pgpSubpacketFlags:
+			if len(value) >= 1 {
+				sig.flags = value[0]
+			}
+		}
+	}
+	return sig, nil
+}
+
+// validAt reports whether the signature was made and hasn't expired by now
+func (s *pgpSignature) validAt(now time.Time) bool {
+	if s.created.IsZero() || s.created.After(now) {
+		return false
+	}
+	return s.expires == 0 || now.Before(s.created.Add(s.expires))
+}
+
+// newerThan reports whether s replaces other, the newest signature so far
+func (s *pgpSignature) newerThan(other *pgpSignature) bool {
+	return other == nil || s.created.After(other.created)
+}
+
+// keyExpired reports whether key, which s is over, is past the expiration
+// s sets for it
+func (s *pgpSignature) keyExpired(key *pgpPublicKey, now time.Time) bool {
+	return s.keyExpires != 0 && !now.Before(key.created.Add(s.keyExpires))
+}
+
+// verify reports whether s is a valid signature by signer over signer's
+// key followed by targets, e.g. a user ID or subkey
+func (s *pgpSignature) verify(signer *pgpPublicKey, targets ...[]byte) bool {
+	newHash, hashID := pgpHash(s.hashAlgo), pgpCryptoHash(s.hashAlgo)
+	if newHash == nil {
+		return false
+	}
+	h := newHash()
+	h.Write(signer.hashPrefix())
+	for _, target := range targets {
+		h.Write(target)
+	}
+	h.Write(s.hashed)
+	h.Write([]byte{4, 0xff})
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(s.hashed))))
+	digest := h.Sum(nil)
+	if !bytes.Equal(digest[:2], s.left16) {
+		return false
+	}
+
+	switch {
+	case s.algo != signer.algo && !(s.algo == pgpAlgoRSA && signer.algo == pgpAlgoRSASign):
+		return false
+	case signer.rsa != nil && len(s.values) == 1:
+		// MPIs drop leading zeros, which the signature must have
+		size := (signer.rsa.N.BitLen() + 7) / 8
+		if len(s.values[0]) > size {
+			return false
+		}
+		signature := make([]byte, size)
+		copy(signature[size-len(s.values[0]):], s.values[0])
+		return rsa.VerifyPKCS1v15(signer.rsa, hashID, digest, signature) == nil
+	case signer.ed25519 != nil && len(s.values) == 2:
+		rs, sv := s.values[0], s.values[1]
+		if len(rs) > 32 || len(sv) > 32 {
+			return false
+		}
+		signature := make([]byte, ed25519.SignatureSize)
+		copy(signature[32-len(rs):32], rs)
+		copy(signature[64-len(sv):], sv)
+		return ed25519.Verify(signer.ed25519, digest, signature)
+	}
+	return false
+}
+
+// pgpKeyFlags returns the key flags of a version 4 signature, or 0 if it
+// has none
+func pgpKeyFlags(sig []byte) byte {
+	if len(sig) < 6 || sig[0] != 4 {
+		return 0
+	}
+	r := &pgpReader{data: sig[4:]}
+	hashed := r.bytes(int(r.uint16()))
+	for r := (&pgpReader{data: hashed}); len(r.data) > 0 && r.err == nil; {
+		subpacket := r.bytes(r.subpacketLength())
+		if len(subpacket) >= 2 && subpacket[0]&0x7f == pgpSubpacketFlags {
+			return subpacket[1]
+		}
+	}
+	return 0
+}
+
+// PGPSigner signs messages with an OpenPGP key. Set it as
+// SMTPClient.PGPSigner; it signs encrypted messages within the encryption
+// and other messages as multipart/signed (RFC 3156).
+type PGPSigner struct {
+	key    *pgpPublicKey
+	signer crypto.Signer
+	now    func() time.Time
+}
+
+// ReadPGPSigner reads an OpenPGP secret key, ASCII-armored or binary, as
+// exported by gpg --export-secret-keys. passphrase decrypts the key if it
+// is protected. Messages are signed with the first RSA or Ed25519 key
+// that can sign.
+func ReadPGPSigner(r io.Reader, passphrase []byte) (*PGPSigner, error) {
+	packets, err := readPGPPackets(r)
+	if err != nil {
+		return nil, err
+	}
+	type secretKey struct {
+		public *pgpPublicKey
+		body   []byte
+	}
+	var keys []secretKey
+read:
+	for _, packet := range packets {
+		switch packet.tag {
+		case pgpTagSecretKey, pgpTagSecretSubkey:
+			if packet.tag == pgpTagSecretKey && keys != nil {
+				// Only the first key of a keyring is read
+				break read
+			}
+			public, n, err := parsePGPPublicKey(packet.body)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, secretKey{public, packet.body[n:]})
+		case pgpTagSignature:
+			if last := len(keys) - 1; last >= 0 && keys[last].public.flags == 0 {
+				keys[last].public.flags = pgpKeyFlags(packet.body)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if !key.public.canSign() {
+			continue
+		}
+		signer, err := parsePGPSecretKey(key.public, key.body, passphrase)
+		if errors.Is(err, errPGPNoSecret) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &PGPSigner{key: key.public, signer: signer, now: time.Now}, nil
+	}
+	return nil, fmt.Errorf("%w: no secret key that can sign", ErrPGPUnsupportedKey)
+}
+
+// Fingerprint returns the fingerprint of the signing key in hexadecimal
+func (s *PGPSigner) Fingerprint() string {
+	return strings.ToUpper(hex.EncodeToString(s.key.fingerprint[:]))
+}
+
+// errPGPNoSecret marks secret key packets without the secret, such as
+// GnuPG stubs of keys held on a smartcard
+var errPGPNoSecret = errors.New("secret key not available")
+
+// parsePGPSecretKey decrypts the secret part of a key packet
+func parsePGPSecretKey(public *pgpPublicKey, body, passphrase []byte) (crypto.Signer, error) {
+	r := &pgpReader{data: body}
+	usage := r.byte()
+	secret := r.data
+	switch usage {
+	case 0:
+	case 254, 255:
+		cipherAlgo := r.byte()
+		key, err := pgpS2K(r, passphrase, cipherAlgo)
+		if err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting OpenPGP key: %w", err)
+		}
+		iv := r.bytes(block.BlockSize())
+		if r.err != nil {
+			return nil, fmt.Errorf("error parsing OpenPGP key: %w", r.err)
+		}
+		secret = make([]byte, len(r.data))
+		cipher.NewCFBDecrypter(block, iv).XORKeyStream(secret, r.data)
+		if usage == 254 {
+			if len(secret) < sha1.Size {
+				return nil, errors.New("error parsing OpenPGP key: truncated")
+			}
+			sum := sha1.Sum(secret[:len(secret)-sha1.Size])
+			if !bytes.Equal(sum[:], secret[len(secret)-sha1.Size:]) {
+				return nil, errors.New("error decrypting OpenPGP key: wrong passphrase")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown secret key protection %d", ErrPGPUnsupportedKey, usage)
+	}
+
+	r = &pgpReader{data: secret}
+	switch public.algo {
+	case pgpAlgoRSA, pgpAlgoRSASign:
+		d, p, q := r.mpi(), r.mpi(), r.mpi()
+		if r.err != nil {
+			return nil, fmt.Errorf("error parsing OpenPGP key: %w", r.err)
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: *public.rsa,
+			D:         new(big.Int).SetBytes(d),
+			Primes:    []*big.Int{new(big.Int).SetBytes(p), new(big.Int).SetBytes(q)},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("error parsing OpenPGP key: %w", err)
+		}
+		key.Precompute()
+		return key, nil
+	default:
+		seed := r.mpi()
+		if r.err != nil || len(seed) > ed25519.SeedSize {
+			return nil, errors.New("error parsing OpenPGP key: invalid Ed25519 secret")
+		}
+		key := ed25519.NewKeyFromSeed(append(make([]byte, ed25519.SeedSize-len(seed)), seed...))
+		if !key.Public().(ed25519.PublicKey).Equal(public.ed25519) {
+			return nil, errors.New("error parsing OpenPGP key: secret doesn't match the public key")
+		}
+		return key, nil
+	}
+}
+
+// pgpS2K derives the key that protects a secret key from passphrase,
+// reading the string-to-key specifier from r (RFC 4880 section 3.7)
+func pgpS2K(r *pgpReader, passphrase []byte, cipherAlgo byte) ([]byte, error) {
+	keySize := pgpCipherKeySize(cipherAlgo)
+	if keySize == 0 {
+		return nil, fmt.Errorf("%w: unsupported key cipher %d", ErrPGPUnsupportedKey, cipherAlgo)
+	}
+	mode := r.byte()
+	if mode == 101 {
+		return nil, errPGPNoSecret
+	}
+	newHash := pgpHash(r.byte())
+	var salt []byte
+	count := 0
+	switch mode {
+	case 0:
+	case 1:
+		salt = r.bytes(8)
+	case 3:
+		salt = r.bytes(8)
+		c := int(r.byte())
+		count = (16 + c&15) << (c>>4 + 6)
+	default:
+		return nil, fmt.Errorf("%w: unsupported S2K mode %d", ErrPGPUnsupportedKey, mode)
+	}
+	if r.err != nil || newHash == nil {
+		return nil, fmt.Errorf("%w: unsupported S2K", ErrPGPUnsupportedKey)
+	}
+
+	input := append(append([]byte{}, salt...), passphrase...)
+	count = max(count, len(input))
+	var key []byte
+	for preload := 0; len(key) < keySize; preload++ {
+		h := newHash()
+		h.Write(make([]byte, preload))
+		for written := 0; written < count; written += len(input) {
+			h.Write(input[:min(len(input), count-written)])
+		}
+		key = h.Sum(key)
+	}
+	return key[:keySize], nil
+}
+
+func pgpHash(id byte) func() hash.Hash {
+	switch id {
+	case pgpHashSHA1:
+		return sha1.New
+	case pgpHashSHA256:
+		return sha256.New
+	case pgpHashSHA384:
+		return sha512.New384
+	case pgpHashSHA512:
+		return sha512.New
+	}
+	return nil
+}
+
+func pgpCryptoHash(id byte) crypto.Hash {
+	switch id {
+	case pgpHashSHA1:
+		return crypto.SHA1
+	case pgpHashSHA256:
+		return crypto.SHA256
+	case pgpHashSHA384:
+		return crypto.SHA384
+	case pgpHashSHA512:
+		return crypto.SHA512
+	}
+	return 0
+}
+
+func pgpCipherKeySize(id byte) int {
+	switch id {
+	case pgpCipherAES128:
+		return 16
+	case pgpCipherAES192:
+		return 24
+	case pgpCipherAES256:
+		return 32
+	}
+	return 0
+}
+
+// pgpWrapper encrypts or signs message bodies with PGP/MIME (RFC 3156)
+type pgpWrapper struct {
+	signer     *PGPSigner
+	recipients []*PGPKey
+}
+
+// wrap returns the Content-Type and body of a multipart/encrypted message
+// holding entity, or a multipart/signed one if there are no recipients
+func (w pgpWrapper) wrap(entity []byte) (string, []byte, error) {
+	var body bytes.Buffer
+	boundary := multipart.NewWriter(nil).Boundary()
+
+	if len(w.recipients) == 0 {
+		signature, err := w.signer.signature(0x00, entity)
+		if err != nil {
+			return "", nil, err
+		}
+		fmt.Fprintf(&body, "This is an OpenPGP/MIME signed message (RFC 4880 and 3156)\r\n\r\n--%s\r\n", boundary)
+		body.Write(entity)
+		fmt.Fprintf(&body, "\r\n--%s\r\n", boundary)
+		body.WriteString("Content-Type: application/pgp-signature; name=\"signature.asc\"\r\n")
+		body.WriteString("Content-Description: OpenPGP digital signature\r\n")
+		body.WriteString("Content-Disposition: attachment; filename=\"signature.asc\"\r\n\r\n")
+		pgpArmor(&body, "PGP SIGNATURE", pgpPacket(pgpTagSignature, signature))
+		fmt.Fprintf(&body, "--%s--\r\n", boundary)
+		contentType := fmt.Sprintf(`multipart/signed; protocol="application/pgp-signature"; micalg=pgp-sha256; boundary=%q`, boundary)
+		return contentType, body.Bytes(), nil
+	}
+
+	encrypted, err := pgpEncrypt(w.recipients, w.signer, entity)
+	if err != nil {
+		return "", nil, err
+	}
+	fmt.Fprintf(&body, "This is an OpenPGP/MIME encrypted message (RFC 4880 and 3156)\r\n\r\n--%s\r\n", boundary)
+	body.WriteString("Content-Type: application/pgp-encrypted\r\n")
+	body.WriteString("Content-Description: PGP/MIME version identification\r\n\r\n")
+	body.WriteString("Version: 1\r\n")
+	fmt.Fprintf(&body, "\r\n--%s\r\n", boundary)
+	body.WriteString("Content-Type: application/octet-stream; name=\"encrypted.asc\"\r\n")
+	body.WriteString("Content-Description: OpenPGP encrypted message\r\n")
+	body.WriteString("Content-Disposition: inline; filename=\"encrypted.asc\"\r\n\r\n")
+	pgpArmor(&body, "PGP MESSAGE", encrypted)
+	fmt.Fprintf(&body, "--%s--\r\n", boundary)
+	contentType := fmt.Sprintf(`multipart/encrypted; protocol="application/pgp-encrypted"; boundary=%q`, boundary)
+	return contentType, body.Bytes(), nil
+}
+
+// pgpEncrypt returns an OpenPGP message holding data, signed by signer if
+// it isn't nil, encrypted with AES-256 to each of recipients
+func pgpEncrypt(recipients []*PGPKey, signer *PGPSigner, data []byte) ([]byte, error) {
+	now := time.Now
+	if signer != nil {
+		now = signer.now
+	}
+	// Literal data: binary, without a filename
+	literal := binary.BigEndian.AppendUint32([]byte{'b', 0}, uint32(now().Unix()))
+
+	var plaintext bytes.Buffer
+	if signer != nil {
+		signature, err := signer.signature(0x00, data)
+		if err != nil {
+			return nil, err
+		}
+		onePass := []byte{3, 0x00, pgpHashSHA256, signer.key.algo}
+		onePass = append(append(onePass, signer.key.keyID()...), 1)
+		plaintext.Write(pgpPacket(pgpTagOnePass, onePass))
+		plaintext.Write(pgpPacket(pgpTagLiteral, append(literal, data...)))
+		plaintext.Write(pgpPacket(pgpTagSignature, signature))
+	} else {
+		plaintext.Write(pgpPacket(pgpTagLiteral, append(literal, data...)))
+	}
+
+	sessionKey := make([]byte, 32)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return nil, fmt.Errorf("error generating session key: %w", err)
+	}
+	var message bytes.Buffer
+	for _, recipient := range recipients {
+		key := recipient.encryptionKey()
+		if key == nil {
+			return nil, fmt.Errorf("%w: key %s can't encrypt", ErrPGPUnsupportedKey, recipient.Fingerprint())
+		}
+		pkesk, err := key.encryptSessionKey(pgpCipherAES256, sessionKey)
+		if err != nil {
+			return nil, err
+		}
+		message.Write(pgpPacket(pgpTagPKESK, pkesk))
+	}
+
+	// Symmetrically encrypted integrity protected data: a random block whose
+	// last two bytes are repeated, the packets and a SHA-1 of it all
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting message: %w", err)
+	}
+	prefix := make([]byte, block.BlockSize()+2)
+	if _, err := rand.Read(prefix[:block.BlockSize()]); err != nil {
+		return nil, fmt.Errorf("error encrypting message: %w", err)
+	}
+	copy(prefix[block.BlockSize():], prefix[block.BlockSize()-2:block.BlockSize()])
+	content := append(prefix, plaintext.Bytes()...)
+	content = append(content, 0xc0|pgpTagMDC, sha1.Size)
+	mdc := sha1.Sum(content)
+	content = append(content, mdc[:]...)
+	seipd := make([]byte, 1+len(content))
+	seipd[0] = 1
+	cipher.NewCFBEncrypter(block, make([]byte, block.BlockSize())).XORKeyStream(seipd[1:], content)
+	message.Write(pgpPacket(pgpTagSEIPD, seipd))
+	return message.Bytes(), nil
+}
+
+// encryptSessionKey returns the body of a public-key encrypted session key
+// packet
+func (k *pgpPublicKey) encryptSessionKey(cipherAlgo byte, sessionKey []byte) ([]byte, error) {
+	checksum := 0
+	for _, b := range sessionKey {
+		checksum += int(b)
+	}
+	m := append([]byte{cipherAlgo}, sessionKey...)
+	m = append(m, byte(checksum>>8), byte(checksum))
+
+	packet := append([]byte{3}, k.keyID()...)
+	packet = append(packet, k.algo)
+	if k.rsa != nil {
+		encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, k.rsa, m)
+		if err != nil {
+			return nil, fmt.Errorf("error encrypting session key: %w", err)
+		}
+		return append(packet, pgpMPI(encrypted)...), nil
+	}
+
+	// ECDH, RFC 6637: the session key is wrapped with a key derived from
+	// an ephemeral X25519 exchange
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting session key: %w", err)
+	}
+	shared, err := ephemeral.ECDH(k.x25519)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting session key: %w", err)
+	}
+	kek, err := k.ecdhKEK(shared)
+	if err != nil {
+		return nil, err
+	}
+	padding := 8 - len(m)%8
+	m = append(m, bytes.Repeat([]byte{byte(padding)}, padding)...)
+	wrapped, err := aesKeyWrap(kek, m)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting session key: %w", err)
+	}
+	packet = append(packet, pgpMPI(append([]byte{0x40}, ephemeral.PublicKey().Bytes()...))...)
+	packet = append(packet, byte(len(wrapped)))
+	return append(packet, wrapped...), nil
+}
+
+// ecdhKEK derives the key wrapping key of an ECDH key from the shared
+// secret, RFC 6637 section 7
+func (k *pgpPublicKey) ecdhKEK(shared []byte) ([]byte, error) {
+	newHash, keySize := pgpHash(k.kdfHash), pgpCipherKeySize(k.kdfCipher)
+	if newHash == nil || keySize == 0 {
+		return nil, fmt.Errorf("%w: unsupported ECDH parameters", ErrPGPUnsupportedKey)
+	}
+	h := newHash()
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(shared)
+	h.Write([]byte{byte(len(pgpOIDCurve25519))})
+	h.Write(pgpOIDCurve25519)
+	h.Write([]byte{pgpAlgoECDH, 3, 1, k.kdfHash, k.kdfCipher})
+	h.Write([]byte("Anonymous Sender    "))
+	h.Write(k.fingerprint[:])
+	return h.Sum(nil)[:keySize], nil
+}
+
+// aesKeyWrap wraps plaintext with kek, RFC 3394
+func aesKeyWrap(kek, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out[8:], plaintext)
+	a := []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf, a)
+			copy(buf[8:], out[8*i:8*i+8])
+			block.Encrypt(buf, buf)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[8*i:], buf[8:])
+		}
+	}
+	copy(out, a)
+	return out, nil
+}
+
+// signature returns the body of a version 4 signature packet of data
+func (s *PGPSigner) signature(sigType byte, data []byte) ([]byte, error) {
+	created := binary.BigEndian.AppendUint32(nil, uint32(s.now().Unix()))
+	subpackets := append([]byte{5, pgpSubpacketCreated}, created...)
+	subpackets = append(subpackets, 22, pgpSubpacketIssuerFingerprint, 4)
+	subpackets = append(subpackets, s.key.fingerprint[:]...)
+
+	hashed := []byte{4, sigType, s.key.algo, pgpHashSHA256, byte(len(subpackets) >> 8), byte(len(subpackets))}
+	hashed = append(hashed, subpackets...)
+	h := sha256.New()
+	h.Write(data)
+	h.Write(hashed)
+	h.Write([]byte{4, 0xff})
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(hashed))))
+	digest := h.Sum(nil)
+
+	packet := append(hashed, 0, 10, 9, pgpSubpacketIssuer)
+	packet = append(packet, s.key.keyID()...)
+	packet = append(packet, digest[:2]...)
+	if s.key.algo == pgpAlgoEdDSA {
+		// EdDSA signs the digest itself; R and S are separate MPIs
+		signature, err := s.signer.Sign(rand.Reader, digest, crypto.Hash(0))
+		if err != nil {
+			return nil, fmt.Errorf("error signing message: %w", err)
+		}
+		packet = append(packet, pgpMPI(signature[:32])...)
+		return append(packet, pgpMPI(signature[32:])...), nil
+	}
+	signature, err := s.signer.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("error signing message: %w", err)
+	}
+	return append(packet, pgpMPI(signature)...), nil
+}
+
+// pgpPacket returns a packet with a new format header
+func pgpPacket(tag byte, body []byte) []byte {
+	packet := []byte{0xc0 | tag}
+	switch n := len(body); {
+	case n < 192:
+		packet = append(packet, byte(n))
+	case n < 8384:
+		packet = append(packet, byte((n-192)>>8+192), byte(n-192))
+	default:
+		packet = binary.BigEndian.AppendUint32(append(packet, 0xff), uint32(n))
+	}
+	return append(packet, body...)
+}
+
+// pgpMPI encodes b, a big-endian integer, as a multiprecision integer
+func pgpMPI(b []byte) []byte {
+	b = bytes.TrimLeft(b, "\x00")
+	bits := new(big.Int).SetBytes(b).BitLen()
+	return append([]byte{byte(bits >> 8), byte(bits)}, b...)
+}
+
+type pgpPacketData struct {
+	tag  byte
+	body []byte
+}
+
+// readPGPPackets reads the packets of an ASCII-armored or binary key
+func readPGPPackets(r io.Reader) ([]pgpPacketData, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading OpenPGP key: %w", err)
+	}
+	if bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		if data, err = pgpDearmor(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var packets []pgpPacketData
+	for len(data) > 0 {
+		header := data[0]
+		if header&0x80 == 0 {
+			return nil, errors.New("error parsing OpenPGP key: invalid packet header")
+		}
+		var tag byte
+		var n, length int
+		if header&0x40 != 0 {
+			tag = header & 0x3f
+			switch {
+			case len(data) < 2:
+				return nil, errors.New("error parsing OpenPGP key: truncated packet")
+			case data[1] < 192:
+				n, length = 2, int(data[1])
+			case data[1] < 224 && len(data) >= 3:
+				n, length = 3, (int(data[1])-192)<<8+int(data[2])+192
+			case data[1] == 255 && len(data) >= 6:
+				n, length = 6, int(binary.BigEndian.Uint32(data[2:6]))
+			default:
+				return nil, errors.New("error parsing OpenPGP key: unsupported packet length")
+			}
+		} else {
+			tag = header >> 2 & 0x0f
+			switch lengthType := header & 3; {
+			case lengthType == 0 && len(data) >= 2:
+				n, length = 2, int(data[1])
+			case lengthType == 1 && len(data) >= 3:
+				n, length = 3, int(binary.BigEndian.Uint16(data[1:3]))
+			case lengthType == 2 && len(data) >= 5:
+				n, length = 5, int(binary.BigEndian.Uint32(data[1:5]))
+			case lengthType == 3:
+				n, length = 1, len(data)-1
+			default:
+				return nil, errors.New("error parsing OpenPGP key: truncated packet")
+			}
+		}
+		if length < 0 || len(data)-n < length {
+			return nil, errors.New("error parsing OpenPGP key: truncated packet")
+		}
+		packets = append(packets, pgpPacketData{tag, data[n : n+length]})
+		data = data[n+length:]
+	}
+	return packets, nil
+}
+
+// pgpDearmor decodes the first ASCII-armored block in data
+func pgpDearmor(data []byte) ([]byte, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	start := 0
+	for start < len(lines) && !strings.HasPrefix(lines[start], "-----BEGIN PGP") {
+		start++
+	}
+	// Armor headers end at the first blank line
+	i := start + 1
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], ": ") {
+		i++
+	}
+	var encoded strings.Builder
+	var checksum string
+	for ; i < len(lines) && !strings.HasPrefix(lines[i], "-----END PGP"); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "=") && len(line) == 5 {
+			checksum = line[1:]
+			continue
+		}
+		encoded.WriteString(line)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return nil, fmt.Errorf("error decoding OpenPGP armor: %w", err)
+	}
+	if checksum != "" {
+		crc := pgpCRC24(decoded)
+		if base64.StdEncoding.EncodeToString([]byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}) != checksum {
+			return nil, errors.New("error decoding OpenPGP armor: checksum mismatch")
+		}
+	}
+	return decoded, nil
+}
+
+// pgpArmor writes data ASCII-armored as blockType, e.g. "PGP MESSAGE"
+func pgpArmor(w *bytes.Buffer, blockType string, data []byte) {
+	fmt.Fprintf(w, "-----BEGIN %s-----\r\n\r\n", blockType)
+	lines := &lineWriter{w: w}
+	encoder := base64.NewEncoder(base64.StdEncoding, lines)
+	encoder.Write(data)
+	encoder.Close()
+	lines.Close()
+	crc := pgpCRC24(data)
+	fmt.Fprintf(w, "=%s\r\n", base64.StdEncoding.EncodeToString([]byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}))
+	fmt.Fprintf(w, "-----END %s-----\r\n", blockType)
+}
+
+// pgpCRC24 returns the armor checksum of data, RFC 4880 section 6.1
+func pgpCRC24(data []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
+
+// pgpReader reads the fields of a packet body, recording the first error
+type pgpReader struct {
+	data []byte
+	err  error
+}
+
+func (r *pgpReader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || len(r.data) < n {
+		r.err = errors.New("truncated packet")
+		return make([]byte, max(n, 0))
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *pgpReader) byte() byte {
+	return r.bytes(1)[0]
+}
+
+func (r *pgpReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.bytes(2))
+}
+
+// mpi reads a multiprecision integer, returning its big-endian bytes
+func (r *pgpReader) mpi() []byte {
+	bits := int(r.uint16())
+	return r.bytes((bits + 7) / 8)
+}
+
+// oid reads a length-prefixed curve OID
+func (r *pgpReader) oid() []byte {
+	return r.bytes(int(r.byte()))
+}
+
+func (r *pgpReader) subpacketLength() int {
+	switch first := int(r.byte()); {
+	case first < 192:
+		return first
+	case first < 255:
+		return (first-192)<<8 + int(r.byte()) + 192
+	default:
+		return int(binary.BigEndian.Uint32(r.bytes(4)))
+	}
+}
@@ -0,0 +1,479 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// errAny marks test cases expecting an error of any kind
+var errAny = errors.New("any error")
+
+// testPGPKeyPacket returns the public part of a version 4 key packet
+func testPGPKeyPacket(algo byte, fields ...[]byte) []byte {
+	body := []byte{4, 0x65, 0, 0, 0, algo}
+	for _, field := range fields {
+		body = append(body, field...)
+	}
+	return body
+}
+
+// testPGPSubpacket returns a signature subpacket
+func testPGPSubpacket(typ byte, value ...byte) []byte {
+	return append([]byte{byte(len(value) + 1), typ}, value...)
+}
+
+// testPGPSignature returns a signature packet of sigType by signer, an
+// RSA or Ed25519 key, over the key packet primary followed by targets
+func testPGPSignature(t *testing.T, signer crypto.Signer, sigType byte, primary []byte, targets [][]byte, subpackets ...[]byte) []byte {
+	t.Helper()
+	algo := byte(pgpAlgoRSA)
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		algo = pgpAlgoEdDSA
+	}
+	created := binary.BigEndian.AppendUint32(nil, uint32(time.Now().Add(-time.Hour).Unix()))
+	hashedSubpackets := testPGPSubpacket(pgpSubpacketCreated, created...)
+	for _, subpacket := range subpackets {
+		hashedSubpackets = append(hashedSubpackets, subpacket...)
+	}
+	hashed := []byte{4, sigType, algo, pgpHashSHA256, 0, byte(len(hashedSubpackets))}
+	hashed = append(hashed, hashedSubpackets...)
+
+	h := sha256.New()
+	h.Write((&pgpPublicKey{packet: primary}).hashPrefix())
+	for _, target := range targets {
+		h.Write(target)
+	}
+	h.Write(hashed)
+	h.Write([]byte{4, 0xff})
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(hashed))))
+	digest := h.Sum(nil)
+
+	opts := crypto.SignerOpts(crypto.SHA256)
+	if algo == pgpAlgoEdDSA {
+		opts = crypto.Hash(0)
+	}
+	signature, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := append(hashed, 0, 0)
+	body = append(body, digest[:2]...)
+	if algo == pgpAlgoEdDSA {
+		body = append(body, pgpMPI(signature[:32])...)
+		body = append(body, pgpMPI(signature[32:])...)
+	} else {
+		body = append(body, pgpMPI(signature)...)
+	}
+	return pgpPacket(pgpTagSignature, body)
+}
+
+// testPGPCertification returns a user ID packet and its certification by
+// signer, setting flags on the primary key
+func testPGPCertification(t *testing.T, signer crypto.Signer, primary []byte, flags byte) []byte {
+	t.Helper()
+	userID := []byte("Ada <ada@example.com>")
+	sig := testPGPSignature(t, signer, pgpSigCertPositive, primary, [][]byte{pgpUserIDHashPrefix(userID)}, testPGPSubpacket(pgpSubpacketFlags, flags))
+	return append(pgpPacket(pgpTagUserID, userID), sig...)
+}
+
+// testPGPRSAKey returns an armored RSA public key and secret key
+func testPGPRSAKey(t *testing.T) (public, secret string, key *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := testPGPKeyPacket(pgpAlgoRSA, pgpMPI(key.N.Bytes()), pgpMPI(big.NewInt(int64(key.E)).Bytes()))
+	secretPart := []byte{0}
+	for _, n := range [][]byte{key.D.Bytes(), key.Primes[0].Bytes(), key.Primes[1].Bytes(), key.Precomputed.Qinv.Bytes()} {
+		secretPart = append(secretPart, pgpMPI(n)...)
+	}
+	secretPart = append(secretPart, 0, 0)
+	certification := testPGPCertification(t, key, packet, pgpKeyFlagSign|pgpKeyFlagsEncrypt)
+
+	var pub, sec bytes.Buffer
+	pgpArmor(&pub, "PGP PUBLIC KEY BLOCK", append(pgpPacket(pgpTagPublicKey, packet), certification...))
+	pgpArmor(&sec, "PGP PRIVATE KEY BLOCK", append(pgpPacket(pgpTagSecretKey, append(packet, secretPart...)), certification...))
+	return pub.String(), sec.String(), key
+}
+
+// testPGPEd25519Key is an Ed25519 primary key and a Curve25519 subkey,
+// from which test keys are assembled
+type testPGPEd25519Key struct {
+	signing    ed25519.PrivateKey
+	encryption *ecdh.PrivateKey
+	// primary and subkey are the key packet bodies
+	primary, subkey []byte
+}
+
+func newTestPGPEd25519Key(t *testing.T) *testPGPEd25519Key {
+	t.Helper()
+	_, signing, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oid := func(oid []byte) []byte { return append([]byte{byte(len(oid))}, oid...) }
+	return &testPGPEd25519Key{
+		signing:    signing,
+		encryption: encryption,
+		primary:    testPGPKeyPacket(pgpAlgoEdDSA, oid(pgpOIDEd25519), pgpMPI(append([]byte{0x40}, signing.Public().(ed25519.PublicKey)...))),
+		subkey:     testPGPKeyPacket(pgpAlgoECDH, oid(pgpOIDCurve25519), pgpMPI(append([]byte{0x40}, encryption.PublicKey().Bytes()...)), []byte{3, 1, pgpHashSHA256, pgpCipherAES128}),
+	}
+}
+
+// withSubkey returns the primary key and its certification, followed by
+// the subkey and signatures of sigTypes over it, with subpackets
+func (k *testPGPEd25519Key) withSubkey(t *testing.T, sigTypes []byte, subpackets ...[]byte) []byte {
+	t.Helper()
+	key := append(pgpPacket(pgpTagPublicKey, k.primary), testPGPCertification(t, k.signing, k.primary, pgpKeyFlagSign)...)
+	if sigTypes == nil {
+		return key
+	}
+	key = append(key, pgpPacket(pgpTagPublicSubkey, k.subkey)...)
+	subkeyPrefix := (&pgpPublicKey{packet: k.subkey}).hashPrefix()
+	for _, sigType := range sigTypes {
+		key = append(key, testPGPSignature(t, k.signing, sigType, k.primary, [][]byte{subkeyPrefix}, subpackets...)...)
+	}
+	return key
+}
+
+// testPGPCurve25519Key returns a binary Ed25519 public key with a
+// Curve25519 encryption subkey
+func testPGPCurve25519Key(t *testing.T) ([]byte, *ecdh.PrivateKey) {
+	t.Helper()
+	k := newTestPGPEd25519Key(t)
+	return k.withSubkey(t, []byte{pgpSigSubkeyBinding}, testPGPSubpacket(pgpSubpacketFlags, pgpKeyFlagsEncrypt)), k.encryption
+}
+
+func TestReadPGPKey(t *testing.T) {
+	rsaPublic, _, _ := testPGPRSAKey(t)
+	corrupted := strings.Replace(rsaPublic, "\r\n=", "\r\nAAAA\r\n=", 1)
+
+	k := newTestPGPEd25519Key(t)
+	encrypt := testPGPSubpacket(pgpSubpacketFlags, pgpKeyFlagsEncrypt)
+	// The key was created in 2023; a lifetime of a second has passed
+	expired := testPGPSubpacket(pgpSubpacketKeyExpires, 0, 0, 0, 1)
+	forged := k.withSubkey(t, []byte{pgpSigSubkeyBinding}, encrypt)
+	forged[len(forged)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		key     string
+		wantAlg byte
+		wantErr error
+	}{
+		{name: "armored rsa", key: rsaPublic, wantAlg: pgpAlgoRSA},
+		{name: "binary curve25519 subkey", key: string(k.withSubkey(t, []byte{pgpSigSubkeyBinding}, encrypt)), wantAlg: pgpAlgoECDH},
+		{name: "checksum mismatch", key: corrupted, wantErr: errAny},
+		{name: "signing only", key: string(k.withSubkey(t, nil)), wantErr: ErrPGPUnsupportedKey},
+		{name: "unbound subkey", key: string(k.withSubkey(t, []byte{})), wantErr: ErrPGPUnsupportedKey},
+		{name: "forged binding", key: string(forged), wantErr: ErrPGPUnsupportedKey},
+		{name: "revoked subkey", key: string(k.withSubkey(t, []byte{pgpSigSubkeyBinding, pgpSigSubkeyRevocation}, encrypt)), wantErr: ErrPGPKeyRevoked},
+		{name: "expired subkey", key: string(k.withSubkey(t, []byte{pgpSigSubkeyBinding}, encrypt, expired)), wantErr: ErrPGPKeyExpired},
+		{name: "revoked key", key: string(append(pgpPacket(pgpTagPublicKey, k.primary), testPGPSignature(t, k.signing, pgpSigKeyRevocation, k.primary, nil)...)), wantErr: ErrPGPKeyRevoked},
+		{name: "expired key", key: string(append(pgpPacket(pgpTagPublicKey, k.primary), testPGPSignature(t, k.signing, pgpSigDirectKey, k.primary, nil, expired)...)), wantErr: ErrPGPKeyExpired},
+		{name: "not a key", key: "hello", wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ReadPGPKey(strings.NewReader(tt.key))
+			if tt.wantErr == errAny && err == nil || tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadPGPKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := key.encryptionKey().algo; got != tt.wantAlg {
+				t.Errorf("encryption key algorithm = %d, want %d", got, tt.wantAlg)
+			}
+			if len(key.Fingerprint()) != 40 {
+				t.Errorf("Fingerprint() = %q", key.Fingerprint())
+			}
+		})
+	}
+}
+
+func TestSMTPClient_PGPEncrypt(t *testing.T) {
+	rsaPublic, rsaSecret, rsaKey := testPGPRSAKey(t)
+	curve25519, x25519Key := testPGPCurve25519Key(t)
+	rsaRecipient, err := ReadPGPKey(strings.NewReader(rsaPublic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x25519Recipient, err := ReadPGPKey(bytes.NewReader(curve25519))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ReadPGPSigner(strings.NewReader(rsaSecret), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer *PGPSigner
+	}{
+		{name: "encrypted"},
+		{name: "signed and encrypted", signer: signer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &SMTPClient{PGPSigner: tt.signer}
+			raw, err := client.buildMessage(&EmailMessage{
+				From:      "sender@example.com",
+				To:        []string{"ada@example.com", "bob@example.com"},
+				Subject:   "Encrypted",
+				HTML:      "<p>Hello</p>",
+				EncryptTo: []*PGPKey{rsaRecipient, x25519Recipient},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(raw, []byte("Hello")) {
+				t.Error("message contains the plaintext body")
+			}
+
+			msg, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			if mediaType != "multipart/encrypted" || params["protocol"] != "application/pgp-encrypted" {
+				t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+			}
+			reader := multipart.NewReader(msg.Body, params["boundary"])
+			control, err := reader.NextPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version, _ := io.ReadAll(control); control.Header.Get("Content-Type") != "application/pgp-encrypted" || string(version) != "Version: 1\r\n" {
+				t.Errorf("control part = %q: %q", control.Header.Get("Content-Type"), version)
+			}
+			part, err := reader.NextPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			packets, err := readPGPPackets(part)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(packets) != 3 || packets[0].tag != pgpTagPKESK || packets[1].tag != pgpTagPKESK || packets[2].tag != pgpTagSEIPD {
+				t.Fatalf("packets = %v", packets)
+			}
+
+			// Each recipient can decrypt the message
+			keys := [][]byte{
+				testDecryptRSASessionKey(t, packets[0].body, rsaKey),
+				testDecryptECDHSessionKey(t, packets[1].body, x25519Recipient.encryptionKey(), x25519Key),
+			}
+			if !bytes.Equal(keys[0], keys[1]) {
+				t.Fatal("recipients have different session keys")
+			}
+			inner, err := readPGPPackets(bytes.NewReader(testDecryptSEIPD(t, packets[2].body, keys[0])))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var literal []byte
+			switch {
+			case tt.signer == nil && len(inner) == 1 && inner[0].tag == pgpTagLiteral:
+				literal = inner[0].body[6:]
+			case tt.signer != nil && len(inner) == 3 && inner[0].tag == pgpTagOnePass && inner[1].tag == pgpTagLiteral && inner[2].tag == pgpTagSignature:
+				literal = inner[1].body[6:]
+				testVerifyPGPSignature(t, inner[2].body, literal, &rsaKey.PublicKey)
+			default:
+				t.Fatalf("decrypted packets = %v", inner)
+			}
+			if !bytes.HasPrefix(literal, []byte("Content-Type: multipart/mixed;")) || !bytes.Contains(literal, []byte("<p>Hello</p>")) {
+				t.Errorf("decrypted body = %q", literal)
+			}
+		})
+	}
+}
+
+func TestSMTPClient_PGPSigned(t *testing.T) {
+	_, secret, key := testPGPRSAKey(t)
+	signer, err := ReadPGPSigner(strings.NewReader(secret), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := (&SMTPClient{PGPSigner: signer}).buildMessage(&EmailMessage{
+		From:    "sender@example.com",
+		To:      []string{"recipient@example.com"},
+		Subject: "Signed",
+		HTML:    "<p>Hello</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/signed" || params["protocol"] != "application/pgp-signature" || params["micalg"] != "pgp-sha256" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+	body, _ := io.ReadAll(msg.Body)
+	start := bytes.Index(body, []byte("--"+params["boundary"]+"\r\n")) + len(params["boundary"]) + 4
+	entity := body[start : start+bytes.Index(body[start:], []byte("\r\n--"+params["boundary"]))]
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	if _, err := reader.NextPart(); err != nil {
+		t.Fatal(err)
+	}
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	packets, err := readPGPPackets(part)
+	if err != nil || len(packets) != 1 || packets[0].tag != pgpTagSignature {
+		t.Fatalf("signature packets = %v, error = %v", packets, err)
+	}
+	testVerifyPGPSignature(t, packets[0].body, entity, &key.PublicKey)
+}
+
+func TestSMTPClient_entityWrapper(t *testing.T) {
+	_, secret, _ := testPGPRSAKey(t)
+	signer, err := ReadPGPSigner(strings.NewReader(secret), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &SMTPClient{SMIMESigner: &SMIMESigner{}, PGPSigner: signer}
+	_, _, err = client.prepare(context.Background(), &EmailMessage{From: "sender@example.com", To: []string{"recipient@example.com"}})
+	if err == nil {
+		t.Error("prepare() with S/MIME and OpenPGP: expected error")
+	}
+}
+
+func TestReadPGPSigner(t *testing.T) {
+	curve25519, _ := testPGPCurve25519Key(t)
+	if _, err := ReadPGPSigner(bytes.NewReader(curve25519), nil); !errors.Is(err, ErrPGPUnsupportedKey) {
+		t.Errorf("ReadPGPSigner() of a public key error = %v, want ErrPGPUnsupportedKey", err)
+	}
+}
+
+func testDecryptRSASessionKey(t *testing.T, pkesk []byte, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	r := &pgpReader{data: pkesk[10:]}
+	m, err := rsa.DecryptPKCS1v15(nil, key, r.mpi())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSessionKey(t, m)
+}
+
+func testDecryptECDHSessionKey(t *testing.T, pkesk []byte, public *pgpPublicKey, key *ecdh.PrivateKey) []byte {
+	t.Helper()
+	r := &pgpReader{data: pkesk[10:]}
+	point := r.mpi()
+	wrapped := r.bytes(int(r.byte()))
+	ephemeral, err := ecdh.X25519().NewPublicKey(point[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := key.ECDH(ephemeral)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kek, err := public.ecdhKEK(shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 3394 key unwrap
+	block, _ := aes.NewCipher(kek)
+	n := len(wrapped)/8 - 1
+	a, m := append([]byte{}, wrapped[:8]...), append([]byte{}, wrapped[8:]...)
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			binary.BigEndian.PutUint64(buf, binary.BigEndian.Uint64(a)^uint64(n*j+i))
+			copy(buf[8:], m[8*(i-1):8*i])
+			block.Decrypt(buf, buf)
+			copy(a, buf[:8])
+			copy(m[8*(i-1):], buf[8:])
+		}
+	}
+	if !bytes.Equal(a, bytes.Repeat([]byte{0xa6}, 8)) {
+		t.Fatal("key unwrap integrity check failed")
+	}
+	return testSessionKey(t, m[:len(m)-int(m[len(m)-1])])
+}
+
+// testSessionKey checks the algorithm and checksum of a decrypted session
+// key
+func testSessionKey(t *testing.T, m []byte) []byte {
+	t.Helper()
+	key := m[1 : len(m)-2]
+	sum := 0
+	for _, b := range key {
+		sum += int(b)
+	}
+	if m[0] != pgpCipherAES256 || binary.BigEndian.Uint16(m[len(m)-2:]) != uint16(sum) {
+		t.Fatalf("invalid session key %x", m)
+	}
+	return key
+}
+
+func testDecryptSEIPD(t *testing.T, seipd, key []byte) []byte {
+	t.Helper()
+	block, _ := aes.NewCipher(key)
+	plaintext := make([]byte, len(seipd)-1)
+	cipher.NewCFBDecrypter(block, make([]byte, 16)).XORKeyStream(plaintext, seipd[1:])
+	if !bytes.Equal(plaintext[14:16], plaintext[16:18]) {
+		t.Fatal("invalid SEIPD prefix")
+	}
+	mdc := sha1.Sum(plaintext[:len(plaintext)-sha1.Size])
+	if !bytes.Equal(mdc[:], plaintext[len(plaintext)-sha1.Size:]) {
+		t.Fatal("modification detection code mismatch")
+	}
+	return plaintext[18 : len(plaintext)-sha1.Size-2]
+}
+
+func testVerifyPGPSignature(t *testing.T, sig, data []byte, key *rsa.PublicKey) {
+	t.Helper()
+	hashedLength := int(binary.BigEndian.Uint16(sig[4:6]))
+	hashed := sig[:6+hashedLength]
+	r := &pgpReader{data: sig[6+hashedLength:]}
+	r.bytes(int(r.uint16()))
+	r.bytes(2)
+	signature := make([]byte, key.Size())
+	value := r.mpi()
+	copy(signature[len(signature)-len(value):], value)
+
+	h := sha256.New()
+	h.Write(data)
+	h.Write(hashed)
+	h.Write([]byte{4, 0xff})
+	h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(hashed))))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, h.Sum(nil), signature); err != nil {
+		t.Errorf("signature verification error = %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package shoutbox
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults of NewSMTPPool
+const (
+	DefaultPoolMaxIdle     = 4
+	DefaultPoolIdleTimeout = 30 * time.Second
+)
+
+// SMTPPool keeps authenticated SMTP connections open between sends so an
+// SMTPClient doesn't dial, negotiate TLS and authenticate for every
+// message. Connections closed by the server are detected and replaced
+// transparently. A pool must only be used by one SMTPClient. It is safe
+// for concurrent use.
+type SMTPPool struct {
+	maxIdle     int
+	idleTimeout time.Duration
+
+	mu     sync.Mutex
+	idle   []pooledConn
+	closed bool
+}
+
+type pooledConn struct {
+	client   *smtpConn
+	lastUsed time.Time
+}
+
+// NewSMTPPool creates a pool keeping up to maxIdle connections open for
+// at most idleTimeout between sends. Zero values use the defaults.
+func NewSMTPPool(maxIdle int, idleTimeout time.Duration) *SMTPPool {
+	if maxIdle <= 0 {
+		maxIdle = DefaultPoolMaxIdle
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultPoolIdleTimeout
+	}
+	return &SMTPPool{maxIdle: maxIdle, idleTimeout: idleTimeout}
+}
+
+// get returns an idle connection that is still alive, or nil, with
+// deadline set on it. Connections are checked with RSET, which also clears
+// any leftover transaction.
+func (p *SMTPPool) get(deadline time.Time) *smtpConn {
+	for {
+		p.mu.Lock()
+		if len(p.idle) == 0 {
+			p.mu.Unlock()
+			return nil
+		}
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		p.mu.Unlock()
+
+		if time.Since(conn.lastUsed) > p.idleTimeout {
+			conn.client.Close()
+			continue
+		}
+		conn.client.conn.SetDeadline(deadline)
+		if err := conn.client.Reset(); err != nil {
+			conn.client.Close()
+			continue
+		}
+		return conn.client
+	}
+}
+
+// put returns a connection to the pool, or quits it if the pool is full
+// or closed
+func (p *SMTPPool) put(client *smtpConn) {
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.maxIdle {
+		client.conn.SetDeadline(time.Time{})
+		p.idle = append(p.idle, pooledConn{client: client, lastUsed: time.Now()})
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	client.Quit()
+	client.Close()
+}
+
+// Len returns the number of idle connections
+func (p *SMTPPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Close quits the idle connections. Connections in use are quit when
+// their send completes.
+func (p *SMTPPool) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.client.Quit()
+		conn.client.Close()
+	}
+	return nil
+}
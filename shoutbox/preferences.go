@@ -0,0 +1,122 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// headerCategory declares the subscription category of an SMTP message
+const headerCategory = "X-Shoutbox-Category"
+
+// ErrRecipientOptedOut is returned when every recipient of a message has
+// opted out of its category
+var ErrRecipientOptedOut = errors.New("recipient opted out of category")
+
+// Preferences are a recipient's subscription choices per category
+type Preferences struct {
+	Email string `json:"email"`
+	// Categories maps category names to whether the recipient is
+	// subscribed. Categories not listed are treated as subscribed.
+	Categories map[string]bool `json:"categories"`
+}
+
+// Allows reports whether the recipient accepts messages in category
+func (p *Preferences) Allows(category string) bool {
+	subscribed, ok := p.Categories[category]
+	return !ok || subscribed
+}
+
+// PreferenceChecker reports whether a recipient accepts messages in a
+// category
+type PreferenceChecker interface {
+	Allows(ctx context.Context, recipient, category string) (bool, error)
+}
+
+// PreferencesService manages per-recipient subscription preferences
+type PreferencesService struct {
+	client *Client
+}
+
+// Preferences returns the subscription preferences API
+func (c *Client) Preferences() *PreferencesService {
+	return &PreferencesService{client: c}
+}
+
+// Get returns the preferences of the recipient with the given email
+func (s *PreferencesService) Get(ctx context.Context, email string) (*Preferences, error) {
+	var prefs Preferences
+	if err := s.client.do(ctx, http.MethodGet, "/preferences/"+url.PathEscape(email), nil, &prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Update replaces the preferences of prefs.Email
+func (s *PreferencesService) Update(ctx context.Context, prefs *Preferences) error {
+	return s.client.do(ctx, http.MethodPut, "/preferences/"+url.PathEscape(prefs.Email), prefs, nil)
+}
+
+// Subscribe opts the recipient with the given email back into category,
+// e.g. an unsubscribe group, leaving their other choices unchanged
+func (s *PreferencesService) Subscribe(ctx context.Context, email, category string) error {
+	return s.setSubscribed(ctx, email, category, true)
+}
+
+// Unsubscribe opts the recipient with the given email out of category,
+// e.g. an unsubscribe group, leaving their other choices unchanged
+func (s *PreferencesService) Unsubscribe(ctx context.Context, email, category string) error {
+	return s.setSubscribed(ctx, email, category, false)
+}
+
+func (s *PreferencesService) setSubscribed(ctx context.Context, email, category string, subscribed bool) error {
+	path := "/preferences/" + url.PathEscape(email) + "/categories/" + url.PathEscape(category)
+	return s.client.do(ctx, http.MethodPut, path, map[string]bool{"subscribed": subscribed}, nil)
+}
+
+// Allows reports whether recipient accepts messages in category.
+// Recipients who never stored preferences are subscribed to every
+// category.
+func (s *PreferencesService) Allows(ctx context.Context, recipient, category string) (bool, error) {
+	prefs, err := s.Get(ctx, recipient)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return prefs.Allows(category), nil
+}
+
+// filterRecipients returns the recipients that accept messages in category
+func filterRecipients(ctx context.Context, checker PreferenceChecker, category string, recipients []string) ([]string, error) {
+	var allowed []string
+	for _, recipient := range recipients {
+		ok, err := checker.Allows(ctx, recipient, category)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			allowed = append(allowed, recipient)
+		}
+	}
+	return allowed, nil
+}
+
+// filterPersonalizations returns the personalizations whose recipient
+// accepts messages in category
+func filterPersonalizations(ctx context.Context, checker PreferenceChecker, category string, personalizations []Personalization) ([]Personalization, error) {
+	var allowed []Personalization
+	for _, p := range personalizations {
+		ok, err := checker.Allows(ctx, p.To, category)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			allowed = append(allowed, p)
+		}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,59 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_PreferenceCheck(t *testing.T) {
+	sent := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/send":
+			sent++
+		case r.URL.Path == "/preferences/new@example.com":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "preferences not found"})
+		case strings.HasPrefix(r.URL.Path, "/preferences/"):
+			email := strings.TrimPrefix(r.URL.Path, "/preferences/")
+			json.NewEncoder(w).Encode(Preferences{
+				Email:      email,
+				Categories: map[string]bool{"marketing": email != "out@example.com"},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithPreferenceCheck())
+	client.baseURL = srv.URL
+
+	tests := []struct {
+		name    string
+		req     *EmailRequest
+		wantErr error
+	}{
+		{name: "subscribed", req: &EmailRequest{To: Recipients{"in@example.com"}, Category: "marketing"}},
+		{name: "opted out", req: &EmailRequest{To: Recipients{"out@example.com"}, Category: "marketing"}, wantErr: ErrRecipientOptedOut},
+		{name: "no stored preferences", req: &EmailRequest{To: Recipients{"new@example.com"}, Category: "marketing"}},
+		{name: "unlisted category", req: &EmailRequest{To: Recipients{"out@example.com"}, Category: "product"}},
+		{name: "no category", req: &EmailRequest{To: Recipients{"out@example.com"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := sent
+			_, err := client.SendEmail(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SendEmail() error = %v, want %v", err, tt.wantErr)
+			}
+			if wantSent := tt.wantErr == nil; (sent > before) != wantSent {
+				t.Errorf("sent = %v, want %v", sent > before, wantSent)
+			}
+		})
+	}
+}
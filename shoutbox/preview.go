@@ -0,0 +1,80 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// PreviewRequest selects what to render: a hosted template with its
+// variables, or a message
+type PreviewRequest struct {
+	TemplateID string
+	Variables  map[string]any
+	Message    *EmailRequest
+}
+
+// Preview is the fully rendered output of a message, as recipients would
+// receive it
+type Preview struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+type previewPayload struct {
+	TemplateID string         `json:"template_id,omitempty"`
+	Variables  map[string]any `json:"variables,omitempty"`
+	Message    *sendPayload   `json:"message,omitempty"`
+}
+
+// RenderPreview renders a template or message without sending it, so admin
+// UIs can show the personalized output. Client settings such as UTM
+// parameters are applied as they would be when sending.
+func (c *Client) RenderPreview(ctx context.Context, req *PreviewRequest) (*Preview, error) {
+	if req.TemplateID == "" && req.Message == nil {
+		return nil, errors.New("preview requires a template ID or a message")
+	}
+
+	payload := &previewPayload{
+		TemplateID: req.TemplateID,
+		Variables:  req.Variables,
+	}
+	if req.Message != nil {
+		payload.Message = c.newSendPayload(req.Message)
+	}
+
+	var preview Preview
+	if err := c.do(ctx, http.MethodPost, "/render", payload, &preview); err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// Preview returns req as its recipients would receive it, so messages can
+// be checked before they are sent. Hosted templates are rendered by the
+// API, as by RenderPreview. Other messages are rendered locally: MJML is
+// compiled and client settings such as UTM parameters are applied, and if
+// req has personalizations, the placeholders are replaced by the
+// variables of the first one.
+func (c *Client) Preview(ctx context.Context, req *EmailRequest) (*Preview, error) {
+	if req.TemplateID != "" {
+		return c.RenderPreview(ctx, &PreviewRequest{TemplateID: req.TemplateID, Variables: req.Variables})
+	}
+
+	r := *req
+	if r.MJML != "" {
+		if err := compileMJML(ctx, c.mjmlCompiler, req.MJML, &r.HTML, &r.Text); err != nil {
+			return nil, err
+		}
+	}
+	payload := c.newSendPayload(&r)
+	preview := &Preview{Subject: payload.Subject, HTML: payload.HTML, Text: payload.Text}
+	if len(req.Personalizations) > 0 {
+		vars := req.Personalizations[0].Variables
+		preview.Subject = substitute(preview.Subject, vars, false)
+		preview.HTML = substitute(preview.HTML, vars, true)
+		preview.Text = substitute(preview.Text, vars, false)
+	}
+	return preview, nil
+}
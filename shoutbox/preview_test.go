@@ -0,0 +1,104 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_RenderPreview(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/render" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(Preview{Subject: "Hi Ada", HTML: "<p>Hi Ada</p>"})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	preview, err := client.RenderPreview(context.Background(), &PreviewRequest{
+		TemplateID: "welcome",
+		Variables:  map[string]any{"name": "Ada"},
+	})
+	if err != nil {
+		t.Fatalf("RenderPreview() error = %v", err)
+	}
+	if preview.Subject != "Hi Ada" || preview.HTML != "<p>Hi Ada</p>" {
+		t.Errorf("RenderPreview() = %+v", preview)
+	}
+	if got["template_id"] != "welcome" || got["variables"].(map[string]any)["name"] != "Ada" {
+		t.Errorf("request body = %v", got)
+	}
+
+	if _, err := client.RenderPreview(context.Background(), &PreviewRequest{}); err == nil {
+		t.Errorf("RenderPreview() with empty request error = nil")
+	}
+}
+
+func TestClient_Preview(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(Preview{Subject: "Welcome", HTML: "<p>Welcome Ada</p>"})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithUTM(UTMParams{Source: "shoutbox"}))
+	client.baseURL = srv.URL
+
+	tests := []struct {
+		name      string
+		req       *EmailRequest
+		want      Preview
+		wantCalls int
+	}{
+		{
+			name:      "hosted template",
+			req:       &EmailRequest{TemplateID: "welcome", Variables: map[string]any{"name": "Ada"}},
+			want:      Preview{Subject: "Welcome", HTML: "<p>Welcome Ada</p>"},
+			wantCalls: 1,
+		},
+		{
+			name: "local message",
+			req:  &EmailRequest{Subject: "Hi", HTML: `<a href="https://example.com">Shop</a>`, Text: "Shop"},
+			want: Preview{Subject: "Hi", HTML: `<a href="https://example.com?utm_source=shoutbox">Shop</a>`, Text: "Shop"},
+		},
+		{
+			name: "campaign from tags",
+			req:  &EmailRequest{Subject: "Hi", HTML: `<a href="https://example.com">Shop</a>`, Tags: []string{"welcome"}},
+			want: Preview{Subject: "Hi", HTML: `<a href="https://example.com?utm_source=shoutbox&amp;utm_campaign=welcome">Shop</a>`},
+		},
+		{
+			name: "first personalization",
+			req: &EmailRequest{
+				Subject:          "Hi {{name}}",
+				HTML:             "<p>Hi {{name}}</p>",
+				Personalizations: []Personalization{{To: "ada@example.com", Variables: map[string]any{"name": "Ada & co"}}},
+			},
+			want: Preview{Subject: "Hi Ada & co", HTML: "<p>Hi Ada &amp; co</p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			got, err := client.Preview(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("Preview() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Preview() = %+v, want %+v", *got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("API calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package shoutbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrUnsupportedProxy is returned for a proxy URL whose scheme is not
+// socks5, http or https
+var ErrUnsupportedProxy = errors.New("unsupported proxy scheme")
+
+// proxyHTTPClient returns a copy of client that sends requests through
+// proxy. Clients with a custom RoundTripper are returned unchanged.
+func proxyHTTPClient(client *http.Client, proxy *url.URL) *http.Client {
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return client
+	}
+	transport.Proxy = http.ProxyURL(proxy)
+	proxied := *client
+	proxied.Transport = transport
+	return &proxied
+}
+
+// dialProxy connects to addr through proxy, a socks5:// URL or an http://
+// or https:// URL of a proxy supporting CONNECT. The proxy is dialed with
+// dialer, whose deadline also limits the proxy handshake.
+func dialProxy(ctx context.Context, dialer *net.Dialer, proxy *url.URL, addr string) (net.Conn, error) {
+	var port string
+	switch proxy.Scheme {
+	case "socks5", "socks5h":
+		port = "1080"
+	case "http":
+		port = "80"
+	case "https":
+		port = "443"
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProxy, proxy.Scheme)
+	}
+	if proxy.Port() != "" {
+		port = proxy.Port()
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(proxy.Hostname(), port))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to proxy: %w", err)
+	}
+	conn.SetDeadline(dialer.Deadline)
+	if proxy.Scheme == "socks5" || proxy.Scheme == "socks5h" {
+		err = socks5Connect(conn, proxy.User, addr)
+	} else {
+		conn, err = httpConnect(conn, proxy.User, addr)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error connecting through proxy: %w", err)
+	}
+	return conn, nil
+}
+
+// socks5Connect asks a SOCKS5 proxy (RFC 1928) to connect conn to addr,
+// authenticating with user (RFC 1929) if set
+func socks5Connect(conn net.Conn, user *url.Userinfo, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", portStr)
+	}
+	if len(host) > 255 {
+		return errors.New("host name too long")
+	}
+
+	method := byte(0x00)
+	if user != nil {
+		method = 0x02
+	}
+	if _, err := conn.Write([]byte{0x05, 1, method}); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[0] != 0x05 || reply[1] != method {
+		return errors.New("socks5: no acceptable authentication method")
+	}
+
+	if user != nil {
+		password, _ := user.Password()
+		if len(user.Username()) > 255 || len(password) > 255 {
+			return errors.New("socks5: credentials too long")
+		}
+		req := []byte{0x01, byte(len(user.Username()))}
+		req = append(req, user.Username()...)
+		req = append(req, byte(len(password)))
+		req = append(req, password...)
+		if _, err := conn.Write(req); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return errors.New("socks5: authentication failed")
+		}
+	}
+
+	req := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip.To4() != nil {
+		req = append(req, 0x01)
+		req = append(req, ip.To4()...)
+	} else if ip != nil {
+		req = append(req, 0x04)
+		req = append(req, ip.To16()...)
+	} else {
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != 0x00 {
+		return fmt.Errorf("socks5: connect failed with code %d", header[1])
+	}
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len
+	case 0x04:
+		skip = net.IPv6len
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return err
+		}
+		skip = int(length[0])
+	default:
+		return errors.New("socks5: invalid bound address")
+	}
+	// The bound address and port aren't needed
+	_, err = io.CopyN(io.Discard, conn, int64(skip+2))
+	return err
+}
+
+// httpConnect opens a tunnel to addr with an HTTP CONNECT request. The
+// returned connection includes any bytes the proxy sent after its response.
+func httpConnect(conn net.Conn, user *url.Userinfo, addr string) (net.Conn, error) {
+	req := "CONNECT " + addr + " HTTP/1.1\r\nHost: " + addr + "\r\n"
+	if user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	if _, err := io.WriteString(conn, req+"\r\n"); err != nil {
+		return conn, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		return conn, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return conn, fmt.Errorf("proxy responded %s", resp.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads from r before the underlying connection
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -0,0 +1,175 @@
+package shoutbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestClient_WithProxy(t *testing.T) {
+	var gotHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.URL.Host
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	client := NewClient("test-key", WithProxy(proxyURL), WithBaseURL("http://api.shoutbox.invalid"))
+	if _, err := client.SendEmail(context.Background(), &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}}); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if gotHost != "api.shoutbox.invalid" {
+		t.Errorf("proxied host = %q, want api.shoutbox.invalid", gotHost)
+	}
+}
+
+func TestDialProxy(t *testing.T) {
+	target := listen(t, func(conn net.Conn) {
+		io.WriteString(conn, "220 ready\r\n")
+	})
+	socks := listen(t, func(conn net.Conn) { serveSOCKS5(conn, "") })
+	socksAuth := listen(t, func(conn net.Conn) { serveSOCKS5(conn, "user:secret") })
+	connect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect || r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go pipe(conn, upstream)
+	}))
+	defer connect.Close()
+	connectAddr := connect.Listener.Addr().String()
+
+	tests := []struct {
+		name    string
+		proxy   string
+		wantErr bool
+	}{
+		{name: "socks5", proxy: "socks5://" + socks},
+		{name: "socks5 auth", proxy: "socks5://user:secret@" + socksAuth},
+		{name: "socks5 missing auth", proxy: "socks5://" + socksAuth, wantErr: true},
+		{name: "connect", proxy: "http://user:secret@" + connectAddr},
+		{name: "connect missing auth", proxy: "http://" + connectAddr, wantErr: true},
+		{name: "unsupported", proxy: "ftp://" + connectAddr, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, _ := url.Parse(tt.proxy)
+			conn, err := dialProxy(context.Background(), &net.Dialer{}, proxy, target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dialProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil || line != "220 ready\r\n" {
+				t.Errorf("read %q, %v", line, err)
+			}
+		})
+	}
+}
+
+// listen accepts connections on a local port, handling each with handle,
+// and returns the address
+func listen(t *testing.T, handle func(net.Conn)) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// serveSOCKS5 implements the parts of a SOCKS5 proxy used by dialProxy,
+// requiring credentials when auth is "user:password"
+func serveSOCKS5(conn net.Conn, auth string) {
+	greeting := make([]byte, 3)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return
+	}
+	method := byte(0x00)
+	if auth != "" {
+		method = 0x02
+	}
+	if greeting[2] != method {
+		conn.Write([]byte{0x05, 0xff})
+		return
+	}
+	conn.Write([]byte{0x05, method})
+	if auth != "" {
+		buf := make([]byte, 2)
+		io.ReadFull(conn, buf)
+		user := make([]byte, buf[1])
+		io.ReadFull(conn, user)
+		io.ReadFull(conn, buf[:1])
+		password := make([]byte, buf[0])
+		io.ReadFull(conn, password)
+		if string(user)+":"+string(password) != auth {
+			conn.Write([]byte{0x01, 0x01})
+			return
+		}
+		conn.Write([]byte{0x01, 0x00})
+	}
+
+	header := make([]byte, 4)
+	io.ReadFull(conn, header)
+	var host string
+	switch header[3] {
+	case 0x01:
+		ip := make([]byte, net.IPv4len)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 0x03:
+		length := make([]byte, 1)
+		io.ReadFull(conn, length)
+		name := make([]byte, length[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+
+	upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	pipe(conn, upstream)
+}
+
+func pipe(a, b net.Conn) {
+	defer a.Close()
+	defer b.Close()
+	go io.Copy(a, b)
+	io.Copy(b, a)
+}
@@ -0,0 +1,519 @@
+package shoutbox
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// qrCode is a QR code symbol encoded in byte mode with error correction
+// level M
+type qrCode struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Error correction codewords per block and number of blocks for each
+// version at error correction level M, indexed by version
+var (
+	qrECCCodewordsPerBlock = [41]int{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28}
+	qrNumECCBlocks         = [41]int{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49}
+)
+
+// qrFormatBitsM is the two-bit format indicator for error correction level M
+const qrFormatBitsM = 0
+
+// errQRDataTooLong is returned when the data doesn't fit in a version 40
+// symbol
+var errQRDataTooLong = errors.New("data too long for a QR code")
+
+// encodeQR encodes data as a QR code using the smallest version that fits
+func encodeQR(data []byte) (*qrCode, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if qrDataBits(data, v) <= qrNumDataCodewords(v)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errQRDataTooLong
+	}
+
+	capacity := qrNumDataCodewords(version) * 8
+	var bb qrBitBuffer
+	bb.append(0x4, 4)
+	bb.append(uint32(len(data)), qrCharCountBits(version))
+	for _, b := range data {
+		bb.append(uint32(b), 8)
+	}
+	bb.append(0, min(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := uint32(0xEC); len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+
+	qr := newQRCode(version)
+	qr.drawFunctionPatterns()
+	qr.drawCodewords(qr.addECCAndInterleave(codewords))
+
+	bestMask, minPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(mask)
+		if penalty := qr.penaltyScore(); minPenalty < 0 || penalty < minPenalty {
+			bestMask, minPenalty = mask, penalty
+		}
+		qr.applyMask(mask)
+	}
+	qr.applyMask(bestMask)
+	qr.drawFormatBits(bestMask)
+
+	return qr, nil
+}
+
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	qr := &qrCode{
+		version:    version,
+		size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range qr.modules {
+		qr.modules[i] = make([]bool, size)
+		qr.isFunction[i] = make([]bool, size)
+	}
+	return qr
+}
+
+func qrCharCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+func qrDataBits(data []byte, version int) int {
+	return 4 + qrCharCountBits(version) + len(data)*8
+}
+
+// qrNumRawDataModules returns the number of modules available for data and
+// error correction in a symbol of the given version
+func qrNumRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func qrNumDataCodewords(version int) int {
+	return qrNumRawDataModules(version)/8 - qrECCCodewordsPerBlock[version]*qrNumECCBlocks[version]
+}
+
+func (qr *qrCode) setFunctionModule(x, y int, dark bool) {
+	qr.modules[y][x] = dark
+	qr.isFunction[y][x] = true
+}
+
+func (qr *qrCode) drawFunctionPatterns() {
+	for i := 0; i < qr.size; i++ {
+		qr.setFunctionModule(6, i, i%2 == 0)
+		qr.setFunctionModule(i, 6, i%2 == 0)
+	}
+
+	qr.drawFinderPattern(3, 3)
+	qr.drawFinderPattern(qr.size-4, 3)
+	qr.drawFinderPattern(3, qr.size-4)
+
+	positions := qr.alignmentPatternPositions()
+	n := len(positions)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue
+			}
+			qr.drawAlignmentPattern(positions[i], positions[j])
+		}
+	}
+
+	qr.drawFormatBits(0)
+	qr.drawVersion()
+}
+
+func (qr *qrCode) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= qr.size || yy < 0 || yy >= qr.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			qr.setFunctionModule(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (qr *qrCode) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			qr.setFunctionModule(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func (qr *qrCode) alignmentPatternPositions() []int {
+	if qr.version == 1 {
+		return nil
+	}
+	numAlign := qr.version/7 + 2
+	step := (qr.version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, qr.size-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// qrFormatBits returns the 15-bit BCH-protected format information
+func qrFormatBits(mask int) int {
+	data := qrFormatBitsM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (qr *qrCode) drawFormatBits(mask int) {
+	bits := qrFormatBits(mask)
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunctionModule(8, i, qrBit(bits, i))
+	}
+	qr.setFunctionModule(8, 7, qrBit(bits, 6))
+	qr.setFunctionModule(8, 8, qrBit(bits, 7))
+	qr.setFunctionModule(7, 8, qrBit(bits, 8))
+	for i := 9; i < 15; i++ {
+		qr.setFunctionModule(14-i, 8, qrBit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.setFunctionModule(qr.size-1-i, 8, qrBit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunctionModule(8, qr.size-15+i, qrBit(bits, i))
+	}
+	qr.setFunctionModule(8, qr.size-8, true)
+}
+
+// qrVersionBits returns the 18-bit BCH-protected version information
+func qrVersionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+func (qr *qrCode) drawVersion() {
+	if qr.version < 7 {
+		return
+	}
+	bits := qrVersionBits(qr.version)
+	for i := 0; i < 18; i++ {
+		dark := qrBit(bits, i)
+		a, b := qr.size-11+i%3, i/3
+		qr.setFunctionModule(a, b, dark)
+		qr.setFunctionModule(b, a, dark)
+	}
+}
+
+func (qr *qrCode) addECCAndInterleave(data []byte) []byte {
+	numBlocks := qrNumECCBlocks[qr.version]
+	blockECCLen := qrECCCodewordsPerBlock[qr.version]
+	rawCodewords := qrNumRawDataModules(qr.version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		datLen := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			datLen++
+		}
+		block := append([]byte(nil), data[k:k+datLen]...)
+		k += datLen
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+func (qr *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if !qr.isFunction[y][x] && i < len(data)*8 {
+					qr.modules[y][x] = qrBit(int(data[i>>3]), 7-(i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (qr *qrCode) applyMask(mask int) {
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !qr.isFunction[y][x] {
+				qr.modules[y][x] = !qr.modules[y][x]
+			}
+		}
+	}
+}
+
+// penaltyScore scores the symbol using the four rules of ISO/IEC 18004;
+// lower scores are easier to scan
+func (qr *qrCode) penaltyScore() int {
+	result := 0
+	get := func(x, y int, transpose bool) bool {
+		if transpose {
+			return qr.modules[x][y]
+		}
+		return qr.modules[y][x]
+	}
+
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < qr.size; y++ {
+			run := 0
+			var prev bool
+			for x := 0; x < qr.size; x++ {
+				cur := get(x, y, transpose)
+				if x > 0 && cur == prev {
+					run++
+					if run == 5 {
+						result += 3
+					} else if run > 5 {
+						result++
+					}
+				} else {
+					run = 1
+				}
+				prev = cur
+			}
+
+			for x := 0; x+11 <= qr.size; x++ {
+				if qr.finderLike(x, y, transpose, get) {
+					result += 40
+				}
+			}
+		}
+	}
+
+	for y := 0; y < qr.size-1; y++ {
+		for x := 0; x < qr.size-1; x++ {
+			c := qr.modules[y][x]
+			if c == qr.modules[y][x+1] && c == qr.modules[y+1][x] && c == qr.modules[y+1][x+1] {
+				result += 3
+			}
+		}
+	}
+
+	dark := 0
+	for _, row := range qr.modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	total := qr.size * qr.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * 10
+
+	return result
+}
+
+var (
+	qrFinderPatternA = [11]bool{true, false, true, true, true, false, true, false, false, false, false}
+	qrFinderPatternB = [11]bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+func (qr *qrCode) finderLike(x, y int, transpose bool, get func(x, y int, transpose bool) bool) bool {
+	matchA, matchB := true, true
+	for i := 0; i < 11; i++ {
+		m := get(x+i, y, transpose)
+		matchA = matchA && m == qrFinderPatternA[i]
+		matchB = matchB && m == qrFinderPatternB[i]
+	}
+	return matchA || matchB
+}
+
+// image renders the symbol with a four-module quiet zone, scaled so the
+// image is at most size pixels wide
+func (qr *qrCode) image(size int) *image.Gray {
+	total := qr.size + 8
+	scale := max(size/total, 1)
+	img := image.NewGray(image.Rect(0, 0, total*scale, total*scale))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < qr.size; y++ {
+		for x := 0; x < qr.size; x++ {
+			if !qr.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+4)*scale+dx, (y+4)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+type qrBitBuffer []bool
+
+func (bb *qrBitBuffer) append(val uint32, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*bb = append(*bb, (val>>uint(i))&1 != 0)
+	}
+}
+
+func qrBit(x, i int) bool {
+	return (x>>uint(i))&1 != 0
+}
+
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8) modulo x^8+x^4+x^3+x^2+1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// NewQRCodeInline renders data as a QR code PNG of at most size pixels
+// square and returns it as an inline attachment. Reference it from the
+// HTML body as <img src="cid:{{ContentID}}">.
+func NewQRCodeInline(data string, size int) (Attachment, error) {
+	qr, err := encodeQR([]byte(data))
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, qr.image(size)); err != nil {
+		return Attachment{}, fmt.Errorf("error encoding QR code: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(data))
+	id := hex.EncodeToString(sum[:8])
+	return Attachment{
+		Filename:    "qrcode-" + id + ".png",
+		Content:     buf.Bytes(),
+		ContentType: "image/png",
+		ContentID:   "qrcode-" + id + "@shoutbox",
+		Inline:      true,
+	}, nil
+}
@@ -0,0 +1,191 @@
+package shoutbox
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// "HELLO WORLD" as version 1-M data codewords, from ISO/IEC 18004 Annex I
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := reedSolomonRemainder(data, reedSolomonDivisor(len(want)))
+	if !bytes.Equal(got, want) {
+		t.Errorf("reedSolomonRemainder() = %v, want %v", got, want)
+	}
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	if got := qrFormatBits(0); got != 0b101010000010010 {
+		t.Errorf("qrFormatBits(0) = %015b", got)
+	}
+	if got := qrFormatBits(1); got != 0b101000100100101 {
+		t.Errorf("qrFormatBits(1) = %015b", got)
+	}
+	if got := qrVersionBits(7); got != 0b000111110010010100 {
+		t.Errorf("qrVersionBits(7) = %018b", got)
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantVersion int
+	}{
+		{name: "short", data: "hello", wantVersion: 1},
+		{name: "version 1 capacity", data: strings.Repeat("a", 14), wantVersion: 1},
+		{name: "version 2", data: strings.Repeat("a", 15), wantVersion: 2},
+		{name: "url", data: "https://example.com/tickets/8f14e45fceea167a5a36dedd4bea2543", wantVersion: 4},
+		{name: "long", data: strings.Repeat("x", 500), wantVersion: 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := encodeQR([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("encodeQR() error = %v", err)
+			}
+			if qr.version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", qr.version, tt.wantVersion)
+			}
+			if got := readQRData(t, qr); got != tt.data {
+				t.Errorf("decoded data = %q, want %q", got, tt.data)
+			}
+		})
+	}
+
+	if _, err := encodeQR(make([]byte, 3000)); err == nil {
+		t.Errorf("encodeQR() with oversized data error = nil")
+	}
+}
+
+// readQRData reverses the mask and codeword placement of a single-block
+// symbol and decodes the byte-mode segment
+func readQRData(t *testing.T, qr *qrCode) string {
+	t.Helper()
+
+	var format int
+	for i := 0; i <= 5; i++ {
+		if qr.modules[i][8] {
+			format |= 1 << i
+		}
+	}
+	if qr.modules[7][8] {
+		format |= 1 << 6
+	}
+	if qr.modules[8][8] {
+		format |= 1 << 7
+	}
+	if qr.modules[8][7] {
+		format |= 1 << 8
+	}
+	for i := 9; i < 15; i++ {
+		if qr.modules[8][14-i] {
+			format |= 1 << i
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if qrFormatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("invalid format bits %015b", format)
+	}
+
+	qr.applyMask(mask)
+	defer qr.applyMask(mask)
+
+	var codewords []byte
+	var cur byte
+	n := 0
+	for right := qr.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < qr.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = qr.size - 1 - vert
+				}
+				if qr.isFunction[y][x] {
+					continue
+				}
+				cur <<= 1
+				if qr.modules[y][x] {
+					cur |= 1
+				}
+				if n++; n%8 == 0 {
+					codewords = append(codewords, cur)
+				}
+			}
+		}
+	}
+
+	// Deinterleave the data codewords of each block
+	numBlocks := qrNumECCBlocks[qr.version]
+	rawCodewords := qrNumRawDataModules(qr.version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLen := rawCodewords/numBlocks - qrECCCodewordsPerBlock[qr.version]
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= shortDataLen; i++ {
+		for j := range blocks {
+			if i == shortDataLen && j < numShortBlocks {
+				continue
+			}
+			blocks[j] = append(blocks[j], codewords[k])
+			k++
+		}
+	}
+	data := bytes.Join(blocks, nil)
+
+	var bits qrBitBuffer
+	for _, b := range data {
+		bits.append(uint32(b), 8)
+	}
+	read := func(pos, length int) int {
+		v := 0
+		for _, b := range bits[pos : pos+length] {
+			v <<= 1
+			if b {
+				v |= 1
+			}
+		}
+		return v
+	}
+	if mode := read(0, 4); mode != 0x4 {
+		t.Fatalf("mode = %x, want byte mode", mode)
+	}
+	countBits := qrCharCountBits(qr.version)
+	length := read(4, countBits)
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(read(4+countBits+i*8, 8))
+	}
+	return string(out)
+}
+
+func TestNewQRCodeInline(t *testing.T) {
+	att, err := NewQRCodeInline("otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP", 200)
+	if err != nil {
+		t.Fatalf("NewQRCodeInline() error = %v", err)
+	}
+	if !att.Inline || att.ContentID == "" || att.ContentType != "image/png" {
+		t.Errorf("NewQRCodeInline() = %+v", att)
+	}
+
+	img, err := png.Decode(bytes.NewReader(att.Content))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() > 200 || b.Dx() != b.Dy() {
+		t.Errorf("image bounds = %v", b)
+	}
+}
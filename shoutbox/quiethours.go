@@ -0,0 +1,55 @@
+package shoutbox
+
+import (
+	"slices"
+	"time"
+)
+
+// QuietHours is a daily window in the recipient's local time during which
+// messages are held back, e.g. 22:00 to 08:00. Start and End are wall-clock
+// times given as offsets from local midnight, so the window keeps its hours
+// on daylight saving changes; windows crossing midnight have Start after
+// End.
+type QuietHours struct {
+	Start time.Duration
+	End   time.Duration
+	// Categories limits the window to messages in these categories, e.g.
+	// "marketing", so transactional mail goes out at any hour. When empty
+	// every message is held back.
+	Categories []string
+	// DefaultLocation is used for recipients whose timezone is unknown or
+	// invalid; defaults to UTC
+	DefaultLocation *time.Location
+}
+
+// Applies reports whether messages in category are held back
+func (q *QuietHours) Applies(category string) bool {
+	return len(q.Categories) == 0 || slices.Contains(q.Categories, category)
+}
+
+// Adjust returns t if it falls outside the quiet window in the IANA
+// timezone tz, or otherwise the end of the window
+func (q *QuietHours) Adjust(t time.Time, tz string) time.Time {
+	loc := SendTimeStrategy{DefaultLocation: q.DefaultLocation}.location(tz)
+	local := t.In(loc)
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+
+	endOn := func(day int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+day,
+			int(q.End/time.Hour), int(q.End%time.Hour/time.Minute),
+			int(q.End%time.Minute/time.Second), int(q.End%time.Second), loc)
+	}
+
+	switch {
+	case q.Start <= q.End:
+		if clock >= q.Start && clock < q.End {
+			return endOn(0)
+		}
+	case clock >= q.Start:
+		return endOn(1)
+	case clock < q.End:
+		return endOn(0)
+	}
+	return t
+}
@@ -0,0 +1,70 @@
+package shoutbox
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuietHours_Adjust(t *testing.T) {
+	overnight := &QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour}
+	lunch := &QuietHours{Start: 12 * time.Hour, End: 13*time.Hour + 30*time.Minute}
+
+	tests := []struct {
+		name  string
+		quiet *QuietHours
+		at    string
+		tz    string
+		want  string
+	}{
+		{name: "before window", quiet: overnight, at: "2024-06-01T20:00:00Z", tz: "UTC", want: "2024-06-01T20:00:00Z"},
+		{name: "late evening", quiet: overnight, at: "2024-06-01T23:30:00Z", tz: "UTC", want: "2024-06-02T08:00:00Z"},
+		{name: "early morning", quiet: overnight, at: "2024-06-02T03:00:00Z", tz: "UTC", want: "2024-06-02T08:00:00Z"},
+		{name: "recipient timezone", quiet: overnight, at: "2024-06-01T20:00:00Z", tz: "Europe/Berlin", want: "2024-06-02T08:00:00+02:00"},
+		{name: "same-day window", quiet: lunch, at: "2024-06-01T12:15:00Z", tz: "UTC", want: "2024-06-01T13:30:00Z"},
+		{name: "after same-day window", quiet: lunch, at: "2024-06-01T13:30:00Z", tz: "UTC", want: "2024-06-01T13:30:00Z"},
+		{name: "spring forward", quiet: overnight, at: "2026-03-08T09:00:00Z", tz: "America/New_York", want: "2026-03-08T08:00:00-04:00"},
+		{name: "after spring forward window", quiet: overnight, at: "2026-03-08T12:30:00Z", tz: "America/New_York", want: "2026-03-08T12:30:00Z"},
+		{name: "fall back", quiet: overnight, at: "2026-11-01T10:00:00Z", tz: "America/New_York", want: "2026-11-01T08:00:00-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, _ := time.Parse(time.RFC3339, tt.at)
+			if got := tt.quiet.Adjust(at, tt.tz).Format(time.RFC3339); got != tt.want {
+				t.Errorf("Adjust() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduler_QuietHours(t *testing.T) {
+	s := NewScheduler(func(ctx context.Context, msg *EmailMessage) error { return nil })
+	// A window covering the whole day holds every message back
+	s.QuietHours = &QuietHours{Start: 0, End: 24*time.Hour - time.Nanosecond}
+
+	s.Send(&EmailMessage{}, "UTC")
+	if n := s.Pending(); n != 1 {
+		t.Errorf("Pending() = %d, want 1", n)
+	}
+	s.Stop()
+}
+
+func TestScheduler_QuietHoursCategories(t *testing.T) {
+	sent := make(chan *EmailMessage, 2)
+	s := NewScheduler(func(ctx context.Context, msg *EmailMessage) error {
+		sent <- msg
+		return nil
+	})
+	s.QuietHours = &QuietHours{Start: 0, End: 24*time.Hour - time.Nanosecond, Categories: []string{"marketing"}}
+
+	s.Send(&EmailMessage{Category: "marketing"}, "UTC")
+	s.Send(&EmailMessage{Category: "receipts"}, "UTC")
+	if msg := <-sent; msg.Category != "receipts" {
+		t.Errorf("sent %q, want the transactional message", msg.Category)
+	}
+	if n := s.Pending(); n != 1 {
+		t.Errorf("Pending() = %d, want the marketing message held", n)
+	}
+	s.Stop()
+}
@@ -0,0 +1,64 @@
+package shoutbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token-bucket rate limiter. A single limiter can be
+// shared by several clients and goroutines to stay within one account
+// limit.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter allows perSecond sends on average with bursts of up to
+// burst sends. A burst below 1 is treated as 1.
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	b := float64(max(burst, 1))
+	return &RateLimiter{
+		rate:   perSecond,
+		burst:  b,
+		tokens: b,
+		now:    time.Now,
+	}
+}
+
+// Wait blocks until a send is allowed or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, wait); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,163 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// rawBccPattern matches the Bcc field of a message header, including
+// folded continuation lines
+var rawBccPattern = regexp.MustCompile(`(?im)^bcc[ \t]*:.*(\r?\n[ \t].*)*\r?\n`)
+
+// rawMessage is a pre-built RFC 5322 message with its envelope
+type rawMessage struct {
+	from       string
+	recipients []string
+	messageID  string
+	// data is the message with the Bcc field removed
+	data []byte
+}
+
+// parseRawMessage reads the sender, recipients and Message-ID of raw.
+// Recipients are the To, Cc and Bcc addresses; the Bcc field is removed
+// so they aren't disclosed.
+func parseRawMessage(raw []byte) (*rawMessage, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("error reading message: %w", err)
+	}
+	from, err := m.Header.AddressList("From")
+	if err == nil && len(from) == 0 {
+		err = mail.ErrHeaderNotPresent
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading From: %w", err)
+	}
+
+	msg := &rawMessage{
+		from:      from[0].Address,
+		messageID: strings.TrimSpace(m.Header.Get("Message-ID")),
+		data:      raw,
+	}
+	for _, field := range []string{"To", "Cc", "Bcc"} {
+		addresses, err := m.Header.AddressList(field)
+		if err == mail.ErrHeaderNotPresent {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", field, err)
+		}
+		for _, address := range addresses {
+			msg.recipients = append(msg.recipients, address.Address)
+		}
+	}
+	if len(msg.recipients) == 0 {
+		return nil, errors.New("missing recipients")
+	}
+
+	if _, ok := m.Header["Bcc"]; ok {
+		header, body := splitRawMessage(raw)
+		msg.data = append(rawBccPattern.ReplaceAll(header, nil), body...)
+	}
+	return msg, nil
+}
+
+// splitRawMessage splits raw after the blank line ending its header
+func splitRawMessage(raw []byte) (header, body []byte) {
+	for _, sep := range []string{"\r\n\r\n", "\n\n"} {
+		if i := bytes.Index(raw, []byte(sep)); i >= 0 {
+			return raw[:i+len(sep)], raw[i+len(sep):]
+		}
+	}
+	return raw, nil
+}
+
+// rawPayload is the request body of a raw send. Recipients are sent
+// separately as Bcc recipients are removed from the message.
+type rawPayload struct {
+	From string   `json:"from"`
+	To   []string `json:"to"`
+	// Raw is base64-encoded in the request
+	Raw []byte `json:"raw"`
+}
+
+// SendRaw sends a pre-built RFC 5322 message, e.g. an .eml file or mail
+// generated by another library, as is. The sender and recipients are read
+// from its From, To, Cc and Bcc fields; the Bcc field is removed before
+// sending. Client defaults that change the message, such as tracking or
+// seed lists, don't apply.
+func (c *Client) SendRaw(ctx context.Context, raw []byte) (*SendResponse, error) {
+	msg, err := parseRawMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+	if c.sandbox {
+		id, err := randomKey()
+		if err != nil {
+			return nil, fmt.Errorf("error generating message ID: %w", err)
+		}
+		return &SendResponse{
+			MessageID: "sandbox-" + id,
+			Accepted:  msg.recipients,
+			Metadata:  map[string]any{"sandbox": true},
+		}, nil
+	}
+	var resp SendResponse
+	payload := rawPayload{From: msg.from, To: msg.recipients, Raw: msg.data}
+	if err := c.do(ctx, http.MethodPost, "/send/raw", payload, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SendRaw sends a pre-built RFC 5322 message, e.g. an .eml file or mail
+// generated by another library, as is. The sender and recipients are read
+// from its From, To, Cc and Bcc fields; the Bcc field is removed before
+// sending and a Message-ID is added if it has none. The message is signed
+// if DKIMSigner is set; other client settings that change the message
+// don't apply.
+func (c *SMTPClient) SendRaw(ctx context.Context, raw []byte) (*SendResponse, error) {
+	msg, err := parseRawMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+	data := msg.data
+	if msg.messageID == "" {
+		if msg.messageID, err = newMessageID(msg.from); err != nil {
+			return nil, fmt.Errorf("error generating message ID: %w", err)
+		}
+		data = slices.Concat([]byte(headerMessageID+": "+msg.messageID+"\r\n"), data)
+	}
+	if c.DKIMSigner != nil {
+		// DKIM requires CRLF line endings, which SMTP uses anyway
+		data = bytes.ReplaceAll(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+		if data, err = c.DKIMSigner.Sign(data); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &SendResponse{
+		MessageID: strings.Trim(msg.messageID, "<>"),
+		Accepted:  msg.recipients,
+	}
+	if c.Sandbox {
+		response.Metadata = map[string]any{"sandbox": true}
+		return response, nil
+	}
+	write := func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}
+	if err := c.submit(ctx, msg.messageID, msg.from, msg.recipients, nil, 0, write); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,105 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testRawMessage = "From: Shop <shop@example.com>\r\n" +
+	"To: ada@example.com\r\n" +
+	"Cc: Bob <bob@example.com>\r\n" +
+	"Bcc: audit@example.com,\r\n" +
+	" archive@example.com\r\n" +
+	"Subject: Receipt\r\n" +
+	"\r\n" +
+	"Thanks for your order.\r\n" +
+	"Bcc: this line is body text\r\n"
+
+func TestParseRawMessage(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            string
+		wantRecipients []string
+		wantErr        bool
+	}{
+		{
+			name:           "bcc removed",
+			raw:            testRawMessage,
+			wantRecipients: []string{"ada@example.com", "bob@example.com", "audit@example.com", "archive@example.com"},
+		},
+		{
+			name:           "bare line feeds",
+			raw:            "From: shop@example.com\nBcc: audit@example.com\nTo: ada@example.com\n\nHi\n",
+			wantRecipients: []string{"ada@example.com", "audit@example.com"},
+		},
+		{name: "missing from", raw: "To: ada@example.com\r\n\r\nHi\r\n", wantErr: true},
+		{name: "missing recipients", raw: "From: shop@example.com\r\n\r\nHi\r\n", wantErr: true},
+		{name: "invalid recipient", raw: "From: shop@example.com\r\nTo: not an address\r\n\r\nHi\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := parseRawMessage([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRawMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(msg.recipients, tt.wantRecipients) {
+				t.Errorf("recipients = %q, want %q", msg.recipients, tt.wantRecipients)
+			}
+			header, body := splitRawMessage(msg.data)
+			if strings.Contains(strings.ToLower(string(header)), "bcc") {
+				t.Errorf("header still contains Bcc: %q", header)
+			}
+			if _, wantBody := splitRawMessage([]byte(tt.raw)); string(body) != string(wantBody) {
+				t.Errorf("body = %q, want %q", body, wantBody)
+			}
+		})
+	}
+}
+
+func TestClient_SendRaw(t *testing.T) {
+	var got rawPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/send/raw" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message_id": "msg_123"}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	resp, err := client.SendRaw(context.Background(), []byte(testRawMessage))
+	if err != nil {
+		t.Fatalf("SendRaw() error = %v", err)
+	}
+	if resp.MessageID != "msg_123" {
+		t.Errorf("MessageID = %q", resp.MessageID)
+	}
+	if got.From != "shop@example.com" || len(got.To) != 4 {
+		t.Errorf("payload from = %q, to = %q", got.From, got.To)
+	}
+	if !strings.HasPrefix(string(got.Raw), "From: Shop <shop@example.com>\r\nTo: ada@example.com\r\nCc: Bob <bob@example.com>\r\nSubject: Receipt\r\n") {
+		t.Errorf("payload raw = %q", got.Raw)
+	}
+}
+
+func TestSMTPClient_SendRawSandbox(t *testing.T) {
+	client := &SMTPClient{Sandbox: true}
+	resp, err := client.SendRaw(context.Background(), []byte(testRawMessage))
+	if err != nil {
+		t.Fatalf("SendRaw() error = %v", err)
+	}
+	if resp.MessageID == "" || !strings.HasSuffix(resp.MessageID, "@example.com") || len(resp.Accepted) != 4 {
+		t.Errorf("SendRaw() = %+v", resp)
+	}
+}
@@ -0,0 +1,149 @@
+package shoutbox
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Money is an amount in the currency's minor unit (e.g. cents)
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// zeroDecimalCurrencies have no minor unit
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true, "KRW": true, "VND": true, "CLP": true, "ISK": true,
+}
+
+// String formats m as e.g. "12.34 USD"
+func (m Money) String() string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	currency := strings.ToUpper(m.Currency)
+	if zeroDecimalCurrencies[currency] {
+		return strings.TrimSpace(fmt.Sprintf("%s%d %s", sign, amount, currency))
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, currency))
+}
+
+// LineItem is a single row of a receipt
+type LineItem struct {
+	Description string
+	Quantity    int
+	UnitPrice   Money
+}
+
+// Total returns the unit price multiplied by the quantity
+func (li LineItem) Total() Money {
+	return Money{Amount: li.UnitPrice.Amount * int64(li.Quantity), Currency: li.UnitPrice.Currency}
+}
+
+// Address is a postal address block
+type Address struct {
+	Name       string
+	Company    string
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+// Totals are the summary rows of a receipt. Zero Discount, Shipping and Tax
+// rows are omitted.
+type Totals struct {
+	Subtotal Money
+	Discount Money
+	Shipping Money
+	Tax      Money
+	Total    Money
+}
+
+// Receipt is a complete receipt or invoice
+type Receipt struct {
+	Title  string
+	Number string
+	BillTo Address
+	Items  []LineItem
+	Totals Totals
+	Footer string
+	// Direction is the text direction of the rendered receipt
+	Direction Direction
+}
+
+// receiptTemplates holds the receipt components for each text direction
+var receiptTemplates = map[Direction]*template.Template{
+	LTR: newReceiptTemplates(LTR),
+	RTL: newReceiptTemplates(RTL),
+}
+
+func newReceiptTemplates(dir Direction) *template.Template {
+	return template.Must(template.New("receipt").Funcs(template.FuncMap{
+		"dir":        dir.String,
+		"alignStart": dir.Start,
+		"alignEnd":   dir.End,
+	}).Parse(receiptTemplateText))
+}
+
+const receiptTemplateText = `
+{{define "lineItems"}}<table dir="{{dir}}" role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="border-collapse:collapse;font-family:Arial,sans-serif;font-size:14px">
+<tr><th align="{{alignStart}}" style="padding:8px;border-bottom:2px solid #dddddd">Item</th><th align="{{alignEnd}}" style="padding:8px;border-bottom:2px solid #dddddd">Qty</th><th align="{{alignEnd}}" style="padding:8px;border-bottom:2px solid #dddddd">Price</th><th align="{{alignEnd}}" style="padding:8px;border-bottom:2px solid #dddddd">Total</th></tr>
+{{range .}}<tr><td align="{{alignStart}}" style="padding:8px;border-bottom:1px solid #eeeeee">{{.Description}}</td><td align="{{alignEnd}}" style="padding:8px;border-bottom:1px solid #eeeeee">{{.Quantity}}</td><td align="{{alignEnd}}" style="padding:8px;border-bottom:1px solid #eeeeee">{{.UnitPrice}}</td><td align="{{alignEnd}}" style="padding:8px;border-bottom:1px solid #eeeeee">{{.Total}}</td></tr>
+{{end}}</table>{{end}}
+{{define "totals"}}<table dir="{{dir}}" role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="border-collapse:collapse;font-family:Arial,sans-serif;font-size:14px">
+<tr><td align="{{alignEnd}}" style="padding:4px 8px">Subtotal</td><td align="{{alignEnd}}" width="120" style="padding:4px 8px">{{.Subtotal}}</td></tr>
+{{if .Discount.Amount}}<tr><td align="{{alignEnd}}" style="padding:4px 8px">Discount</td><td align="{{alignEnd}}" width="120" style="padding:4px 8px">{{.Discount}}</td></tr>
+{{end}}{{if .Shipping.Amount}}<tr><td align="{{alignEnd}}" style="padding:4px 8px">Shipping</td><td align="{{alignEnd}}" width="120" style="padding:4px 8px">{{.Shipping}}</td></tr>
+{{end}}{{if .Tax.Amount}}<tr><td align="{{alignEnd}}" style="padding:4px 8px">Tax</td><td align="{{alignEnd}}" width="120" style="padding:4px 8px">{{.Tax}}</td></tr>
+{{end}}<tr><td align="{{alignEnd}}" style="padding:8px;font-weight:bold;border-top:2px solid #dddddd">Total</td><td align="{{alignEnd}}" width="120" style="padding:8px;font-weight:bold;border-top:2px solid #dddddd">{{.Total}}</td></tr>
+</table>{{end}}
+{{define "address"}}<table dir="{{dir}}" role="presentation" cellpadding="0" cellspacing="0" border="0" style="font-family:Arial,sans-serif;font-size:14px;line-height:20px">
+<tr><td align="{{alignStart}}">{{with .Name}}<strong>{{.}}</strong><br>{{end}}{{with .Company}}{{.}}<br>{{end}}{{with .Line1}}{{.}}<br>{{end}}{{with .Line2}}{{.}}<br>{{end}}{{.City}}{{if and .City .Region}}, {{end}}{{.Region}} {{.PostalCode}}{{with .Country}}<br>{{.}}{{end}}</td></tr>
+</table>{{end}}
+{{define "receipt"}}<table dir="{{dir}}" role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="max-width:600px;font-family:Arial,sans-serif">
+<tr><td align="{{alignStart}}" style="padding:16px 0"><h1 style="margin:0;font-size:22px">{{.Title}}</h1>{{with .Number}}<p style="margin:4px 0 0;color:#666666">#{{.}}</p>{{end}}</td></tr>
+<tr><td align="{{alignStart}}" style="padding:0 0 16px">{{template "address" .BillTo}}</td></tr>
+<tr><td>{{template "lineItems" .Items}}</td></tr>
+<tr><td>{{template "totals" .Totals}}</td></tr>
+{{with .Footer}}<tr><td align="{{alignStart}}" style="padding:16px 0;color:#666666;font-size:12px">{{.}}</td></tr>
+{{end}}</table>{{end}}
+`
+
+// RenderLineItems renders items as an email-safe HTML table
+func RenderLineItems(items []LineItem, dir Direction) (template.HTML, error) {
+	return renderReceiptTemplate(dir, "lineItems", items)
+}
+
+// RenderTotals renders the totals rows as an email-safe HTML table
+func RenderTotals(totals Totals, dir Direction) (template.HTML, error) {
+	return renderReceiptTemplate(dir, "totals", totals)
+}
+
+// RenderAddress renders an address block as an email-safe HTML table
+func RenderAddress(addr Address, dir Direction) (template.HTML, error) {
+	return renderReceiptTemplate(dir, "address", addr)
+}
+
+// Render renders the complete receipt as email-safe HTML
+func (r Receipt) Render() (template.HTML, error) {
+	return renderReceiptTemplate(r.Direction, "receipt", r)
+}
+
+func renderReceiptTemplate(dir Direction, name string, data any) (template.HTML, error) {
+	tmpl, ok := receiptTemplates[dir]
+	if !ok {
+		tmpl = receiptTemplates[LTR]
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("error rendering %s: %w", name, err)
+	}
+	return template.HTML(buf.String()), nil
+}
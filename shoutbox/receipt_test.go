@@ -0,0 +1,56 @@
+package shoutbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 1234, Currency: "usd"}, "12.34 USD"},
+		{Money{Amount: 5, Currency: "EUR"}, "0.05 EUR"},
+		{Money{Amount: -250, Currency: "EUR"}, "-2.50 EUR"},
+		{Money{Amount: 1500, Currency: "JPY"}, "1500 JPY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.money.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceipt_Render(t *testing.T) {
+	receipt := Receipt{
+		Title:  "Your receipt",
+		Number: "INV-1001",
+		BillTo: Address{Name: "Jane <Doe>", Line1: "1 Main St", City: "Springfield", PostalCode: "12345"},
+		Items: []LineItem{
+			{Description: "Widget", Quantity: 2, UnitPrice: Money{Amount: 500, Currency: "USD"}},
+		},
+		Totals: Totals{
+			Subtotal: Money{Amount: 1000, Currency: "USD"},
+			Tax:      Money{Amount: 80, Currency: "USD"},
+			Total:    Money{Amount: 1080, Currency: "USD"},
+		},
+	}
+
+	got, err := receipt.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	for _, want := range []string{"#INV-1001", "Jane &lt;Doe&gt;", "Widget", "10.00 USD", "Tax", "10.80 USD"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Render() missing %q", want)
+		}
+	}
+	if strings.Contains(string(got), "Shipping") {
+		t.Errorf("Render() contains zero Shipping row")
+	}
+}
@@ -0,0 +1,46 @@
+package shoutbox
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Recipients is a list of recipient addresses, each optionally with a
+// display name, e.g. "Jane Doe <jane@example.com>". A single recipient is
+// sent as a string and several as an array; both forms are accepted when
+// decoding.
+type Recipients []string
+
+// ParseRecipients splits a comma-separated list of addresses
+func ParseRecipients(list string) Recipients {
+	var r Recipients
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			r = append(r, addr)
+		}
+	}
+	return r
+}
+
+// String returns the recipients as a comma-separated list
+func (r Recipients) String() string {
+	return strings.Join(r, ", ")
+}
+
+// MarshalJSON implements json.Marshaler
+func (r Recipients) MarshalJSON() ([]byte, error) {
+	if len(r) <= 1 {
+		return json.Marshal(r.String())
+	}
+	return json.Marshal([]string(r))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *Recipients) UnmarshalJSON(data []byte) error {
+	var list string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*r = ParseRecipients(list)
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(r))
+}
@@ -0,0 +1,46 @@
+package shoutbox
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestRecipients_JSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		recipients Recipients
+		want       string
+	}{
+		{name: "empty", recipients: nil, want: `""`},
+		{name: "single", recipients: Recipients{"ada@example.com"}, want: `"ada@example.com"`},
+		{name: "multiple", recipients: Recipients{"Ada <ada@example.com>", "grace@example.com"}, want: `["Ada \u003cada@example.com\u003e","grace@example.com"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.recipients)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+
+			var got Recipients
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !slices.Equal(got, tt.recipients) {
+				t.Errorf("Unmarshal() = %v, want %v", got, tt.recipients)
+			}
+		})
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	got := ParseRecipients(" ada@example.com, ,grace@example.com ")
+	if want := (Recipients{"ada@example.com", "grace@example.com"}); !slices.Equal(got, want) {
+		t.Errorf("ParseRecipients() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package shoutbox
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed API requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles with
+	// every attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts; zero means no cap
+	MaxBackoff time.Duration
+	// MaxRetryAfter is the longest wait asked for by Retry-After or
+	// X-RateLimit-Reset that is honoured; if the API asks for longer, the
+	// request fails with its *APIError instead. Zero means MaxBackoff.
+	MaxRetryAfter time.Duration
+	// RetryStatuses are the HTTP status codes that are retried
+	RetryStatuses []int
+	// RetryNetworkErrors retries requests that failed without a response.
+	// The API may have processed such a request; retries of a POST send
+	// the same Idempotency-Key header so it isn't processed twice.
+	RetryNetworkErrors bool
+}
+
+// DefaultRetryPolicy retries rate-limited requests, server errors and
+// network errors up to three times
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	MaxRetryAfter:  time.Minute,
+	RetryStatuses: []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+	RetryNetworkErrors: true,
+}
+
+// backoff returns the wait before retrying after attempt failed, with
+// jitter so concurrent clients don't retry in lockstep
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for range attempt - 1 {
+		if d > math.MaxInt64/2 || (p.MaxBackoff > 0 && d >= p.MaxBackoff) {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && (d > p.MaxBackoff || d <= 0) {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// maxRetryAfter returns the longest server-requested wait to honour, or
+// zero for no limit
+func (p RetryPolicy) maxRetryAfter() time.Duration {
+	if p.MaxRetryAfter > 0 {
+		return p.MaxRetryAfter
+	}
+	return p.MaxBackoff
+}
+
+func (p RetryPolicy) retryStatus(code int) bool {
+	return slices.Contains(p.RetryStatuses, code)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// RateLimit is the request quota reported in the X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset response headers. Fields of
+// headers the API didn't send are zero.
+type RateLimit struct {
+	// Limit is the number of requests allowed per period
+	Limit int
+	// Remaining is the number of requests left in the current period
+	Remaining int
+	// Reset is when the quota is replenished
+	Reset time.Time
+}
+
+// parseRateLimit parses the rate-limit headers, reporting whether any was
+// set. X-RateLimit-Reset is a Unix time, or, if too small to be one, the
+// number of seconds until the reset.
+func parseRateLimit(h http.Header, now time.Time) (RateLimit, bool) {
+	var limit RateLimit
+	var ok bool
+	if n, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		limit.Limit, ok = n, true
+	}
+	if n, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		limit.Remaining, ok = n, true
+	}
+	if n, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil && n >= 0 {
+		if n >= 1e9 {
+			limit.Reset = time.Unix(n, 0)
+		} else {
+			limit.Reset = now.Add(time.Duration(n) * time.Second)
+		}
+		ok = true
+	}
+	return limit, ok
+}
+
+// retryDelay returns how long the API asked to wait before retrying resp:
+// its Retry-After or, for a 429 or 503 without one, the time until the
+// rate limit resets
+func retryDelay(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if d, ok := retryAfter(resp.Header, now); ok {
+		return d, true
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	if limit, _ := parseRateLimit(resp.Header, now); !limit.Reset.IsZero() {
+		return max(limit.Reset.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,264 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Retry(t *testing.T) {
+	fast := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		RetryStatuses:  []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "recovers", opts: []Option{WithRetryPolicy(fast)}, statuses: []int{503, 429, 200}, wantCalls: 3},
+		{name: "gives up", opts: []Option{WithRetryPolicy(fast)}, statuses: []int{503, 503, 503, 200}, wantCalls: 3, wantErr: true},
+		{name: "not retryable", opts: []Option{WithRetryPolicy(fast)}, statuses: []int{400, 200}, wantCalls: 1, wantErr: true},
+		{name: "disabled", opts: []Option{WithoutRetries()}, statuses: []int{503, 200}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			client := NewClient("test-key", tt.opts...)
+			client.baseURL = srv.URL
+			_, err := client.SendEmail(context.Background(), &EmailRequest{To: Recipients{"ada@example.com"}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SendEmail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestClient_RetryIdempotencyKey(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys)%2 == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		RetryStatuses:  []int{http.StatusInternalServerError},
+	}))
+	client.baseURL = srv.URL
+
+	for range 2 {
+		if _, err := client.SendEmail(context.Background(), &EmailRequest{To: Recipients{"ada@example.com"}}); err != nil {
+			t.Fatalf("SendEmail() error = %v", err)
+		}
+	}
+	if len(keys) != 4 || keys[0] == "" || keys[0] != keys[1] || keys[2] != keys[3] || keys[1] == keys[2] {
+		t.Errorf("Idempotency-Key headers = %q, want one key per send, repeated on its retry", keys)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{name: "first retry", policy: RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, attempt: 1, want: time.Second},
+		{name: "doubled", policy: RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, attempt: 3, want: 4 * time.Second},
+		{name: "capped", policy: RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second}, attempt: 3, want: 3 * time.Second},
+		{name: "no cap", policy: RetryPolicy{InitialBackoff: time.Second}, attempt: 4, want: 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 20 {
+				if got := tt.policy.backoff(tt.attempt); got < tt.want/2 || got > tt.want {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.want/2, tt.want)
+				}
+			}
+		})
+	}
+
+	if got := (RetryPolicy{InitialBackoff: time.Second}).backoff(100); got < time.Hour {
+		t.Errorf("backoff(100) without cap = %v, want it to keep growing without overflowing", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "2", want: 2 * time.Second, wantOK: true},
+		{value: now.Add(5 * time.Second).Format(http.TimeFormat), want: 5 * time.Second, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			h := http.Header{}
+			h.Set("Retry-After", tt.value)
+			got, ok := retryAfter(h, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    RateLimit
+		wantOK  bool
+	}{
+		{name: "none", wantOK: false},
+		{
+			name:    "unix reset",
+			headers: map[string]string{"X-RateLimit-Limit": "60", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1704110460"},
+			want:    RateLimit{Limit: 60, Reset: time.Unix(1704110460, 0)},
+			wantOK:  true,
+		},
+		{
+			name:    "seconds until reset",
+			headers: map[string]string{"X-RateLimit-Remaining": "12", "X-RateLimit-Reset": "30"},
+			want:    RateLimit{Remaining: 12, Reset: now.Add(30 * time.Second)},
+			wantOK:  true,
+		},
+		{name: "invalid", headers: map[string]string{"X-RateLimit-Reset": "soon"}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for key, value := range tt.headers {
+				h.Set(key, value)
+			}
+			got, ok := parseRateLimit(h, now)
+			if got.Limit != tt.want.Limit || got.Remaining != tt.want.Remaining || !got.Reset.Equal(tt.want.Reset) || ok != tt.wantOK {
+				t.Errorf("parseRateLimit() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		want    time.Duration
+		wantOK  bool
+	}{
+		{name: "retry after", status: 429, headers: map[string]string{"Retry-After": "5", "X-RateLimit-Reset": "30"}, want: 5 * time.Second, wantOK: true},
+		{name: "rate limit reset", status: 429, headers: map[string]string{"X-RateLimit-Reset": "30"}, want: 30 * time.Second, wantOK: true},
+		{name: "unavailable", status: 503, headers: map[string]string{"X-RateLimit-Reset": "1704110460"}, want: time.Minute, wantOK: true},
+		{name: "past reset", status: 429, headers: map[string]string{"X-RateLimit-Reset": "1704110000"}, want: 0, wantOK: true},
+		{name: "not rate limited", status: 500, headers: map[string]string{"X-RateLimit-Reset": "30"}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+			got, ok := retryDelay(resp, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryDelay() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClient_RetryRateLimitReset(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxRetryAfter:  2 * time.Second,
+		RetryStatuses:  []int{http.StatusTooManyRequests},
+	}))
+	client.baseURL = srv.URL
+
+	start := time.Now()
+	if _, err := client.SendEmail(context.Background(), &EmailRequest{To: Recipients{"ada@example.com"}}); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the rate limit reset of 1s", elapsed)
+	}
+}
+
+func TestClient_RetryAfterTooLong(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+		MaxRetryAfter:  time.Second,
+		RetryStatuses:  []int{http.StatusTooManyRequests},
+	}))
+	client.baseURL = srv.URL
+
+	start := time.Now()
+	_, err := client.SendEmail(context.Background(), &EmailRequest{To: Recipients{"ada@example.com"}})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("SendEmail() error = %v, want the 429 *APIError", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %v, want no wait", elapsed)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
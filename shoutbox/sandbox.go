@@ -0,0 +1,108 @@
+package shoutbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Validate reports the first problem that would make the API reject req
+func (req *EmailRequest) Validate() error {
+	if err := req.validateHeaders(); err != nil {
+		return err
+	}
+	if err := req.validateAddresses(); err != nil {
+		return err
+	}
+	return validateMessage(req.From, len(req.To)+len(req.Cc)+len(req.Bcc)+len(req.Personalizations), req.Subject, req.HTML+req.Text+req.MJML, req.TemplateID)
+}
+
+// Validate reports the first problem that would make the relay reject msg
+func (msg *EmailMessage) Validate() error {
+	if err := msg.validateHeaders(); err != nil {
+		return err
+	}
+	if err := msg.validateAddresses(); err != nil {
+		return err
+	}
+	body := msg.HTML + msg.Text + msg.MJML
+	if msg.Calendar != nil {
+		body += msg.Calendar.Summary
+	}
+	return validateMessage(msg.From, len(msg.recipients()), msg.Subject, body, "")
+}
+
+// validateAddresses validates the addresses of req; recipients may have
+// display names
+func (req *EmailRequest) validateAddresses() error {
+	if err := validateAddresses(req.From, req.ReplyTo, req.To, req.Cc, req.Bcc, false); err != nil {
+		return err
+	}
+	if req.ReturnPath != "" {
+		if err := validateAddress("return_path", req.ReturnPath, true); err != nil {
+			return err
+		}
+	}
+	for _, p := range req.Personalizations {
+		if err := validateAddress("personalizations", p.To, false); err != nil {
+			return err
+		}
+	}
+	if req.ReadReceiptTo != "" {
+		return validateAddress("read_receipt_to", req.ReadReceiptTo, false)
+	}
+	return nil
+}
+
+// validateAddresses validates the addresses of msg; the sender must be
+// bare since its display name is Name
+func (msg *EmailMessage) validateAddresses() error {
+	if err := validateAddresses(msg.From, msg.ReplyTo, msg.To, msg.Cc, msg.Bcc, true); err != nil {
+		return err
+	}
+	if msg.EnvelopeFrom != "" {
+		if err := validateAddress("envelope_from", msg.EnvelopeFrom, true); err != nil {
+			return err
+		}
+	}
+	if msg.ReadReceiptTo != "" {
+		return validateAddress("read_receipt_to", msg.ReadReceiptTo, false)
+	}
+	return nil
+}
+
+func validateMessage(from string, recipients int, subject, body, templateID string) error {
+	if from == "" {
+		return errors.New("missing sender")
+	}
+	if recipients == 0 {
+		return errors.New("missing recipients")
+	}
+	if templateID == "" && subject == "" {
+		return errors.New("missing subject")
+	}
+	if templateID == "" && body == "" {
+		return errors.New("missing body")
+	}
+	return nil
+}
+
+// sendSandboxed validates req and builds its payload without sending it
+func (c *Client) sendSandboxed(req *EmailRequest) (*SendResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("error validating message: %w", err)
+	}
+	if _, err := json.Marshal(c.newSendPayload(req)); err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+	id, err := randomKey()
+	if err != nil {
+		return nil, fmt.Errorf("error generating message ID: %w", err)
+	}
+	return &SendResponse{
+		MessageID: "sandbox-" + id,
+		Accepted:  slices.Concat(req.To, req.Cc, req.Bcc),
+		Metadata:  map[string]any{"sandbox": true},
+	}, nil
+}
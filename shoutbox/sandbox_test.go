@@ -0,0 +1,74 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEmailRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     EmailRequest
+		wantErr string
+	}{
+		{name: "valid", req: EmailRequest{From: "a@example.com", To: Recipients{"b@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}},
+		{name: "template", req: EmailRequest{From: "a@example.com", To: Recipients{"b@example.com"}, TemplateID: "tpl_welcome"}},
+		{name: "no sender", req: EmailRequest{To: Recipients{"b@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}, wantErr: "missing sender"},
+		{name: "no recipients", req: EmailRequest{From: "a@example.com", Subject: "Hi", HTML: "<p>Hi</p>"}, wantErr: "missing recipients"},
+		{name: "invalid bcc", req: EmailRequest{From: "a@example.com", To: Recipients{"b@example.com"}, Bcc: Recipients{"nobody"}, Subject: "Hi", Text: "Hi"}, wantErr: `invalid bcc address "nobody": missing '@' or angle-addr`},
+		{name: "no subject", req: EmailRequest{From: "a@example.com", To: Recipients{"b@example.com"}, HTML: "<p>Hi</p>"}, wantErr: "missing subject"},
+		{name: "no body", req: EmailRequest{From: "a@example.com", To: Recipients{"b@example.com"}, Subject: "Hi"}, wantErr: "missing body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Sandbox(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithSandbox())
+	client.baseURL = srv.URL
+
+	resp, err := client.SendEmail(context.Background(), &EmailRequest{
+		From: "a@example.com", To: Recipients{"b@example.com"}, Cc: Recipients{"c@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>",
+	})
+	if err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	if !strings.HasPrefix(resp.MessageID, "sandbox-") || len(resp.Accepted) != 2 {
+		t.Errorf("SendEmail() = %+v", resp)
+	}
+	if _, err := client.SendEmail(context.Background(), &EmailRequest{From: "a@example.com", Subject: "Hi"}); err == nil {
+		t.Error("SendEmail() of an invalid message succeeded")
+	}
+	if requests != 0 {
+		t.Errorf("sandbox made %d API requests", requests)
+	}
+}
+
+func TestSMTPClient_Sandbox(t *testing.T) {
+	client := &SMTPClient{Host: "127.0.0.1", Port: 1, Sandbox: true}
+	resp, err := client.Send(context.Background(), &EmailMessage{
+		From: "a@example.com", To: []string{"b@example.com"}, Subject: "Hi", Text: "Hi",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !strings.HasSuffix(resp.MessageID, "@example.com") || resp.Metadata["sandbox"] != true {
+		t.Errorf("Send() = %+v", resp)
+	}
+}
@@ -0,0 +1,110 @@
+package shoutbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scheduler sends messages at a later time from within the process, for
+// transports such as SMTP that have no server-side scheduling
+type Scheduler struct {
+	send SendFunc
+
+	// OnError is called when a scheduled send fails
+	OnError func(msg *EmailMessage, err error)
+	// QuietHours, when set, delays sends of the categories it applies to
+	// that would fall within the quiet window of the recipient's timezone
+	QuietHours *QuietHours
+
+	mu      sync.Mutex
+	pending map[*scheduledMessage]struct{}
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+type scheduledMessage struct {
+	msg   *EmailMessage
+	at    time.Time
+	timer *time.Timer
+}
+
+// NewScheduler creates a scheduler that delivers messages with send
+func NewScheduler(send SendFunc) *Scheduler {
+	return &Scheduler{
+		send:    send,
+		pending: make(map[*scheduledMessage]struct{}),
+	}
+}
+
+// Schedule sends msg at the given time, or immediately if it has passed.
+// It returns false if the scheduler has been stopped.
+func (s *Scheduler) Schedule(at time.Time, msg *EmailMessage) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return false
+	}
+
+	sm := &scheduledMessage{msg: msg, at: at}
+	s.pending[sm] = struct{}{}
+	s.wg.Add(1)
+	sm.timer = time.AfterFunc(time.Until(at), func() {
+		defer s.wg.Done()
+
+		s.mu.Lock()
+		_, ok := s.pending[sm]
+		delete(s.pending, sm)
+		s.mu.Unlock()
+		if !ok {
+			return
+		}
+
+		if err := s.send(context.Background(), msg); err != nil && s.OnError != nil {
+			s.OnError(msg, err)
+		}
+	})
+	return true
+}
+
+// ScheduleLocal is like Schedule but first moves at out of the quiet hours
+// of the recipient's IANA timezone tz, if they apply to msg's Category
+func (s *Scheduler) ScheduleLocal(at time.Time, tz string, msg *EmailMessage) bool {
+	if s.QuietHours != nil && s.QuietHours.Applies(msg.Category) {
+		at = s.QuietHours.Adjust(at, tz)
+	}
+	return s.Schedule(at, msg)
+}
+
+// Send sends msg now, or at the end of the quiet hours of the recipient's
+// IANA timezone tz
+func (s *Scheduler) Send(msg *EmailMessage, tz string) bool {
+	return s.ScheduleLocal(time.Now(), tz, msg)
+}
+
+// Pending returns the number of messages waiting to be sent
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Stop cancels all pending sends, waits for in-flight sends to finish and
+// returns the messages that were not sent so they can be persisted
+func (s *Scheduler) Stop() []*EmailMessage {
+	s.mu.Lock()
+	s.stopped = true
+	var unsent []*EmailMessage
+	for sm := range s.pending {
+		if sm.timer.Stop() {
+			s.wg.Done()
+		}
+		unsent = append(unsent, sm.msg)
+		delete(s.pending, sm)
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return unsent
+}
@@ -0,0 +1,40 @@
+package shoutbox
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	sent := make(chan *EmailMessage, 2)
+	s := NewScheduler(func(ctx context.Context, msg *EmailMessage) error {
+		sent <- msg
+		return nil
+	})
+
+	soon := &EmailMessage{Subject: "soon"}
+	later := &EmailMessage{Subject: "later"}
+	s.Schedule(time.Now().Add(10*time.Millisecond), soon)
+	s.Schedule(time.Now().Add(time.Hour), later)
+
+	select {
+	case msg := <-sent:
+		if msg != soon {
+			t.Errorf("sent %q, want %q", msg.Subject, soon.Subject)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("scheduled message not sent")
+	}
+
+	if n := s.Pending(); n != 1 {
+		t.Errorf("Pending() = %d, want 1", n)
+	}
+	unsent := s.Stop()
+	if len(unsent) != 1 || unsent[0] != later {
+		t.Errorf("Stop() = %v, want the later message", unsent)
+	}
+	if s.Schedule(time.Now(), soon) {
+		t.Errorf("Schedule() after Stop() = true")
+	}
+}
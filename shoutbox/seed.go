@@ -0,0 +1,36 @@
+package shoutbox
+
+import (
+	"maps"
+	"strings"
+)
+
+// headerOriginalTo records the intended recipients of a seed-list send
+const headerOriginalTo = "X-Shoutbox-Original-To"
+
+// SeedList redirects every send to a list of internal test inboxes, for
+// QA of a campaign before launch. Message content, including any
+// personalization, is sent unchanged apart from the subject prefix.
+type SeedList struct {
+	Addresses []string
+	// SubjectPrefix is prepended to the subject; defaults to "[TEST] "
+	SubjectPrefix string
+}
+
+func (s *SeedList) subject(subject string) string {
+	prefix := s.SubjectPrefix
+	if prefix == "" {
+		prefix = "[TEST] "
+	}
+	return prefix + subject
+}
+
+// headers returns a copy of headers recording the original recipients
+func (s *SeedList) headers(headers map[string]string, to []string) map[string]string {
+	h := maps.Clone(headers)
+	if h == nil {
+		h = make(map[string]string)
+	}
+	h[headerOriginalTo] = strings.Join(to, ", ")
+	return h
+}
@@ -0,0 +1,96 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClient_SeedList(t *testing.T) {
+	var mu sync.Mutex
+	var got []EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmailRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		got = append(got, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithSeedList(SeedList{Addresses: []string{"qa1@example.com", "qa2@example.com"}}))
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{From: "news@example.com", To: Recipients{"customer@example.com"}, Subject: "Spring sale", HTML: "<p>Hi Ada</p>"}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(got))
+	}
+	for i, seed := range []string{"qa1@example.com", "qa2@example.com"} {
+		if got[i].To.String() != seed || got[i].Subject != "[TEST] Spring sale" || got[i].HTML != req.HTML {
+			t.Errorf("request %d = %+v", i, got[i])
+		}
+		if got[i].Headers[headerOriginalTo] != "customer@example.com" {
+			t.Errorf("request %d headers = %v", i, got[i].Headers)
+		}
+	}
+	if req.Headers != nil || req.Subject != "Spring sale" {
+		t.Errorf("SendEmail() modified the request: %+v", req)
+	}
+}
+
+func TestClient_SeedListPersonalizations(t *testing.T) {
+	var got []json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithSeedList(SeedList{Addresses: []string{"qa@example.com"}}))
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{
+		From:    "news@example.com",
+		Subject: "Hi {{name}}",
+		HTML:    "<p>Hi {{name}}</p>",
+		Personalizations: []Personalization{
+			{To: "ada@example.com", Variables: map[string]any{"name": "Ada"}},
+			{To: "grace@example.com", Variables: map[string]any{"name": "Grace"}},
+		},
+	}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(got))
+	}
+	var sent EmailRequest
+	json.Unmarshal(got[0], &sent)
+	if len(sent.Personalizations) != 2 || len(sent.To) != 0 {
+		t.Errorf("sent %+v, want a personalized copy per recipient to the seed", sent)
+	}
+	for _, p := range sent.Personalizations {
+		if p.To != "qa@example.com" {
+			t.Errorf("personalization sent to %s, want the seed", p.To)
+		}
+	}
+	// The real recipients appear only in the original-recipients header
+	for _, address := range []string{"ada@example.com", "grace@example.com"} {
+		if !strings.Contains(sent.Headers[headerOriginalTo], address) {
+			t.Errorf("%s = %q, want it to list %s", headerOriginalTo, sent.Headers[headerOriginalTo], address)
+		}
+		if strings.Count(string(got[0]), address) != 1 {
+			t.Errorf("payload %s reaches %s", got[0], address)
+		}
+	}
+}
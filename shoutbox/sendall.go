@@ -0,0 +1,73 @@
+package shoutbox
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultSendAllConcurrency is the number of messages SendAll sends at once
+// unless set with Concurrency
+const DefaultSendAllConcurrency = 8
+
+// SendAllOption configures SendAll
+type SendAllOption func(*sendAllConfig)
+
+type sendAllConfig struct {
+	concurrency int
+}
+
+// Concurrency sets the number of messages SendAll sends at once
+func Concurrency(n int) SendAllOption {
+	return func(cfg *sendAllConfig) {
+		cfg.concurrency = n
+	}
+}
+
+// SendAll sends each message with SendEmail, several at once, and returns
+// a result per message, in the order of reqs. Unlike SendBatch, every
+// message is its own API call, so each gets the full send pipeline. When
+// ctx is done no further messages are started; their results hold the
+// context's error, which is also returned.
+func (c *Client) SendAll(ctx context.Context, reqs []*EmailRequest, opts ...SendAllOption) ([]SendResult, error) {
+	cfg := sendAllConfig{concurrency: DefaultSendAllConcurrency}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = DefaultSendAllConcurrency
+	}
+
+	results := newSendResults(len(reqs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(cfg.concurrency, len(reqs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i].set(c.SendEmail(ctx, reqs[i]))
+			}
+		}()
+	}
+
+	started := 0
+feed:
+	for started < len(reqs) && ctx.Err() == nil {
+		select {
+		case next <- started:
+			started++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil && started < len(reqs) {
+		for i := started; i < len(reqs); i++ {
+			results[i].Err = err
+		}
+		return results, err
+	}
+	return results, nil
+}
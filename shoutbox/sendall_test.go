@@ -0,0 +1,105 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SendAll(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var req EmailRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.To.String() == "bad@example.com" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		json.NewEncoder(w).Encode(SendResponse{MessageID: "msg_" + req.To.String()})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithoutRetries())
+	client.baseURL = srv.URL
+
+	var reqs []*EmailRequest
+	for i := range 10 {
+		to := fmt.Sprintf("user%d@example.com", i)
+		if i == 4 {
+			to = "bad@example.com"
+		}
+		reqs = append(reqs, &EmailRequest{From: "news@example.com", To: Recipients{to}, Subject: "Hi", HTML: "<p>Hi</p>"})
+	}
+
+	results, err := client.SendAll(context.Background(), reqs, Concurrency(3))
+	if err != nil {
+		t.Fatalf("SendAll() error = %v", err)
+	}
+	for i, result := range results {
+		if i == 4 {
+			var apiErr *APIError
+			if !errors.As(result.Err, &apiErr) {
+				t.Errorf("results[4].Err = %v, want *APIError", result.Err)
+			}
+			continue
+		}
+		if want := fmt.Sprintf("msg_user%d@example.com", i); result.Err != nil || result.Response.MessageID != want {
+			t.Errorf("results[%d] = %+v, want %s", i, result, want)
+		}
+	}
+	if n := maxInFlight.Load(); n != 3 {
+		t.Errorf("max concurrent sends = %d, want 3", n)
+	}
+}
+
+func TestClient_SendAllCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 2 {
+			cancel()
+		}
+		json.NewEncoder(w).Encode(SendResponse{MessageID: "msg-1"})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithoutRetries())
+	client.baseURL = srv.URL
+
+	reqs := make([]*EmailRequest, 10)
+	for i := range reqs {
+		reqs[i] = &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}
+	}
+
+	results, err := client.SendAll(ctx, reqs, Concurrency(1))
+	if err != context.Canceled {
+		t.Fatalf("SendAll() error = %v, want %v", err, context.Canceled)
+	}
+	if results[0].Err != nil {
+		t.Errorf("results[0].Err = %v, want the message sent", results[0].Err)
+	}
+	if !errors.Is(results[len(results)-1].Err, context.Canceled) {
+		t.Errorf("last result error = %v, want %v", results[len(results)-1].Err, context.Canceled)
+	}
+	if n := calls.Load(); n > 3 {
+		t.Errorf("calls = %d, want sending to stop after the cancellation", n)
+	}
+}
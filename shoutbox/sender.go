@@ -0,0 +1,121 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// EmailSender sends email messages. Client, SMTPClient and MockSender
+// implement it, so application code can depend on the interface and be
+// tested without network calls.
+type EmailSender interface {
+	Send(ctx context.Context, msg *EmailMessage) (*SendResponse, error)
+}
+
+var (
+	_ EmailSender = (*Client)(nil)
+	_ EmailSender = (*SMTPClient)(nil)
+	_ EmailSender = (*MockSender)(nil)
+)
+
+// ErrSMTPOnly is returned by Client.Send for messages that set fields
+// only the SMTP client supports, rather than sending them without
+var ErrSMTPOnly = errors.New("message field is only supported over SMTP")
+
+// Send sends msg using the Shoutbox API. Messages to be encrypted or with
+// DSN requests fail with ErrSMTPOnly; send them with an SMTPClient.
+func (c *Client) Send(ctx context.Context, msg *EmailMessage) (*SendResponse, error) {
+	if err := msg.checkAPI(); err != nil {
+		return nil, err
+	}
+	return c.SendEmail(ctx, msg.request())
+}
+
+// checkAPI returns an error if msg sets fields the API can't honor
+func (msg *EmailMessage) checkAPI() error {
+	switch {
+	case len(msg.EncryptTo) > 0:
+		return fmt.Errorf("%w: EncryptTo", ErrSMTPOnly)
+	case msg.DSN != nil:
+		return fmt.Errorf("%w: DSN", ErrSMTPOnly)
+	}
+	return nil
+}
+
+// request converts msg to the equivalent API request. A calendar invite
+// is sent as an attachment.
+func (msg *EmailMessage) request() *EmailRequest {
+	attachments := msg.Attachments
+	if msg.Calendar != nil {
+		attachments = append(slices.Clip(attachments), NewCalendarAttachment(msg.Calendar))
+	}
+	return &EmailRequest{
+		From:           msg.From,
+		To:             Recipients(msg.To),
+		Cc:             Recipients(msg.Cc),
+		Bcc:            Recipients(msg.Bcc),
+		Subject:        msg.Subject,
+		HTML:           msg.HTML,
+		Text:           msg.Text,
+		MJML:           msg.MJML,
+		Name:           msg.Name,
+		ReplyTo:        msg.ReplyTo,
+		Headers:        msg.Headers,
+		ReturnPath:     msg.EnvelopeFrom,
+		Attachments:    attachments,
+		TrackOpens:     msg.TrackOpens,
+		TrackClicks:    msg.TrackClicks,
+		TrackingDomain: msg.TrackingDomain,
+		Direction:      msg.Direction,
+		Category:       msg.Category,
+		Tags:           msg.Tags,
+		Metadata:       msg.Metadata,
+		MessageID:      msg.MessageID,
+		InReplyTo:      msg.InReplyTo,
+		References:     msg.References,
+		ReadReceiptTo:  msg.ReadReceiptTo,
+		Timeout:        msg.Timeout,
+	}
+}
+
+// MockSender is an EmailSender that records messages instead of sending
+// them. It is safe for concurrent use.
+type MockSender struct {
+	// Err, when set, is returned by Send and the message isn't recorded
+	Err error
+
+	mu       sync.Mutex
+	messages []*EmailMessage
+}
+
+// Send records msg
+func (m *MockSender) Send(ctx context.Context, msg *EmailMessage) (*SendResponse, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return &SendResponse{
+		MessageID: "mock-" + strconv.Itoa(len(m.messages)),
+		Accepted:  msg.recipients(),
+	}, nil
+}
+
+// Messages returns the recorded messages in the order they were sent
+func (m *MockSender) Messages() []*EmailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.messages)
+}
+
+// Reset discards the recorded messages
+func (m *MockSender) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
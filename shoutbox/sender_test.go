@@ -0,0 +1,97 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Send(t *testing.T) {
+	var got EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"message_id": "msg_1"}`))
+	}))
+	defer srv.Close()
+
+	var sender EmailSender = NewClient("test-key")
+	sender.(*Client).baseURL = srv.URL
+
+	resp, err := sender.Send(context.Background(), &EmailMessage{
+		From:    "sender@example.com",
+		To:      []string{"ada@example.com", "grace@example.com"},
+		Bcc:     []string{"archive@example.com"},
+		Subject: "Hello",
+		Text:    "Hi",
+
+		EnvelopeFrom: "bounces+ada@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.MessageID != "msg_1" {
+		t.Errorf("MessageID = %q", resp.MessageID)
+	}
+	if len(got.To) != 2 || got.Bcc.String() != "archive@example.com" || got.Text != "Hi" || got.ReturnPath != "bounces+ada@example.com" {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestClient_SendSMTPOnly(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	tests := []struct {
+		name string
+		msg  *EmailMessage
+	}{
+		{name: "encrypted", msg: &EmailMessage{EncryptTo: []*PGPKey{{}}}},
+		{name: "DSN", msg: &EmailMessage{DSN: &DSN{Notify: []DSNNotify{DSNFailure}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg.From, tt.msg.To, tt.msg.Subject, tt.msg.HTML = "news@example.com", []string{"ada@example.com"}, "Hi", "<p>Hi</p>"
+			if _, err := client.Send(context.Background(), tt.msg); !errors.Is(err, ErrSMTPOnly) {
+				t.Errorf("Send() error = %v, want %v", err, ErrSMTPOnly)
+			}
+		})
+	}
+	if calls != 0 {
+		t.Errorf("sent %d requests, want none", calls)
+	}
+}
+
+func TestMockSender(t *testing.T) {
+	mock := &MockSender{}
+	var sender EmailSender = mock
+
+	resp, err := sender.Send(context.Background(), &EmailMessage{To: []string{"ada@example.com"}, Subject: "Welcome"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.MessageID != "mock-1" || len(resp.Accepted) != 1 {
+		t.Errorf("Send() = %+v", resp)
+	}
+	if msgs := mock.Messages(); len(msgs) != 1 || msgs[0].Subject != "Welcome" {
+		t.Errorf("Messages() = %+v", msgs)
+	}
+
+	mock.Err = errors.New("boom")
+	if _, err := sender.Send(context.Background(), &EmailMessage{}); err != mock.Err {
+		t.Errorf("Send() error = %v, want %v", err, mock.Err)
+	}
+	mock.Reset()
+	if msgs := mock.Messages(); len(msgs) != 0 {
+		t.Errorf("Messages() after Reset = %d", len(msgs))
+	}
+}
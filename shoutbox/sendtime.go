@@ -0,0 +1,56 @@
+package shoutbox
+
+import "time"
+
+// SendTimeStrategy schedules messages to arrive at a fixed local time of
+// day in each recipient's timezone, e.g. 09:00
+type SendTimeStrategy struct {
+	Hour   int
+	Minute int
+	// DefaultLocation is used for recipients whose timezone is unknown or
+	// invalid; defaults to UTC
+	DefaultLocation *time.Location
+
+	now func() time.Time
+}
+
+// Next returns the next occurrence of the target time in the IANA timezone
+// tz, such as "Europe/Amsterdam"
+func (s SendTimeStrategy) Next(tz string) time.Time {
+	loc := s.location(tz)
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	local := now.In(loc)
+
+	next := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, s.Minute, 0, 0, loc)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, s.Hour, s.Minute, 0, 0, loc)
+	}
+	return next
+}
+
+// Apply sets req.SendAt so the API delivers it at the target local time
+func (s SendTimeStrategy) Apply(req *EmailRequest, tz string) {
+	at := s.Next(tz)
+	req.SendAt = &at
+}
+
+// Schedule queues msg on scheduler for the target local time, subject to
+// the scheduler's quiet hours
+func (s SendTimeStrategy) Schedule(scheduler *Scheduler, msg *EmailMessage, tz string) bool {
+	return scheduler.ScheduleLocal(s.Next(tz), tz, msg)
+}
+
+func (s SendTimeStrategy) location(tz string) *time.Location {
+	if tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+	}
+	if s.DefaultLocation != nil {
+		return s.DefaultLocation
+	}
+	return time.UTC
+}
@@ -0,0 +1,35 @@
+package shoutbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSendTimeStrategy_Next(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	s := SendTimeStrategy{Hour: 9, now: func() time.Time { return now }}
+
+	tests := []struct {
+		tz   string
+		want string
+	}{
+		{tz: "Asia/Tokyo", want: "2024-03-11T09:00:00+09:00"},
+		{tz: "America/Los_Angeles", want: "2024-03-10T09:00:00-07:00"},
+		{tz: "Pacific/Auckland", want: "2024-03-11T09:00:00+13:00"},
+		{tz: "Not/AZone", want: "2024-03-11T09:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tz, func(t *testing.T) {
+			if got := s.Next(tt.tz).Format(time.RFC3339); got != tt.want {
+				t.Errorf("Next() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	req := &EmailRequest{}
+	s.Apply(req, "Asia/Tokyo")
+	if req.SendAt == nil || req.SendAt.Format(time.RFC3339) != "2024-03-11T09:00:00+09:00" {
+		t.Errorf("Apply() SendAt = %v", req.SendAt)
+	}
+}
@@ -0,0 +1,215 @@
+package shoutbox
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"mime/multipart"
+	"slices"
+	"time"
+)
+
+// Object identifiers of the CMS structures and algorithms used for
+// signing, RFC 5652 and RFC 8551
+var (
+	oidData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// SMIMESigner signs messages with an S/MIME certificate, producing
+// multipart/signed messages with a detached SHA-256 signature (RFC 8551).
+// Set it as SMTPClient.SMIMESigner; the REST API builds messages on the
+// server, so it applies to SMTP sends only.
+type SMIMESigner struct {
+	cert  *x509.Certificate
+	key   crypto.Signer
+	chain []*x509.Certificate
+	now   func() time.Time
+}
+
+// NewSMIMESigner creates a signer for cert and its private key, which must
+// be an RSA or ECDSA key. chain holds intermediate certificates included
+// in signatures so recipients can verify cert.
+func NewSMIMESigner(cert *x509.Certificate, key crypto.Signer, chain ...*x509.Certificate) (*SMIMESigner, error) {
+	switch key.Public().(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, errors.New("S/MIME signing requires an RSA or ECDSA key")
+	}
+	if !publicKeyEqual(cert.PublicKey, key.Public()) {
+		return nil, errors.New("S/MIME key doesn't match the certificate")
+	}
+	return &SMIMESigner{cert: cert, key: key, chain: chain, now: time.Now}, nil
+}
+
+// LoadSMIMESigner creates a signer from PEM files holding the certificate,
+// followed by any intermediates, and the private key
+func LoadSMIMESigner(certFile, keyFile string) (*SMIMESigner, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading S/MIME certificate: %w", err)
+	}
+	var certs []*x509.Certificate
+	for _, der := range pair.Certificate {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing S/MIME certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("S/MIME key can't sign")
+	}
+	return NewSMIMESigner(certs[0], key, certs[1:]...)
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// wrap returns the Content-Type and body of a multipart/signed message
+// holding entity and its signature
+func (s *SMIMESigner) wrap(entity []byte) (string, []byte, error) {
+	signature, err := s.Sign(entity)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var body bytes.Buffer
+	boundary := multipart.NewWriter(nil).Boundary()
+	fmt.Fprintf(&body, "This is a cryptographically signed message in MIME format.\r\n\r\n--%s\r\n", boundary)
+	body.Write(entity)
+	fmt.Fprintf(&body, "\r\n--%s\r\n", boundary)
+	body.WriteString("Content-Type: application/pkcs7-signature; name=\"smime.p7s\"\r\n")
+	body.WriteString("Content-Transfer-Encoding: base64\r\n")
+	body.WriteString("Content-Disposition: attachment; filename=\"smime.p7s\"\r\n\r\n")
+	lines := &lineWriter{w: &body}
+	encoder := base64.NewEncoder(base64.StdEncoding, lines)
+	encoder.Write(signature)
+	encoder.Close()
+	lines.Close()
+	fmt.Fprintf(&body, "--%s--\r\n", boundary)
+
+	contentType := fmt.Sprintf(`multipart/signed; protocol="application/pkcs7-signature"; micalg=sha-256; boundary=%q`, boundary)
+	return contentType, body.Bytes(), nil
+}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []algorithmIdentifier `asn1:"set"`
+	EncapContentInfo contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    algorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm algorithmIdentifier
+	Signature          []byte
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []any `asn1:"set"`
+}
+
+// Sign returns a DER-encoded CMS SignedData structure holding a detached
+// signature of content
+func (s *SMIMESigner) Sign(content []byte) ([]byte, error) {
+	digest := sha256.Sum256(content)
+
+	// Signed attributes are a DER SET, so they are sorted by encoding
+	var attrs [][]byte
+	for _, attr := range []attribute{
+		{Type: oidContentType, Values: []any{oidData}},
+		{Type: oidMessageDigest, Values: []any{digest[:]}},
+		{Type: oidSigningTime, Values: []any{s.now().UTC()}},
+	} {
+		der, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding signed attributes: %w", err)
+		}
+		attrs = append(attrs, der)
+	}
+	slices.SortFunc(attrs, bytes.Compare)
+	attrsContent := bytes.Join(attrs, nil)
+
+	// The signature covers the attributes encoded as a SET
+	attrsSet, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrsContent})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding signed attributes: %w", err)
+	}
+	attrsDigest := sha256.Sum256(attrsSet)
+	signature, err := s.key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("error signing message: %w", err)
+	}
+
+	signatureAlgorithm := algorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	if _, ok := s.key.Public().(*rsa.PublicKey); ok {
+		signatureAlgorithm = algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	}
+
+	var certs []byte
+	for _, cert := range append([]*x509.Certificate{s.cert}, s.chain...) {
+		certs = append(certs, cert.Raw...)
+	}
+	sha256Algorithm := algorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	signed, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: []algorithmIdentifier{sha256Algorithm},
+		EncapContentInfo: contentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: s.cert.RawIssuer}, SerialNumber: s.cert.SerialNumber},
+			DigestAlgorithm:    sha256Algorithm,
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrsContent},
+			SignatureAlgorithm: signatureAlgorithm,
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding signature: %w", err)
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signed},
+	})
+}
@@ -0,0 +1,197 @@
+package shoutbox
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+	"time"
+)
+
+func testSMIMECert(t *testing.T, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(42),
+		Subject:        pkix.Name{CommonName: "sender@example.com"},
+		EmailAddresses: []string{"sender@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestNewSMIMESigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := testSMIMECert(t, rsaKey)
+
+	if _, err := NewSMIMESigner(cert, rsaKey); err != nil {
+		t.Errorf("NewSMIMESigner() error = %v", err)
+	}
+	if _, err := NewSMIMESigner(cert, otherKey); err == nil {
+		t.Error("NewSMIMESigner() with a mismatched key: expected error")
+	}
+}
+
+func TestSMTPClient_SMIMESigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  crypto.Signer
+	}{
+		{name: "rsa", key: rsaKey},
+		{name: "ecdsa", key: ecKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := testSMIMECert(t, tt.key)
+			signer, err := NewSMIMESigner(cert, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &SMTPClient{SMIMESigner: signer}
+			raw, err := client.buildMessage(&EmailMessage{
+				From:    "sender@example.com",
+				To:      []string{"recipient@example.com"},
+				Subject: "Signed",
+				HTML:    "<p>Hello</p>",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mediaType != "multipart/signed" || params["protocol"] != "application/pkcs7-signature" || params["micalg"] != "sha-256" {
+				t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+			}
+
+			// The first part is signed exactly as it appears in the message
+			body, _ := io.ReadAll(msg.Body)
+			delimiter := []byte("\r\n--" + params["boundary"])
+			start := bytes.Index(body, []byte("--"+params["boundary"]+"\r\n")) + len(params["boundary"]) + 4
+			entity := body[start : start+bytes.Index(body[start:], delimiter)]
+			if !bytes.HasPrefix(entity, []byte("Content-Type: multipart/mixed;")) {
+				t.Errorf("signed entity = %q", entity)
+			}
+
+			reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+			if _, err := reader.NextPart(); err != nil {
+				t.Fatal(err)
+			}
+			part, err := reader.NextRawPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := part.Header.Get("Content-Type"); got != `application/pkcs7-signature; name="smime.p7s"` {
+				t.Errorf("signature Content-Type = %q", got)
+			}
+			encoded, _ := io.ReadAll(part)
+			signature, err := base64.StdEncoding.DecodeString(string(encoded))
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifySMIMESignature(t, signature, entity, cert)
+		})
+	}
+}
+
+// verifySMIMESignature checks a detached CMS signature of content by cert
+func verifySMIMESignature(t *testing.T, der, content []byte, cert *x509.Certificate) {
+	t.Helper()
+	var info contentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		t.Fatalf("content type = %v", info.ContentType)
+	}
+	var signed signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signed); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(signed.Certificates.Bytes, cert.Raw) {
+		t.Error("signature doesn't include the certificate")
+	}
+	signer := signed.SignerInfos[0]
+	if signer.SID.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("serial number = %v", signer.SID.SerialNumber)
+	}
+
+	var attrs []struct {
+		Type   asn1.ObjectIdentifier
+		Values asn1.RawValue
+	}
+	if _, err := asn1.UnmarshalWithParams(signer.SignedAttrs.FullBytes, &attrs, "set,tag:0"); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(content)
+	found := false
+	for _, attr := range attrs {
+		if attr.Type.Equal(oidMessageDigest) {
+			var value []byte
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &value); err != nil {
+				t.Fatal(err)
+			}
+			found = bytes.Equal(value, digest[:])
+		}
+	}
+	if !found {
+		t.Error("message digest doesn't match the signed content")
+	}
+
+	// The signature covers the attributes encoded as a SET
+	signedAttrs := append([]byte{}, signer.SignedAttrs.FullBytes...)
+	signedAttrs[0] = 0x31
+	algorithm := x509.SHA256WithRSA
+	if signer.SignatureAlgorithm.Algorithm.Equal(oidECDSAWithSHA256) {
+		algorithm = x509.ECDSAWithSHA256
+	}
+	if err := cert.CheckSignature(algorithm, signedAttrs, signer.Signature); err != nil {
+		t.Errorf("CheckSignature() error = %v", err)
+	}
+}
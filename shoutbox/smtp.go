@@ -1,13 +1,58 @@
 package shoutbox
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
 	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
 	"net/smtp"
 	"net/textproto"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+)
+
+// Headers understood by the Shoutbox SMTP relay
+const (
+	headerTrackOpens  = "X-Shoutbox-Track-Opens"
+	headerTrackClicks = "X-Shoutbox-Track-Clicks"
+	headerTrackDomain = "X-Shoutbox-Tracking-Domain"
+	headerTags        = "X-Shoutbox-Tags"
+	headerMetadata    = "X-Shoutbox-Metadata"
+)
+
+// ErrSMTPUTF8Unsupported is returned when an address has a non-ASCII
+// local part, e.g. "müller@example.com", and the server doesn't support
+// SMTPUTF8
+var ErrSMTPUTF8Unsupported = errors.New("smtp: server doesn't support SMTPUTF8")
+
+// ErrTLSRequired is returned when RequireTLS is set and the server doesn't
+// offer STARTTLS
+var ErrTLSRequired = errors.New("smtp: server doesn't support STARTTLS")
+
+// Ports of the Shoutbox SMTP relay
+const (
+	// SMTPSubmissionPort is the default port, upgraded with STARTTLS
+	SMTPSubmissionPort = 587
+	// SMTPSPort is the port of SMTP over implicit TLS
+	SMTPSPort = 465
+	// SMTPAlternatePort accepts STARTTLS like SMTPSubmissionPort, for
+	// networks that block 587
+	SMTPAlternatePort = 2525
 )
 
 // SMTPClient represents a Shoutbox SMTP client
@@ -17,115 +62,1105 @@ type SMTPClient struct {
 	Username string
 	Password string
 	Auth     smtp.Auth
+
+	// ImplicitTLS dials a TLS connection directly instead of upgrading
+	// with STARTTLS. It is implied when Port is SMTPSPort.
+	ImplicitTLS bool
+	// TLSConfig, when set, configures TLS for both STARTTLS and implicit
+	// TLS, e.g. RootCAs to verify the server against a custom pool or
+	// CipherSuites. ServerName defaults to Host and MinVersion to TLS 1.2.
+	TLSConfig *tls.Config
+	// RequireTLS fails sends with ErrTLSRequired when the server doesn't
+	// offer STARTTLS instead of continuing unencrypted. NewSMTPClient sets
+	// it.
+	RequireTLS bool
+	// Proxy, when set, connects through a SOCKS5 proxy (socks5://) or an
+	// HTTP proxy supporting CONNECT (http:// or https://). Credentials are
+	// taken from the URL.
+	Proxy *url.URL
+
+	// Environment marks messages as test traffic when it is Test. Host is
+	// derived from it by NewSMTPClient; use SMTPHost when changing it.
+	Environment Environment
+
+	// TrackOpens and TrackClicks set the default tracking for messages
+	// that don't set it themselves. Leave nil to use the account default.
+	TrackOpens  *bool
+	TrackClicks *bool
+	// TrackingDomain is a custom domain used for tracking links
+	TrackingDomain string
+	// UTM, when set, is appended to every link in the HTML body
+	UTM *UTMParams
+
+	// AttachmentStore, when set, receives attachments larger than
+	// OffloadThreshold bytes, which are replaced by download links
+	AttachmentStore  AttachmentStore
+	OffloadThreshold int
+	// ImageOptimizer, when set, downscales and re-encodes inline images
+	ImageOptimizer *ImageOptimizer
+	// MJMLCompiler, when set, compiles the MJML body of messages
+	MJMLCompiler MJMLCompiler
+	// DarkMode, when set, injects dark-mode meta tags and styles
+	DarkMode *DarkModeOptions
+	// EmojiShortcodes expands :shortcode: emoji in the subject and body
+	EmojiShortcodes bool
+	// SMIMESigner, when set, signs every message with S/MIME
+	SMIMESigner *SMIMESigner
+	// PGPSigner, when set, signs every message with OpenPGP. It can't be
+	// combined with SMIMESigner.
+	PGPSigner *PGPSigner
+	// DKIMSigner, when set, adds a DKIM signature of your own domain to
+	// every message
+	DKIMSigner *DKIMSigner
+	// SpamChecker, when set, scores every message before it is sent;
+	// messages scoring SpamThreshold or more are rejected with a
+	// *SpamError. SpamThreshold defaults to DefaultSpamThreshold.
+	// Sandboxed clients skip the check.
+	SpamChecker   SpamChecker
+	SpamThreshold float64
+	// SeedList, when set, redirects every send to the seed addresses
+	SeedList *SeedList
+	// Preferences, when set, removes recipients who opted out of the
+	// message's Category
+	Preferences PreferenceChecker
+	// RateLimiter, when set, limits how fast messages are sent
+	RateLimiter *RateLimiter
+	// SizeLimits overrides the default attachment and message size limits
+	// checked before sending
+	SizeLimits SizeLimits
+	// Pool, when set, keeps connections open and reuses them across sends
+	Pool *SMTPPool
+	// ConnectTimeout limits connecting to the relay, including the TLS
+	// handshake and authentication
+	ConnectTimeout time.Duration
+	// Timeout limits each send, from connecting to the end of the SMTP
+	// transaction. EmailMessage.Timeout overrides it. The deadline of the
+	// send's context applies too, and cancelling it aborts the send.
+	Timeout time.Duration
+	// Sandbox validates and builds every message but doesn't connect to
+	// the relay
+	Sandbox bool
+	// Tracer, when set, records a span for every SMTP transaction
+	Tracer Tracer
+	// Logger, when set, receives send attempts and failures at LogLevel
+	// or above. Password is redacted from logged values.
+	Logger   Logger
+	LogLevel slog.Level
 }
 
-// NewSMTPClient creates a new Shoutbox SMTP client
+// SMTPConfig configures the relay an SMTPClient connects to. Zero fields
+// use the Shoutbox relay defaults.
+type SMTPConfig struct {
+	// Host defaults to the relay of the environment named by SHOUTBOX_ENV
+	Host string
+	// Port defaults to SMTPSubmissionPort. SMTPSPort uses implicit TLS.
+	Port int
+	// Username defaults to "shoutbox"
+	Username string
+	// Password is the API key for the Shoutbox relay
+	Password string
+	// Auth defaults to PLAIN authentication with Username and Password
+	// when Password is set. Without either, the client doesn't
+	// authenticate, e.g. for an internal relay that trusts the network.
+	Auth smtp.Auth
+	// TLS configures certificate verification, versions and cipher suites
+	TLS *tls.Config
+	// AllowPlaintext sends without TLS when the server doesn't offer
+	// STARTTLS
+	AllowPlaintext bool
+	// Proxy connects through a SOCKS5 or HTTP CONNECT proxy
+	Proxy *url.URL
+}
+
+// NewSMTPClient creates a new Shoutbox SMTP client for the environment
+// named by SHOUTBOX_ENV
 func NewSMTPClient(apiKey string) *SMTPClient {
-	host := "mail.shoutbox.net"
-	return &SMTPClient{
-		Host:     host,
-		Port:     587,
-		Username: "shoutbox",
-		Password: apiKey,
-		Auth:     smtp.PlainAuth("", "shoutbox", apiKey, host),
+	return NewSMTPClientWithConfig(SMTPConfig{Password: apiKey})
+}
+
+// NewSMTPClientWithConfig creates an SMTP client for the relay described by
+// config, e.g. an internal relay or port SMTPAlternatePort
+func NewSMTPClientWithConfig(config SMTPConfig) *SMTPClient {
+	env := EnvironmentFromEnv()
+	c := &SMTPClient{
+		Host:        config.Host,
+		Port:        config.Port,
+		Username:    config.Username,
+		Password:    config.Password,
+		Auth:        config.Auth,
+		TLSConfig:   config.TLS,
+		RequireTLS:  !config.AllowPlaintext,
+		Proxy:       config.Proxy,
+		Environment: env,
+	}
+	if c.Host == "" {
+		c.Host = env.SMTPHost()
+	}
+	if c.Port == 0 {
+		c.Port = SMTPSubmissionPort
+	}
+	if c.Username == "" {
+		c.Username = "shoutbox"
 	}
+	if c.Auth == nil && c.Password != "" {
+		c.Auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+	return c
 }
 
 // Attachment represents an email attachment
 type Attachment struct {
-	Filename    string
-	Content     []byte
-	ContentType string
+	Filename string `json:"filename"`
+	// Content is base64-encoded in REST requests
+	Content     []byte `json:"content"`
+	ContentType string `json:"content_type"`
+
+	// ContentID lets the HTML body reference the attachment as
+	// cid:ContentID. Inline attachments are displayed within the body
+	// instead of being listed as files.
+	ContentID string `json:"content_id,omitempty"`
+	Inline    bool   `json:"inline,omitempty"`
+
+	// Open, when set, streams the content instead of Content. The SMTP
+	// client reads and encodes it while writing the message, so large
+	// files aren't held in memory. See NewStreamingAttachment.
+	Open func() (io.ReadCloser, error) `json:"-"`
+	// Size is the length of the streamed content, if known, used to
+	// enforce size limits before sending
+	Size int `json:"-"`
+
+	// UploadID references content uploaded with UploadsService, sent
+	// instead of Content by the REST client
+	UploadID string `json:"upload_id,omitempty"`
+
+	// Compress, when set, compresses the content before sending, e.g. for
+	// log bundles and CSV exports. The filename and content type are
+	// changed to match, e.g. "export.csv" is sent as "export.csv.zip".
+	Compress AttachmentCompression `json:"-"`
 }
 
 // EmailMessage represents an email message for SMTP
 type EmailMessage struct {
-	From        string
-	To          []string
-	Subject     string
-	HTML        string
+	From string
+	To   []string
+	Cc   []string
+	// Bcc recipients receive the message but aren't listed in its headers
+	Bcc     []string
+	Subject string
+	HTML    string
+	// Text is an optional plain-text version of HTML
+	Text        string
 	Name        string
 	ReplyTo     string
 	Attachments []Attachment
 	Headers     map[string]string
+
+	// EnvelopeFrom, when set, is the envelope sender (MAIL FROM) bounces
+	// go to instead of From, e.g. a VERP bounce address
+	EnvelopeFrom string
+
+	// MJML, when set, is compiled by the client's MJMLCompiler into the
+	// HTML body, and the plain-text body if Text is empty
+	MJML string
+
+	// TrackOpens and TrackClicks override the account tracking settings for
+	// this message. Leave nil to use the account default.
+	TrackOpens  *bool
+	TrackClicks *bool
+	// TrackingDomain overrides the client's tracking domain for this
+	// message
+	TrackingDomain string
+
+	// Direction sets the text direction of the HTML body, e.g. RTL for
+	// Arabic or Hebrew content
+	Direction Direction
+
+	// Category is the subscription category of the message, e.g.
+	// "newsletter", used for preference-center opt-outs
+	Category string
+
+	// Tags categorize the message in stats and events, e.g. "welcome".
+	// Metadata correlates it with internal entities, e.g. an order ID;
+	// both are echoed back in webhook events.
+	Tags     []string
+	Metadata map[string]string
+
+	// MessageID sets the Message-ID, e.g. "order-1234@yourdomain.com", so
+	// later messages can reply to this one. The SMTP client generates one
+	// when empty. InReplyTo and References thread the message with earlier
+	// ones; see SetParent.
+	MessageID  string
+	InReplyTo  string
+	References []string
+
+	// DSN requests delivery status notifications from the servers along
+	// the delivery path. ReadReceiptTo asks the recipient's mail client to
+	// send a read receipt to the address (Disposition-Notification-To);
+	// clients may ignore it or ask the recipient first.
+	DSN           *DSN
+	ReadReceiptTo string
+
+	// Calendar, when set, is sent as a meeting invitation alongside the body
+	Calendar *CalendarEvent
+
+	// EncryptTo, when set, encrypts the body and attachments with PGP/MIME
+	// to each of the keys, typically one per recipient. Headers, including
+	// the subject, are not encrypted. It applies to SMTP sends only.
+	EncryptTo []*PGPKey
+
+	// Timeout, when set, limits this send instead of SMTPClient.Timeout,
+	// or the client's timeout on the REST API
+	Timeout time.Duration
 }
 
 // SendEmail sends an email using SMTP
 func (c *SMTPClient) SendEmail(msg *EmailMessage) error {
-	buffer := &bytes.Buffer{}
+	_, err := c.Send(context.Background(), msg)
+	return err
+}
+
+// Send sends an email using SMTP. A Message-ID header is added unless msg
+// sets one, and returned in the response.
+func (c *SMTPClient) Send(ctx context.Context, msg *EmailMessage) (*SendResponse, error) {
+	msg, messageID, err := c.prepare(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Sandbox {
+		if err := msg.Validate(); err != nil {
+			return nil, fmt.Errorf("error validating message: %w", err)
+		}
+		if err := c.writeMessage(io.Discard, msg); err != nil {
+			return nil, err
+		}
+		return &SendResponse{
+			MessageID: strings.Trim(messageID, "<>"),
+			Accepted:  msg.recipients(),
+			Metadata:  map[string]any{"sandbox": true},
+		}, nil
+	}
+
+	write := func(w io.Writer) error {
+		return c.writeMessage(w, msg)
+	}
+	if c.SpamChecker != nil {
+		// The checked rendering is sent, as boundaries and signatures
+		// differ between renderings
+		data, err := c.buildMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		report, err := c.SpamChecker.CheckSpam(ctx, data)
+		if err != nil {
+			return nil, fmt.Errorf("error checking spam score: %w", err)
+		}
+		threshold := c.SpamThreshold
+		if threshold == 0 {
+			threshold = DefaultSpamThreshold
+		}
+		if err := rejectSpam(report, threshold); err != nil {
+			return nil, err
+		}
+		write = func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}
+	}
+	if err := c.submit(ctx, messageID, msg.envelopeFrom(), msg.recipients(), msg.DSN, msg.Timeout, write); err != nil {
+		return nil, err
+	}
+	return &SendResponse{
+		MessageID: strings.Trim(messageID, "<>"),
+		Accepted:  msg.recipients(),
+	}, nil
+}
+
+// envelopeFrom returns the envelope sender of msg
+func (msg *EmailMessage) envelopeFrom() string {
+	if msg.EnvelopeFrom != "" {
+		return msg.EnvelopeFrom
+	}
+	return msg.From
+}
+
+// submit sends the message written by write to recipients, waiting for
+// the rate limiter and recording the transaction in logs and traces. A
+// positive timeout overrides c.Timeout.
+func (c *SMTPClient) submit(ctx context.Context, messageID, from string, to []string, dsn *DSN, timeout time.Duration, write func(io.Writer) error) error {
+	if c.RateLimiter != nil {
+		if err := c.RateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Domains are sent as punycode; non-ASCII local parts need SMTPUTF8
+	from = asciiAddress(from)
+	recipients := make([]string, len(to))
+	for i, rcpt := range to {
+		recipients[i] = envelopeAddress(rcpt)
+	}
+	log := eventLogger{logger: c.Logger, level: c.LogLevel, secret: c.Password}
+	log.log(ctx, slog.LevelDebug, "shoutbox: sending message", "host", c.Host, "recipients", len(recipients))
+	_, span := startSpan(ctx, c.Tracer, "shoutbox smtp send")
+	defer span.End()
+	span.SetAttributes(Attribute{AttrServerAddress, c.Host}, Attribute{AttrRecipients, len(recipients)})
+	var size int
+	counted := func(w io.Writer) error {
+		counter := &countingWriter{w: w}
+		defer func() { size = counter.n }()
+		return write(counter)
+	}
+	if timeout <= 0 {
+		timeout = c.Timeout
+	}
+	err := c.sendMail(ctx, timeout, from, recipients, dsn, counted)
+	span.SetAttributes(Attribute{AttrMessageSize, size})
+	if status, ok := smtpStatus(err); ok {
+		span.SetAttributes(Attribute{AttrSMTPStatusCode, status})
+	}
+	if err != nil {
+		span.RecordError(err)
+		log.log(ctx, slog.LevelError, "shoutbox: send failed", "host", c.Host, "recipients", len(recipients), "error", err)
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	log.log(ctx, slog.LevelDebug, "shoutbox: message sent", "host", c.Host, "message_id", strings.Trim(messageID, "<>"), "size", size)
+	return nil
+}
+
+// prepare applies the client's preference checks, seed list and
+// attachment handling to msg and adds a Message-ID header if it has none,
+// returning a copy if anything changed and the message ID
+func (c *SMTPClient) prepare(ctx context.Context, msg *EmailMessage) (*EmailMessage, string, error) {
+	if err := msg.validateHeaders(); err != nil {
+		return nil, "", err
+	}
+	if err := msg.validateAddresses(); err != nil {
+		return nil, "", err
+	}
+	if msg.DSN != nil {
+		if err := msg.DSN.validate(); err != nil {
+			return nil, "", err
+		}
+	}
+	if _, err := c.entityWrapper(msg); err != nil {
+		return nil, "", err
+	}
+	if c.Preferences != nil && msg.Category != "" {
+		filtered := *msg
+		for _, list := range []*[]string{&filtered.To, &filtered.Cc, &filtered.Bcc} {
+			allowed, err := filterRecipients(ctx, c.Preferences, msg.Category, *list)
+			if err != nil {
+				return nil, "", fmt.Errorf("error checking preferences: %w", err)
+			}
+			*list = allowed
+		}
+		if len(filtered.To)+len(filtered.Cc)+len(filtered.Bcc) == 0 {
+			return nil, "", ErrRecipientOptedOut
+		}
+		msg = &filtered
+	}
+
+	if c.SeedList != nil {
+		seeded := *msg
+		seeded.To = c.SeedList.Addresses
+		seeded.Cc, seeded.Bcc = nil, nil
+		seeded.Subject = c.SeedList.subject(msg.Subject)
+		seeded.Headers = c.SeedList.headers(msg.Headers, msg.recipients())
+		msg = &seeded
+	}
+
+	if msg.MJML != "" {
+		compiled := *msg
+		if err := compileMJML(ctx, c.MJMLCompiler, msg.MJML, &compiled.HTML, &compiled.Text); err != nil {
+			return nil, "", err
+		}
+		msg = &compiled
+	}
+
+	if slices.ContainsFunc(msg.Attachments, func(a Attachment) bool { return a.Compress != "" }) {
+		compressed := *msg
+		var err error
+		if compressed.Attachments, err = compressAttachments(msg.Attachments); err != nil {
+			return nil, "", err
+		}
+		msg = &compressed
+	}
+
+	if c.AttachmentStore != nil || c.ImageOptimizer != nil {
+		prepared := *msg
+		if c.AttachmentStore != nil {
+			if err := OffloadAttachments(ctx, c.AttachmentStore, c.OffloadThreshold, &prepared); err != nil {
+				return nil, "", err
+			}
+		}
+		if c.ImageOptimizer != nil {
+			if err := c.ImageOptimizer.OptimizeMessage(&prepared); err != nil {
+				return nil, "", err
+			}
+		}
+		msg = &prepared
+	}
+
+	if err := c.SizeLimits.check(msg.Attachments, len(msg.HTML)+len(msg.Text)); err != nil {
+		return nil, "", err
+	}
+
+	messageID := headerValue(msg.Headers, headerMessageID)
+	if msg.MessageID != "" {
+		messageID = formatMessageID(msg.MessageID)
+	}
+	if messageID == "" {
+		var err error
+		if messageID, err = newMessageID(msg.From); err != nil {
+			return nil, "", fmt.Errorf("error generating message ID: %w", err)
+		}
+		withID := *msg
+		withID.Headers = maps.Clone(msg.Headers)
+		if withID.Headers == nil {
+			withID.Headers = make(map[string]string)
+		}
+		withID.Headers[headerMessageID] = messageID
+		msg = &withID
+	}
+	return msg, messageID, nil
+}
+
+// buildMessage renders msg as an RFC 5322 message
+func (c *SMTPClient) buildMessage(msg *EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.writeMessage(&buf, msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// entityWrapper signs or encrypts the body of a message as a MIME entity
+type entityWrapper interface {
+	// wrap returns the Content-Type and body of the message holding entity
+	wrap(entity []byte) (string, []byte, error)
+}
+
+// entityWrapper returns the wrapper that signs or encrypts msg, or nil if
+// it is sent as is
+func (c *SMTPClient) entityWrapper(msg *EmailMessage) (entityWrapper, error) {
+	pgp := c.PGPSigner != nil || len(msg.EncryptTo) > 0
+	switch {
+	case c.SMIMESigner != nil && pgp:
+		return nil, errors.New("S/MIME and OpenPGP can't be combined")
+	case c.SMIMESigner != nil:
+		return c.SMIMESigner, nil
+	case pgp:
+		return pgpWrapper{signer: c.PGPSigner, recipients: msg.EncryptTo}, nil
+	}
+	return nil, nil
+}
+
+// writeMessage writes msg as an RFC 5322 message to w. Streamed
+// attachments are read and encoded as they are written, unless the
+// message is signed or encrypted.
+func (c *SMTPClient) writeMessage(w io.Writer, msg *EmailMessage) error {
+	if c.DKIMSigner == nil {
+		return c.writeMIME(w, msg)
+	}
+	// DKIM signs the final message, so it is rendered first
+	var buf bytes.Buffer
+	if err := c.writeMIME(&buf, msg); err != nil {
+		return err
+	}
+	signed, err := c.DKIMSigner.Sign(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(signed)
+	return err
+}
+
+// writeMIME writes msg as an RFC 5322 message to w, signed or encrypted
+// by the client's S/MIME or OpenPGP settings
+func (c *SMTPClient) writeMIME(w io.Writer, msg *EmailMessage) error {
+	headers, err := c.messageHeaders(msg)
+	if err != nil {
+		return err
+	}
+	wrapper, err := c.entityWrapper(msg)
+	if err != nil {
+		return err
+	}
+	buffer := bufio.NewWriter(w)
+
+	if wrapper != nil {
+		// The signature covers the whole body, so it is rendered first
+		var entity bytes.Buffer
+		if err := c.writeEntity(&entity, msg); err != nil {
+			return err
+		}
+		contentType, wrapped, err := wrapper.wrap(entity.Bytes())
+		if err != nil {
+			return err
+		}
+		headers.Set("Content-Type", contentType)
+		writeHeaders(buffer, headers)
+		buffer.Write(wrapped)
+		return buffer.Flush()
+	}
+
 	writer := multipart.NewWriter(buffer)
+	headers.Set("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%s", writer.Boundary()))
+	writeHeaders(buffer, headers)
+	if err := c.writeBody(writer, msg); err != nil {
+		return err
+	}
+	return buffer.Flush()
+}
 
-	// Add headers
+// messageHeaders returns the headers of msg other than Content-Type
+func (c *SMTPClient) messageHeaders(msg *EmailMessage) (textproto.MIMEHeader, error) {
 	headers := textproto.MIMEHeader{}
 	headers.Set("From", formatAddress(msg.From, msg.Name))
-	headers.Set("To", strings.Join(msg.To, ", "))
-	headers.Set("Subject", msg.Subject)
+	headers.Set("To", formatAddressList(msg.To))
+	if len(msg.Cc) > 0 {
+		headers.Set("Cc", formatAddressList(msg.Cc))
+	}
+	subject := msg.Subject
+	if c.EmojiShortcodes {
+		subject = ExpandEmoji(subject)
+	}
+	headers.Set("Subject", mime.QEncoding.Encode("UTF-8", subject))
 	headers.Set("MIME-Version", "1.0")
-	headers.Set("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%s", writer.Boundary()))
 
 	if msg.ReplyTo != "" {
 		headers.Set("Reply-To", msg.ReplyTo)
 	}
+	if msg.ReadReceiptTo != "" {
+		headers.Set(headerReadReceiptTo, msg.ReadReceiptTo)
+	}
+
+	if msg.Category != "" {
+		headers.Set(headerCategory, msg.Category)
+	}
+	if len(msg.Tags) > 0 {
+		headers.Set(headerTags, strings.Join(msg.Tags, ", "))
+	}
+	if len(msg.Metadata) > 0 {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding metadata: %w", err)
+		}
+		headers.Set(headerMetadata, mime.QEncoding.Encode("UTF-8", string(metadata)))
+	}
 
-	// Add custom headers
-	for key, value := range msg.Headers {
+	// Add tracking settings
+	if trackOpens := firstBool(msg.TrackOpens, c.TrackOpens); trackOpens != nil {
+		headers.Set(headerTrackOpens, strconv.FormatBool(*trackOpens))
+	}
+	if trackClicks := firstBool(msg.TrackClicks, c.TrackClicks); trackClicks != nil {
+		headers.Set(headerTrackClicks, strconv.FormatBool(*trackClicks))
+	}
+	if msg.TrackingDomain != "" {
+		headers.Set(headerTrackDomain, msg.TrackingDomain)
+	} else if c.TrackingDomain != "" {
+		headers.Set(headerTrackDomain, c.TrackingDomain)
+	}
+	if c.Environment.IsTest() {
+		headers.Set(headerTest, "true")
+	}
+
+	// Add custom headers, then the threading fields, which replace them
+	for key, value := range threadHeaders(msg.Headers, msg.MessageID, msg.InReplyTo, msg.References) {
 		headers.Set(key, value)
 	}
 
-	// Write headers
-	for key, values := range headers {
-		for _, value := range values {
-			fmt.Fprintf(buffer, "%s: %s\r\n", key, value)
+	return headers, nil
+}
+
+// writeHeaders writes headers followed by the blank line ending them,
+// sorted so the output is stable
+func writeHeaders(w *bufio.Writer, headers textproto.MIMEHeader) {
+	for _, key := range slices.Sorted(maps.Keys(headers)) {
+		for _, value := range headers[key] {
+			fmt.Fprintf(w, "%s: %s\r\n", key, value)
+		}
+	}
+	w.WriteString("\r\n")
+}
+
+// writeEntity writes the body of msg as a MIME entity with its own
+// Content-Type header, as it is signed or encrypted
+func (c *SMTPClient) writeEntity(w io.Writer, msg *EmailMessage) error {
+	writer := multipart.NewWriter(w)
+	if _, err := fmt.Fprintf(w, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary()); err != nil {
+		return err
+	}
+	return c.writeBody(writer, msg)
+}
+
+// writeBody writes the parts of msg to writer and closes it
+func (c *SMTPClient) writeBody(writer *multipart.Writer, msg *EmailMessage) error {
+	body := msg.HTML
+	if c.EmojiShortcodes {
+		body = ExpandEmoji(body)
+	}
+	body = ApplyDirection(body, msg.Direction)
+	if c.UTM != nil {
+		body = AppendUTM(body, c.UTM.forTags(msg.Tags))
+	}
+	if c.DarkMode != nil {
+		body = ApplyDarkMode(body, *c.DarkMode)
+	}
+
+	text := msg.Text
+	if c.EmojiShortcodes {
+		text = ExpandEmoji(text)
+	}
+
+	// Inline attachments referenced by cid: go in multipart/related with
+	// the body; other attachments follow in multipart/mixed
+	var inline, attached []Attachment
+	for _, attachment := range msg.Attachments {
+		if attachment.Inline && attachment.ContentID != "" {
+			inline = append(inline, attachment)
+		} else {
+			attached = append(attached, attachment)
 		}
 	}
-	buffer.WriteString("\r\n")
 
-	// Add HTML part
-	htmlPart, err := writer.CreatePart(textproto.MIMEHeader{
-		"Content-Type":              {"text/html; charset=UTF-8"},
-		"Content-Transfer-Encoding": {"quoted-printable"},
-	})
+	// writeBody adds the HTML part, wrapped in multipart/alternative with
+	// the plain-text version and calendar invite, if any
+	bodyType := "text/html"
+	if text != "" || msg.Calendar != nil {
+		bodyType = "multipart/alternative"
+	}
+	writeBody := func(w *multipart.Writer) error {
+		if bodyType == "text/html" {
+			return writeTextPart(w, "text/html", body)
+		}
+		return writeMultipart(w, "multipart/alternative", func(alternative *multipart.Writer) error {
+			if text != "" {
+				if err := writeTextPart(alternative, "text/plain", text); err != nil {
+					return err
+				}
+			}
+			if err := writeTextPart(alternative, "text/html", body); err != nil {
+				return err
+			}
+			if msg.Calendar != nil {
+				return writeTextPart(alternative, "text/calendar; method="+msg.Calendar.method(), string(msg.Calendar.ICS()))
+			}
+			return nil
+		})
+	}
+
+	var err error
+	if len(inline) > 0 {
+		err = writeMultipart(writer, fmt.Sprintf("multipart/related; type=%q", bodyType), func(related *multipart.Writer) error {
+			if err := writeBody(related); err != nil {
+				return err
+			}
+			for _, attachment := range inline {
+				if err := writeAttachment(related, attachment); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	} else {
+		err = writeBody(writer)
+	}
 	if err != nil {
-		return fmt.Errorf("error creating HTML part: %w", err)
+		return err
 	}
-	htmlPart.Write([]byte(msg.HTML))
 
 	// Add attachments
-	for _, attachment := range msg.Attachments {
-		part, err := writer.CreatePart(textproto.MIMEHeader{
-			"Content-Type":              {fmt.Sprintf("%s; name=%q", attachment.ContentType, attachment.Filename)},
-			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", attachment.Filename)},
-			"Content-Transfer-Encoding": {"base64"},
-		})
+	for _, attachment := range attached {
+		if err := writeAttachment(writer, attachment); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// smtpConn is a connection to the relay. conn is the underlying
+// connection, on which deadlines are set.
+type smtpConn struct {
+	*smtp.Client
+	conn net.Conn
+}
+
+// sendMail sends a message in one SMTP transaction, on a pooled connection
+// if one is available. The send is limited by timeout, if positive, and
+// the deadline of ctx; cancelling ctx aborts it.
+func (c *SMTPClient) sendMail(ctx context.Context, timeout time.Duration, from string, to []string, dsn *DSN, write func(io.Writer) error) error {
+	deadline, _ := ctx.Deadline()
+	if timeout > 0 {
+		deadline = earliest(deadline, time.Now().Add(timeout))
+	}
+
+	var conn *smtpConn
+	if c.Pool != nil {
+		conn = c.Pool.get(deadline)
+	}
+	if conn == nil {
+		var err error
+		if conn, err = c.dial(ctx, deadline); err != nil {
+			return contextError(ctx, err)
+		}
+	}
+
+	stop := context.AfterFunc(ctx, conn.abort)
+	err := transaction(conn.Client, from, to, dsn, write)
+	if !stop() {
+		// An aborted connection can't be reused, even if the message was
+		// accepted before
+		conn.Close()
 		if err != nil {
-			return fmt.Errorf("error creating attachment part: %w", err)
+			return contextError(ctx, err)
+		}
+		return nil
+	}
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if c.Pool != nil {
+		c.Pool.put(conn)
+		return nil
+	}
+	err = conn.Quit()
+	conn.Close()
+	return err
+}
+
+// dial connects and authenticates to the relay within ConnectTimeout and
+// the send's deadline, which is set on the connection it returns
+func (c *SMTPClient) dial(ctx context.Context, deadline time.Time) (*smtpConn, error) {
+	connectDeadline := deadline
+	if c.ConnectTimeout > 0 {
+		connectDeadline = earliest(deadline, time.Now().Add(c.ConnectTimeout))
+	}
+	dialer := &net.Dialer{Deadline: connectDeadline}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	var conn net.Conn
+	var err error
+	if c.Proxy != nil {
+		conn, err = dialProxy(ctx, dialer, c.Proxy, addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(connectDeadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(aborted) })
+	defer stop()
+
+	rawConn := conn
+	if c.implicitTLS() {
+		tlsConn := tls.Client(conn, c.tlsConfig())
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !c.implicitTLS() {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(c.tlsConfig()); err != nil {
+				client.Close()
+				return nil, fmt.Errorf("error starting TLS: %w", err)
+			}
+		} else if c.RequireTLS {
+			client.Close()
+			return nil, ErrTLSRequired
+		}
+	}
+	if c.Auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			client.Close()
+			return nil, errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(c.Auth); err != nil {
+			client.Close()
+			return nil, err
 		}
+	}
+	if !stop() {
+		client.Close()
+		return nil, ctx.Err()
+	}
+	rawConn.SetDeadline(deadline)
+	return &smtpConn{Client: client, conn: rawConn}, nil
+}
+
+// aborted is a deadline in the past, set to abort blocked reads and writes
+var aborted = time.Unix(1, 0)
+
+// abort makes pending and future reads and writes on the connection fail
+func (c *smtpConn) abort() {
+	c.conn.SetDeadline(aborted)
+}
+
+// earliest returns the earlier of two deadlines, where zero means none
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
 
-		encoder := base64.NewEncoder(base64.StdEncoding, part)
-		encoder.Write(attachment.Content)
-		encoder.Close()
+// contextError returns the error of ctx if it is done, which caused err,
+// or err otherwise
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %v", ctxErr, err)
 	}
+	return err
+}
+
+func (c *SMTPClient) implicitTLS() bool {
+	return c.ImplicitTLS || c.Port == SMTPSPort
+}
 
-	writer.Close()
+func (c *SMTPClient) tlsConfig() *tls.Config {
+	config := &tls.Config{}
+	if c.TLSConfig != nil {
+		config = c.TLSConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = c.Host
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	return config
+}
 
-	// Send email
-	err = smtp.SendMail(
-		fmt.Sprintf("%s:%d", c.Host, c.Port),
-		c.Auth,
-		msg.From,
-		msg.To,
-		buffer.Bytes(),
-	)
+// transaction sends one message over an open connection, requesting
+// delivery status notifications if dsn is set
+func transaction(client *smtp.Client, from string, to []string, dsn *DSN, write func(io.Writer) error) error {
+	if !isASCII(from + strings.Join(to, "")) {
+		if ok, _ := client.Extension("SMTPUTF8"); !ok {
+			return ErrSMTPUTF8Unsupported
+		}
+	}
+
+	if dsn != nil {
+		if err := mailWithDSN(client, from, to, dsn); err != nil {
+			return err
+		}
+	} else {
+		if err := client.Mail(from); err != nil {
+			return err
+		}
+		for _, rcpt := range to {
+			if err := client.Rcpt(rcpt); err != nil {
+				return err
+			}
+		}
+	}
+	w, err := client.Data()
 	if err != nil {
-		return fmt.Errorf("error sending email: %w", err)
+		return err
+	}
+	if err := write(w); err != nil {
+		return err
 	}
+	return w.Close()
+}
 
-	return nil
+// recipients returns the envelope recipients of msg, including Bcc
+func (msg *EmailMessage) recipients() []string {
+	return slices.Concat(msg.To, msg.Cc, msg.Bcc)
+}
+
+// writeMultipart adds a nested multipart part of contentType to parent,
+// filled in by fill
+func writeMultipart(parent *multipart.Writer, contentType string, fill func(*multipart.Writer) error) error {
+	// The boundary goes in the part header, before the nested parts
+	boundary := multipart.NewWriter(nil).Boundary()
+	part, err := parent.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("%s; boundary=%s", contentType, boundary)},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating %s part: %w", contentType, err)
+	}
+
+	w := multipart.NewWriter(part)
+	if err := w.SetBoundary(boundary); err != nil {
+		return err
+	}
+	if err := fill(w); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// writeTextPart adds a UTF-8 text part of contentType, e.g. "text/html"
+func writeTextPart(w *multipart.Writer, contentType, content string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating %s part: %w", contentType, err)
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeAttachment adds a base64-encoded attachment part
+func writeAttachment(w *multipart.Writer, attachment Attachment) error {
+	disposition := "attachment"
+	if attachment.Inline {
+		disposition = "inline"
+	}
+	partHeader := textproto.MIMEHeader{
+		"Content-Type":              {fmt.Sprintf("%s; name=%s", attachment.ContentType, mimeParam(attachment.Filename))},
+		"Content-Disposition":       {fmt.Sprintf("%s; filename=%s", disposition, mimeParam(attachment.Filename))},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if attachment.ContentID != "" {
+		partHeader.Set("Content-ID", "<"+attachment.ContentID+">")
+	}
+	part, err := w.CreatePart(partHeader)
+	if err != nil {
+		return fmt.Errorf("error creating attachment part: %w", err)
+	}
+
+	lines := &lineWriter{w: part}
+	encoder := base64.NewEncoder(base64.StdEncoding, lines)
+	if attachment.Open != nil {
+		if err := attachment.copyTo(encoder); err != nil {
+			return err
+		}
+	} else if _, err := encoder.Write(attachment.Content); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	return lines.Close()
+}
+
+// lineWriter breaks base64 output into 76-character lines, as RFC 2045
+// requires
+type lineWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), 76-l.col)
+		if _, err := l.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		l.col += n
+		p = p[n:]
+		if l.col == 76 {
+			if _, err := io.WriteString(l.w, "\r\n"); err != nil {
+				return written, err
+			}
+			l.col = 0
+		}
+	}
+	return written, nil
+}
+
+// Close ends the last line
+func (l *lineWriter) Close() error {
+	if l.col == 0 {
+		return nil
+	}
+	_, err := io.WriteString(l.w, "\r\n")
+	return err
+}
+
+// headerValue returns the value of the header name, matched
+// case-insensitively
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// newMessageID returns a unique Message-ID in the domain of from
+func newMessageID(from string) (string, error) {
+	id, err := randomKey()
+	if err != nil {
+		return "", err
+	}
+	domain := "shoutbox.net"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(asciiAddress(addr.Address), "@"); ok {
+			domain = d
+		}
+	}
+	return "<" + id + "@" + domain + ">", nil
 }
 
+// formatAddress formats email with an optional display name for a header.
+// The name is quoted or RFC 2047 encoded as needed and the domain is
+// converted to ASCII.
 func formatAddress(email, name string) string {
+	email = asciiAddress(email)
 	if name == "" {
 		return email
 	}
-	return fmt.Sprintf("%s <%s>", name, email)
+	return (&mail.Address{Name: name, Address: email}).String()
+}
+
+// formatAddressList formats addresses, each optionally with a display
+// name, e.g. "Zoë <zoe@example.com>", for a header
+func formatAddressList(addresses []string) string {
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		if addr, err := mail.ParseAddress(address); err == nil {
+			formatted[i] = formatAddress(addr.Address, addr.Name)
+		} else {
+			formatted[i] = asciiAddress(address)
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// envelopeAddress returns the bare ASCII-domain address of a recipient
+// that may have a display name, for the SMTP envelope
+func envelopeAddress(address string) string {
+	if addr, err := mail.ParseAddress(address); err == nil {
+		address = addr.Address
+	}
+	return asciiAddress(address)
+}
+
+// mimeParam quotes value as a MIME header parameter, encoding non-ASCII
+// values as RFC 2047
+func mimeParam(value string) string {
+	if !isASCII(value) {
+		value = mime.QEncoding.Encode("UTF-8", value)
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func firstBool(values ...*bool) *bool {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
 }
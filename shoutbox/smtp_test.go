@@ -1,8 +1,21 @@
 package shoutbox
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSMTPClient_SendEmail(t *testing.T) {
@@ -91,6 +104,31 @@ func TestValidateEmail(t *testing.T) {
 			email:   "invalid-email",
 			wantErr: true,
 		},
+		{
+			name:    "display name",
+			email:   "Ada Lovelace <ada@example.com>",
+			wantErr: false,
+		},
+		{
+			name:    "empty",
+			email:   " ",
+			wantErr: true,
+		},
+		{
+			name:    "two at signs",
+			email:   "ada@@example.com",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed angle bracket",
+			email:   "Ada <ada@example.com",
+			wantErr: true,
+		},
+		{
+			name:    "two addresses",
+			email:   "ada@example.com, grace@example.com",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -99,6 +137,34 @@ func TestValidateEmail(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateEmail() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if err != nil && !errors.Is(err, ErrInvalidAddress) {
+				t.Errorf("ValidateEmail() error = %v, want ErrInvalidAddress", err)
+			}
+		})
+	}
+}
+
+func TestSMTPClient_SendInvalidAddress(t *testing.T) {
+	client := &SMTPClient{Host: "127.0.0.1", Port: 1}
+
+	tests := []struct {
+		name      string
+		msg       *EmailMessage
+		wantField string
+	}{
+		{name: "from", msg: &EmailMessage{From: "news", To: []string{"ada@example.com"}}, wantField: "from"},
+		{name: "display name in from", msg: &EmailMessage{From: "News <news@example.com>", To: []string{"ada@example.com"}}, wantField: "from"},
+		{name: "bcc", msg: &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Bcc: []string{"audit"}}, wantField: "bcc"},
+		{name: "reply-to", msg: &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, ReplyTo: "support@"}, wantField: "reply_to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Send(context.Background(), tt.msg)
+			var addrErr *AddressError
+			if !errors.As(err, &addrErr) || addrErr.Field != tt.wantField {
+				t.Errorf("Send() error = %v, want %s address error", err, tt.wantField)
+			}
 		})
 	}
 }
@@ -130,3 +196,448 @@ func TestValidateEmailList(t *testing.T) {
 		})
 	}
 }
+
+func TestSMTPClient_buildMessageCcBcc(t *testing.T) {
+	client := &SMTPClient{}
+	msg := &EmailMessage{
+		From:    "sender@example.com",
+		To:      []string{"ada@example.com"},
+		Cc:      []string{"grace@example.com", "alan@example.com"},
+		Bcc:     []string{"archive@example.com"},
+		Subject: "Quarterly report",
+		HTML:    "<p>Hi</p>",
+	}
+
+	data, err := client.buildMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+
+	if got := parsed.Header.Get("Cc"); got != "grace@example.com, alan@example.com" {
+		t.Errorf("Cc = %q", got)
+	}
+	if strings.Contains(string(data), "archive@example.com") {
+		t.Error("Bcc recipient appears in the message")
+	}
+	want := []string{"ada@example.com", "grace@example.com", "alan@example.com", "archive@example.com"}
+	if got := msg.recipients(); !slices.Equal(got, want) {
+		t.Errorf("recipients() = %v, want %v", got, want)
+	}
+}
+
+func TestSMTPClient_buildMessageAlternative(t *testing.T) {
+	client := &SMTPClient{}
+	msg := &EmailMessage{
+		From:        "sender@example.com",
+		To:          []string{"ada@example.com"},
+		Subject:     "Welcome",
+		HTML:        "<p>Welcome</p>",
+		Text:        "Welcome, café lovers",
+		Attachments: []Attachment{{Filename: "a.txt", Content: []byte("a"), ContentType: "text/plain"}},
+	}
+
+	data, err := client.buildMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	parsed, err := ParseInbound(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+	if parsed.Text != msg.Text || parsed.HTML != msg.HTML || len(parsed.Attachments) != 1 {
+		t.Errorf("Text = %q, HTML = %q, Attachments = %d", parsed.Text, parsed.HTML, len(parsed.Attachments))
+	}
+	if !strings.Contains(string(data), "Content-Type: multipart/alternative; boundary=") {
+		t.Error("missing multipart/alternative part")
+	}
+}
+
+func TestNewMessageID(t *testing.T) {
+	id, err := newMessageID("Ada <ada@example.com>")
+	if err != nil {
+		t.Fatalf("newMessageID() error = %v", err)
+	}
+	if !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("newMessageID() = %q", id)
+	}
+	if other, _ := newMessageID("ada@example.com"); other == id {
+		t.Error("message IDs should be unique")
+	}
+}
+
+func TestSMTPClient_buildMessageRelated(t *testing.T) {
+	client := &SMTPClient{}
+	msg := &EmailMessage{
+		From: "sender@example.com",
+		To:   []string{"ada@example.com"},
+		HTML: `<img src="cid:logo">`,
+		Text: "Logo",
+		Attachments: []Attachment{
+			{Filename: "logo.png", Content: []byte("png"), ContentType: "image/png", ContentID: "logo", Inline: true},
+			{Filename: "terms.pdf", Content: []byte("pdf"), ContentType: "application/pdf"},
+		},
+	}
+
+	data, err := client.buildMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	raw := string(data)
+
+	related := strings.Index(raw, `Content-Type: multipart/related; type="multipart/alternative"; boundary=`)
+	alternative := strings.Index(raw, "Content-Type: multipart/alternative; boundary=")
+	logo := strings.Index(raw, "Content-Id: <logo>")
+	terms := strings.Index(raw, `filename="terms.pdf"`)
+	if related < 0 || !(related < alternative && alternative < logo && logo < terms) {
+		t.Errorf("unexpected MIME structure:\n%s", raw)
+	}
+
+	parsed, err := ParseInbound(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+	if parsed.HTML != msg.HTML || parsed.Text != msg.Text || len(parsed.Attachments) != 2 {
+		t.Errorf("parsed = %+v", parsed)
+	}
+}
+
+func TestSMTPClient_buildMessageEncodedWords(t *testing.T) {
+	client := &SMTPClient{}
+	msg := &EmailMessage{
+		From:    "jose@example.com",
+		Name:    "José Núñez",
+		To:      []string{"Zoë Ångström <zoe@example.com>", "plain@example.com"},
+		Subject: "José — Informe Q3 ✨",
+		HTML:    "<p>Hola</p>",
+		Attachments: []Attachment{
+			{Filename: "Informe Q3 — año.pdf", Content: []byte("%PDF"), ContentType: "application/pdf"},
+			{Filename: `say "hi".txt`, Content: []byte("hi"), ContentType: "text/plain"},
+		},
+	}
+
+	data, err := client.buildMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	header, _, _ := bytes.Cut(data, []byte("\r\n\r\n"))
+	if !isASCII(string(header)) {
+		t.Errorf("header isn't ASCII:\n%s", header)
+	}
+
+	parsed, err := ParseInbound(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "subject", got: parsed.Subject, want: msg.Subject},
+		{name: "from name", got: parsed.From.Name, want: "José Núñez"},
+		{name: "to name", got: parsed.To[0].Name, want: "Zoë Ångström"},
+		{name: "to address", got: parsed.To[1].Address, want: "plain@example.com"},
+		{name: "filename", got: parsed.Attachments[0].Filename, want: "Informe Q3 — año.pdf"},
+		{name: "quoted filename", got: parsed.Attachments[1].Filename, want: `say "hi".txt`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMTPClient_buildMessageQuotedPrintableHTML(t *testing.T) {
+	client := &SMTPClient{}
+	html := `<p style="color: #333">Café — ` + strings.Repeat("long line ", 200) + `</p>`
+	msg := &EmailMessage{From: "sender@example.com", To: []string{"ada@example.com"}, Subject: "QP", HTML: html}
+
+	data, err := client.buildMessage(msg)
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	_, part, _ := strings.Cut(string(data), "Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	part, _, _ = strings.Cut(part, "\r\n--")
+	if part == "" {
+		t.Fatal("missing text/html part")
+	}
+	for _, line := range strings.Split(part, "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("line longer than 76 characters: %q", line)
+		}
+		if !isASCII(line) {
+			t.Fatalf("8-bit line: %q", line)
+		}
+	}
+
+	parsed, err := ParseInbound(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+	if parsed.HTML != html {
+		t.Errorf("HTML = %q, want %q", parsed.HTML, html)
+	}
+}
+
+func TestSMTPClient_writeMessageStreamingAttachment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.bin")
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := NewStreamingAttachmentFromFile(path)
+	if err != nil {
+		t.Fatalf("NewStreamingAttachmentFromFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		attachment Attachment
+		want       []byte
+		wantErr    bool
+	}{
+		{name: "file", attachment: fromFile, want: content},
+		{name: "reader", attachment: NewStreamingAttachmentFromReader(strings.NewReader("streamed"), "notes.txt"), want: []byte("streamed")},
+		{name: "open error", attachment: NewStreamingAttachment("gone.txt", func() (io.ReadCloser, error) {
+			return nil, os.ErrNotExist
+		}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &EmailMessage{From: "sender@example.com", To: []string{"ada@example.com"}, Subject: "Files", Text: "Attached", Attachments: []Attachment{tt.attachment}}
+			var buf bytes.Buffer
+			err := (&SMTPClient{}).writeMessage(&buf, msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for _, line := range strings.Split(buf.String(), "\r\n") {
+				if len(line) > 998 {
+					t.Fatalf("line of %d characters", len(line))
+				}
+			}
+			parsed, err := ParseInbound(&buf)
+			if err != nil {
+				t.Fatalf("ParseInbound() error = %v", err)
+			}
+			if len(parsed.Attachments) != 1 || !bytes.Equal(parsed.Attachments[0].Content, tt.want) {
+				t.Errorf("attachment content differs (%d attachments)", len(parsed.Attachments))
+			}
+		})
+	}
+}
+
+func TestSMTPClient_implicitTLS(t *testing.T) {
+	tests := []struct {
+		name   string
+		client *SMTPClient
+		want   bool
+	}{
+		{name: "submission", client: &SMTPClient{Port: 587}, want: false},
+		{name: "smtps port", client: &SMTPClient{Port: 465}, want: true},
+		{name: "explicit", client: &SMTPClient{Port: 2465, ImplicitTLS: true}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.client.implicitTLS(); got != tt.want {
+				t.Errorf("implicitTLS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	config := (&SMTPClient{Host: "smtp.example.com", TLSConfig: &tls.Config{MinVersion: tls.VersionTLS13}}).tlsConfig()
+	if config.ServerName != "smtp.example.com" || config.MinVersion != tls.VersionTLS13 {
+		t.Errorf("tlsConfig() = %+v", config)
+	}
+	if config := (&SMTPClient{Host: "smtp.example.com"}).tlsConfig(); config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("default MinVersion = %x, want TLS 1.2", config.MinVersion)
+	}
+}
+
+func TestNewSMTPClientWithConfig(t *testing.T) {
+	t.Setenv(EnvironmentVar, "")
+
+	tests := []struct {
+		name     string
+		config   SMTPConfig
+		want     SMTPClient
+		wantAuth bool
+	}{
+		{
+			name:     "defaults",
+			config:   SMTPConfig{Password: "key"},
+			want:     SMTPClient{Host: "mail.shoutbox.net", Port: SMTPSubmissionPort, Username: "shoutbox", Password: "key", RequireTLS: true},
+			wantAuth: true,
+		},
+		{
+			name:     "alternate port",
+			config:   SMTPConfig{Port: SMTPAlternatePort, Password: "key"},
+			want:     SMTPClient{Host: "mail.shoutbox.net", Port: SMTPAlternatePort, Username: "shoutbox", Password: "key", RequireTLS: true},
+			wantAuth: true,
+		},
+		{
+			name:   "internal relay",
+			config: SMTPConfig{Host: "relay.internal", Port: 25, AllowPlaintext: true},
+			want:   SMTPClient{Host: "relay.internal", Port: 25, Username: "shoutbox"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSMTPClientWithConfig(tt.config)
+			if got.Host != tt.want.Host || got.Port != tt.want.Port || got.Username != tt.want.Username ||
+				got.Password != tt.want.Password || got.RequireTLS != tt.want.RequireTLS {
+				t.Errorf("NewSMTPClientWithConfig() = %+v, want %+v", got, tt.want)
+			}
+			if (got.Auth != nil) != tt.wantAuth {
+				t.Errorf("Auth = %v, want set %v", got.Auth, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestSMTPClient_TrackingDomain(t *testing.T) {
+	client := &SMTPClient{TrackingDomain: "links.example.com"}
+	tests := []struct {
+		name   string
+		domain string
+		want   string
+	}{
+		{name: "client default", want: "links.example.com"},
+		{name: "message override", domain: "click.tenant.example", want: "click.tenant.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Hi", Text: "Hi", TrackingDomain: tt.domain}
+			raw, err := client.Render(context.Background(), msg)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := parsed.Header.Get(headerTrackDomain); got != tt.want {
+				t.Errorf("%s = %q, want %q", headerTrackDomain, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMTPClient_TagsAndMetadata(t *testing.T) {
+	msg := &EmailMessage{
+		From:     "shop@example.com",
+		To:       []string{"ada@example.com"},
+		Subject:  "Your order",
+		Text:     "Thanks",
+		Tags:     []string{"receipt", "eu"},
+		Metadata: map[string]string{"order_id": "1234", "user": "Zoë"},
+	}
+	raw, err := (&SMTPClient{}).Render(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Header.Get(headerTags); got != "receipt, eu" {
+		t.Errorf("%s = %q", headerTags, got)
+	}
+	metadata, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get(headerMetadata))
+	if err != nil || metadata != `{"order_id":"1234","user":"Zoë"}` {
+		t.Errorf("%s = %q, %v", headerMetadata, metadata, err)
+	}
+
+	req := msg.request()
+	if len(req.Tags) != 2 || req.Metadata["order_id"] != "1234" {
+		t.Errorf("request() tags = %v, metadata = %v", req.Tags, req.Metadata)
+	}
+}
+
+func TestSMTPClient_Timeouts(t *testing.T) {
+	// The relay accepts connections but never greets
+	addr := listen(t, func(conn net.Conn) { io.Copy(io.Discard, conn) })
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+
+	tests := []struct {
+		name    string
+		setup   func(c *SMTPClient, msg *EmailMessage) (context.Context, context.CancelFunc)
+		wantErr error
+	}{
+		{
+			name: "connect timeout",
+			setup: func(c *SMTPClient, msg *EmailMessage) (context.Context, context.CancelFunc) {
+				c.ConnectTimeout = 50 * time.Millisecond
+				return context.WithCancel(context.Background())
+			},
+			wantErr: os.ErrDeadlineExceeded,
+		},
+		{
+			name: "client timeout",
+			setup: func(c *SMTPClient, msg *EmailMessage) (context.Context, context.CancelFunc) {
+				c.Timeout = 50 * time.Millisecond
+				return context.WithCancel(context.Background())
+			},
+			wantErr: os.ErrDeadlineExceeded,
+		},
+		{
+			name: "message timeout",
+			setup: func(c *SMTPClient, msg *EmailMessage) (context.Context, context.CancelFunc) {
+				c.Timeout = time.Minute
+				msg.Timeout = 50 * time.Millisecond
+				return context.WithCancel(context.Background())
+			},
+			wantErr: os.ErrDeadlineExceeded,
+		},
+		{
+			name: "context deadline",
+			setup: func(c *SMTPClient, msg *EmailMessage) (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "cancelled",
+			setup: func(c *SMTPClient, msg *EmailMessage) (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewSMTPClient("test-key")
+			client.Host, client.Port = host, portNum
+			msg := &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}
+			ctx, cancel := tt.setup(client, msg)
+			defer cancel()
+
+			start := time.Now()
+			_, err := client.Send(ctx, msg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Send() error = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Send() took %v", elapsed)
+			}
+		})
+	}
+}
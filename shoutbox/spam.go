@@ -0,0 +1,221 @@
+package shoutbox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultSpamThreshold is the score from which SpamAssassin marks
+// messages as spam by default
+const DefaultSpamThreshold = 5.0
+
+// DefaultSpamdAddr is the address spamd listens on by default
+const DefaultSpamdAddr = "localhost:783"
+
+// spamdRulePattern matches a rule line of a SpamAssassin report: the
+// points, the rule name and the start of its description
+var spamdRulePattern = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s+(\S+)\s*(.*)$`)
+
+// SpamReport is the result of a spam check
+type SpamReport struct {
+	// Score is the total of the rules' scores; higher is spammier
+	Score float64    `json:"score"`
+	Rules []SpamRule `json:"rules,omitempty"`
+}
+
+// SpamRule is a spam filter rule a message matched
+type SpamRule struct {
+	Name        string  `json:"name"`
+	Score       float64 `json:"score"`
+	Description string  `json:"description,omitempty"`
+}
+
+// SpamError is returned when a message's spam score reaches the client's
+// threshold. Use errors.As to inspect it.
+type SpamError struct {
+	Report    *SpamReport
+	Threshold float64
+}
+
+func (e *SpamError) Error() string {
+	names := make([]string, len(e.Report.Rules))
+	for i, rule := range e.Report.Rules {
+		names[i] = rule.Name
+	}
+	msg := fmt.Sprintf("message rejected as spam: score %.1f reaches threshold %.1f", e.Report.Score, e.Threshold)
+	if len(names) > 0 {
+		msg += " (" + strings.Join(names, ", ") + ")"
+	}
+	return msg
+}
+
+// SpamChecker scores an RFC 5322 message with a spam filter
+type SpamChecker interface {
+	CheckSpam(ctx context.Context, message []byte) (*SpamReport, error)
+}
+
+// SpamCheckerFunc adapts a function to a SpamChecker
+type SpamCheckerFunc func(ctx context.Context, message []byte) (*SpamReport, error)
+
+// CheckSpam calls f(ctx, message)
+func (f SpamCheckerFunc) CheckSpam(ctx context.Context, message []byte) (*SpamReport, error) {
+	return f(ctx, message)
+}
+
+// Spamd checks messages with SpamAssassin's spamd daemon, or another
+// server speaking its protocol such as rspamd
+type Spamd struct {
+	// Addr defaults to DefaultSpamdAddr
+	Addr string
+	// User, when set, selects the user whose preferences spamd applies
+	User string
+	// Dialer defaults to a zero net.Dialer
+	Dialer *net.Dialer
+}
+
+var _ SpamChecker = (*Spamd)(nil)
+
+// CheckSpam scores message with spamd, returning the total score and the
+// rules it matched
+func (s *Spamd) CheckSpam(ctx context.Context, message []byte) (*SpamReport, error) {
+	addr := s.Addr
+	if addr == "" {
+		addr = DefaultSpamdAddr
+	}
+	dialer := s.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to spamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	request := fmt.Sprintf("REPORT SPAMC/1.5\r\nContent-length: %d\r\n", len(message))
+	if s.User != "" {
+		request += "User: " + s.User + "\r\n"
+	}
+	if _, err := io.WriteString(conn, request+"\r\n"); err != nil {
+		return nil, fmt.Errorf("error writing to spamd: %w", err)
+	}
+	if _, err := conn.Write(message); err != nil {
+		return nil, fmt.Errorf("error writing to spamd: %w", err)
+	}
+	return readSpamdResponse(bufio.NewReader(conn))
+}
+
+// readSpamdResponse parses the response to a REPORT request: a status
+// line, headers with the score and the report listing the matched rules
+func readSpamdResponse(r *bufio.Reader) (*SpamReport, error) {
+	tp := textproto.NewReader(r)
+	status, err := tp.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("error reading spamd response: %w", err)
+	}
+	// e.g. "SPAMD/1.1 0 EX_OK"
+	fields := strings.Fields(status)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "SPAMD/") {
+		return nil, fmt.Errorf("unexpected spamd response %q", status)
+	}
+	if fields[1] != "0" {
+		return nil, fmt.Errorf("spamd error: %s", strings.Join(fields[1:], " "))
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("error reading spamd response: %w", err)
+	}
+
+	// e.g. "True ; 15.0 / 5.0"
+	_, score, _ := strings.Cut(header.Get("Spam"), ";")
+	score, _, _ = strings.Cut(score, "/")
+	report := &SpamReport{}
+	if report.Score, err = strconv.ParseFloat(strings.TrimSpace(score), 64); err != nil {
+		return nil, fmt.Errorf("error reading spamd score %q", header.Get("Spam"))
+	}
+
+	// The rules are listed in a table below a line of dashes; long
+	// descriptions continue on indented lines
+	inTable := false
+	for {
+		line, err := tp.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading spamd report: %w", err)
+		}
+		switch {
+		case !inTable:
+			inTable = strings.HasPrefix(line, "----")
+		case strings.TrimSpace(line) == "":
+			inTable = false
+		default:
+			if m := spamdRulePattern.FindStringSubmatch(line); m != nil {
+				points, _ := strconv.ParseFloat(m[1], 64)
+				report.Rules = append(report.Rules, SpamRule{Name: m[2], Score: points, Description: m[3]})
+			} else if n := len(report.Rules); n > 0 {
+				report.Rules[n-1].Description += " " + strings.TrimSpace(line)
+			}
+		}
+	}
+	return report, nil
+}
+
+// CheckSpam scores req with the API's spam filter as SendEmail would send
+// it, with MJML compiled and the client's settings applied, without
+// sending it
+func (c *Client) CheckSpam(ctx context.Context, req *EmailRequest) (*SpamReport, error) {
+	if err := req.validateHeaders(); err != nil {
+		return nil, err
+	}
+	if req.MJML != "" {
+		compiled := *req
+		if err := compileMJML(ctx, c.mjmlCompiler, req.MJML, &compiled.HTML, &compiled.Text); err != nil {
+			return nil, err
+		}
+		req = &compiled
+	}
+	return c.checkSpam(ctx, c.newSendPayload(req))
+}
+
+// checkSpam posts a send payload to the spam check endpoint
+func (c *Client) checkSpam(ctx context.Context, payload *sendPayload) (*SpamReport, error) {
+	var report SpamReport
+	if err := c.do(ctx, http.MethodPost, "/spam-check", payload, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// checkSpamThreshold scores a prepared request if the client has a spam
+// threshold, returning a *SpamError if it reaches it
+func (c *Client) checkSpamThreshold(ctx context.Context, req *EmailRequest) error {
+	if c.spamThreshold == nil {
+		return nil
+	}
+	report, err := c.checkSpam(ctx, c.newSendPayload(req))
+	if err != nil {
+		return fmt.Errorf("error checking spam score: %w", err)
+	}
+	return rejectSpam(report, *c.spamThreshold)
+}
+
+// rejectSpam returns a *SpamError if report reaches threshold
+func rejectSpam(report *SpamReport, threshold float64) error {
+	if report.Score >= threshold {
+		return &SpamError{Report: report, Threshold: threshold}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package shoutbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const spamdReport = "Spam detection software has identified this incoming email as possible spam.\r\n" +
+	"\r\n" +
+	"Content analysis details:   (6.2 points, 5.0 required)\r\n" +
+	"\r\n" +
+	" pts rule name              description\r\n" +
+	"---- ---------------------- --------------------------------------------------\r\n" +
+	" 2.0 PYZOR_CHECK            Listed in Pyzor\r\n" +
+	"                            (https://pyzor.readthedocs.io/en/latest/)\r\n" +
+	" 4.3 FREE_MONEY             BODY: Free money\r\n" +
+	"-0.1 DKIM_VALID             Message has at least one valid DKIM or DK signature\r\n" +
+	"\r\n"
+
+func TestReadSpamdResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     *SpamReport
+		wantErr  bool
+	}{
+		{
+			name:     "report",
+			response: "SPAMD/1.1 0 EX_OK\r\nContent-length: 512\r\nSpam: True ; 6.2 / 5.0\r\n\r\n" + spamdReport,
+			want: &SpamReport{Score: 6.2, Rules: []SpamRule{
+				{Name: "PYZOR_CHECK", Score: 2.0, Description: "Listed in Pyzor (https://pyzor.readthedocs.io/en/latest/)"},
+				{Name: "FREE_MONEY", Score: 4.3, Description: "BODY: Free money"},
+				{Name: "DKIM_VALID", Score: -0.1, Description: "Message has at least one valid DKIM or DK signature"},
+			}},
+		},
+		{
+			name:     "no rules",
+			response: "SPAMD/1.1 0 EX_OK\r\nSpam: False ; -0.5 / 5.0\r\n\r\n",
+			want:     &SpamReport{Score: -0.5},
+		},
+		{
+			name:     "error status",
+			response: "SPAMD/1.0 76 Bad header line: foo\r\n",
+			wantErr:  true,
+		},
+		{
+			name:     "missing score",
+			response: "SPAMD/1.1 0 EX_OK\r\n\r\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readSpamdResponse(bufio.NewReader(strings.NewReader(tt.response)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readSpamdResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readSpamdResponse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpamd_CheckSpam(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type request struct {
+		command string
+		header  textproto.MIMEHeader
+		message []byte
+	}
+	requests := make(chan request, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewReader(bufio.NewReader(conn))
+		var req request
+		req.command, _ = tp.ReadLine()
+		req.header, _ = tp.ReadMIMEHeader()
+		n, _ := strconv.Atoi(req.header.Get("Content-Length"))
+		req.message = make([]byte, n)
+		io.ReadFull(tp.R, req.message)
+		requests <- req
+		io.WriteString(conn, "SPAMD/1.1 0 EX_OK\r\nSpam: True ; 6.2 / 5.0\r\n\r\n"+spamdReport)
+	}()
+
+	message := []byte("From: news@example.com\r\nSubject: Free money\r\n\r\nClaim it now\r\n")
+	report, err := (&Spamd{Addr: ln.Addr().String(), User: "news"}).CheckSpam(context.Background(), message)
+	if err != nil {
+		t.Fatalf("CheckSpam() error = %v", err)
+	}
+	if report.Score != 6.2 || len(report.Rules) != 3 {
+		t.Errorf("CheckSpam() = %+v, want score 6.2 and 3 rules", report)
+	}
+
+	req := <-requests
+	if req.command != "REPORT SPAMC/1.5" {
+		t.Errorf("command = %q, want REPORT SPAMC/1.5", req.command)
+	}
+	if req.header.Get("User") != "news" {
+		t.Errorf("User = %q, want news", req.header.Get("User"))
+	}
+	if !bytes.Equal(req.message, message) {
+		t.Errorf("message = %q, want %q", req.message, message)
+	}
+}
+
+func TestClient_SpamCheck(t *testing.T) {
+	var sent bool
+	var checked map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/spam-check":
+			json.NewDecoder(r.Body).Decode(&checked)
+			score := 1.0
+			if strings.Contains(checked["subject"].(string), "FREE") {
+				score = 7.5
+			}
+			json.NewEncoder(w).Encode(SpamReport{Score: score, Rules: []SpamRule{{Name: "FREE_MONEY", Score: score}}})
+		case "/send":
+			sent = true
+			json.NewEncoder(w).Encode(SendResponse{MessageID: "msg-1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithSpamCheck(5), WithUTM(UTMParams{Source: "shoutbox"}))
+	client.baseURL = srv.URL
+
+	tests := []struct {
+		name     string
+		subject  string
+		wantSent bool
+	}{
+		{name: "below threshold", subject: "Your receipt", wantSent: true},
+		{name: "reaches threshold", subject: "FREE MONEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = false
+			req := &EmailRequest{
+				From:    "news@example.com",
+				To:      Recipients{"ada@example.com"},
+				Subject: tt.subject,
+				HTML:    `<a href="https://example.com">Open</a>`,
+			}
+			_, err := client.SendEmail(context.Background(), req)
+			var spamErr *SpamError
+			if tt.wantSent && err != nil {
+				t.Fatalf("SendEmail() error = %v", err)
+			}
+			if !tt.wantSent && (!errors.As(err, &spamErr) || spamErr.Report.Score != 7.5) {
+				t.Errorf("SendEmail() error = %v, want spam error", err)
+			}
+			if sent != tt.wantSent {
+				t.Errorf("sent = %v, want %v", sent, tt.wantSent)
+			}
+			if !strings.Contains(checked["html"].(string), "utm_source=shoutbox") {
+				t.Errorf("checked HTML = %q, want client settings applied", checked["html"])
+			}
+		})
+	}
+
+	report, err := client.CheckSpam(context.Background(), &EmailRequest{From: "news@example.com", Subject: "FREE"})
+	if err != nil || report.Score != 7.5 {
+		t.Errorf("CheckSpam() = %+v, %v, want score 7.5", report, err)
+	}
+}
+
+func TestClient_SendBatchSpamCheck(t *testing.T) {
+	var batched []EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/spam-check":
+			var checked EmailRequest
+			json.NewDecoder(r.Body).Decode(&checked)
+			score := 1.0
+			if strings.Contains(checked.Subject, "FREE") {
+				score = 7.5
+			}
+			json.NewEncoder(w).Encode(SpamReport{Score: score})
+		case "/send/batch":
+			var payload struct {
+				Messages []EmailRequest `json:"messages"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			batched = payload.Messages
+			results := make([]SendResponse, len(batched))
+			for i := range results {
+				results[i].MessageID = fmt.Sprintf("msg-%d", i)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"results": results})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", WithSpamCheck(5))
+	client.baseURL = srv.URL
+
+	results, err := client.SendBatch(context.Background(), []*EmailRequest{
+		{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Your receipt"},
+		{From: "news@example.com", To: Recipients{"grace@example.com"}, Subject: "FREE MONEY"},
+	})
+	if err != nil {
+		t.Fatalf("SendBatch() error = %v", err)
+	}
+	if results[0].Err != nil || results[0].MessageID != "msg-0" {
+		t.Errorf("results[0] = %+v, want sent", results[0])
+	}
+	var spamErr *SpamError
+	if !errors.As(results[1].Err, &spamErr) || spamErr.Report.Score != 7.5 {
+		t.Errorf("results[1].Err = %v, want spam error", results[1].Err)
+	}
+	if len(batched) != 1 || batched[0].Subject != "Your receipt" {
+		t.Errorf("batched = %+v, want only the message below the threshold", batched)
+	}
+}
+
+func TestSMTPClient_SpamChecker(t *testing.T) {
+	var checked []byte
+	client := &SMTPClient{
+		Host: "127.0.0.1",
+		Port: 1,
+		SpamChecker: SpamCheckerFunc(func(ctx context.Context, message []byte) (*SpamReport, error) {
+			checked = message
+			return &SpamReport{Score: 5.0}, nil
+		}),
+	}
+	msg := &EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Free money", Text: "Claim it now"}
+
+	_, err := client.Send(context.Background(), msg)
+	var spamErr *SpamError
+	if !errors.As(err, &spamErr) || spamErr.Threshold != DefaultSpamThreshold {
+		t.Fatalf("Send() error = %v, want spam error at the default threshold", err)
+	}
+	if !bytes.Contains(checked, []byte("Subject: Free money")) {
+		t.Errorf("checked message = %q, want rendered message", checked)
+	}
+}
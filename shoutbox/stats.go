@@ -0,0 +1,110 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// StatsGroup is how statistics are bucketed
+type StatsGroup string
+
+const (
+	StatsByDay    StatsGroup = "day"
+	StatsByTag    StatsGroup = "tag"
+	StatsByDomain StatsGroup = "domain"
+)
+
+// StatsQuery selects the statistics returned by Stats
+type StatsQuery struct {
+	Since   time.Time
+	Until   time.Time
+	GroupBy StatsGroup
+	// Tags limits the statistics to messages with any of these tags
+	Tags []string
+	// Domain limits the statistics to one sending domain
+	Domain string
+}
+
+// StatsCounts are aggregate message counts
+type StatsCounts struct {
+	Sent       int64 `json:"sent"`
+	Delivered  int64 `json:"delivered"`
+	Opened     int64 `json:"opened"`
+	Clicked    int64 `json:"clicked"`
+	Bounced    int64 `json:"bounced"`
+	Complained int64 `json:"complained"`
+}
+
+// DeliveryRate returns the share of sent messages that were delivered
+func (c StatsCounts) DeliveryRate() float64 {
+	return ratio(c.Delivered, c.Sent)
+}
+
+// OpenRate returns the share of delivered messages that were opened
+func (c StatsCounts) OpenRate() float64 {
+	return ratio(c.Opened, c.Delivered)
+}
+
+// ClickRate returns the share of delivered messages that were clicked
+func (c StatsCounts) ClickRate() float64 {
+	return ratio(c.Clicked, c.Delivered)
+}
+
+// BounceRate returns the share of sent messages that bounced
+func (c StatsCounts) BounceRate() float64 {
+	return ratio(c.Bounced, c.Sent)
+}
+
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// StatsBucket holds the counts of one day, tag or domain
+type StatsBucket struct {
+	// Key is the day (YYYY-MM-DD), tag or domain of the bucket
+	Key string `json:"key"`
+	StatsCounts
+}
+
+// Stats are aggregate sending statistics
+type Stats struct {
+	Totals  StatsCounts   `json:"totals"`
+	Buckets []StatsBucket `json:"buckets"`
+}
+
+// Stats returns aggregate sending statistics matching q
+func (c *Client) Stats(ctx context.Context, q *StatsQuery) (*Stats, error) {
+	var stats Stats
+	if err := c.do(ctx, http.MethodGet, "/stats?"+q.values().Encode(), nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (q *StatsQuery) values() url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		v.Set("until", q.Until.Format(time.RFC3339))
+	}
+	if q.GroupBy != "" {
+		v.Set("group_by", string(q.GroupBy))
+	}
+	for _, tag := range q.Tags {
+		v.Add("tag", tag)
+	}
+	if q.Domain != "" {
+		v.Set("domain", q.Domain)
+	}
+	return v
+}
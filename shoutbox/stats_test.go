@@ -0,0 +1,56 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_Stats(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{
+			"totals": {"sent": 200, "delivered": 190, "opened": 95, "clicked": 19, "bounced": 10},
+			"buckets": [
+				{"key": "welcome", "sent": 150, "delivered": 145},
+				{"key": "receipt", "sent": 50, "delivered": 45}
+			]
+		}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	stats, err := client.Stats(context.Background(), &StatsQuery{GroupBy: StatsByTag, Tags: []string{"welcome", "receipt"}})
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+
+	if want := "group_by=tag&tag=welcome&tag=receipt"; query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+	if len(stats.Buckets) != 2 || stats.Buckets[1].Key != "receipt" || stats.Buckets[1].Delivered != 45 {
+		t.Errorf("Buckets = %+v", stats.Buckets)
+	}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "delivery", got: stats.Totals.DeliveryRate(), want: 0.95},
+		{name: "open", got: stats.Totals.OpenRate(), want: 0.5},
+		{name: "click", got: stats.Totals.ClickRate(), want: 0.1},
+		{name: "bounce", got: stats.Totals.BounceRate(), want: 0.05},
+		{name: "empty", got: StatsCounts{}.OpenRate(), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("rate = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
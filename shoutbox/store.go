@@ -0,0 +1,308 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AttachmentStore uploads attachment content to external storage and
+// returns a link from which recipients can download it
+type AttachmentStore interface {
+	Store(ctx context.Context, attachment Attachment) (string, error)
+}
+
+// DefaultOffloadThreshold is the attachment size above which attachments
+// are offloaded when a store is configured without a threshold
+const DefaultOffloadThreshold = 10 << 20
+
+// OffloadAttachments uploads every non-inline attachment of msg larger than
+// threshold bytes to store, removes it from the message and appends a block
+// of download links to the HTML body
+func OffloadAttachments(ctx context.Context, store AttachmentStore, threshold int, msg *EmailMessage) error {
+	return offloadAttachments(ctx, store, threshold, &msg.HTML, &msg.Attachments)
+}
+
+func offloadAttachments(ctx context.Context, store AttachmentStore, threshold int, body *string, attachments *[]Attachment) error {
+	if threshold <= 0 {
+		threshold = DefaultOffloadThreshold
+	}
+
+	var kept []Attachment
+	var links strings.Builder
+	for _, attachment := range *attachments {
+		if attachment.Inline || len(attachment.Content) <= threshold {
+			kept = append(kept, attachment)
+			continue
+		}
+
+		link, err := store.Store(ctx, attachment)
+		if err != nil {
+			return fmt.Errorf("error storing attachment %s: %w", attachment.Filename, err)
+		}
+		fmt.Fprintf(&links, `<li><a href="%s">%s</a> (%s)</li>`,
+			html.EscapeString(link), html.EscapeString(attachment.Filename), formatSize(len(attachment.Content)))
+	}
+	if links.Len() == 0 {
+		return nil
+	}
+
+	block := `<table role="presentation" cellpadding="0" cellspacing="0" border="0" style="margin-top:16px;font-family:Arial,sans-serif;font-size:14px"><tr><td><p style="margin:0 0 8px">Attachments:</p><ul style="margin:0">` +
+		links.String() + `</ul></td></tr></table>`
+	if i := strings.LastIndex(strings.ToLower(*body), "</body>"); i >= 0 {
+		*body = (*body)[:i] + block + (*body)[i:]
+	} else {
+		*body += block
+	}
+	*attachments = kept
+	return nil
+}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// randomKey returns a random prefix for stored objects so that links
+// can't be guessed
+func randomKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// FileSystemStore stores attachments in a local directory served by
+// Handler, with links signed to expire after TTL
+type FileSystemStore struct {
+	Dir     string
+	BaseURL string
+	Secret  []byte
+	TTL     time.Duration
+}
+
+// Store writes the attachment to Dir and returns a signed link to it
+func (s *FileSystemStore) Store(ctx context.Context, attachment Attachment) (string, error) {
+	key, err := randomKey()
+	if err != nil {
+		return "", err
+	}
+	name := path.Join(key, filepath.Base(attachment.Filename))
+
+	dir := filepath.Join(s.Dir, key)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("error creating directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.Dir, filepath.FromSlash(name)), attachment.Content, 0o600); err != nil {
+		return "", fmt.Errorf("error writing file: %w", err)
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(s.TTL).Unix(), 10)
+	query := url.Values{
+		"expires": {expires},
+		"sig":     {s.sign(name, expires)},
+	}
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + (&url.URL{Path: name}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// Handler serves stored attachments, rejecting links with an invalid
+// signature or past their expiry. Mount it at the path of BaseURL with
+// http.StripPrefix.
+func (s *FileSystemStore) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		expires := r.URL.Query().Get("expires")
+		sig := r.URL.Query().Get("sig")
+		if !hmac.Equal([]byte(sig), []byte(s.sign(name, expires))) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		if exp, err := strconv.ParseInt(expires, 10, 64); err != nil || time.Now().Unix() > exp {
+			http.Error(w, "link expired", http.StatusGone)
+			return
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(name)))
+		http.ServeFile(w, r, filepath.Join(s.Dir, filepath.FromSlash(name)))
+	})
+}
+
+func (s *FileSystemStore) sign(name, expires string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(name + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// S3Store stores attachments in an S3-compatible bucket and returns
+// pre-signed download links valid for TTL
+type S3Store struct {
+	// Endpoint is the service URL, e.g. "https://s3.eu-west-1.amazonaws.com".
+	// Leave Bucket empty if Endpoint is a virtual-hosted bucket URL.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	TTL       time.Duration
+
+	// HTTPClient is used for uploads; defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// NewS3Store creates a store for an AWS S3 bucket
+func NewS3Store(region, bucket, accessKey, secretKey string, ttl time.Duration) *S3Store {
+	return &S3Store{
+		Endpoint:  "https://s3." + region + ".amazonaws.com",
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		TTL:       ttl,
+	}
+}
+
+// NewGCSStore creates a store for a Google Cloud Storage bucket using its
+// S3-compatible XML API and HMAC keys
+func NewGCSStore(bucket, accessKey, secretKey string, ttl time.Duration) *S3Store {
+	return &S3Store{
+		Endpoint:  "https://storage.googleapis.com",
+		Region:    "auto",
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		TTL:       ttl,
+	}
+}
+
+// Store uploads the attachment and returns a pre-signed download link
+func (s *S3Store) Store(ctx context.Context, attachment Attachment) (string, error) {
+	prefix, err := randomKey()
+	if err != nil {
+		return "", err
+	}
+	key := prefix + "/" + path.Base(attachment.Filename)
+	now := time.Now()
+
+	uploadURL, err := s.presign(http.MethodPut, key, now, 15*time.Minute)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(attachment.Content))
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", attachment.ContentType)
+
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error uploading attachment: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("upload failed with status %d", resp.StatusCode)
+	}
+
+	return s.presign(http.MethodGet, key, now, s.TTL)
+}
+
+// presign returns a URL for method on key signed with AWS Signature
+// Version 4 query parameters
+func (s *S3Store) presign(method, key string, now time.Time, ttl time.Duration) (string, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return "", fmt.Errorf("error parsing endpoint: %w", err)
+	}
+
+	now = now.UTC()
+	date := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+
+	canonicalURI := "/" + sigV4Escape(key, true)
+	if s.Bucket != "" {
+		canonicalURI = "/" + sigV4Escape(s.Bucket, false) + canonicalURI
+	}
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    s.AccessKey + "/" + scope,
+		"X-Amz-Date":          timestamp,
+		"X-Amz-Expires":       strconv.Itoa(int(ttl.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = sigV4Escape(k, false) + "=" + sigV4Escape(query[k], false)
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		canonicalQuery,
+		"host:" + endpoint.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + timestamp + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return endpoint.Scheme + "://" + endpoint.Host + canonicalURI + "?" + canonicalQuery + "&X-Amz-Signature=" + signature, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// sigV4Escape percent-encodes s as required by AWS Signature Version 4,
+// leaving "/" unescaped in object keys
+func sigV4Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
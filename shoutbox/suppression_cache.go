@@ -0,0 +1,160 @@
+package shoutbox
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SuppressionSource lists every suppressed address of an account
+type SuppressionSource interface {
+	SuppressedAddresses(ctx context.Context) ([]string, error)
+}
+
+// SuppressionSourceFunc adapts a function to a SuppressionSource
+type SuppressionSourceFunc func(ctx context.Context) ([]string, error)
+
+// SuppressedAddresses calls f(ctx)
+func (f SuppressionSourceFunc) SuppressedAddresses(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// SuppressionCache is an in-process copy of the suppression list, synced
+// from a SuppressionSource on an interval and updated immediately from
+// bounce and complaint webhooks via Add
+type SuppressionCache struct {
+	source   SuppressionSource
+	interval time.Duration
+
+	// OnError is called when a background sync fails; the previous
+	// contents are kept
+	OnError func(err error)
+
+	mu         sync.RWMutex
+	suppressed map[string]struct{}
+	added      map[string]time.Time
+	lastSync   time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSuppressionCache creates a cache synced from source every interval
+func NewSuppressionCache(source SuppressionSource, interval time.Duration) *SuppressionCache {
+	return &SuppressionCache{
+		source:     source,
+		interval:   interval,
+		suppressed: make(map[string]struct{}),
+		added:      make(map[string]time.Time),
+	}
+}
+
+// Start performs an initial sync and then keeps the cache in sync in the
+// background until Stop is called
+func (c *SuppressionCache) Start(ctx context.Context) error {
+	if err := c.Sync(ctx); err != nil {
+		return err
+	}
+
+	c.stop = make(chan struct{})
+	c.done = make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+				if err := c.Sync(context.Background()); err != nil && c.OnError != nil {
+					c.OnError(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends background syncing
+func (c *SuppressionCache) Stop() {
+	if c.stop == nil {
+		return
+	}
+	close(c.stop)
+	<-c.done
+	c.stop = nil
+}
+
+// Sync replaces the cache contents with the source's list. Addresses added
+// while the sync was in progress are kept.
+func (c *SuppressionCache) Sync(ctx context.Context) error {
+	started := time.Now()
+	addresses, err := c.source.SuppressedAddresses(ctx)
+	if err != nil {
+		return err
+	}
+
+	suppressed := make(map[string]struct{}, len(addresses))
+	for _, address := range addresses {
+		suppressed[normalizeAddress(address)] = struct{}{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for address, at := range c.added {
+		if at.After(started) {
+			suppressed[address] = struct{}{}
+		} else {
+			delete(c.added, address)
+		}
+	}
+	c.suppressed = suppressed
+	c.lastSync = started
+	return nil
+}
+
+// IsSuppressed reports whether email is on the suppression list
+func (c *SuppressionCache) IsSuppressed(email string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.suppressed[normalizeAddress(email)]
+	return ok
+}
+
+// Add suppresses email immediately, e.g. on a hard bounce webhook
+func (c *SuppressionCache) Add(email string) {
+	address := normalizeAddress(email)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.suppressed[address] = struct{}{}
+	c.added[address] = time.Now()
+}
+
+// Remove removes email from the cache until the next sync
+func (c *SuppressionCache) Remove(email string) {
+	address := normalizeAddress(email)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.suppressed, address)
+	delete(c.added, address)
+}
+
+// Len returns the number of suppressed addresses
+func (c *SuppressionCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.suppressed)
+}
+
+// LastSync returns when the last successful sync started
+func (c *SuppressionCache) LastSync() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastSync
+}
+
+func normalizeAddress(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,65 @@
+package shoutbox
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSuppressionCache(t *testing.T) {
+	var syncs atomic.Int32
+	source := SuppressionSourceFunc(func(ctx context.Context) ([]string, error) {
+		if syncs.Add(1) == 1 {
+			return []string{"Bounced@Example.com"}, nil
+		}
+		return []string{"complained@example.com"}, nil
+	})
+
+	cache := NewSuppressionCache(source, 10*time.Millisecond)
+	if err := cache.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer cache.Stop()
+
+	if !cache.IsSuppressed("bounced@example.com") {
+		t.Errorf("IsSuppressed() after initial sync = false")
+	}
+	cache.Add("webhook@example.com")
+
+	deadline := time.Now().Add(time.Second)
+	for syncs.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if cache.IsSuppressed("bounced@example.com") {
+		t.Errorf("IsSuppressed() after resync kept removed address")
+	}
+	if !cache.IsSuppressed("complained@example.com") {
+		t.Errorf("IsSuppressed() after resync = false")
+	}
+	if cache.LastSync().IsZero() {
+		t.Errorf("LastSync() is zero")
+	}
+}
+
+func TestSuppressionCache_AddDuringSync(t *testing.T) {
+	release := make(chan struct{})
+	cache := NewSuppressionCache(SuppressionSourceFunc(func(ctx context.Context) ([]string, error) {
+		<-release
+		return nil, nil
+	}), time.Hour)
+
+	done := make(chan error)
+	go func() { done <- cache.Sync(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	cache.Add("new@example.com")
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	if !cache.IsSuppressed("new@example.com") {
+		t.Errorf("address added during sync was dropped")
+	}
+}
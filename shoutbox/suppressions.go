@@ -0,0 +1,96 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Reasons an address is suppressed
+const (
+	SuppressionBounce      = "bounce"
+	SuppressionUnsubscribe = "unsubscribe"
+	SuppressionComplaint   = "complaint"
+	SuppressionManual      = "manual"
+)
+
+// Suppression is an address that Shoutbox won't deliver to
+type Suppression struct {
+	Email     string    `json:"email"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SuppressionQuery filters the suppressions returned by List
+type SuppressionQuery struct {
+	Reason string
+	Limit  int
+	Cursor string
+}
+
+// SuppressionList is one page of suppressions
+type SuppressionList struct {
+	Suppressions []Suppression `json:"suppressions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// SuppressionsService manages the account's suppression list
+type SuppressionsService struct {
+	client *Client
+}
+
+var _ SuppressionSource = (*SuppressionsService)(nil)
+
+// Suppressions returns the suppression list API
+func (c *Client) Suppressions() *SuppressionsService {
+	return &SuppressionsService{client: c}
+}
+
+// Add suppresses email for reason, e.g. SuppressionManual
+func (s *SuppressionsService) Add(ctx context.Context, email, reason string) error {
+	return s.client.do(ctx, http.MethodPost, "/suppressions", Suppression{Email: email, Reason: reason}, nil)
+}
+
+// Remove lifts the suppression of email
+func (s *SuppressionsService) Remove(ctx context.Context, email string) error {
+	return s.client.do(ctx, http.MethodDelete, "/suppressions/"+url.PathEscape(email), nil, nil)
+}
+
+// List returns one page of suppressions matching q. Pass NextCursor as
+// q.Cursor to fetch the following page.
+func (s *SuppressionsService) List(ctx context.Context, q *SuppressionQuery) (*SuppressionList, error) {
+	v := url.Values{}
+	if q != nil {
+		if q.Reason != "" {
+			v.Set("reason", q.Reason)
+		}
+		if q.Limit > 0 {
+			v.Set("limit", strconv.Itoa(q.Limit))
+		}
+		if q.Cursor != "" {
+			v.Set("cursor", q.Cursor)
+		}
+	}
+
+	var list SuppressionList
+	if err := s.client.do(ctx, http.MethodGet, "/suppressions?"+v.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// SuppressedAddresses pages through the whole suppression list, so the
+// service can be used as the source of a SuppressionCache
+func (s *SuppressionsService) SuppressedAddresses(ctx context.Context) ([]string, error) {
+	suppressions, err := s.Iterate(nil).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(suppressions))
+	for i, suppression := range suppressions {
+		addresses[i] = suppression.Email
+	}
+	return addresses, nil
+}
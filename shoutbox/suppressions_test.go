@@ -0,0 +1,61 @@
+package shoutbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestSuppressionsService(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.Method != http.MethodGet {
+			return
+		}
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			json.NewEncoder(w).Encode(SuppressionList{
+				Suppressions: []Suppression{{Email: "a@example.com", Reason: SuppressionBounce}},
+				NextCursor:   "page2",
+			})
+		case "page2":
+			json.NewEncoder(w).Encode(SuppressionList{
+				Suppressions: []Suppression{{Email: "b@example.com", Reason: SuppressionComplaint}},
+			})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+	s := client.Suppressions()
+	ctx := context.Background()
+
+	if err := s.Add(ctx, "c@example.com", SuppressionManual); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Remove(ctx, "a+tag@example.com"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	got, err := s.SuppressedAddresses(ctx)
+	if err != nil {
+		t.Fatalf("SuppressedAddresses() error = %v", err)
+	}
+	if want := []string{"a@example.com", "b@example.com"}; !slices.Equal(got, want) {
+		t.Errorf("SuppressedAddresses() = %v, want %v", got, want)
+	}
+
+	want := []string{
+		"POST /suppressions",
+		"DELETE /suppressions/a+tag@example.com",
+		"GET /suppressions?",
+		"GET /suppressions?cursor=page2",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
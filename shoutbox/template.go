@@ -0,0 +1,139 @@
+package shoutbox
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TextTemplateName is the name of the template that RenderTemplate executes
+// for the plain-text body, if the template defines it
+const TextTemplateName = "text"
+
+// RenderTemplate executes tmpl with data for the HTML body. The plain-text
+// body is rendered from the associated template named "text", if tmpl
+// defines one, e.g. {{define "text"}}...{{end}}, and derived from the HTML
+// otherwise.
+func RenderTemplate(tmpl *template.Template, data any) (htmlBody, textBody string, err error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("error rendering template: %w", err)
+	}
+	htmlBody = buf.String()
+
+	textTmpl := tmpl.Lookup(TextTemplateName)
+	if textTmpl == nil || textTmpl == tmpl {
+		return htmlBody, htmlToText(htmlBody), nil
+	}
+	buf.Reset()
+	if err := textTmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("error rendering text template: %w", err)
+	}
+	// html/template escapes the text template as HTML
+	return htmlBody, strings.TrimSpace(html.UnescapeString(buf.String())), nil
+}
+
+// SetBodyTemplate sets the HTML and plain-text bodies of msg from tmpl, as
+// rendered by RenderTemplate
+func (msg *EmailMessage) SetBodyTemplate(tmpl *template.Template, data any) error {
+	htmlBody, textBody, err := RenderTemplate(tmpl, data)
+	if err != nil {
+		return err
+	}
+	msg.HTML, msg.Text = htmlBody, textBody
+	return nil
+}
+
+// SetBodyTemplate sets the HTML and plain-text bodies of req from tmpl, as
+// rendered by RenderTemplate
+func (req *EmailRequest) SetBodyTemplate(tmpl *template.Template, data any) error {
+	htmlBody, textBody, err := RenderTemplate(tmpl, data)
+	if err != nil {
+		return err
+	}
+	req.HTML, req.Text = htmlBody, textBody
+	return nil
+}
+
+var (
+	// htmlDropPattern matches elements whose content isn't displayed
+	htmlDropPattern = regexp.MustCompile(`(?is)<head\b.*?</head\s*>|<style\b.*?</style\s*>|<script\b.*?</script\s*>|<!--.*?-->`)
+	// htmlLinkPattern matches links, capturing the target and the text
+	htmlLinkPattern = regexp.MustCompile(`(?is)<a\b[^>]*?\bhref\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a\s*>`)
+	// htmlBreakPattern matches tags that end a line
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(div|tr|h[1-6]|p|table|blockquote)\s*>`)
+	// htmlItemPattern matches the start of a list item
+	htmlItemPattern = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	// htmlOrderedListPattern matches ordered lists, whose items are
+	// numbered
+	htmlOrderedListPattern = regexp.MustCompile(`(?is)<ol\b[^>]*>.*?</ol\s*>`)
+	htmlStartPattern       = regexp.MustCompile(`(?i)^<ol\b[^>]*\bstart\s*=\s*["']?(\d+)`)
+	// htmlParagraphPattern matches tags that end a paragraph
+	htmlParagraphPattern = regexp.MustCompile(`(?i)</(p|h[1-6]|table|blockquote|ul|ol)\s*>`)
+	// htmlPrePattern matches preformatted blocks, capturing the content
+	htmlPrePattern    = regexp.MustCompile(`(?is)<pre\b[^>]*>(.*?)</pre\s*>`)
+	htmlTagPattern    = regexp.MustCompile(`(?s)<[^>]*>`)
+	spacePattern      = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText derives a plain-text body from HTML: markup is removed, block
+// elements end lines, list items are bulleted, link targets follow the
+// link text and preformatted text keeps its line breaks
+func htmlToText(s string) string {
+	s = htmlDropPattern.ReplaceAllString(s, "")
+	var pre []string
+	s = htmlPrePattern.ReplaceAllStringFunc(s, func(block string) string {
+		content := htmlPrePattern.FindStringSubmatch(block)[1]
+		content = html.UnescapeString(htmlTagPattern.ReplaceAllString(content, ""))
+		pre = append(pre, strings.Trim(content, "\r\n"))
+		return "<p>" + prePlaceholder(len(pre)-1) + "</p>"
+	})
+	s = strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+	s = htmlLinkPattern.ReplaceAllStringFunc(s, func(link string) string {
+		m := htmlLinkPattern.FindStringSubmatch(link)
+		href, text := html.UnescapeString(m[1]), strings.TrimSpace(htmlTagPattern.ReplaceAllString(m[2], ""))
+		if text == "" || html.UnescapeString(text) == href || strings.HasPrefix(href, "#") {
+			if text == "" {
+				return href
+			}
+			return text
+		}
+		return text + " (" + href + ")"
+	})
+	s = htmlOrderedListPattern.ReplaceAllStringFunc(s, func(list string) string {
+		n := 1
+		if m := htmlStartPattern.FindStringSubmatch(list); m != nil {
+			n, _ = strconv.Atoi(m[1])
+		}
+		return htmlItemPattern.ReplaceAllStringFunc(list, func(string) string {
+			n++
+			return "\n" + strconv.Itoa(n-1) + ". "
+		})
+	})
+	s = htmlParagraphPattern.ReplaceAllString(s, "\n\n")
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlItemPattern.ReplaceAllString(s, "\n- ")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesPattern.ReplaceAllString(s, "\n\n")
+	for i, content := range pre {
+		s = strings.Replace(s, prePlaceholder(i), content, 1)
+	}
+	return strings.TrimSpace(s)
+}
+
+// prePlaceholder marks where preformatted block i is restored
+func prePlaceholder(i int) string {
+	return "\x00" + strconv.Itoa(i) + "\x00"
+}
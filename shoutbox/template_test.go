@@ -0,0 +1,88 @@
+package shoutbox
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := map[string]any{"Name": "Ada & Co", "URL": "https://example.com/orders/1"}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		wantHTML string
+		wantText string
+	}{
+		{
+			name:     "derived text",
+			tmpl:     `<h1>Hi {{.Name}}</h1><p>Your order shipped.</p><p><a href="{{.URL}}">Track it</a></p>`,
+			wantHTML: `<h1>Hi Ada &amp; Co</h1><p>Your order shipped.</p><p><a href="https://example.com/orders/1">Track it</a></p>`,
+			wantText: "Hi Ada & Co\n\nYour order shipped.\n\nTrack it (https://example.com/orders/1)",
+		},
+		{
+			name:     "text template",
+			tmpl:     `<p>Hi {{.Name}}</p>{{define "text"}}Hi {{.Name}}, see {{.URL}}{{end}}`,
+			wantHTML: `<p>Hi Ada &amp; Co</p>`,
+			wantText: "Hi Ada & Co, see https://example.com/orders/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("email").Parse(tt.tmpl))
+			msg := &EmailMessage{}
+			if err := msg.SetBodyTemplate(tmpl, data); err != nil {
+				t.Fatalf("SetBodyTemplate() error = %v", err)
+			}
+			if msg.HTML != tt.wantHTML {
+				t.Errorf("HTML = %q, want %q", msg.HTML, tt.wantHTML)
+			}
+			if msg.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", msg.Text, tt.wantText)
+			}
+		})
+	}
+
+	tmpl := template.Must(template.New("email").Option("missingkey=error").Parse(`{{.Missing}}`))
+	if _, _, err := RenderTemplate(tmpl, map[string]any{}); err == nil {
+		t.Error("RenderTemplate() with missing key succeeded")
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "document",
+			html: "<html><head><title>Receipt</title><style>p{color:red}</style></head><body>\n<p>Thanks,\nAda</p><!-- footer --></body></html>",
+			want: "Thanks, Ada",
+		},
+		{
+			name: "list",
+			html: "<p>Items:</p><ul><li>Tea</li><li>Cake &amp; cream</li></ul><p>Done</p>",
+			want: "Items:\n\n- Tea\n- Cake & cream\n\nDone",
+		},
+		{
+			name: "breaks",
+			html: "Line one<br>Line two<br/>",
+			want: "Line one\nLine two",
+		},
+		{
+			name: "link matching its text",
+			html: `<a href="https://example.com">https://example.com</a>`,
+			want: "https://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToText(tt.html); got != tt.want {
+				t.Errorf("htmlToText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package shoutbox
+
+import (
+	"maps"
+	"strings"
+)
+
+// Threading headers, RFC 5322 section 3.6.4
+const (
+	headerMessageID  = "Message-ID"
+	headerInReplyTo  = "In-Reply-To"
+	headerReferences = "References"
+)
+
+// SetParent threads msg as a reply to the message with the given ID, whose
+// own References are references, so mail clients show them together. IDs
+// may be given with or without angle brackets, e.g. as parsed by
+// ParseInbound.
+func (msg *EmailMessage) SetParent(messageID string, references ...string) {
+	msg.InReplyTo, msg.References = messageID, threadReferences(messageID, references)
+}
+
+// SetParent threads req as a reply to the message with the given ID, whose
+// own References are references. See EmailMessage.SetParent.
+func (req *EmailRequest) SetParent(messageID string, references ...string) {
+	req.InReplyTo, req.References = messageID, threadReferences(messageID, references)
+}
+
+// threadReferences returns the References of a reply: those of the parent
+// followed by the parent itself
+func threadReferences(messageID string, references []string) []string {
+	refs := make([]string, 0, len(references)+1)
+	for _, ref := range references {
+		if id := strings.Trim(ref, "<> "); id != "" && id != strings.Trim(messageID, "<> ") {
+			refs = append(refs, id)
+		}
+	}
+	return append(refs, strings.Trim(messageID, "<> "))
+}
+
+// formatMessageID returns id in angle brackets
+func formatMessageID(id string) string {
+	return "<" + strings.Trim(id, "<> ") + ">"
+}
+
+// formatMessageIDList returns ids in angle brackets, separated by spaces
+func formatMessageIDList(ids []string) string {
+	formatted := make([]string, len(ids))
+	for i, id := range ids {
+		formatted[i] = formatMessageID(id)
+	}
+	return strings.Join(formatted, " ")
+}
+
+// threadHeaders returns a copy of headers with the threading headers set,
+// replacing custom headers of the same names. Empty values are skipped.
+func threadHeaders(headers map[string]string, messageID, inReplyTo string, references []string) map[string]string {
+	threaded := maps.Clone(headers)
+	if threaded == nil {
+		threaded = make(map[string]string)
+	}
+	set := func(name, value string) {
+		for key := range threaded {
+			if strings.EqualFold(key, name) {
+				delete(threaded, key)
+			}
+		}
+		threaded[name] = value
+	}
+	if messageID != "" {
+		set(headerMessageID, formatMessageID(messageID))
+	}
+	if inReplyTo != "" {
+		set(headerInReplyTo, formatMessageID(inReplyTo))
+	}
+	if len(references) > 0 {
+		set(headerReferences, formatMessageIDList(references))
+	}
+	return threaded
+}
+
+// validateMessageIDs checks the threading fields of a message: each ID
+// must look like "local@domain", with or without angle brackets
+func validateMessageIDs(messageID, inReplyTo string, references []string) error {
+	fields := [][2]string{{"message_id", messageID}, {"in_reply_to", inReplyTo}}
+	for _, ref := range references {
+		fields = append(fields, [2]string{"references", ref})
+	}
+	for _, f := range fields {
+		if f[1] == "" && f[0] != "references" {
+			continue
+		}
+		id := strings.Trim(f[1], "<>")
+		local, domain, ok := strings.Cut(id, "@")
+		if !ok || local == "" || domain == "" || strings.ContainsAny(id, "<> \t\r\n") {
+			return &HeaderError{Field: f[0], Value: f[1], Reason: "not a message ID"}
+		}
+		if err := validateHeaderValue(f[0], f[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
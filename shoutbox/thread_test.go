@@ -0,0 +1,85 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"slices"
+	"testing"
+)
+
+func TestSMTPClient_Threading(t *testing.T) {
+	parent := &EmailMessage{From: "support@example.com", To: []string{"ada@example.com"}, Subject: "Ticket #42", Text: "We're on it", MessageID: "ticket-42@example.com"}
+	client := &SMTPClient{Sandbox: true}
+	resp, err := client.Send(context.Background(), parent)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if resp.MessageID != "ticket-42@example.com" {
+		t.Errorf("MessageID = %q", resp.MessageID)
+	}
+
+	reply := &EmailMessage{
+		From:    "support@example.com",
+		To:      []string{"ada@example.com"},
+		Subject: "Re: Ticket #42",
+		Text:    "Fixed",
+		Headers: map[string]string{"in-reply-to": "<stale@example.com>"},
+	}
+	reply.SetParent("<ticket-42@example.com>", "<root@example.com>", "ticket-42@example.com")
+	raw, err := client.Render(context.Background(), reply)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Header.Get("In-Reply-To"); got != "<ticket-42@example.com>" {
+		t.Errorf("In-Reply-To = %q", got)
+	}
+	if got := parsed.Header.Get("References"); got != "<root@example.com> <ticket-42@example.com>" {
+		t.Errorf("References = %q", got)
+	}
+	if parsed.Header.Get("Message-ID") == "" {
+		t.Error("no Message-ID generated")
+	}
+
+	inbound, err := ParseInbound(bytes.NewReader(raw))
+	if err != nil || inbound.InReplyTo != "ticket-42@example.com" || !slices.Equal(inbound.References, reply.References) {
+		t.Errorf("ParseInbound() = %+v, %v", inbound, err)
+	}
+}
+
+func TestClient_SendEmailThreading(t *testing.T) {
+	var got EmailRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key")
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{From: "support@example.com", To: Recipients{"ada@example.com"}, Subject: "Re: Ticket #42", HTML: "Fixed", MessageID: "reply-1@example.com"}
+	req.SetParent("ticket-42@example.com")
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+	want := map[string]string{
+		"Message-ID":  "<reply-1@example.com>",
+		"In-Reply-To": "<ticket-42@example.com>",
+		"References":  "<ticket-42@example.com>",
+	}
+	for name, value := range want {
+		if got.Headers[name] != value {
+			t.Errorf("%s = %q, want %q", name, got.Headers[name], value)
+		}
+	}
+	if req.Headers != nil {
+		t.Error("SendEmail() modified the request headers")
+	}
+}
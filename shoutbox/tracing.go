@@ -0,0 +1,95 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/textproto"
+)
+
+// Tracer starts spans around REST API requests and SMTP transactions. It
+// mirrors the OpenTelemetry tracing API, so an OpenTelemetry tracer can be
+// adapted in a few lines without this package depending on it.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation started by a Tracer
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute describes a span. Value is a string, int or bool.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Keys of the attributes set on spans, following the OpenTelemetry
+// semantic conventions where one exists
+const (
+	AttrHTTPMethod     = "http.request.method"
+	AttrHTTPStatusCode = "http.response.status_code"
+	AttrURLPath        = "url.path"
+	AttrServerAddress  = "server.address"
+	AttrSMTPStatusCode = "smtp.response.status_code"
+	AttrRecipients     = "shoutbox.recipients"
+	AttrMessageSize    = "shoutbox.message.size"
+	AttrRetries        = "shoutbox.retries"
+)
+
+// startSpan starts a span with tracer, or a span that does nothing if
+// tracer is nil
+func startSpan(ctx context.Context, tracer Tracer, name string) (context.Context, Span) {
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return tracer.Start(ctx, name)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// payloadRecipients returns the number of recipients in a request body
+func payloadRecipients(body any) (int, bool) {
+	switch body := body.(type) {
+	case *sendPayload:
+		return len(body.To) + len(body.Cc) + len(body.Bcc) + len(body.Personalizations), true
+	case batchPayload:
+		n := 0
+		for _, payload := range body.Messages {
+			n += len(payload.To) + len(payload.Cc) + len(payload.Bcc) + len(payload.Personalizations)
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// smtpStatus returns the SMTP reply code of the result of a transaction
+func smtpStatus(err error) (int, bool) {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code, true
+	}
+	if err == nil {
+		return 250, true
+	}
+	return 0, false
+}
+
+// countingWriter counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += n
+	return n, err
+}
@@ -0,0 +1,108 @@
+package shoutbox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTracer records the attributes and errors of every span
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordingSpan{name: name, attrs: make(map[string]any)}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return ctx, span
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) RecordError(err error) { s.err = err }
+func (s *recordingSpan) End()                  { s.ended = true }
+
+func TestClient_WithTracer(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message_id":"msg_1"}`))
+	}))
+	defer srv.Close()
+
+	tracer := &recordingTracer{}
+	client := NewClient("test-key", WithTracer(tracer), WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		RetryStatuses:  []int{http.StatusServiceUnavailable},
+	}))
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Cc: Recipients{"bob@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "shoutbox POST /send" || !span.ended || span.err != nil {
+		t.Errorf("span = %+v", span)
+	}
+	want := map[string]any{
+		AttrHTTPMethod:     http.MethodPost,
+		AttrURLPath:        "/send",
+		AttrHTTPStatusCode: http.StatusOK,
+		AttrRecipients:     2,
+		AttrRetries:        1,
+	}
+	for key, value := range want {
+		if span.attrs[key] != value {
+			t.Errorf("%s = %v, want %v", key, span.attrs[key], value)
+		}
+	}
+	if size, _ := span.attrs[AttrMessageSize].(int); size == 0 {
+		t.Errorf("%s not set", AttrMessageSize)
+	}
+}
+
+func TestClient_WithTracerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	tracer := &recordingTracer{}
+	client := NewClient("test-key", WithTracer(tracer), WithoutRetries())
+	client.baseURL = srv.URL
+
+	if _, err := client.Stats(context.Background(), &StatsQuery{Domain: "example.com"}); err == nil {
+		t.Fatal("Stats() error = nil")
+	}
+	span := tracer.spans[0]
+	if span.name != "shoutbox GET /stats" || span.err == nil || span.attrs[AttrHTTPStatusCode] != http.StatusNotFound {
+		t.Errorf("span = %+v", span)
+	}
+}
@@ -0,0 +1,92 @@
+package shoutbox
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxIdleConnsPerHost is the number of idle connections to the API
+// WithTransportOptions keeps by default. net/http keeps 2, so a process
+// sending from more goroutines than that opens a new connection, with a
+// new TLS handshake, for most requests.
+const DefaultMaxIdleConnsPerHost = 100
+
+// maxDrainBytes is how much of an unread response body is read so its
+// connection can be reused. Larger bodies are closed instead.
+const maxDrainBytes = 256 << 10
+
+// TransportOptions tunes the connection pool of the REST client for bulk
+// sending from a single process. Zero fields use the defaults below.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost defaults to DefaultMaxIdleConnsPerHost
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections to the API, including those
+	// in use; requests beyond it wait for a connection. Zero means no
+	// limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer; defaults to 90
+	// seconds
+	IdleConnTimeout time.Duration
+	// KeepAlive is the TCP keep-alive period; defaults to 30 seconds. It
+	// isn't applied to a transport with its own DialContext.
+	KeepAlive time.Duration
+}
+
+// WithTransportOptions tunes the HTTP transport's connection pool with
+// opts, so concurrent sends reuse open connections. The HTTP client is
+// copied, not modified; clients with a custom RoundTripper are unchanged.
+func WithTransportOptions(opts TransportOptions) Option {
+	return func(c *Client) {
+		c.httpClient = tunedHTTPClient(c.httpClient, opts)
+	}
+}
+
+// tunedHTTPClient returns a copy of client with its transport's connection
+// pool configured by opts
+func tunedHTTPClient(client *http.Client, opts TransportOptions) *http.Client {
+	var transport *http.Transport
+	// dial is whether the transport dials with the default dialer, which
+	// is replaced to set the keep-alive period
+	var dial bool
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+		dial = true
+	case *http.Transport:
+		transport = t.Clone()
+		dial = t.DialContext == nil && t.Dial == nil
+	default:
+		return client
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = 90 * time.Second
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	if transport.MaxIdleConns != 0 && transport.MaxIdleConns < opts.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = opts.MaxIdleConnsPerHost
+	}
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	transport.IdleConnTimeout = opts.IdleConnTimeout
+	transport.DisableKeepAlives = false
+	if dial {
+		transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: opts.KeepAlive}).DialContext
+	}
+	tuned := *client
+	tuned.Transport = transport
+	return &tuned
+}
+
+// drainBody reads what is left of a response body before closing it, so
+// the transport can reuse the connection
+func drainBody(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, maxDrainBytes)
+	body.Close()
+}
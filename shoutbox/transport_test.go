@@ -0,0 +1,131 @@
+package shoutbox
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newConnCountingServer starts a TLS server answering every send, padding its
+// responses so the JSON decoder leaves part of the body unread, and counts
+// the connections opened to it
+func newConnCountingServer(tb testing.TB) (*httptest.Server, *atomic.Int64) {
+	var conns atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message_id":"msg-1"}` + "\n\n\n"))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.StartTLS()
+	tb.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithTransportOptions(t *testing.T) {
+	base := &http.Client{Timeout: time.Minute}
+	client := NewClient("test-key", WithHTTPClient(base), WithTransportOptions(TransportOptions{MaxConnsPerHost: 10}))
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", client.httpClient.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || transport.MaxConnsPerHost != 10 {
+		t.Errorf("MaxIdleConnsPerHost = %d, MaxConnsPerHost = %d", transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if transport.MaxIdleConns < DefaultMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConns = %d, want at least %d", transport.MaxIdleConns, DefaultMaxIdleConnsPerHost)
+	}
+	if client.httpClient.Timeout != time.Minute || base.Transport != nil {
+		t.Error("WithTransportOptions() modified the original client or dropped its settings")
+	}
+
+	var dialed bool
+	dialer := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = true
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	resp, err := tunedHTTPClient(dialer, TransportOptions{}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if !dialed {
+		t.Error("tunedHTTPClient() replaced the transport's DialContext")
+	}
+
+	custom := &http.Client{Transport: roundTripper{}}
+	if got := tunedHTTPClient(custom, TransportOptions{}); got != custom {
+		t.Error("tunedHTTPClient() changed a client with a custom RoundTripper")
+	}
+}
+
+func TestClient_ReusesConnections(t *testing.T) {
+	srv, conns := newConnCountingServer(t)
+	client := NewClient("test-key", WithHTTPClient(srv.Client()))
+	client.baseURL = srv.URL
+
+	req := &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}
+	for range 10 {
+		if _, err := client.SendEmail(context.Background(), req); err != nil {
+			t.Fatalf("SendEmail() error = %v", err)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("connections = %d, want 1", n)
+	}
+}
+
+// benchmarkSendEmail sends bursts of concurrent messages, as a bulk
+// sender working through batches does. Between bursts the transport keeps
+// only MaxIdleConnsPerHost connections open.
+func benchmarkSendEmail(b *testing.B, tune bool) {
+	const burst = 32
+	srv, conns := newConnCountingServer(b)
+	client := NewClient("test-key", WithHTTPClient(srv.Client()))
+	if tune {
+		client = NewClient("test-key", WithHTTPClient(srv.Client()), WithTransportOptions(TransportOptions{}))
+	}
+	client.baseURL = srv.URL
+	req := &EmailRequest{From: "news@example.com", To: Recipients{"ada@example.com"}, Subject: "Hi", HTML: "<p>Hi</p>"}
+
+	b.ResetTimer()
+	for range b.N {
+		var wg sync.WaitGroup
+		for range burst {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := client.SendEmail(context.Background(), req); err != nil {
+					b.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+}
+
+func BenchmarkClient_SendEmail(b *testing.B) {
+	b.Run("default transport", func(b *testing.B) {
+		benchmarkSendEmail(b, false)
+	})
+	b.Run("tuned transport", func(b *testing.B) {
+		benchmarkSendEmail(b, true)
+	})
+}
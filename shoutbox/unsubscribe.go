@@ -0,0 +1,258 @@
+package shoutbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidUnsubscribeToken is returned when a token is malformed or its
+	// signature doesn't match
+	ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+	// ErrUnsubscribeTokenExpired is returned when a token is past its expiry
+	ErrUnsubscribeTokenExpired = errors.New("unsubscribe token expired")
+	// ErrInvalidListUnsubscribe is returned when a ListUnsubscribe has no
+	// valid target
+	ErrInvalidListUnsubscribe = errors.New("invalid List-Unsubscribe")
+)
+
+// List-Unsubscribe headers, RFC 2369 and RFC 8058
+const (
+	headerListUnsubscribe     = "List-Unsubscribe"
+	headerListUnsubscribePost = "List-Unsubscribe-Post"
+	listUnsubscribeOneClick   = "List-Unsubscribe=One-Click"
+)
+
+// ListUnsubscribe describes the List-Unsubscribe header that lets
+// recipients unsubscribe from the mailbox UI. Gmail and Yahoo require it,
+// with one-click unsubscribe, for bulk senders.
+type ListUnsubscribe struct {
+	// URL is an https endpoint, e.g. from UnsubscribeSigner.URL
+	URL string
+	// Mailto is an address that unsubscribes the sender of any email to
+	// it, optionally with a subject: "unsubscribe@example.com?subject=stop"
+	Mailto string
+	// OneClick adds List-Unsubscribe-Post so mailbox providers unsubscribe
+	// with a POST to URL without showing a page (RFC 8058). It requires URL.
+	OneClick bool
+}
+
+// Headers returns the headers for l, validating its targets
+func (l ListUnsubscribe) Headers() (map[string]string, error) {
+	var targets []string
+	if l.Mailto != "" {
+		mailto, err := listUnsubscribeMailto(l.Mailto)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, "<"+mailto+">")
+	}
+	if l.URL != "" {
+		u, err := url.Parse(l.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") || strings.ContainsAny(l.URL, "<>, \t\r\n") {
+			return nil, fmt.Errorf("%w: URL %q must be an absolute http or https URL", ErrInvalidListUnsubscribe, l.URL)
+		}
+		if l.OneClick && u.Scheme != "https" {
+			return nil, fmt.Errorf("%w: one-click unsubscribe requires an https URL", ErrInvalidListUnsubscribe)
+		}
+		targets = append(targets, "<"+l.URL+">")
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: a URL or mailto address is required", ErrInvalidListUnsubscribe)
+	}
+	if l.OneClick && l.URL == "" {
+		return nil, fmt.Errorf("%w: one-click unsubscribe requires a URL", ErrInvalidListUnsubscribe)
+	}
+
+	headers := map[string]string{headerListUnsubscribe: strings.Join(targets, ", ")}
+	if l.OneClick {
+		headers[headerListUnsubscribePost] = listUnsubscribeOneClick
+	}
+	return headers, nil
+}
+
+// listUnsubscribeMailto returns the mailto URL of an address with an
+// optional query
+func listUnsubscribeMailto(mailto string) (string, error) {
+	address, query, _ := strings.Cut(strings.TrimPrefix(mailto, "mailto:"), "?")
+	if _, err := mail.ParseAddress(address); err != nil || strings.ContainsAny(address, "<> ") {
+		return "", fmt.Errorf("%w: invalid mailto address %q", ErrInvalidListUnsubscribe, address)
+	}
+	if query == "" {
+		return "mailto:" + address, nil
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid mailto query %q", ErrInvalidListUnsubscribe, query)
+	}
+	// Encode with %20 for spaces, which mail clients expect in mailto URLs
+	return "mailto:" + address + "?" + strings.ReplaceAll(values.Encode(), "+", "%20"), nil
+}
+
+// SetListUnsubscribe sets the List-Unsubscribe headers of msg, replacing
+// any set before
+func (msg *EmailMessage) SetListUnsubscribe(l ListUnsubscribe) error {
+	return setListUnsubscribe(&msg.Headers, l)
+}
+
+// SetListUnsubscribe sets the List-Unsubscribe headers of req, replacing
+// any set before
+func (req *EmailRequest) SetListUnsubscribe(l ListUnsubscribe) error {
+	return setListUnsubscribe(&req.Headers, l)
+}
+
+func setListUnsubscribe(headers *map[string]string, l ListUnsubscribe) error {
+	values, err := l.Headers()
+	if err != nil {
+		return err
+	}
+	if *headers == nil {
+		*headers = make(map[string]string, len(values))
+	}
+	delete(*headers, headerListUnsubscribePost)
+	for name, value := range values {
+		(*headers)[name] = value
+	}
+	return nil
+}
+
+// UnsubscribeSigner mints and verifies signed, expiring unsubscribe tokens
+// for a (recipient, list) pair
+type UnsubscribeSigner struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+type unsubscribeClaims struct {
+	Recipient string `json:"r"`
+	List      string `json:"l,omitempty"`
+	Expires   int64  `json:"e"`
+}
+
+// NewUnsubscribeSigner creates a signer using secret as the HMAC key.
+// Tokens are valid for ttl after they are minted.
+func NewUnsubscribeSigner(secret []byte, ttl time.Duration) *UnsubscribeSigner {
+	return &UnsubscribeSigner{
+		secret: secret,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Token returns a signed token for recipient and list
+func (s *UnsubscribeSigner) Token(recipient, list string) string {
+	payload, _ := json.Marshal(unsubscribeClaims{
+		Recipient: recipient,
+		List:      list,
+		Expires:   s.now().Add(s.ttl).Unix(),
+	})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+}
+
+// URL returns baseURL with the token for recipient and list added as the
+// "token" query parameter, suitable for a List-Unsubscribe header
+func (s *UnsubscribeSigner) URL(baseURL, recipient, list string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing unsubscribe URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("token", s.Token(recipient, list))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify checks token and returns the recipient and list it was minted for
+func (s *UnsubscribeSigner) Verify(token string) (recipient, list string, err error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, s.sign(encoded)) {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	var claims unsubscribeClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", "", ErrInvalidUnsubscribeToken
+	}
+	if s.now().Unix() > claims.Expires {
+		return "", "", ErrUnsubscribeTokenExpired
+	}
+
+	return claims.Recipient, claims.List, nil
+}
+
+// unsubscribeConfirmPage is served for GET requests; it posts back to the
+// same token, since link scanners follow GET links in mail
+var unsubscribeConfirmPage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width"><title>Unsubscribe</title></head>
+<body>
+<form method="post" action="?token={{.}}">
+<p>Unsubscribe from these emails?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// Handler returns an http.Handler that verifies the "token" query parameter
+// and calls unsubscribe for the recipient and list it was minted for.
+// Only POST requests unsubscribe, as mail clients send for RFC 8058
+// one-click unsubscribes; GET requests, e.g. a recipient following the
+// link, get a confirmation page that posts back.
+func (s *UnsubscribeSigner) Handler(unsubscribe func(ctx context.Context, recipient, list string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.URL.Query().Get("token")
+		recipient, list, err := s.Verify(token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			unsubscribeConfirmPage.Execute(w, token)
+			return
+		}
+
+		if err := unsubscribe(r.Context(), recipient, list); err != nil {
+			http.Error(w, "error processing unsubscribe", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "You have been unsubscribed.")
+	})
+}
+
+func (s *UnsubscribeSigner) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
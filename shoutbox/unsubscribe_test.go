@@ -0,0 +1,144 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnsubscribeSigner_Verify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer := NewUnsubscribeSigner([]byte("secret"), time.Hour)
+	signer.now = func() time.Time { return now }
+
+	valid := signer.Token("user@example.com", "newsletter")
+	other := NewUnsubscribeSigner([]byte("other"), time.Hour)
+	other.now = signer.now
+
+	tests := []struct {
+		name    string
+		token   string
+		at      time.Time
+		wantErr error
+	}{
+		{name: "valid token", token: valid, at: now},
+		{name: "expired token", token: valid, at: now.Add(2 * time.Hour), wantErr: ErrUnsubscribeTokenExpired},
+		{name: "wrong secret", token: other.Token("user@example.com", "newsletter"), at: now, wantErr: ErrInvalidUnsubscribeToken},
+		{name: "tampered token", token: "x" + valid, at: now, wantErr: ErrInvalidUnsubscribeToken},
+		{name: "malformed token", token: "garbage", at: now, wantErr: ErrInvalidUnsubscribeToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer.now = func() time.Time { return tt.at }
+			recipient, list, err := signer.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (recipient != "user@example.com" || list != "newsletter") {
+				t.Errorf("Verify() = %q, %q", recipient, list)
+			}
+		})
+	}
+}
+
+func TestUnsubscribeSigner_Handler(t *testing.T) {
+	signer := NewUnsubscribeSigner([]byte("secret"), time.Hour)
+
+	var gotRecipient, gotList string
+	handler := signer.Handler(func(ctx context.Context, recipient, list string) error {
+		gotRecipient, gotList = recipient, list
+		return nil
+	})
+
+	link, err := signer.URL("https://example.com/unsubscribe", "user@example.com", "newsletter")
+	if err != nil {
+		t.Fatalf("URL() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, link, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if gotRecipient != "" {
+		t.Fatalf("GET unsubscribed %q, want a confirmation page only", gotRecipient)
+	}
+	token := strings.TrimPrefix(link[strings.Index(link, "?"):], "?token=")
+	if body := rec.Body.String(); !strings.Contains(body, `<form method="post" action="?token=`+token+`">`) {
+		t.Errorf("confirmation page = %s, want a form posting the token back", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, link, strings.NewReader("List-Unsubscribe=One-Click")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if gotRecipient != "user@example.com" || gotList != "newsletter" {
+		t.Errorf("unsubscribe called with %q, %q", gotRecipient, gotList)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unsubscribe?token=bad", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestListUnsubscribe_Headers(t *testing.T) {
+	tests := []struct {
+		name     string
+		l        ListUnsubscribe
+		want     string
+		wantPost bool
+		wantErr  bool
+	}{
+		{
+			name:     "url and mailto with one-click",
+			l:        ListUnsubscribe{URL: "https://example.com/unsubscribe?token=abc", Mailto: "unsubscribe@example.com", OneClick: true},
+			want:     "<mailto:unsubscribe@example.com>, <https://example.com/unsubscribe?token=abc>",
+			wantPost: true,
+		},
+		{
+			name: "mailto with subject",
+			l:    ListUnsubscribe{Mailto: "mailto:unsubscribe@example.com?subject=stop sending"},
+			want: "<mailto:unsubscribe@example.com?subject=stop%20sending>",
+		},
+		{name: "http url", l: ListUnsubscribe{URL: "http://example.com/u"}, want: "<http://example.com/u>"},
+		{name: "empty", l: ListUnsubscribe{}, wantErr: true},
+		{name: "one-click without url", l: ListUnsubscribe{Mailto: "u@example.com", OneClick: true}, wantErr: true},
+		{name: "one-click over http", l: ListUnsubscribe{URL: "http://example.com/u", OneClick: true}, wantErr: true},
+		{name: "relative url", l: ListUnsubscribe{URL: "/unsubscribe"}, wantErr: true},
+		{name: "url with injection", l: ListUnsubscribe{URL: "https://example.com/>, <https://evil.example"}, wantErr: true},
+		{name: "invalid mailto", l: ListUnsubscribe{Mailto: "not an address"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &EmailMessage{Headers: map[string]string{headerListUnsubscribePost: listUnsubscribeOneClick}}
+			err := msg.SetListUnsubscribe(tt.l)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidListUnsubscribe) {
+					t.Errorf("SetListUnsubscribe() error = %v, want ErrInvalidListUnsubscribe", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetListUnsubscribe() error = %v", err)
+			}
+			if got := msg.Headers[headerListUnsubscribe]; got != tt.want {
+				t.Errorf("List-Unsubscribe = %q, want %q", got, tt.want)
+			}
+			if _, ok := msg.Headers[headerListUnsubscribePost]; ok != tt.wantPost {
+				t.Errorf("List-Unsubscribe-Post set = %v, want %v", ok, tt.wantPost)
+			}
+			if err := msg.validateHeaders(); err != nil {
+				t.Errorf("validateHeaders() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// DefaultUploadChunkSize is the size of the chunks attachments are
+// uploaded in
+const DefaultUploadChunkSize = 8 << 20
+
+// Upload is attachment content uploaded ahead of a send
+type Upload struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	// Size is the announced length of the content, or zero if unknown
+	Size int `json:"size,omitempty"`
+	// Received is the number of bytes stored so far; an interrupted
+	// upload resumes from there
+	Received int  `json:"received"`
+	Complete bool `json:"complete"`
+}
+
+// UploadError is returned when uploading an attachment fails after the
+// upload was created. Pass UploadID to UploadsService.Resume to continue
+// it.
+type UploadError struct {
+	UploadID string
+	Err      error
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("error uploading attachment (upload %s): %v", e.UploadID, e.Err)
+}
+
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// uploadChunk is the request body of a chunk of an upload
+type uploadChunk struct {
+	Offset int `json:"offset"`
+	// Data is base64-encoded in the request
+	Data []byte `json:"data"`
+}
+
+// UploadsService uploads large attachments in chunks, so they are
+// referenced by ID in send requests instead of being inlined
+type UploadsService struct {
+	client    *Client
+	chunkSize int
+}
+
+// Uploads returns the attachment upload API
+func (c *Client) Uploads() *UploadsService {
+	return &UploadsService{client: c, chunkSize: DefaultUploadChunkSize}
+}
+
+// Upload uploads the content of a in chunks and returns an attachment
+// referencing it, to send in its place. Streamed attachments are read one
+// chunk at a time instead of into memory.
+func (s *UploadsService) Upload(ctx context.Context, a Attachment) (Attachment, error) {
+	created := Upload{Filename: a.Filename, ContentType: a.ContentType, Size: a.size()}
+	var upload Upload
+	if err := s.client.do(ctx, http.MethodPost, "/uploads", created, &upload); err != nil {
+		return Attachment{}, fmt.Errorf("error creating upload for %s: %w", a.Filename, err)
+	}
+	return s.upload(ctx, &upload, a)
+}
+
+// Resume continues an interrupted upload of a, skipping the content the
+// API already received, and returns an attachment referencing it
+func (s *UploadsService) Resume(ctx context.Context, id string, a Attachment) (Attachment, error) {
+	upload, err := s.Get(ctx, id)
+	if err != nil {
+		return Attachment{}, &UploadError{UploadID: id, Err: err}
+	}
+	return s.upload(ctx, upload, a)
+}
+
+// Get returns the state of an upload
+func (s *UploadsService) Get(ctx context.Context, id string) (*Upload, error) {
+	var upload Upload
+	if err := s.client.do(ctx, http.MethodGet, "/uploads/"+url.PathEscape(id), nil, &upload); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// upload sends the content of a after what upload already received and
+// completes it
+func (s *UploadsService) upload(ctx context.Context, upload *Upload, a Attachment) (Attachment, error) {
+	ref := Attachment{Filename: a.Filename, ContentType: a.ContentType, UploadID: upload.ID}
+	if upload.Complete {
+		return ref, nil
+	}
+
+	var r io.Reader = bytes.NewReader(a.Content)
+	if a.Open != nil {
+		rc, err := a.Open()
+		if err != nil {
+			return Attachment{}, &UploadError{UploadID: upload.ID, Err: fmt.Errorf("error opening attachment %s: %w", a.Filename, err)}
+		}
+		defer rc.Close()
+		r = rc
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(upload.Received)); err != nil {
+		return Attachment{}, &UploadError{UploadID: upload.ID, Err: fmt.Errorf("error skipping uploaded content of %s: %w", a.Filename, err)}
+	}
+
+	path := "/uploads/" + url.PathEscape(upload.ID)
+	chunk := make([]byte, s.chunkSize)
+	offset := upload.Received
+	for {
+		n, err := io.ReadFull(r, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return Attachment{}, &UploadError{UploadID: upload.ID, Err: fmt.Errorf("error reading attachment %s: %w", a.Filename, err)}
+		}
+		if n > 0 {
+			if err := s.client.do(ctx, http.MethodPut, path+"/chunks", uploadChunk{Offset: offset, Data: chunk[:n]}, nil); err != nil {
+				return Attachment{}, &UploadError{UploadID: upload.ID, Err: err}
+			}
+			offset += n
+		}
+		if n < len(chunk) {
+			break
+		}
+	}
+	if err := s.client.do(ctx, http.MethodPost, path+"/complete", nil, nil); err != nil {
+		return Attachment{}, &UploadError{UploadID: upload.ID, Err: err}
+	}
+	return ref, nil
+}
+
+// uploadAttachments returns a copy of req with non-inline attachments
+// larger than the client's upload threshold uploaded and referenced by ID
+func (c *Client) uploadAttachments(ctx context.Context, req *EmailRequest) (*EmailRequest, error) {
+	uploaded := *req
+	uploaded.Attachments = slices.Clone(req.Attachments)
+	for i, a := range uploaded.Attachments {
+		if a.Inline || a.UploadID != "" || a.size() <= c.uploadThreshold {
+			continue
+		}
+		ref, err := c.Uploads().Upload(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		uploaded.Attachments[i] = ref
+	}
+	return &uploaded, nil
+}
@@ -0,0 +1,162 @@
+package shoutbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeUploads serves the upload endpoints, storing uploaded content, and
+// fails the first chunk at failOffset if it is positive
+type fakeUploads struct {
+	content    bytes.Buffer
+	complete   bool
+	failOffset int
+	chunks     int
+	sent       EmailRequest
+}
+
+func (f *fakeUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/uploads":
+		var upload Upload
+		json.NewDecoder(r.Body).Decode(&upload)
+		upload.ID = "up-1"
+		json.NewEncoder(w).Encode(upload)
+	case r.Method == http.MethodGet && r.URL.Path == "/uploads/up-1":
+		json.NewEncoder(w).Encode(Upload{ID: "up-1", Received: f.content.Len(), Complete: f.complete})
+	case r.Method == http.MethodPut && r.URL.Path == "/uploads/up-1/chunks":
+		var chunk uploadChunk
+		json.NewDecoder(r.Body).Decode(&chunk)
+		if f.failOffset > 0 && chunk.Offset == f.failOffset {
+			f.failOffset = 0
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if chunk.Offset != f.content.Len() {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.chunks++
+		f.content.Write(chunk.Data)
+	case r.Method == http.MethodPost && r.URL.Path == "/uploads/up-1/complete":
+		f.complete = true
+	case r.URL.Path == "/send":
+		json.NewDecoder(r.Body).Decode(&f.sent)
+		json.NewEncoder(w).Encode(SendResponse{MessageID: "msg-1"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestUploadsService(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 2) + "abcde")
+
+	tests := []struct {
+		name       string
+		attachment Attachment
+		failOffset int
+		wantChunks int
+	}{
+		{
+			name:       "in memory",
+			attachment: Attachment{Filename: "video.mp4", ContentType: "video/mp4", Content: content},
+			wantChunks: 3,
+		},
+		{
+			name: "streamed",
+			attachment: Attachment{
+				Filename: "video.mp4",
+				Open:     func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(content)), nil },
+				Size:     len(content),
+			},
+			wantChunks: 3,
+		},
+		{
+			name:       "resumed",
+			attachment: Attachment{Filename: "video.mp4", Content: content},
+			failOffset: 10,
+			wantChunks: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeUploads{failOffset: tt.failOffset}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			client := NewClient("test-key", WithoutRetries())
+			client.baseURL = srv.URL
+			uploads := client.Uploads()
+			uploads.chunkSize = 10
+			ctx := context.Background()
+
+			ref, err := uploads.Upload(ctx, tt.attachment)
+			if tt.failOffset > 0 {
+				var uploadErr *UploadError
+				if !errors.As(err, &uploadErr) || uploadErr.UploadID != "up-1" {
+					t.Fatalf("Upload() error = %v, want UploadError for up-1", err)
+				}
+				ref, err = uploads.Resume(ctx, uploadErr.UploadID, tt.attachment)
+			}
+			if err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+
+			if ref.UploadID != "up-1" || ref.Filename != "video.mp4" || ref.Content != nil {
+				t.Errorf("Upload() = %+v, want a reference to up-1", ref)
+			}
+			if !bytes.Equal(fake.content.Bytes(), content) || !fake.complete {
+				t.Errorf("uploaded %q (complete %v), want %q", fake.content.Bytes(), fake.complete, content)
+			}
+			if fake.chunks != tt.wantChunks {
+				t.Errorf("chunks = %d, want %d", fake.chunks, tt.wantChunks)
+			}
+		})
+	}
+}
+
+func TestClient_ChunkedUploads(t *testing.T) {
+	fake := &fakeUploads{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := NewClient("test-key", WithChunkedUploads(16))
+	client.baseURL = srv.URL
+
+	large := bytes.Repeat([]byte("x"), 32)
+	req := &EmailRequest{
+		From:    "news@example.com",
+		To:      Recipients{"ada@example.com"},
+		Subject: "Recording",
+		HTML:    "<p>Attached</p>",
+		Attachments: []Attachment{
+			{Filename: "small.txt", Content: []byte("hi")},
+			{Filename: "large.bin", Content: large},
+		},
+	}
+	if _, err := client.SendEmail(context.Background(), req); err != nil {
+		t.Fatalf("SendEmail() error = %v", err)
+	}
+
+	got := fake.sent.Attachments
+	if len(got) != 2 || got[0].UploadID != "" || string(got[0].Content) != "hi" {
+		t.Errorf("small attachment = %+v, want it inlined", got)
+	}
+	if len(got) == 2 && (got[1].UploadID != "up-1" || got[1].Content != nil) {
+		t.Errorf("large attachment = %+v, want a reference to up-1", got[1])
+	}
+	if !bytes.Equal(fake.content.Bytes(), large) {
+		t.Errorf("uploaded %d bytes, want %d", fake.content.Len(), len(large))
+	}
+	if req.Attachments[1].UploadID != "" {
+		t.Error("SendEmail() modified the request's attachments")
+	}
+}
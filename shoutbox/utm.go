@@ -0,0 +1,95 @@
+package shoutbox
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// UTMParams are the campaign parameters appended to links in the HTML body
+type UTMParams struct {
+	Source string
+	Medium string
+	// Campaign defaults to the message's first tag
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// forTags returns the parameters with Campaign defaulted from tags
+func (p UTMParams) forTags(tags []string) UTMParams {
+	if p.Campaign == "" && len(tags) > 0 {
+		p.Campaign = tags[0]
+	}
+	return p
+}
+
+var linkHrefPattern = regexp.MustCompile(`(?i)(<a\s[^>]*?\bhref\s*=\s*)("[^"]*"|'[^']*')`)
+
+// AppendUTM appends the UTM parameters to every http(s) link in body.
+// Parameters already present on a link are left untouched, as are links
+// containing {{placeholders}}, which are filled in after sending.
+func AppendUTM(body string, params UTMParams) string {
+	return linkHrefPattern.ReplaceAllStringFunc(body, func(match string) string {
+		parts := linkHrefPattern.FindStringSubmatch(match)
+		quoted := parts[2]
+		quote, value := quoted[:1], quoted[1:len(quoted)-1]
+
+		link, ok := appendUTMToURL(html.UnescapeString(value), params)
+		if !ok {
+			return match
+		}
+		return parts[1] + quote + html.EscapeString(link) + quote
+	})
+}
+
+// appendUTMToURL adds the parameters to the query of link as text, so the
+// rest of the link keeps its exact spelling
+func appendUTMToURL(link string, params UTMParams) (string, bool) {
+	if hasPlaceholder(link) {
+		return "", false
+	}
+	lower := strings.ToLower(link)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return "", false
+	}
+
+	link, fragment, hasFragment := strings.Cut(link, "#")
+	_, query, _ := strings.Cut(link, "?")
+	existing, _ := url.ParseQuery(query)
+	var added []string
+	for _, p := range [][2]string{
+		{"utm_source", params.Source},
+		{"utm_medium", params.Medium},
+		{"utm_campaign", params.Campaign},
+		{"utm_term", params.Term},
+		{"utm_content", params.Content},
+	} {
+		if p[1] == "" || existing.Has(p[0]) {
+			continue
+		}
+		added = append(added, p[0]+"="+url.QueryEscape(p[1]))
+	}
+	if len(added) == 0 {
+		return "", false
+	}
+
+	switch {
+	case !strings.Contains(link, "?"):
+		link += "?"
+	case !strings.HasSuffix(link, "?") && !strings.HasSuffix(link, "&"):
+		link += "&"
+	}
+	link += strings.Join(added, "&")
+	if hasFragment {
+		link += "#" + fragment
+	}
+	return link, true
+}
+
+// hasPlaceholder reports whether s contains a {{placeholder}}
+func hasPlaceholder(s string) bool {
+	_, after, ok := strings.Cut(s, "{{")
+	return ok && strings.Contains(after, "}}")
+}
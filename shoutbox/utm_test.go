@@ -0,0 +1,68 @@
+package shoutbox
+
+import "testing"
+
+func TestAppendUTM(t *testing.T) {
+	params := UTMParams{Source: "shoutbox", Medium: "email", Campaign: "spring sale"}
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "plain link",
+			html: `<a href="https://example.com/shop">Shop</a>`,
+			want: `<a href="https://example.com/shop?utm_source=shoutbox&amp;utm_medium=email&amp;utm_campaign=spring+sale">Shop</a>`,
+		},
+		{
+			name: "existing query and fragment",
+			html: `<a class="btn" href='https://example.com/?a=1&amp;b=2#top'>Go</a>`,
+			want: `<a class="btn" href='https://example.com/?a=1&amp;b=2&amp;utm_source=shoutbox&amp;utm_medium=email&amp;utm_campaign=spring+sale#top'>Go</a>`,
+		},
+		{
+			name: "existing utm parameter kept",
+			html: `<a href="https://example.com/?utm_source=blog">Go</a>`,
+			want: `<a href="https://example.com/?utm_source=blog&amp;utm_medium=email&amp;utm_campaign=spring+sale">Go</a>`,
+		},
+		{
+			name: "non-http links untouched",
+			html: `<a href="mailto:help@example.com">Mail</a><a href="#top">Top</a>`,
+			want: `<a href="mailto:help@example.com">Mail</a><a href="#top">Top</a>`,
+		},
+		{
+			name: "placeholder links untouched",
+			html: `<a href="https://example.com/{{path}}">Go</a><a href="{{ unsubscribe_url }}">Leave</a>`,
+			want: `<a href="https://example.com/{{path}}">Go</a><a href="{{ unsubscribe_url }}">Leave</a>`,
+		},
+		{
+			name: "link spelling kept",
+			html: `<a href="HTTPS://example.com/café/a%2fb?q=x%20y&amp;">Go</a>`,
+			want: `<a href="HTTPS://example.com/café/a%2fb?q=x%20y&amp;utm_source=shoutbox&amp;utm_medium=email&amp;utm_campaign=spring+sale">Go</a>`,
+		},
+		{
+			name: "non-link tags untouched",
+			html: `<link href="https://example.com/style.css">`,
+			want: `<link href="https://example.com/style.css">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppendUTM(tt.html, params); got != tt.want {
+				t.Errorf("AppendUTM() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUTMParams_CampaignFromTags(t *testing.T) {
+	params := UTMParams{Source: "shoutbox"}
+	if got := params.forTags([]string{"welcome", "onboarding"}).Campaign; got != "welcome" {
+		t.Errorf("Campaign = %q, want the first tag", got)
+	}
+	params.Campaign = "spring"
+	if got := params.forTags([]string{"welcome"}).Campaign; got != "spring" {
+		t.Errorf("Campaign = %q, want the set campaign kept", got)
+	}
+}
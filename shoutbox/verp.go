@@ -0,0 +1,195 @@
+package shoutbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidVERPAddress is returned when an address wasn't generated by the
+// VERP or its signature doesn't match
+var ErrInvalidVERPAddress = errors.New("invalid VERP address")
+
+// VERPKind is the purpose of a VERP address
+type VERPKind string
+
+const (
+	// VERPBounce addresses are used as the envelope sender so bounces can
+	// be mapped back to the recipient
+	VERPBounce VERPKind = "bounce"
+	// VERPReply addresses are used as the Reply-To so replies can be mapped
+	// back to the recipient
+	VERPReply VERPKind = "reply"
+)
+
+// verpEncoding is lowercase so tags survive MTAs that fold the case of
+// the local part
+var verpEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// VERP generates per-recipient plus-addressed envelope senders and
+// reply-to addresses, and maps them back to the original message and
+// recipient. Addresses carry a short token signed with the secret, so
+// their local part stays within the 64 octets SMTP allows; the message and
+// recipient behind each token are kept in a VERPStore.
+type VERP struct {
+	// Domain receives the bounces and replies
+	Domain string
+	// BounceLocal is the local part of bounce addresses, "bounces" by default
+	BounceLocal string
+	// ReplyLocal is the local part of reply addresses, "reply" by default
+	ReplyLocal string
+
+	secret []byte
+	store  VERPStore
+}
+
+// VERPStore keeps the message and recipient behind each VERP token. Tokens
+// must be kept for as long as bounces and replies are expected.
+type VERPStore interface {
+	Save(ctx context.Context, token string, tag VERPTag) error
+	// Load returns false if token isn't known
+	Load(ctx context.Context, token string) (VERPTag, bool, error)
+}
+
+// MemoryVERPStore is a VERPStore in memory, for tests and single processes
+// that receive bounces while they run
+type MemoryVERPStore struct {
+	mu   sync.Mutex
+	tags map[string]VERPTag
+}
+
+// NewMemoryVERPStore returns an empty MemoryVERPStore
+func NewMemoryVERPStore() *MemoryVERPStore {
+	return &MemoryVERPStore{tags: make(map[string]VERPTag)}
+}
+
+// Save implements VERPStore
+func (s *MemoryVERPStore) Save(ctx context.Context, token string, tag VERPTag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[token] = tag
+	return nil
+}
+
+// Load implements VERPStore
+func (s *MemoryVERPStore) Load(ctx context.Context, token string) (VERPTag, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag, ok := s.tags[token]
+	return tag, ok, nil
+}
+
+// VERPTag identifies the message and recipient an address was generated for
+type VERPTag struct {
+	Kind      VERPKind
+	MessageID string
+	Recipient string
+}
+
+// NewVERP creates a VERP for domain using secret as the HMAC key and
+// store to map tokens back to messages
+func NewVERP(domain string, secret []byte, store VERPStore) *VERP {
+	return &VERP{
+		Domain:      domain,
+		BounceLocal: "bounces",
+		ReplyLocal:  "reply",
+		secret:      secret,
+		store:       store,
+	}
+}
+
+// BounceAddress returns the envelope sender for messageID sent to recipient
+func (v *VERP) BounceAddress(ctx context.Context, messageID, recipient string) (string, error) {
+	return v.address(ctx, VERPBounce, messageID, recipient)
+}
+
+// ReplyAddress returns the reply-to address for messageID sent to recipient
+func (v *VERP) ReplyAddress(ctx context.Context, messageID, recipient string) (string, error) {
+	return v.address(ctx, VERPReply, messageID, recipient)
+}
+
+// Parse verifies address and returns the message and recipient it was
+// generated for
+func (v *VERP) Parse(ctx context.Context, address string) (VERPTag, error) {
+	local, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(address)), "@")
+	if !ok || domain != strings.ToLower(v.Domain) {
+		return VERPTag{}, ErrInvalidVERPAddress
+	}
+	prefix, token, ok := strings.Cut(local, "+")
+	if !ok || len(token) != verpTokenLength {
+		return VERPTag{}, ErrInvalidVERPAddress
+	}
+
+	var kind VERPKind
+	switch prefix {
+	case strings.ToLower(v.BounceLocal):
+		kind = VERPBounce
+	case strings.ToLower(v.ReplyLocal):
+		kind = VERPReply
+	default:
+		return VERPTag{}, ErrInvalidVERPAddress
+	}
+
+	tag, ok, err := v.store.Load(ctx, token)
+	if err != nil {
+		return VERPTag{}, fmt.Errorf("error loading VERP token: %w", err)
+	}
+	if !ok || tag.Kind != kind || !hmac.Equal([]byte(token), []byte(v.token(tag))) {
+		return VERPTag{}, ErrInvalidVERPAddress
+	}
+	return tag, nil
+}
+
+// Match maps an inbound bounce or reply back to the message and recipient
+// it answers. The envelope headers added by the receiving MTA are checked
+// before To and Cc.
+func (v *VERP) Match(ctx context.Context, msg *InboundMessage) (VERPTag, error) {
+	for _, name := range []string{"Delivered-To", "X-Original-To", "Envelope-To", "To", "Cc"} {
+		for _, value := range msg.Header[name] {
+			addrs, err := mail.ParseAddressList(value)
+			if err != nil {
+				addrs = []*mail.Address{{Address: value}}
+			}
+			for _, addr := range addrs {
+				tag, err := v.Parse(ctx, addr.Address)
+				if err == nil {
+					return tag, nil
+				}
+				if !errors.Is(err, ErrInvalidVERPAddress) {
+					return VERPTag{}, err
+				}
+			}
+		}
+	}
+	return VERPTag{}, ErrInvalidVERPAddress
+}
+
+func (v *VERP) address(ctx context.Context, kind VERPKind, messageID, recipient string) (string, error) {
+	local := v.BounceLocal
+	if kind == VERPReply {
+		local = v.ReplyLocal
+	}
+	tag := VERPTag{Kind: kind, MessageID: messageID, Recipient: strings.ToLower(recipient)}
+	token := v.token(tag)
+	if err := v.store.Save(ctx, token, tag); err != nil {
+		return "", fmt.Errorf("error saving VERP token: %w", err)
+	}
+	return local + "+" + token + "@" + v.Domain, nil
+}
+
+// verpTokenLength is the length of the token in VERP addresses: 80 bits
+// of HMAC, enough that tokens neither collide nor can be guessed
+const verpTokenLength = 16
+
+// token returns the token for tag, a truncated signature of its fields
+func (v *VERP) token(tag VERPTag) string {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(string(tag.Kind) + ":" + tag.MessageID + "\x00" + tag.Recipient))
+	return verpEncoding.EncodeToString(mac.Sum(nil)[:10])
+}
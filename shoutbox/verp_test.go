@@ -0,0 +1,109 @@
+package shoutbox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVERP_Parse(t *testing.T) {
+	ctx := context.Background()
+	v := NewVERP("mail.example.com", []byte("secret"), NewMemoryVERPStore())
+	bounce, err := v.BounceAddress(ctx, "msg-42", "Ada@Example.com")
+	if err != nil {
+		t.Fatalf("BounceAddress() error = %v", err)
+	}
+	reply, _ := v.ReplyAddress(ctx, "msg-42", "ada@example.com")
+
+	tests := []struct {
+		name    string
+		address string
+		want    VERPTag
+		wantErr error
+	}{
+		{
+			name:    "bounce",
+			address: bounce,
+			want:    VERPTag{Kind: VERPBounce, MessageID: "msg-42", Recipient: "ada@example.com"},
+		},
+		{
+			name:    "reply with folded case",
+			address: strings.ToUpper(reply),
+			want:    VERPTag{Kind: VERPReply, MessageID: "msg-42", Recipient: "ada@example.com"},
+		},
+		{
+			name:    "kind swapped",
+			address: strings.Replace(bounce, "bounces+", "reply+", 1),
+			wantErr: ErrInvalidVERPAddress,
+		},
+		{
+			name:    "other domain",
+			address: strings.Replace(bounce, "mail.example.com", "evil.example", 1),
+			wantErr: ErrInvalidVERPAddress,
+		},
+		{
+			name:    "unknown token",
+			address: "bounces+aaaaaaaaaaaaaaaa@mail.example.com",
+			wantErr: ErrInvalidVERPAddress,
+		},
+		{
+			name:    "plain address",
+			address: "bounces@mail.example.com",
+			wantErr: ErrInvalidVERPAddress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Parse(ctx, tt.address)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	other := NewVERP("mail.example.com", []byte("other"), NewMemoryVERPStore())
+	if address, _ := other.BounceAddress(ctx, "msg-42", "ada@example.com"); address == bounce {
+		t.Error("addresses signed with different secrets should differ")
+	}
+
+	long := strings.Repeat("x", 200)
+	address, _ := v.BounceAddress(ctx, "<"+long+"@mail.example.com>", long+"@example.com")
+	if local, _, _ := strings.Cut(address, "@"); len(local) > 64 {
+		t.Errorf("local part is %d octets, want at most 64: %s", len(local), local)
+	}
+	if got, err := v.Parse(ctx, address); err != nil || got.Recipient != long+"@example.com" {
+		t.Errorf("Parse() = %+v, %v, want the long recipient", got, err)
+	}
+}
+
+func TestVERP_Match(t *testing.T) {
+	ctx := context.Background()
+	v := NewVERP("mail.example.com", []byte("secret"), NewMemoryVERPStore())
+	bounce, err := v.BounceAddress(ctx, "msg-7", "bob@example.com")
+	if err != nil {
+		t.Fatalf("BounceAddress() error = %v", err)
+	}
+	raw := "Delivered-To: " + bounce + "\r\n" +
+		"From: MAILER-DAEMON@mx.example.com\r\n" +
+		"To: someone-else@example.com\r\n" +
+		"Subject: Undelivered Mail Returned to Sender\r\n" +
+		"\r\n" +
+		"The mail system could not deliver your message.\r\n"
+
+	msg, err := ParseInbound(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ParseInbound() error = %v", err)
+	}
+	got, err := v.Match(ctx, msg)
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if got.Kind != VERPBounce || got.MessageID != "msg-7" || got.Recipient != "bob@example.com" {
+		t.Errorf("Match() = %+v", got)
+	}
+}
@@ -0,0 +1,181 @@
+package shoutbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// WinZip AES (AE-2) constants
+const (
+	zipMethodAES      = 99
+	zipAESExtraID     = 0x9901
+	zipAESVersionAE2  = 2
+	zipAESStrength256 = 3
+	zipAESKeyLen      = 32
+	zipAESSaltLen     = 16
+	zipAESMACLen      = 10
+	zipAESIterations  = 1000
+	zipVersionAES     = 51
+	zipFlagEncrypted  = 0x1
+	zipFlagUTF8       = 0x800
+)
+
+// NewEncryptedZipAttachment returns a zip archive named name containing
+// files, each encrypted with AES-256 using password (WinZip AE-2 format,
+// supported by 7-Zip, WinZip and macOS Archive Utility)
+func NewEncryptedZipAttachment(name, password string, files []Attachment) (Attachment, error) {
+	if password == "" {
+		return Attachment{}, errors.New("password is required")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	modified := time.Now()
+	for _, file := range files {
+		raw, err := encryptZipEntry(file.Content, password)
+		if err != nil {
+			return Attachment{}, fmt.Errorf("error encrypting %s: %w", file.Filename, err)
+		}
+
+		fh := &zip.FileHeader{
+			Name:               file.Filename,
+			Method:             zipMethodAES,
+			Flags:              zipFlagEncrypted | zipFlagUTF8,
+			CreatorVersion:     zipVersionAES,
+			ReaderVersion:      zipVersionAES,
+			CompressedSize64:   uint64(len(raw)),
+			UncompressedSize64: uint64(len(file.Content)),
+			Extra:              zipAESExtra(zip.Deflate),
+		}
+		fh.ModifiedDate, fh.ModifiedTime = msDosTime(modified)
+
+		w, err := zw.CreateRaw(fh)
+		if err != nil {
+			return Attachment{}, fmt.Errorf("error creating zip entry: %w", err)
+		}
+		if _, err := w.Write(raw); err != nil {
+			return Attachment{}, fmt.Errorf("error writing zip entry: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return Attachment{}, fmt.Errorf("error writing zip: %w", err)
+	}
+
+	return Attachment{
+		Filename:    name,
+		Content:     buf.Bytes(),
+		ContentType: "application/zip",
+	}, nil
+}
+
+// encryptZipEntry deflates content and encrypts it, returning the salt,
+// password verifier, ciphertext and authentication code
+func encryptZipEntry(content []byte, password string) ([]byte, error) {
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	fw.Write(content)
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, zipAESSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := pbkdf2SHA1([]byte(password), salt, zipAESIterations, 2*zipAESKeyLen+2)
+	encKey, macKey, verifier := key[:zipAESKeyLen], key[zipAESKeyLen:2*zipAESKeyLen], key[2*zipAESKeyLen:]
+
+	ciphertext := compressed.Bytes()
+	if err := zipAESCTR(encKey, ciphertext); err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(ciphertext)
+
+	raw := make([]byte, 0, len(salt)+len(verifier)+len(ciphertext)+zipAESMACLen)
+	raw = append(raw, salt...)
+	raw = append(raw, verifier...)
+	raw = append(raw, ciphertext...)
+	raw = append(raw, mac.Sum(nil)[:zipAESMACLen]...)
+	return raw, nil
+}
+
+// zipAESCTR encrypts data in place with AES in counter mode, using the
+// little-endian counter starting at 1 that WinZip AES specifies
+func zipAESCTR(key, data []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	var counter, stream [aes.BlockSize]byte
+	for i := 0; i < len(data); i += aes.BlockSize {
+		for j := range counter {
+			counter[j]++
+			if counter[j] != 0 {
+				break
+			}
+		}
+		block.Encrypt(stream[:], counter[:])
+		for j := i; j < min(i+aes.BlockSize, len(data)); j++ {
+			data[j] ^= stream[j-i]
+		}
+	}
+	return nil
+}
+
+func zipAESExtra(method uint16) []byte {
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], zipAESExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], zipAESVersionAE2)
+	copy(extra[6:], "AE")
+	extra[8] = zipAESStrength256
+	binary.LittleEndian.PutUint16(extra[9:], method)
+	return extra
+}
+
+// pbkdf2SHA1 derives a key from password as specified in RFC 8018
+func pbkdf2SHA1(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha1.New, password)
+	var dk []byte
+	for block := uint32(1); len(dk) < keyLen; block++ {
+		dk = append(dk, pbkdf2Block(prf, salt, iterations, block)...)
+	}
+	return dk[:keyLen]
+}
+
+func pbkdf2Block(prf hash.Hash, salt []byte, iterations int, block uint32) []byte {
+	prf.Reset()
+	prf.Write(salt)
+	prf.Write(binary.BigEndian.AppendUint32(nil, block))
+	u := prf.Sum(nil)
+	t := append([]byte(nil), u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range t {
+			t[j] ^= u[j]
+		}
+	}
+	return t
+}
+
+func msDosTime(t time.Time) (date, clock uint16) {
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, clock
+}
@@ -0,0 +1,101 @@
+package shoutbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+func TestPBKDF2SHA1(t *testing.T) {
+	// Test vectors from RFC 6070
+	tests := []struct {
+		iterations int
+		want       string
+	}{
+		{1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{2, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{4096, "4b007901b765489abead49d926f721d065a429c1"},
+	}
+
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2SHA1([]byte("password"), []byte("salt"), tt.iterations, 20))
+		if got != tt.want {
+			t.Errorf("pbkdf2SHA1(%d) = %s, want %s", tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func TestNewEncryptedZipAttachment(t *testing.T) {
+	files := []Attachment{
+		{Filename: "statement.csv", Content: bytes.Repeat([]byte("date,amount\n2024-01-01,12.50\n"), 50)},
+		{Filename: "notes.txt", Content: []byte("confidential")},
+	}
+
+	att, err := NewEncryptedZipAttachment("documents.zip", "s3cret", files)
+	if err != nil {
+		t.Fatalf("NewEncryptedZipAttachment() error = %v", err)
+	}
+	if att.ContentType != "application/zip" || att.Filename != "documents.zip" {
+		t.Errorf("NewEncryptedZipAttachment() = %q, %q", att.Filename, att.ContentType)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(att.Content), int64(len(att.Content)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	if len(zr.File) != len(files) {
+		t.Fatalf("zip has %d files, want %d", len(zr.File), len(files))
+	}
+	for i, f := range zr.File {
+		if f.Method != zipMethodAES || f.Flags&zipFlagEncrypted == 0 {
+			t.Errorf("%s: method %d flags %x", f.Name, f.Method, f.Flags)
+		}
+		if got := decryptZipEntry(t, f, "s3cret"); !bytes.Equal(got, files[i].Content) {
+			t.Errorf("%s: decrypted content mismatch", f.Name)
+		}
+	}
+
+	if _, err := NewEncryptedZipAttachment("documents.zip", "", files); err == nil {
+		t.Errorf("NewEncryptedZipAttachment() without password error = nil")
+	}
+}
+
+func decryptZipEntry(t *testing.T, f *zip.File, password string) []byte {
+	t.Helper()
+
+	r, err := f.OpenRaw()
+	if err != nil {
+		t.Fatalf("OpenRaw() error = %v", err)
+	}
+	raw, _ := io.ReadAll(r)
+
+	salt := raw[:zipAESSaltLen]
+	verifier := raw[zipAESSaltLen : zipAESSaltLen+2]
+	ciphertext := raw[zipAESSaltLen+2 : len(raw)-zipAESMACLen]
+	tag := raw[len(raw)-zipAESMACLen:]
+
+	key := pbkdf2SHA1([]byte(password), salt, zipAESIterations, 2*zipAESKeyLen+2)
+	if !bytes.Equal(key[2*zipAESKeyLen:], verifier) {
+		t.Fatalf("%s: password verifier mismatch", f.Name)
+	}
+	mac := hmac.New(sha1.New, key[zipAESKeyLen:2*zipAESKeyLen])
+	mac.Write(ciphertext)
+	if !bytes.Equal(mac.Sum(nil)[:zipAESMACLen], tag) {
+		t.Fatalf("%s: authentication code mismatch", f.Name)
+	}
+
+	plain := append([]byte(nil), ciphertext...)
+	if err := zipAESCTR(key[:zipAESKeyLen], plain); err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(flate.NewReader(bytes.NewReader(plain)))
+	if err != nil {
+		t.Fatalf("inflate error = %v", err)
+	}
+	return out
+}
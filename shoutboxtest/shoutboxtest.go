@@ -0,0 +1,86 @@
+// Package shoutboxtest captures messages sent with the shoutbox package,
+// so tests can inspect them without credentials or network access
+package shoutboxtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+// Message is a captured message, parsed from the raw data received
+type Message struct {
+	*shoutbox.InboundMessage
+	// EnvelopeFrom and Recipients are the SMTP envelope sender and
+	// recipients; Recipients includes Bcc addresses
+	EnvelopeFrom string
+	Recipients   []string
+	// MailParams are the parameters of the MAIL command, e.g. "RET=HDRS
+	// BODY=8BITMIME", and RcptParams those of the RCPT command of each
+	// recipient, e.g. "NOTIFY=SUCCESS,FAILURE"
+	MailParams string
+	RcptParams []string
+	// Raw is the message as received
+	Raw []byte
+}
+
+// HasRecipient reports whether email is an envelope recipient of m
+func (m *Message) HasRecipient(email string) bool {
+	for _, rcpt := range m.Recipients {
+		if equalAddress(rcpt, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// Attachment returns the attachment named filename, or nil
+func (m *Message) Attachment(filename string) *shoutbox.Attachment {
+	for i := range m.Attachments {
+		if m.Attachments[i].Filename == filename {
+			return &m.Attachments[i]
+		}
+	}
+	return nil
+}
+
+// CaptureSender is a shoutbox.EmailSender that sends through a real
+// SMTPClient to an in-process SMTPServer, so messages go through the same
+// MIME encoding as in production
+type CaptureSender struct {
+	*SMTPServer
+	// SMTP is the client used to send; set its options, e.g. TrackOpens,
+	// to test them
+	SMTP *shoutbox.SMTPClient
+}
+
+var _ shoutbox.EmailSender = (*CaptureSender)(nil)
+
+// NewCaptureSender starts an SMTPServer that is closed when t ends and
+// returns a sender connected to it
+func NewCaptureSender(t testing.TB) *CaptureSender {
+	server := NewSMTPServer(t)
+	return &CaptureSender{SMTPServer: server, SMTP: server.NewClient()}
+}
+
+// Send sends msg to the capture server
+func (s *CaptureSender) Send(ctx context.Context, msg *shoutbox.EmailMessage) (*shoutbox.SendResponse, error) {
+	return s.SMTP.Send(ctx, msg)
+}
+
+func parseMessage(env envelope, raw []byte) (*Message, error) {
+	inbound, err := shoutbox.ParseInbound(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		InboundMessage: inbound,
+		EnvelopeFrom:   env.from,
+		Recipients:     env.recipients,
+		MailParams:     env.mailParams,
+		RcptParams:     env.rcptParams,
+		Raw:            raw,
+	}, nil
+}
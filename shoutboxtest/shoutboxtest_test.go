@@ -0,0 +1,312 @@
+package shoutboxtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+func TestCaptureSender(t *testing.T) {
+	sender := NewCaptureSender(t)
+	sender.SMTP.TrackOpens = shoutbox.Bool(false)
+
+	msg := &shoutbox.EmailMessage{
+		From:    "news@example.com",
+		Name:    "Example News",
+		To:      []string{"ada@example.com"},
+		Cc:      []string{"grace@example.com"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "Weekly digest",
+		HTML:    "<p>Hello Ada</p>",
+		Text:    "Hello Ada",
+		Headers: map[string]string{"X-Campaign": "weekly"},
+		Attachments: []shoutbox.Attachment{
+			{Filename: "report.csv", Content: []byte("a,b\n1,2\n"), ContentType: "text/csv"},
+		},
+	}
+	resp, err := sender.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	sender.AssertCount(t, 1)
+	got := sender.SentTo(t, "audit@example.com")
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "envelope from", got: got.EnvelopeFrom, want: "news@example.com"},
+		{name: "from name", got: got.From.Name, want: "Example News"},
+		{name: "subject", got: got.Subject, want: "Weekly digest"},
+		{name: "html", got: got.HTML, want: "<p>Hello Ada</p>"},
+		{name: "text", got: got.Text, want: "Hello Ada"},
+		{name: "custom header", got: got.Header.Get("X-Campaign"), want: "weekly"},
+		{name: "tracking header", got: got.Header.Get("X-Shoutbox-Track-Opens"), want: "false"},
+		{name: "bcc hidden", got: got.Header.Get("Bcc"), want: ""},
+		{name: "message id", got: got.MessageID, want: resp.MessageID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+
+	if len(got.Recipients) != 3 {
+		t.Errorf("Recipients = %v", got.Recipients)
+	}
+	if a := got.Attachment("report.csv"); a == nil || string(a.Content) != "a,b\n1,2\n" {
+		t.Errorf("Attachment() = %+v", a)
+	}
+
+	sender.Reset()
+	sender.AssertCount(t, 0)
+}
+
+func TestCaptureSender_InternationalAddresses(t *testing.T) {
+	sender := NewCaptureSender(t)
+
+	msg := &shoutbox.EmailMessage{
+		From:    "news@bücher.example",
+		To:      []string{"müller@beispiel.de", "user@日本.jp"},
+		Subject: "Hallo",
+		Text:    "Hallo",
+	}
+	if _, err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := sender.Last(t)
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "envelope from", got: got.EnvelopeFrom, want: "news@xn--bcher-kva.example"},
+		{name: "utf-8 local part", got: got.Recipients[0], want: "müller@beispiel.de"},
+		{name: "punycode domain", got: got.Recipients[1], want: "user@xn--wgv71a.jp"},
+		{name: "to header", got: got.Header.Get("To"), want: "müller@beispiel.de, user@xn--wgv71a.jp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+	if !got.HasRecipient("user@日本.jp") {
+		t.Error("HasRecipient() = false for the Unicode domain")
+	}
+}
+
+func TestCaptureSender_EnvelopeFrom(t *testing.T) {
+	sender := NewCaptureSender(t)
+	verp := shoutbox.NewVERP("bounces.example.com", []byte("secret"), shoutbox.NewMemoryVERPStore())
+	bounce, err := verp.BounceAddress(context.Background(), "msg-1", "ada@example.com")
+	if err != nil {
+		t.Fatalf("BounceAddress() error = %v", err)
+	}
+
+	msg := &shoutbox.EmailMessage{
+		From:         "news@example.com",
+		To:           []string{"ada@example.com"},
+		Subject:      "Hello",
+		Text:         "Hello",
+		EnvelopeFrom: bounce,
+	}
+	if _, err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := sender.Last(t)
+	if got.EnvelopeFrom != bounce {
+		t.Errorf("EnvelopeFrom = %q, want %q", got.EnvelopeFrom, bounce)
+	}
+	if got.From.Address != "news@example.com" {
+		t.Errorf("From = %q, want the header sender unchanged", got.From.Address)
+	}
+	if tag, err := verp.Parse(context.Background(), got.EnvelopeFrom); err != nil || tag.Recipient != "ada@example.com" {
+		t.Errorf("Parse() = %+v, %v, want the bounce mapped to the recipient", tag, err)
+	}
+}
+
+func TestCaptureSender_FailedStreamIsNotDelivered(t *testing.T) {
+	sender := NewCaptureSender(t)
+
+	msg := &shoutbox.EmailMessage{
+		From:    "news@example.com",
+		To:      []string{"ada@example.com"},
+		Subject: "Report",
+		Text:    "Attached",
+		Attachments: []shoutbox.Attachment{
+			shoutbox.NewStreamingAttachment("report.csv", func() (io.ReadCloser, error) {
+				return nil, errors.New("disk unavailable")
+			}),
+		},
+	}
+	if _, err := sender.Send(context.Background(), msg); err == nil {
+		t.Fatal("Send() succeeded with a failing attachment")
+	}
+	sender.AssertCount(t, 0)
+}
+
+func TestCaptureSender_Pool(t *testing.T) {
+	sender := NewCaptureSender(t)
+	pool := shoutbox.NewSMTPPool(1, time.Minute)
+	defer pool.Close()
+	sender.SMTP.Pool = pool
+
+	send := func() {
+		t.Helper()
+		msg := &shoutbox.EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Hi", Text: "Hi"}
+		if _, err := sender.Send(context.Background(), msg); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	for range 3 {
+		send()
+	}
+	if got := sender.Connections(); got != 1 {
+		t.Errorf("Connections() = %d after pooled sends, want 1", got)
+	}
+
+	sender.DropConnections()
+	send()
+	if got := sender.Connections(); got != 2 {
+		t.Errorf("Connections() = %d after reconnecting, want 2", got)
+	}
+	sender.AssertCount(t, 4)
+}
+
+func TestTLSSMTPServer(t *testing.T) {
+	server := NewTLSSMTPServer(t)
+	client := server.NewClient()
+
+	msg := &shoutbox.EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Secure", Text: "Hi"}
+	if _, err := client.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := server.Last(t).Subject; got != "Secure" {
+		t.Errorf("Subject = %q", got)
+	}
+}
+
+func TestSMTPServer_requireTLS(t *testing.T) {
+	server := NewSMTPServer(t)
+	client := server.NewClient()
+	client.RequireTLS = true
+
+	msg := &shoutbox.EmailMessage{From: "news@example.com", To: []string{"ada@example.com"}, Subject: "Secure", Text: "Hi"}
+	if _, err := client.Send(context.Background(), msg); !errors.Is(err, shoutbox.ErrTLSRequired) {
+		t.Fatalf("Send() error = %v, want ErrTLSRequired", err)
+	}
+	server.AssertCount(t, 0)
+}
+
+type recordingTracer struct {
+	attrs map[string]any
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, shoutbox.Span) {
+	t.attrs = map[string]any{"name": name}
+	return ctx, t
+}
+
+func (t *recordingTracer) SetAttributes(attrs ...shoutbox.Attribute) {
+	for _, attr := range attrs {
+		t.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (t *recordingTracer) RecordError(err error) { t.attrs["error"] = err }
+func (t *recordingTracer) End()                  {}
+
+func TestSMTPServer_tracer(t *testing.T) {
+	server := NewSMTPServer(t)
+	tracer := &recordingTracer{}
+	client := server.NewClient()
+	client.Tracer = tracer
+
+	msg := &shoutbox.EmailMessage{From: "news@example.com", To: []string{"ada@example.com", "bob@example.com"}, Subject: "Traced", Text: "Hi"}
+	if _, err := client.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if tracer.attrs["name"] != "shoutbox smtp send" || tracer.attrs[shoutbox.AttrRecipients] != 2 ||
+		tracer.attrs[shoutbox.AttrSMTPStatusCode] != 250 || tracer.attrs["error"] != nil {
+		t.Errorf("span attributes = %v", tracer.attrs)
+	}
+	if size, _ := tracer.attrs[shoutbox.AttrMessageSize].(int); size == 0 {
+		t.Errorf("%s not set", shoutbox.AttrMessageSize)
+	}
+}
+
+func TestCaptureSender_DSN(t *testing.T) {
+	sender := NewCaptureSender(t)
+	msg := &shoutbox.EmailMessage{
+		From:          "billing@example.com",
+		To:            []string{"ada@example.com"},
+		Subject:       "Invoice",
+		Text:          "Attached",
+		ReadReceiptTo: "billing@example.com",
+		DSN: &shoutbox.DSN{
+			Notify:     []shoutbox.DSNNotify{shoutbox.DSNSuccess, shoutbox.DSNFailure},
+			Return:     shoutbox.DSNReturnHeaders,
+			EnvelopeID: "invoice=42",
+		},
+	}
+	if _, err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := sender.Last(t)
+	if got.MailParams != "RET=HDRS ENVID=invoice+3D42 BODY=8BITMIME SMTPUTF8" {
+		t.Errorf("MAIL parameters = %q", got.MailParams)
+	}
+	if len(got.RcptParams) != 1 || got.RcptParams[0] != "NOTIFY=SUCCESS,FAILURE ORCPT=rfc822;ada@example.com" {
+		t.Errorf("RCPT parameters = %q", got.RcptParams)
+	}
+	if got.Header.Get("Disposition-Notification-To") != "billing@example.com" {
+		t.Errorf("Disposition-Notification-To = %q", got.Header.Get("Disposition-Notification-To"))
+	}
+
+	msg.DSN = &shoutbox.DSN{Notify: []shoutbox.DSNNotify{shoutbox.DSNNever, shoutbox.DSNFailure}}
+	if _, err := sender.Send(context.Background(), msg); err == nil {
+		t.Error("Send() with NOTIFY=NEVER,FAILURE succeeded")
+	}
+}
+
+func TestSMTPServer_SendRaw(t *testing.T) {
+	server := NewSMTPServer(t)
+	raw := "From: shop@example.com\r\n" +
+		"To: ada@example.com\r\n" +
+		"Bcc: audit@example.com\r\n" +
+		"Subject: Receipt\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		"Thanks for your order.\r\n"
+	resp, err := server.NewClient().SendRaw(context.Background(), []byte(raw))
+	if err != nil {
+		t.Fatalf("SendRaw() error = %v", err)
+	}
+
+	got := server.Last(t)
+	if !got.HasRecipient("ada@example.com") || !got.HasRecipient("audit@example.com") {
+		t.Errorf("Recipients = %q", got.Recipients)
+	}
+	if got.Header.Get("Bcc") != "" {
+		t.Errorf("Bcc = %q", got.Header.Get("Bcc"))
+	}
+	if got.MessageID != resp.MessageID || strings.TrimSpace(got.Text) != "Thanks for your order." {
+		t.Errorf("MessageID = %q, want %q; Text = %q", got.MessageID, resp.MessageID, got.Text)
+	}
+}
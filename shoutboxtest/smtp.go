@@ -0,0 +1,306 @@
+package shoutboxtest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+// SMTPServer is a minimal in-process SMTP server that accepts every
+// message and records it. It is safe for concurrent use.
+type SMTPServer struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+	// clientTLS is trusted by NewClient when the server uses implicit TLS
+	clientTLS *tls.Config
+
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	accepted int
+	messages []*Message
+	err      error
+}
+
+// NewSMTPServer starts a server on a local port that is closed when t ends
+func NewSMTPServer(t testing.TB) *SMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("shoutboxtest: error starting SMTP server: %v", err)
+	}
+	s := &SMTPServer{listener: listener, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewTLSSMTPServer starts a server that expects TLS from the start of each
+// connection, as on port 465, with a self-signed certificate that clients
+// from NewClient trust
+func NewTLSSMTPServer(t testing.TB) *SMTPServer {
+	t.Helper()
+	// httptest provides a certificate valid for 127.0.0.1
+	certServer := httptest.NewUnstartedServer(nil)
+	certServer.StartTLS()
+	cert := certServer.TLS.Certificates[0]
+	roots := x509.NewCertPool()
+	roots.AddCert(certServer.Certificate())
+	certServer.Close()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("shoutboxtest: error starting SMTP server: %v", err)
+	}
+	s := &SMTPServer{listener: listener, conns: make(map[net.Conn]struct{}), clientTLS: &tls.Config{RootCAs: roots}}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Addr returns the host:port the server listens on
+func (s *SMTPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// NewClient returns an SMTPClient that sends to the server
+func (s *SMTPServer) NewClient() *shoutbox.SMTPClient {
+	host, portStr, _ := net.SplitHostPort(s.Addr())
+	port, _ := strconv.Atoi(portStr)
+	return &shoutbox.SMTPClient{
+		Host:        host,
+		Port:        port,
+		Username:    "shoutbox",
+		Password:    "test",
+		Auth:        smtp.PlainAuth("", "shoutbox", "test", host),
+		ImplicitTLS: s.clientTLS != nil,
+		TLSConfig:   s.clientTLS,
+	}
+}
+
+// Close stops the server and closes open connections
+func (s *SMTPServer) Close() {
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Connections returns the number of connections accepted so far
+func (s *SMTPServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted
+}
+
+// DropConnections closes every open connection, as a server does when
+// idle connections time out
+func (s *SMTPServer) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Messages returns the captured messages in the order they were received
+func (s *SMTPServer) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// Reset discards the captured messages
+func (s *SMTPServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.err = nil
+}
+
+// AssertCount fails t unless exactly n messages were captured
+func (s *SMTPServer) AssertCount(t testing.TB, n int) {
+	t.Helper()
+	s.checkErr(t)
+	if got := len(s.Messages()); got != n {
+		t.Fatalf("captured %d messages, want %d", got, n)
+	}
+}
+
+// Last returns the last captured message, failing t if there is none
+func (s *SMTPServer) Last(t testing.TB) *Message {
+	t.Helper()
+	s.checkErr(t)
+	messages := s.Messages()
+	if len(messages) == 0 {
+		t.Fatal("no messages captured")
+	}
+	return messages[len(messages)-1]
+}
+
+// SentTo returns the last message with email as an envelope recipient,
+// failing t if there is none
+func (s *SMTPServer) SentTo(t testing.TB, email string) *Message {
+	t.Helper()
+	s.checkErr(t)
+	messages := s.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].HasRecipient(email) {
+			return messages[i]
+		}
+	}
+	t.Fatalf("no message captured for %s", email)
+	return nil
+}
+
+// checkErr fails t if a received message couldn't be parsed
+func (s *SMTPServer) checkErr(t testing.TB) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		t.Fatalf("shoutboxtest: %v", s.err)
+	}
+}
+
+func (s *SMTPServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.accepted++
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// handle speaks just enough SMTP for net/smtp: EHLO, AUTH, MAIL, RCPT
+// and DATA
+func (s *SMTPServer) handle(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	defer tp.Close()
+
+	var env envelope
+	tp.PrintfLine("220 shoutboxtest ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-shoutboxtest")
+			tp.PrintfLine("250-8BITMIME")
+			tp.PrintfLine("250-SMTPUTF8")
+			tp.PrintfLine("250-DSN")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			tp.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			env = envelope{from: envelopeAddress(arg), mailParams: envelopeParams(arg)}
+			tp.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			env.recipients = append(env.recipients, envelopeAddress(arg))
+			env.rcptParams = append(env.rcptParams, envelopeParams(arg))
+			tp.PrintfLine("250 2.1.5 OK")
+		case "DATA":
+			if len(env.recipients) == 0 {
+				tp.PrintfLine("503 5.5.1 No recipients")
+				continue
+			}
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			raw, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.record(env, raw)
+			env = envelope{}
+			tp.PrintfLine("250 2.0.0 OK")
+		case "RSET":
+			env = envelope{}
+			tp.PrintfLine("250 2.0.0 OK")
+		case "NOOP":
+			tp.PrintfLine("250 2.0.0 OK")
+		case "QUIT":
+			tp.PrintfLine("221 2.0.0 Bye")
+			return
+		default:
+			tp.PrintfLine("502 5.5.2 Command not recognized")
+		}
+	}
+}
+
+// envelope is the SMTP envelope of the transaction in progress
+type envelope struct {
+	from       string
+	mailParams string
+	recipients []string
+	rcptParams []string
+}
+
+func (s *SMTPServer) record(env envelope, raw []byte) {
+	msg, err := parseMessage(env, raw)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.err = errors.Join(s.err, err)
+		return
+	}
+	s.messages = append(s.messages, msg)
+}
+
+// envelopeAddress returns the address of a MAIL FROM or RCPT TO argument,
+// e.g. "FROM:<ada@example.com> BODY=8BITMIME"
+func envelopeAddress(arg string) string {
+	_, rest, _ := strings.Cut(arg, "<")
+	address, _, _ := strings.Cut(rest, ">")
+	return address
+}
+
+// envelopeParams returns the parameters after the address of a MAIL FROM
+// or RCPT TO argument
+func envelopeParams(arg string) string {
+	_, params, _ := strings.Cut(arg, ">")
+	return strings.TrimSpace(params)
+}
+
+// equalAddress compares addresses case-insensitively, with internationalized
+// domains matching their punycode form
+func equalAddress(a, b string) bool {
+	return strings.EqualFold(normalizeAddress(a), normalizeAddress(b))
+}
+
+func normalizeAddress(email string) string {
+	email = strings.TrimSpace(email)
+	if converted, err := shoutbox.AddressToASCII(email); err == nil {
+		return converted
+	}
+	return email
+}
@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Backpressure controls what the handler does when the channel is full
+type Backpressure int
+
+const (
+	// Block waits for the consumer until the request is cancelled or the
+	// block timeout expires, then responds 503 so Shoutbox retries
+	Block Backpressure = iota
+	// Reject responds 503 as soon as the channel is full
+	Reject
+	// Drop accepts the request and discards events that don't fit
+	Drop
+)
+
+// ChannelOption configures a ChannelHandler
+type ChannelOption func(*ChannelHandler)
+
+// WithBackpressure sets the behavior when the channel is full. The
+// default is Block.
+func WithBackpressure(b Backpressure) ChannelOption {
+	return func(h *ChannelHandler) {
+		h.backpressure = b
+	}
+}
+
+// WithBlockTimeout limits how long a request waits for the consumer in
+// Block mode
+func WithBlockTimeout(d time.Duration) ChannelOption {
+	return func(h *ChannelHandler) {
+		h.blockTimeout = d
+	}
+}
+
+// WithTolerance sets how far the signing time of a request may be from
+// now. The default is DefaultTolerance.
+func WithTolerance(d time.Duration) ChannelOption {
+	return func(h *ChannelHandler) {
+		h.tolerance = d
+	}
+}
+
+// WithOnDrop is called for each event discarded in Drop mode
+func WithOnDrop(fn func(Event)) ChannelOption {
+	return func(h *ChannelHandler) {
+		h.onDrop = fn
+	}
+}
+
+// ChannelHandler is an http.Handler that delivers webhook events to a
+// channel. Shoutbox retries rejected requests, so consumers should
+// deduplicate events by ID.
+type ChannelHandler struct {
+	secret       string
+	tolerance    time.Duration
+	events       chan Event
+	done         chan struct{}
+	backpressure Backpressure
+	blockTimeout time.Duration
+	onDrop       func(Event)
+
+	mu        sync.RWMutex
+	closeOnce sync.Once
+}
+
+// NewChannelHandler returns a handler and the channel it delivers events
+// to. Requests must be signed with secret, the webhook's signing secret;
+// others are rejected with 401. buffer is the capacity of the channel.
+func NewChannelHandler(secret string, buffer int, opts ...ChannelOption) (*ChannelHandler, <-chan Event) {
+	h := &ChannelHandler{
+		secret: secret,
+		events: make(chan Event, buffer),
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, h.events
+}
+
+// ServeHTTP implements http.Handler
+func (h *ChannelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, ok := readSigned(w, r, h.secret, h.tolerance)
+	if !ok {
+		return
+	}
+	events, err := ParseEvents(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	select {
+	case <-h.done:
+		http.Error(w, "handler closed", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if h.backpressure == Block && h.blockTimeout > 0 {
+		timer := time.NewTimer(h.blockTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for _, event := range events {
+		select {
+		case h.events <- event:
+			continue
+		default:
+		}
+
+		switch h.backpressure {
+		case Drop:
+			if h.onDrop != nil {
+				h.onDrop(event)
+			}
+			continue
+		case Reject:
+			http.Error(w, "event buffer full", http.StatusServiceUnavailable)
+			return
+		}
+
+		select {
+		case h.events <- event:
+		case <-h.done:
+			http.Error(w, "handler closed", http.StatusServiceUnavailable)
+			return
+		case <-timeout:
+			http.Error(w, "event buffer full", http.StatusServiceUnavailable)
+			return
+		case <-r.Context().Done():
+			http.Error(w, "request cancelled", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Close stops accepting events and closes the channel once in-flight
+// requests have finished, ending the consumer's range loop
+func (h *ChannelHandler) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+		h.mu.Lock()
+		close(h.events)
+		h.mu.Unlock()
+	})
+}
@@ -0,0 +1,163 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEvents = `[
+	{"id": "evt_1", "type": "delivered", "message_id": "msg_1", "recipient": "ada@example.com"},
+	{"id": "evt_2", "type": "opened", "message_id": "msg_1", "recipient": "ada@example.com"}
+]`
+
+const testSecret = "whsec_test"
+
+// post sends body to h signed with testSecret and returns the status
+func post(h http.Handler, body string) int {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(testSecret, timestamp, []byte(body)))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestChannelHandler(t *testing.T) {
+	var dropped []string
+	tests := []struct {
+		name       string
+		opts       []ChannelOption
+		wantStatus int
+		wantEvents int
+	}{
+		{
+			name:       "block times out",
+			opts:       []ChannelOption{WithBlockTimeout(10 * time.Millisecond)},
+			wantStatus: http.StatusServiceUnavailable,
+			wantEvents: 1,
+		},
+		{
+			name:       "reject",
+			opts:       []ChannelOption{WithBackpressure(Reject)},
+			wantStatus: http.StatusServiceUnavailable,
+			wantEvents: 1,
+		},
+		{
+			name: "drop",
+			opts: []ChannelOption{WithBackpressure(Drop), WithOnDrop(func(e Event) {
+				dropped = append(dropped, e.ID)
+			})},
+			wantStatus: http.StatusNoContent,
+			wantEvents: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, events := NewChannelHandler(testSecret, 1, tt.opts...)
+			if got := post(h, testEvents); got != tt.wantStatus {
+				t.Errorf("status = %d, want %d", got, tt.wantStatus)
+			}
+			h.Close()
+
+			var got int
+			for range events {
+				got++
+			}
+			if got != tt.wantEvents {
+				t.Errorf("received %d events, want %d", got, tt.wantEvents)
+			}
+		})
+	}
+
+	if len(dropped) != 1 || dropped[0] != "evt_2" {
+		t.Errorf("dropped = %v", dropped)
+	}
+}
+
+func TestChannelHandler_Consumer(t *testing.T) {
+	h, events := NewChannelHandler(testSecret, 0)
+	received := make(chan Event, 2)
+	go func() {
+		for event := range events {
+			received <- event
+		}
+		close(received)
+	}()
+
+	if got := post(h, `{"id": "evt_1", "type": "bounced"}`); got != http.StatusNoContent {
+		t.Fatalf("status = %d", got)
+	}
+	if got := post(h, `not json`); got != http.StatusBadRequest {
+		t.Errorf("invalid body status = %d", got)
+	}
+	h.Close()
+
+	if event := <-received; event.ID != "evt_1" || event.Type != "bounced" {
+		t.Errorf("event = %+v", event)
+	}
+	if _, ok := <-received; ok {
+		t.Error("channel should be closed")
+	}
+	if got := post(h, testEvents); got != http.StatusServiceUnavailable {
+		t.Errorf("status after Close = %d", got)
+	}
+}
+
+func TestChannelHandler_Signature(t *testing.T) {
+	body := `{"id": "evt_1", "type": "delivered"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-DefaultTolerance-time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		timestamp  string
+		signature  string
+		wantStatus int
+	}{
+		{"valid", now, Sign(testSecret, now, []byte(body)), http.StatusNoContent},
+		{"missing signature", now, "", http.StatusUnauthorized},
+		{"missing timestamp", "", Sign(testSecret, now, []byte(body)), http.StatusUnauthorized},
+		{"bad signature", now, Sign("other", now, []byte(body)), http.StatusUnauthorized},
+		{"other timestamp", now, Sign(testSecret, stale, []byte(body)), http.StatusUnauthorized},
+		{"stale", stale, Sign(testSecret, stale, []byte(body)), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, events := NewChannelHandler(testSecret, 1)
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+			if tt.timestamp != "" {
+				req.Header.Set(TimestampHeader, tt.timestamp)
+			}
+			if tt.signature != "" {
+				req.Header.Set(SignatureHeader, tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			h.Close()
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var got int
+			for range events {
+				got++
+			}
+			if want := tt.wantStatus == http.StatusNoContent; (got == 1) != want {
+				t.Errorf("received %d events", got)
+			}
+		})
+	}
+
+	h, _ := NewChannelHandler("", 1)
+	if got := post(h, body); got != http.StatusInternalServerError {
+		t.Errorf("status without secret = %d, want %d", got, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,112 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+// DeliveredEvent is sent when the recipient's server accepted the message
+type DeliveredEvent struct {
+	Event
+	// Response is the SMTP response of the receiving server
+	Response string `json:"response,omitempty"`
+}
+
+// DeferredEvent is sent when delivery was temporarily refused and will be
+// retried
+type DeferredEvent struct {
+	Event
+	Reason  string `json:"reason,omitempty"`
+	Attempt int    `json:"attempt,omitempty"`
+}
+
+// BouncedEvent is sent when the message could not be delivered
+type BouncedEvent struct {
+	Event
+	// BounceType is "hard" for permanent failures and "soft" for
+	// temporary ones
+	BounceType     string `json:"bounce_type,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	DiagnosticCode string `json:"diagnostic_code,omitempty"`
+}
+
+// Bounce converts the event for a shoutbox.BounceHook. The reason is
+// classified from the diagnostic code when the event doesn't carry one.
+func (e *BouncedEvent) Bounce() *shoutbox.Bounce {
+	reason := shoutbox.BounceReason(e.Reason)
+	if reason == "" {
+		reason = shoutbox.ClassifyBounce(e.DiagnosticCode)
+	}
+	return &shoutbox.Bounce{
+		Email:          e.Recipient,
+		MessageID:      e.MessageID,
+		Type:           shoutbox.BounceType(e.BounceType),
+		Reason:         reason,
+		DiagnosticCode: e.DiagnosticCode,
+		Timestamp:      e.Timestamp,
+	}
+}
+
+// OpenedEvent is sent when the recipient opened the message
+type OpenedEvent struct {
+	Event
+	UserAgent string `json:"user_agent,omitempty"`
+	IP        string `json:"ip,omitempty"`
+}
+
+// ClickedEvent is sent when the recipient clicked a tracked link
+type ClickedEvent struct {
+	Event
+	URL       string `json:"url"`
+	UserAgent string `json:"user_agent,omitempty"`
+	IP        string `json:"ip,omitempty"`
+}
+
+// ComplainedEvent is sent when the recipient marked the message as spam
+type ComplainedEvent struct {
+	Event
+	FeedbackType string `json:"feedback_type,omitempty"`
+}
+
+// UnsubscribedEvent is sent when the recipient unsubscribed
+type UnsubscribedEvent struct {
+	Event
+	// List is the list or category the recipient unsubscribed from
+	List string `json:"list,omitempty"`
+}
+
+// ParseWebhookEvent parses a single event and returns it as its typed
+// struct, e.g. *BouncedEvent. Events of unknown types are returned as
+// *Event.
+func ParseWebhookEvent(data []byte) (any, error) {
+	var base Event
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("error decoding event: %w", err)
+	}
+
+	var event any
+	switch base.Type {
+	case shoutbox.EventDelivered:
+		event = &DeliveredEvent{}
+	case shoutbox.EventDeferred:
+		event = &DeferredEvent{}
+	case shoutbox.EventBounced:
+		event = &BouncedEvent{}
+	case shoutbox.EventOpened:
+		event = &OpenedEvent{}
+	case shoutbox.EventClicked:
+		event = &ClickedEvent{}
+	case shoutbox.EventComplained:
+		event = &ComplainedEvent{}
+	case shoutbox.EventUnsubscribed:
+		event = &UnsubscribedEvent{}
+	default:
+		return &base, nil
+	}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("error decoding %s event: %w", base.Type, err)
+	}
+	return event, nil
+}
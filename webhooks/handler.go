@@ -0,0 +1,86 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Handler is an http.Handler that parses webhook requests and calls the
+// callback for each event's type. Events without a callback are ignored.
+// If a callback returns an error the handler responds 500, so Shoutbox
+// retries the request; callbacks should therefore be idempotent.
+type Handler struct {
+	// Secret is the webhook's signing secret. Requests without a valid
+	// signature are rejected with 401; it must be set.
+	Secret string
+	// Tolerance is how far the signing time of a request may be from now.
+	// Zero means DefaultTolerance.
+	Tolerance time.Duration
+
+	OnDelivered    func(ctx context.Context, e *DeliveredEvent) error
+	OnDeferred     func(ctx context.Context, e *DeferredEvent) error
+	OnBounced      func(ctx context.Context, e *BouncedEvent) error
+	OnOpened       func(ctx context.Context, e *OpenedEvent) error
+	OnClicked      func(ctx context.Context, e *ClickedEvent) error
+	OnComplained   func(ctx context.Context, e *ComplainedEvent) error
+	OnUnsubscribed func(ctx context.Context, e *UnsubscribedEvent) error
+	// OnUnknown receives events of types this package doesn't know
+	OnUnknown func(ctx context.Context, e *Event) error
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, ok := readSigned(w, r, h.Secret, h.Tolerance)
+	if !ok {
+		return
+	}
+	raw, err := splitEvents(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, data := range raw {
+		event, err := ParseWebhookEvent(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.dispatch(r.Context(), event); err != nil {
+			http.Error(w, "error handling event", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) dispatch(ctx context.Context, event any) error {
+	switch e := event.(type) {
+	case *DeliveredEvent:
+		return call(ctx, h.OnDelivered, e)
+	case *DeferredEvent:
+		return call(ctx, h.OnDeferred, e)
+	case *BouncedEvent:
+		return call(ctx, h.OnBounced, e)
+	case *OpenedEvent:
+		return call(ctx, h.OnOpened, e)
+	case *ClickedEvent:
+		return call(ctx, h.OnClicked, e)
+	case *ComplainedEvent:
+		return call(ctx, h.OnComplained, e)
+	case *UnsubscribedEvent:
+		return call(ctx, h.OnUnsubscribed, e)
+	case *Event:
+		return call(ctx, h.OnUnknown, e)
+	}
+	return nil
+}
+
+func call[E any](ctx context.Context, fn func(context.Context, *E) error, e *E) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(ctx, e)
+}
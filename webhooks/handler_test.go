@@ -0,0 +1,131 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+func TestParseWebhookEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		check func(t *testing.T, event any)
+	}{
+		{
+			name: "bounced",
+			data: `{"id": "evt_1", "type": "bounced", "recipient": "ada@example.com", "bounce_type": "hard", "diagnostic_code": "550 5.1.1 user unknown"}`,
+			check: func(t *testing.T, event any) {
+				e, ok := event.(*BouncedEvent)
+				if !ok || e.Recipient != "ada@example.com" || e.BounceType != "hard" || e.DiagnosticCode != "550 5.1.1 user unknown" {
+					t.Fatalf("event = %#v", event)
+				}
+				if b := e.Bounce(); !b.IsHard() || b.Reason != shoutbox.BounceUnknownUser {
+					t.Errorf("Bounce() = %+v", b)
+				}
+			},
+		},
+		{
+			name: "clicked",
+			data: `{"id": "evt_2", "type": "clicked", "url": "https://example.com/pricing", "tags": ["welcome"], "metadata": {"order_id": "1234"}}`,
+			check: func(t *testing.T, event any) {
+				if e, ok := event.(*ClickedEvent); !ok || e.URL != "https://example.com/pricing" || e.ID != "evt_2" {
+					t.Fatalf("event = %#v", event)
+				}
+				if e := event.(*ClickedEvent); len(e.Tags) != 1 || e.Tags[0] != "welcome" || e.Metadata["order_id"] != "1234" {
+					t.Errorf("tags = %v, metadata = %v", e.Tags, e.Metadata)
+				}
+			},
+		},
+		{
+			name: "unknown",
+			data: `{"id": "evt_3", "type": "archived"}`,
+			check: func(t *testing.T, event any) {
+				if e, ok := event.(*Event); !ok || e.Type != "archived" {
+					t.Errorf("event = %#v", event)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseWebhookEvent([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseWebhookEvent() error = %v", err)
+			}
+			tt.check(t, event)
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var bounced, opened []string
+	h := &Handler{
+		Secret: testSecret,
+		OnBounced: func(ctx context.Context, e *BouncedEvent) error {
+			bounced = append(bounced, e.Recipient)
+			return nil
+		},
+		OnOpened: func(ctx context.Context, e *OpenedEvent) error {
+			opened = append(opened, e.Recipient)
+			return nil
+		},
+	}
+
+	body := `[
+		{"type": "bounced", "recipient": "ada@example.com"},
+		{"type": "delivered", "recipient": "grace@example.com"},
+		{"type": "opened", "recipient": "grace@example.com"}
+	]`
+	if got := post(h, body); got != http.StatusNoContent {
+		t.Errorf("status = %d", got)
+	}
+	if len(bounced) != 1 || bounced[0] != "ada@example.com" || len(opened) != 1 {
+		t.Errorf("bounced = %v, opened = %v", bounced, opened)
+	}
+
+	h.OnBounced = func(ctx context.Context, e *BouncedEvent) error {
+		return errors.New("database unavailable")
+	}
+	if got := post(h, `{"type": "bounced"}`); got != http.StatusInternalServerError {
+		t.Errorf("status on callback error = %d", got)
+	}
+	if got := post(h, `{"type": `); got != http.StatusBadRequest {
+		t.Errorf("status on invalid body = %d", got)
+	}
+
+	bounced = nil
+	h.OnBounced = func(ctx context.Context, e *BouncedEvent) error {
+		bounced = append(bounced, e.Recipient)
+		return nil
+	}
+	body = `{"type": "bounced", "recipient": "ada@example.com"}`
+	unsigned := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	forged := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	forged.Header.Set(TimestampHeader, timestamp)
+	forged.Header.Set(SignatureHeader, Sign("other", timestamp, []byte(body)))
+	for name, req := range map[string]*http.Request{"unsigned": unsigned, "forged": forged} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s request status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	if len(bounced) != 0 {
+		t.Errorf("unverified events dispatched: %v", bounced)
+	}
+
+	h.Secret = ""
+	if got := post(h, body); got != http.StatusInternalServerError {
+		t.Errorf("status without secret = %d, want %d", got, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,140 @@
+// Package webhooks receives Shoutbox delivery and engagement events
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/shoutboxnet/shoutbox-go/shoutbox"
+)
+
+// Event is a delivery or engagement event posted by Shoutbox
+type Event = shoutbox.Event
+
+// MaxBodySize is the largest webhook request body that is accepted
+const MaxBodySize = 1 << 20
+
+// Headers of webhook requests signed by Shoutbox
+const (
+	// SignatureHeader holds the hex-encoded signature made by Sign
+	SignatureHeader = "X-Shoutbox-Signature"
+	// TimestampHeader holds the Unix time the request was signed at
+	TimestampHeader = "X-Shoutbox-Timestamp"
+)
+
+// DefaultTolerance is how far the signing time of a request may be from
+// now, limiting replays of captured requests
+const DefaultTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned for webhook requests without a valid,
+// current signature
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature of a webhook body signed at timestamp, a Unix
+// time: the hex-encoded HMAC-SHA256 of the timestamp, a dot and the body,
+// keyed with the webhook's signing secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature headers of a webhook request with
+// body against secret. Requests signed more than tolerance before or after
+// now are rejected; zero means DefaultTolerance.
+func VerifySignature(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	return verifySignature(secret, header, body, tolerance, time.Now())
+}
+
+func verifySignature(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return errors.New("webhook signing secret not set")
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	timestamp, signature := header.Get(TimestampHeader), header.Get(SignatureHeader)
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("%w: missing %s or %s", ErrInvalidSignature, SignatureHeader, TimestampHeader)
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: signed %v ago", ErrInvalidSignature, age.Round(time.Second))
+	}
+	return nil
+}
+
+// readSigned reads the body of a webhook request and verifies its
+// signature, responding with an error and returning false if the request
+// is invalid
+func readSigned(w http.ResponseWriter, r *http.Request, secret string, tolerance time.Duration) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	if secret == "" {
+		http.Error(w, "webhook signing secret not configured", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return nil, false
+	}
+	if err := VerifySignature(secret, r.Header, body, tolerance); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	return body, true
+}
+
+// ParseEvents parses a webhook body, which holds either a single event or
+// an array of events
+func ParseEvents(body []byte) ([]Event, error) {
+	raw, err := splitEvents(body)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, len(raw))
+	for i, data := range raw {
+		if err := json.Unmarshal(data, &events[i]); err != nil {
+			return nil, fmt.Errorf("error decoding event: %w", err)
+		}
+	}
+	return events, nil
+}
+
+// splitEvents returns the JSON of each event in a webhook body
+func splitEvents(body []byte) ([]json.RawMessage, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("error decoding events: %w", err)
+		}
+		return raw, nil
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("error decoding event: invalid JSON")
+	}
+	return []json.RawMessage{body}, nil
+}